@@ -0,0 +1,49 @@
+package agent
+
+import (
+	"fmt"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/codegangsta/cli"
+	"github.com/ingrammicro/concerto/cmd"
+	"github.com/ingrammicro/concerto/utils/format"
+)
+
+func cmdOutboxList(c *cli.Context) error {
+	log.Debug("cmdOutboxList")
+
+	formatter := format.GetFormatter()
+	outbox := cmd.WireUpOutbox(c)
+	if outbox == nil {
+		formatter.PrintFatal("Couldn't open outbox", fmt.Errorf("outbox is not available"))
+	}
+
+	entries, err := outbox.Entries()
+	if err != nil {
+		formatter.PrintFatal("Couldn't read outbox entries", err)
+	}
+	if err = formatter.PrintList(entries); err != nil {
+		formatter.PrintFatal("Couldn't print/format result", err)
+	}
+	return nil
+}
+
+func cmdOutboxFlush(c *cli.Context) error {
+	log.Debug("cmdOutboxFlush")
+
+	formatter := format.GetFormatter()
+	outbox := cmd.WireUpOutbox(c)
+	if outbox == nil {
+		formatter.PrintFatal("Couldn't open outbox", fmt.Errorf("outbox is not available"))
+	}
+
+	pending, err := outbox.Flush()
+	if err != nil {
+		formatter.PrintFatal("Couldn't flush outbox", err)
+	}
+	if pending > 0 {
+		formatter.PrintFatal("Couldn't flush outbox", fmt.Errorf("%d reports are still pending to be delivered", pending))
+	}
+	log.Info("Outbox successfully flushed")
+	return nil
+}
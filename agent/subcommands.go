@@ -0,0 +1,27 @@
+package agent
+
+import (
+	"github.com/codegangsta/cli"
+)
+
+// SubCommands returns agent commands
+func SubCommands() []cli.Command {
+	return []cli.Command{
+		{
+			Name:  "outbox",
+			Usage: "Manages the agent reports queued while IMCO is unreachable",
+			Subcommands: []cli.Command{
+				{
+					Name:   "list",
+					Usage:  "Lists the reports pending to be delivered, in delivery order",
+					Action: cmdOutboxList,
+				},
+				{
+					Name:   "flush",
+					Usage:  "Delivers the pending reports, in order",
+					Action: cmdOutboxFlush,
+				},
+			},
+		},
+	}
+}
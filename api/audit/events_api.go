@@ -26,9 +26,14 @@ func NewEventService(concertoService utils.ConcertoService) (*EventService, erro
 
 // GetEventList returns the list of events as an array of Event
 func (cl *EventService) GetEventList() (events []*types.Event, err error) {
-	log.Debug("GetEventList")
+	return cl.GetEventListWithFilter(nil)
+}
+
+// GetEventListWithFilter returns the list of events matching the filter as an array of Event
+func (cl *EventService) GetEventListWithFilter(filter *types.EventFilter) (events []*types.Event, err error) {
+	log.Debug("GetEventListWithFilter")
 
-	data, status, err := cl.concertoService.Get("/audit/events")
+	data, status, err := cl.concertoService.Get("/audit/events" + filter.QueryString())
 	if err != nil {
 		return nil, err
 	}
@@ -41,14 +46,19 @@ func (cl *EventService) GetEventList() (events []*types.Event, err error) {
 		return nil, err
 	}
 
-	return events, nil
+	return filter.Filter(events), nil
 }
 
 // GetSysEventList returns the list of events as an array of Event
 func (cl *EventService) GetSysEventList() (events []*types.Event, err error) {
-	log.Debug("GetSysEventList")
+	return cl.GetSysEventListWithFilter(nil)
+}
+
+// GetSysEventListWithFilter returns the list of system events matching the filter as an array of Event
+func (cl *EventService) GetSysEventListWithFilter(filter *types.EventFilter) (events []*types.Event, err error) {
+	log.Debug("GetSysEventListWithFilter")
 
-	data, status, err := cl.concertoService.Get("/audit/system_events")
+	data, status, err := cl.concertoService.Get("/audit/system_events" + filter.QueryString())
 	if err != nil {
 		return nil, err
 	}
@@ -61,5 +71,5 @@ func (cl *EventService) GetSysEventList() (events []*types.Event, err error) {
 		return nil, err
 	}
 
-	return events, nil
+	return filter.Filter(events), nil
 }
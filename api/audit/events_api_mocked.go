@@ -35,6 +35,31 @@ func GetEventListMocked(t *testing.T, eventsIn []*types.Event) []*types.Event {
 	return eventsOut
 }
 
+// GetEventListWithFilterMocked test mocked function
+func GetEventListWithFilterMocked(t *testing.T, eventsIn []*types.Event) []*types.Event {
+
+	assert := assert.New(t)
+
+	// wire up
+	cs := &utils.MockConcertoService{}
+	ds, err := NewEventService(cs)
+	assert.Nil(err, "Couldn't load event service")
+	assert.NotNil(ds, "Event service not instanced")
+
+	// to json
+	dIn, err := json.Marshal(eventsIn)
+	assert.Nil(err, "Event test data corrupted")
+
+	// call service, which ignores the filter so that it is applied by the client
+	filter := &types.EventFilter{Since: eventsIn[1].Timestamp, Levels: []string{eventsIn[1].Level}}
+	cs.On("Get", "/audit/events"+filter.QueryString()).Return(dIn, 200, nil)
+	eventsOut, err := ds.GetEventListWithFilter(filter)
+	assert.Nil(err, "Error getting event list")
+	assert.Equal(eventsIn[1:], eventsOut, "GetEventListWithFilter returned different events")
+
+	return eventsOut
+}
+
 // GetEventListFailErrMocked test mocked function
 func GetEventListFailErrMocked(t *testing.T, eventsIn []*types.Event) []*types.Event {
 
@@ -136,6 +161,31 @@ func GetSysEventListMocked(t *testing.T, eventsIn []*types.Event) []*types.Event
 	return eventsOut
 }
 
+// GetSysEventListWithFilterMocked test mocked function
+func GetSysEventListWithFilterMocked(t *testing.T, eventsIn []*types.Event) []*types.Event {
+
+	assert := assert.New(t)
+
+	// wire up
+	cs := &utils.MockConcertoService{}
+	ds, err := NewEventService(cs)
+	assert.Nil(err, "Couldn't load event service")
+	assert.NotNil(ds, "Event service not instanced")
+
+	// to json
+	dIn, err := json.Marshal(eventsIn)
+	assert.Nil(err, "Event test data corrupted")
+
+	// call service, which ignores the filter so that it is applied by the client
+	filter := &types.EventFilter{Since: eventsIn[1].Timestamp, Levels: []string{eventsIn[1].Level}}
+	cs.On("Get", "/audit/system_events"+filter.QueryString()).Return(dIn, 200, nil)
+	eventsOut, err := ds.GetSysEventListWithFilter(filter)
+	assert.Nil(err, "Error getting event list")
+	assert.Equal(eventsIn[1:], eventsOut, "GetSysEventListWithFilter returned different events")
+
+	return eventsOut
+}
+
 // GetSysEventListFailErrMocked test mocked function
 func GetSysEventListFailErrMocked(t *testing.T, eventsIn []*types.Event) []*types.Event {
 
@@ -16,6 +16,7 @@ func TestNewEventServiceNil(t *testing.T) {
 func TestGetEventList(t *testing.T) {
 	eventsIn := testdata.GetEventData()
 	GetEventListMocked(t, eventsIn)
+	GetEventListWithFilterMocked(t, eventsIn)
 	GetEventListFailErrMocked(t, eventsIn)
 	GetEventListFailStatusMocked(t, eventsIn)
 	GetEventListFailJSONMocked(t, eventsIn)
@@ -24,6 +25,7 @@ func TestGetEventList(t *testing.T) {
 func TestGetSysEventList(t *testing.T) {
 	eventsIn := testdata.GetEventData()
 	GetSysEventListMocked(t, eventsIn)
+	GetSysEventListWithFilterMocked(t, eventsIn)
 	GetSysEventListFailErrMocked(t, eventsIn)
 	GetSysEventListFailStatusMocked(t, eventsIn)
 	GetSysEventListFailJSONMocked(t, eventsIn)
@@ -9,6 +9,12 @@ import (
 	"github.com/ingrammicro/concerto/utils"
 )
 
+// AppliedConfigurationPath is the path where the applied configuration is reported
+const AppliedConfigurationPath = "/blueprint/applied_configuration"
+
+// BootstrapLogsPath is the path where the bootstrapping logs are reported
+const BootstrapLogsPath = "/blueprint/bootstrap_logs"
+
 // BootstrappingService manages bootstrapping operations
 type BootstrappingService struct {
 	concertoService utils.ConcertoService
@@ -47,26 +53,26 @@ func (bs *BootstrappingService) GetBootstrappingConfiguration() (bootstrappingCo
 }
 
 // ReportBootstrappingAppliedConfiguration informs the platform of applied changes
-func (bs *BootstrappingService) ReportBootstrappingAppliedConfiguration(BootstrappingAppliedConfigurationVector *map[string]interface{}) (err error) {
+func (bs *BootstrappingService) ReportBootstrappingAppliedConfiguration(BootstrappingAppliedConfigurationVector *map[string]interface{}) (status int, err error) {
 	log.Debug("ReportBootstrappingAppliedConfiguration")
 
-	data, status, err := bs.concertoService.Put("/blueprint/applied_configuration", BootstrappingAppliedConfigurationVector)
+	data, status, err := bs.concertoService.Put(AppliedConfigurationPath, BootstrappingAppliedConfigurationVector)
 	if err != nil {
-		return err
+		return status, err
 	}
 
 	if err = utils.CheckStandardStatus(status, data); err != nil {
-		return err
+		return status, err
 	}
 
-	return nil
+	return status, nil
 }
 
 // ReportBootstrappingLog reports a policy files application result
 func (bs *BootstrappingService) ReportBootstrappingLog(BootstrappingContinuousReportVector *map[string]interface{}) (command *types.BootstrappingContinuousReport, status int, err error) {
 	log.Debug("ReportBootstrappingLog")
 
-	data, status, err := bs.concertoService.Post("/blueprint/bootstrap_logs", BootstrappingContinuousReportVector)
+	data, status, err := bs.concertoService.Post(BootstrapLogsPath, BootstrappingContinuousReportVector)
 	if err != nil {
 		return nil, status, err
 	}
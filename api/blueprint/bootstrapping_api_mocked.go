@@ -128,8 +128,9 @@ func ReportBootstrappingAppliedConfigurationMocked(t *testing.T, commandIn *type
 	// call service
 	payload := make(map[string]interface{})
 	cs.On("Put", fmt.Sprintf("/blueprint/applied_configuration"), &payload).Return(dOut, 200, nil)
-	err = ds.ReportBootstrappingAppliedConfiguration(&payload)
+	status, err := ds.ReportBootstrappingAppliedConfiguration(&payload)
 	assert.Nil(err, "Error getting bootstrapping command")
+	assert.Equal(200, status, "Status should be returned")
 }
 
 // ReportBootstrappingAppliedConfigurationFailErrMocked test mocked function
@@ -152,7 +153,7 @@ func ReportBootstrappingAppliedConfigurationFailErrMocked(t *testing.T, commandI
 	// call service
 	payload := make(map[string]interface{})
 	cs.On("Put", fmt.Sprintf("/blueprint/applied_configuration"), &payload).Return(dIn, 499, fmt.Errorf("mocked error"))
-	err = ds.ReportBootstrappingAppliedConfiguration(&payload)
+	_, err = ds.ReportBootstrappingAppliedConfiguration(&payload)
 	assert.NotNil(err, "We are expecting an error")
 	assert.Equal(err.Error(), "mocked error", "Error should be 'mocked error'")
 }
@@ -177,8 +178,9 @@ func ReportBootstrappingAppliedConfigurationFailStatusMocked(t *testing.T, comma
 	// call service
 	payload := make(map[string]interface{})
 	cs.On("Put", fmt.Sprintf("/blueprint/applied_configuration"), &payload).Return(dIn, 499, fmt.Errorf("error 499 Mocked error"))
-	err = ds.ReportBootstrappingAppliedConfiguration(&payload)
+	status, err := ds.ReportBootstrappingAppliedConfiguration(&payload)
 	assert.NotNil(err, "We are expecting a status code error")
+	assert.Equal(499, status, "Status should be returned")
 	assert.Contains(err.Error(), "499", "Error should contain http code 499")
 }
 
@@ -199,7 +201,7 @@ func ReportBootstrappingAppliedConfigurationFailJSONMocked(t *testing.T, command
 	// call service
 	payload := make(map[string]interface{})
 	cs.On("Put", fmt.Sprintf("/blueprint/applied_configuration"), &payload).Return(dIn, 499, nil)
-	err = ds.ReportBootstrappingAppliedConfiguration(&payload)
+	_, err = ds.ReportBootstrappingAppliedConfiguration(&payload)
 	assert.Contains(err.Error(), "499", "Error should contain http code 499")
 }
 
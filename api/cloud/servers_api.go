@@ -245,9 +245,14 @@ func (dm *ServerService) GetServerVolumesList(serverID string) (volumes []*types
 
 // GetEventsList returns a list of events by server ID
 func (dm *ServerService) GetEventsList(serverID string) (events []*types.Event, err error) {
-	log.Debug("GetEventsList")
+	return dm.GetEventsListWithFilter(serverID, nil)
+}
+
+// GetEventsListWithFilter returns a list of events by server ID matching the filter
+func (dm *ServerService) GetEventsListWithFilter(serverID string, filter *types.EventFilter) (events []*types.Event, err error) {
+	log.Debug("GetEventsListWithFilter")
 
-	data, status, err := dm.concertoService.Get(fmt.Sprintf("/cloud/servers/%s/events", serverID))
+	data, status, err := dm.concertoService.Get(fmt.Sprintf("/cloud/servers/%s/events%s", serverID, filter.QueryString()))
 	if err != nil {
 		return nil, err
 	}
@@ -260,7 +265,7 @@ func (dm *ServerService) GetEventsList(serverID string) (events []*types.Event,
 		return nil, err
 	}
 
-	return events, nil
+	return filter.Filter(events), nil
 }
 
 //======= Operational Scripts ==========
@@ -1205,6 +1205,31 @@ func GetServerEventListMocked(t *testing.T, eventsIn []*types.Event, serverID st
 	return evOut
 }
 
+// GetServerEventListWithFilterMocked test mocked function
+func GetServerEventListWithFilterMocked(t *testing.T, eventsIn []*types.Event, serverID string) []*types.Event {
+
+	assert := assert.New(t)
+
+	// wire up
+	cs := &utils.MockConcertoService{}
+	ds, err := NewServerService(cs)
+	assert.Nil(err, "Couldn't load server service")
+	assert.NotNil(ds, "Server service not instanced")
+
+	// to json
+	evIn, err := json.Marshal(eventsIn)
+	assert.Nil(err, "Server event test data corrupted")
+
+	// call service, which ignores the filter so that it is applied by the client
+	filter := &types.EventFilter{Until: eventsIn[1].Timestamp, Text: eventsIn[0].Description}
+	cs.On("Get", fmt.Sprintf("/cloud/servers/%s/events%s", serverID, filter.QueryString())).Return(evIn, 200, nil)
+	evOut, err := ds.GetEventsListWithFilter(serverID, filter)
+	assert.Nil(err, "Error getting server event list")
+	assert.Equal(eventsIn[:1], evOut, "GetEventsListWithFilter returned different server events")
+
+	return evOut
+}
+
 // GetServerEventListFailErrMocked test mocked function
 func GetServerEventListFailErrMocked(t *testing.T, eventsIn []*types.Event, serverID string) []*types.Event {
 
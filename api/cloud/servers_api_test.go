@@ -130,6 +130,7 @@ func TestGetEventsList(t *testing.T) {
 	eventsIn := testdata.GetEventData()
 	for _, serverIn := range serversIn {
 		GetServerEventListMocked(t, eventsIn, serverIn.ID)
+		GetServerEventListWithFilterMocked(t, eventsIn, serverIn.ID)
 		GetServerEventListFailErrMocked(t, eventsIn, serverIn.ID)
 		GetServerEventListFailStatusMocked(t, eventsIn, serverIn.ID)
 		GetServerEventListFailJSONMocked(t, eventsIn, serverIn.ID)
@@ -3,11 +3,16 @@ package dispatcher
 import (
 	"encoding/json"
 	"fmt"
+	"net/http"
+
 	log "github.com/Sirupsen/logrus"
 	"github.com/ingrammicro/concerto/api/types"
 	"github.com/ingrammicro/concerto/utils"
 )
 
+// ScriptConclusionsPath is the path where the script conclusions are reported
+const ScriptConclusionsPath = "/blueprint/script_conclusions"
+
 // DispatcherService manages bootstrapping operations
 type DispatcherService struct {
 	concertoService utils.ConcertoService
@@ -68,7 +73,7 @@ func (ds *DispatcherService) GetDispatcherScriptCharacterizationsByUUID(scriptCh
 func (ds *DispatcherService) ReportScriptConclusions(scriptConclusions *map[string]interface{}) (command *types.ScriptConclusion, status int, err error) {
 	log.Debug("ReportScriptConclusions")
 
-	data, status, err := ds.concertoService.Post("/blueprint/script_conclusions", scriptConclusions)
+	data, status, err := ds.concertoService.Post(ScriptConclusionsPath, scriptConclusions)
 	if err != nil {
 		return nil, status, err
 	}
@@ -95,3 +100,30 @@ func (ds *DispatcherService) DownloadAttachment(url string, filePath string) (re
 
 	return realFileName, status, nil
 }
+
+// DownloadAttachmentIfModified gets a file from given url saving it into given directory, unless the attachment matches
+// the given validators (ETag and Last-Modified of a previous download), in which case status 304 is returned
+func (ds *DispatcherService) DownloadAttachmentIfModified(url string, dirPath string, etag string, lastModified string) (realFileName string, header http.Header, status int, err error) {
+	log.Debug("DownloadAttachmentIfModified")
+
+	headers := map[string]string{}
+	if etag != "" {
+		headers["If-None-Match"] = etag
+	}
+	if lastModified != "" {
+		headers["If-Modified-Since"] = lastModified
+	}
+
+	realFileName, header, status, err = ds.concertoService.GetFileConditional(url, dirPath, headers)
+	if err != nil {
+		return realFileName, header, status, err
+	}
+
+	if status != http.StatusNotModified {
+		if err = utils.CheckStandardStatus(status, []byte{}); err != nil {
+			return realFileName, header, status, err
+		}
+	}
+
+	return realFileName, header, status, nil
+}
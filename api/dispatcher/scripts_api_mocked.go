@@ -6,6 +6,7 @@ import (
 	"github.com/ingrammicro/concerto/api/types"
 	"github.com/ingrammicro/concerto/utils"
 	"github.com/stretchr/testify/assert"
+	"net/http"
 	"testing"
 )
 
@@ -368,3 +369,95 @@ func DownloadAttachmentFailErrMocked(t *testing.T, dataIn map[string]string) {
 	assert.Equal(status, 499, "DownloadAttachment returned an unexpected status code")
 	assert.Equal(err.Error(), "mocked error", "Error should be 'mocked error'")
 }
+
+// DownloadAttachmentIfModifiedMocked test mocked function
+func DownloadAttachmentIfModifiedMocked(t *testing.T, dataIn map[string]string) {
+
+	assert := assert.New(t)
+
+	// wire up
+	cs := &utils.MockConcertoService{}
+	ds, err := NewDispatcherService(cs)
+	assert.Nil(err, "Couldn't load dispatcher service")
+	assert.NotNil(ds, "Dispatcher service not instanced")
+
+	urlSource := dataIn["fakeEndpoint"]
+	pathDir := dataIn["fakeAttachmentDir"]
+	headersIn := map[string]string{"If-None-Match": dataIn["fakeETag"], "If-Modified-Since": dataIn["fakeLastModified"]}
+	headerOut := http.Header{"Etag": []string{dataIn["fakeETag"]}}
+
+	// call service
+	cs.On("GetFileConditional", urlSource, pathDir, headersIn).Return(dataIn["fakeFileName"], headerOut, 200, nil)
+	realFileName, header, status, err := ds.DownloadAttachmentIfModified(urlSource, pathDir, dataIn["fakeETag"], dataIn["fakeLastModified"])
+	assert.Nil(err, "Error downloading attachment file")
+	assert.Equal(status, 200, "DownloadAttachmentIfModified returned invalid response")
+	assert.Equal(realFileName, dataIn["fakeFileName"], "Invalid downloaded file path")
+	assert.Equal(header, headerOut, "DownloadAttachmentIfModified returned different headers")
+}
+
+// DownloadAttachmentIfModifiedNotModifiedMocked test mocked function
+func DownloadAttachmentIfModifiedNotModifiedMocked(t *testing.T, dataIn map[string]string) {
+
+	assert := assert.New(t)
+
+	// wire up
+	cs := &utils.MockConcertoService{}
+	ds, err := NewDispatcherService(cs)
+	assert.Nil(err, "Couldn't load dispatcher service")
+	assert.NotNil(ds, "Dispatcher service not instanced")
+
+	urlSource := dataIn["fakeEndpoint"]
+	pathDir := dataIn["fakeAttachmentDir"]
+	headersIn := map[string]string{"If-None-Match": dataIn["fakeETag"]}
+
+	// call service
+	cs.On("GetFileConditional", urlSource, pathDir, headersIn).Return("", http.Header{}, 304, nil)
+	realFileName, _, status, err := ds.DownloadAttachmentIfModified(urlSource, pathDir, dataIn["fakeETag"], "")
+	assert.Nil(err, "Not modified attachment should not return error")
+	assert.Equal(status, 304, "DownloadAttachmentIfModified returned invalid response")
+	assert.Equal(realFileName, "", "Not modified attachment should not be downloaded")
+}
+
+// DownloadAttachmentIfModifiedFailErrMocked test mocked function
+func DownloadAttachmentIfModifiedFailErrMocked(t *testing.T, dataIn map[string]string) {
+
+	assert := assert.New(t)
+
+	// wire up
+	cs := &utils.MockConcertoService{}
+	ds, err := NewDispatcherService(cs)
+	assert.Nil(err, "Couldn't load dispatcher service")
+	assert.NotNil(ds, "Dispatcher service not instanced")
+
+	urlSource := dataIn["fakeEndpoint"]
+	pathDir := dataIn["fakeAttachmentDir"]
+
+	// call service
+	cs.On("GetFileConditional", urlSource, pathDir, map[string]string{}).Return("", http.Header{}, 499, fmt.Errorf("mocked error"))
+	_, _, status, err := ds.DownloadAttachmentIfModified(urlSource, pathDir, "", "")
+	assert.NotNil(err, "We are expecting an error")
+	assert.Equal(status, 499, "DownloadAttachmentIfModified returned an unexpected status code")
+	assert.Equal(err.Error(), "mocked error", "Error should be 'mocked error'")
+}
+
+// DownloadAttachmentIfModifiedFailStatusMocked test mocked function
+func DownloadAttachmentIfModifiedFailStatusMocked(t *testing.T, dataIn map[string]string) {
+
+	assert := assert.New(t)
+
+	// wire up
+	cs := &utils.MockConcertoService{}
+	ds, err := NewDispatcherService(cs)
+	assert.Nil(err, "Couldn't load dispatcher service")
+	assert.NotNil(ds, "Dispatcher service not instanced")
+
+	urlSource := dataIn["fakeEndpoint"]
+	pathDir := dataIn["fakeAttachmentDir"]
+
+	// call service
+	cs.On("GetFileConditional", urlSource, pathDir, map[string]string{}).Return("", http.Header{}, 499, nil)
+	_, _, status, err := ds.DownloadAttachmentIfModified(urlSource, pathDir, "", "")
+	assert.NotNil(err, "We are expecting an status code error")
+	assert.Equal(status, 499, "DownloadAttachmentIfModified returned an unexpected status code")
+	assert.Contains(err.Error(), "499", "Error should contain http code 499")
+}
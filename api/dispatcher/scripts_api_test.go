@@ -42,3 +42,11 @@ func TestDownloadAttachment(t *testing.T) {
 	DownloadAttachmentMocked(t, dataIn)
 	DownloadAttachmentFailErrMocked(t, dataIn)
 }
+
+func TestDownloadAttachmentIfModified(t *testing.T) {
+	dataIn := testdata.GetDownloadAttachmentData()
+	DownloadAttachmentIfModifiedMocked(t, dataIn)
+	DownloadAttachmentIfModifiedNotModifiedMocked(t, dataIn)
+	DownloadAttachmentIfModifiedFailErrMocked(t, dataIn)
+	DownloadAttachmentIfModifiedFailStatusMocked(t, dataIn)
+}
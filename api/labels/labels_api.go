@@ -29,19 +29,11 @@ func NewLabelService(concertoService utils.ConcertoService) (*LabelService, erro
 func (lbl *LabelService) GetLabelList() (labels []*types.Label, err error) {
 	log.Debug("GetLabelList")
 
-	data, status, err := lbl.concertoService.Get("/labels")
+	labels, err = lbl.GetLabelListWithNamespaces()
 	if err != nil {
 		return nil, err
 	}
 
-	if err = utils.CheckStandardStatus(status, data); err != nil {
-		return nil, err
-	}
-
-	if err = json.Unmarshal(data, &labels); err != nil {
-		return nil, err
-	}
-
 	// exclude internal labels (with a Namespace defined)
 	var filteredLabels []*types.Label
 	for _, label := range labels {
@@ -53,6 +45,26 @@ func (lbl *LabelService) GetLabelList() (labels []*types.Label, err error) {
 	return filteredLabels, nil
 }
 
+// GetLabelListWithNamespaces returns the list of labels as an array of Label, including the ones with a Namespace
+func (lbl *LabelService) GetLabelListWithNamespaces() (labels []*types.Label, err error) {
+	log.Debug("GetLabelListWithNamespaces")
+
+	data, status, err := lbl.concertoService.Get("/labels")
+	if err != nil {
+		return nil, err
+	}
+
+	if err = utils.CheckStandardStatus(status, data); err != nil {
+		return nil, err
+	}
+
+	if err = json.Unmarshal(data, &labels); err != nil {
+		return nil, err
+	}
+
+	return labels, nil
+}
+
 // CreateLabel creates a label
 func (lbl *LabelService) CreateLabel(labelVector *map[string]interface{}) (label *types.Label, err error) {
 	log.Debug("CreateLabel")
@@ -108,3 +120,79 @@ func (lbl *LabelService) RemoveLabel(labelID string, resourceType string, resour
 
 	return nil
 }
+
+// GetLabel returns a label by its ID
+func (lbl *LabelService) GetLabel(ID string) (label *types.Label, err error) {
+	log.Debug("GetLabel")
+
+	data, status, err := lbl.concertoService.Get(fmt.Sprintf("/labels/%s", ID))
+	if err != nil {
+		return nil, err
+	}
+
+	if err = utils.CheckStandardStatus(status, data); err != nil {
+		return nil, err
+	}
+
+	if err = json.Unmarshal(data, &label); err != nil {
+		return nil, err
+	}
+
+	return label, nil
+}
+
+// UpdateLabel updates a label by its ID
+func (lbl *LabelService) UpdateLabel(labelVector *map[string]interface{}, ID string) (label *types.Label, err error) {
+	log.Debug("UpdateLabel")
+
+	data, status, err := lbl.concertoService.Put(fmt.Sprintf("/labels/%s", ID), labelVector)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = utils.CheckStandardStatus(status, data); err != nil {
+		return nil, err
+	}
+
+	if err = json.Unmarshal(data, &label); err != nil {
+		return nil, err
+	}
+
+	return label, nil
+}
+
+// DeleteLabel deletes a label by its ID
+func (lbl *LabelService) DeleteLabel(ID string) (err error) {
+	log.Debug("DeleteLabel")
+
+	data, status, err := lbl.concertoService.Delete(fmt.Sprintf("/labels/%s", ID))
+	if err != nil {
+		return err
+	}
+
+	if err = utils.CheckStandardStatus(status, data); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetLabeledResourceList returns the list of resources carrying a label, as an array of LabeledResource
+func (lbl *LabelService) GetLabeledResourceList(ID string) (labeledResources []*types.LabeledResource, err error) {
+	log.Debug("GetLabeledResourceList")
+
+	data, status, err := lbl.concertoService.Get(fmt.Sprintf("/labels/%s/resources", ID))
+	if err != nil {
+		return nil, err
+	}
+
+	if err = utils.CheckStandardStatus(status, data); err != nil {
+		return nil, err
+	}
+
+	if err = json.Unmarshal(data, &labeledResources); err != nil {
+		return nil, err
+	}
+
+	return labeledResources, nil
+}
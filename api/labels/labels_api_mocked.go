@@ -58,6 +58,30 @@ func GetLabelListMockedWithNamespace(t *testing.T, labelsIn []*types.Label) []*t
 	return labelsOut
 }
 
+// GetLabelListWithNamespacesMocked test mocked function
+func GetLabelListWithNamespacesMocked(t *testing.T, labelsIn []*types.Label) []*types.Label {
+
+	assert := assert.New(t)
+
+	// wire up
+	cs := &utils.MockConcertoService{}
+	ds, err := NewLabelService(cs)
+	assert.Nil(err, "Couldn't load label service")
+	assert.NotNil(ds, "Label service not instanced")
+
+	// to json
+	dIn, err := json.Marshal(labelsIn)
+	assert.Nil(err, "Label test data corrupted")
+
+	// call service
+	cs.On("Get", "/labels").Return(dIn, 200, nil)
+	labelsOut, err := ds.GetLabelListWithNamespaces()
+	assert.Nil(err, "Error getting labels list")
+	assert.Equal(labelsIn, labelsOut, "GetLabelListWithNamespaces returned different labels")
+
+	return labelsOut
+}
+
 // GetLabelListFailErrMocked test mocked function
 func GetLabelListFailErrMocked(t *testing.T, labelsIn []*types.Label) []*types.Label {
 
@@ -435,3 +459,390 @@ func RemoveLabelFailStatusMocked(t *testing.T, labelIn *types.Label) {
 	assert.NotNil(err, "We are expecting an status code error")
 	assert.Contains(err.Error(), "404", "Error should contain http code 404")
 }
+
+// GetLabelMocked test mocked function
+func GetLabelMocked(t *testing.T, labelIn *types.Label) *types.Label {
+
+	assert := assert.New(t)
+
+	// wire up
+	cs := &utils.MockConcertoService{}
+	ds, err := NewLabelService(cs)
+	assert.Nil(err, "Couldn't load label service")
+	assert.NotNil(ds, "Label service not instanced")
+
+	// to json
+	dIn, err := json.Marshal(labelIn)
+	assert.Nil(err, "Label test data corrupted")
+
+	// call service
+	cs.On("Get", fmt.Sprintf("/labels/%s", labelIn.ID)).Return(dIn, 200, nil)
+	labelOut, err := ds.GetLabel(labelIn.ID)
+	assert.Nil(err, "Error getting label")
+	assert.Equal(*labelIn, *labelOut, "GetLabel returned different labels")
+
+	return labelOut
+}
+
+// GetLabelFailErrMocked test mocked function
+func GetLabelFailErrMocked(t *testing.T, labelIn *types.Label) *types.Label {
+
+	assert := assert.New(t)
+
+	// wire up
+	cs := &utils.MockConcertoService{}
+	ds, err := NewLabelService(cs)
+	assert.Nil(err, "Couldn't load label service")
+	assert.NotNil(ds, "Label service not instanced")
+
+	// to json
+	dIn, err := json.Marshal(labelIn)
+	assert.Nil(err, "Label test data corrupted")
+
+	// call service
+	cs.On("Get", fmt.Sprintf("/labels/%s", labelIn.ID)).Return(dIn, 200, fmt.Errorf("mocked error"))
+	labelOut, err := ds.GetLabel(labelIn.ID)
+
+	assert.NotNil(err, "We are expecting an error")
+	assert.Nil(labelOut, "Expecting nil output")
+	assert.Equal(err.Error(), "mocked error", "Error should be 'mocked error'")
+
+	return labelOut
+}
+
+// GetLabelFailStatusMocked test mocked function
+func GetLabelFailStatusMocked(t *testing.T, labelIn *types.Label) *types.Label {
+
+	assert := assert.New(t)
+
+	// wire up
+	cs := &utils.MockConcertoService{}
+	ds, err := NewLabelService(cs)
+	assert.Nil(err, "Couldn't load label service")
+	assert.NotNil(ds, "Label service not instanced")
+
+	// to json
+	dIn, err := json.Marshal(labelIn)
+	assert.Nil(err, "Label test data corrupted")
+
+	// call service
+	cs.On("Get", fmt.Sprintf("/labels/%s", labelIn.ID)).Return(dIn, 499, nil)
+	labelOut, err := ds.GetLabel(labelIn.ID)
+
+	assert.NotNil(err, "We are expecting an status code error")
+	assert.Nil(labelOut, "Expecting nil output")
+	assert.Contains(err.Error(), "499", "Error should contain http code 499")
+
+	return labelOut
+}
+
+// GetLabelFailJSONMocked test mocked function
+func GetLabelFailJSONMocked(t *testing.T, labelIn *types.Label) *types.Label {
+
+	assert := assert.New(t)
+
+	// wire up
+	cs := &utils.MockConcertoService{}
+	ds, err := NewLabelService(cs)
+	assert.Nil(err, "Couldn't load label service")
+	assert.NotNil(ds, "Label service not instanced")
+
+	// wrong json
+	dIn := []byte{10, 20, 30}
+
+	// call service
+	cs.On("Get", fmt.Sprintf("/labels/%s", labelIn.ID)).Return(dIn, 200, nil)
+	labelOut, err := ds.GetLabel(labelIn.ID)
+
+	assert.NotNil(err, "We are expecting a marshalling error")
+	assert.Nil(labelOut, "Expecting nil output")
+	assert.Contains(err.Error(), "invalid character", "Error message should include the string 'invalid character'")
+
+	return labelOut
+}
+
+// UpdateLabelMocked test mocked function
+func UpdateLabelMocked(t *testing.T, labelIn *types.Label) *types.Label {
+
+	assert := assert.New(t)
+
+	// wire up
+	cs := &utils.MockConcertoService{}
+	ds, err := NewLabelService(cs)
+	assert.Nil(err, "Couldn't load label service")
+	assert.NotNil(ds, "Label service not instanced")
+
+	// convertMap
+	mapIn, err := utils.ItemConvertParams(*labelIn)
+	assert.Nil(err, "Label test data corrupted")
+
+	// to json
+	dOut, err := json.Marshal(labelIn)
+	assert.Nil(err, "Label test data corrupted")
+
+	// call service
+	cs.On("Put", fmt.Sprintf("/labels/%s", labelIn.ID), mapIn).Return(dOut, 200, nil)
+	labelOut, err := ds.UpdateLabel(mapIn, labelIn.ID)
+	assert.Nil(err, "Error updating label")
+	assert.Equal(labelIn, labelOut, "UpdateLabel returned different labels")
+
+	return labelOut
+}
+
+// UpdateLabelFailErrMocked test mocked function
+func UpdateLabelFailErrMocked(t *testing.T, labelIn *types.Label) *types.Label {
+
+	assert := assert.New(t)
+
+	// wire up
+	cs := &utils.MockConcertoService{}
+	ds, err := NewLabelService(cs)
+	assert.Nil(err, "Couldn't load label service")
+	assert.NotNil(ds, "Label service not instanced")
+
+	// convertMap
+	mapIn, err := utils.ItemConvertParams(*labelIn)
+	assert.Nil(err, "Label test data corrupted")
+
+	// to json
+	dOut, err := json.Marshal(labelIn)
+	assert.Nil(err, "Label test data corrupted")
+
+	// call service
+	cs.On("Put", fmt.Sprintf("/labels/%s", labelIn.ID), mapIn).Return(dOut, 200, fmt.Errorf("mocked error"))
+	labelOut, err := ds.UpdateLabel(mapIn, labelIn.ID)
+
+	assert.NotNil(err, "We are expecting an error")
+	assert.Nil(labelOut, "Expecting nil output")
+	assert.Equal(err.Error(), "mocked error", "Error should be 'mocked error'")
+
+	return labelOut
+}
+
+// UpdateLabelFailStatusMocked test mocked function
+func UpdateLabelFailStatusMocked(t *testing.T, labelIn *types.Label) *types.Label {
+
+	assert := assert.New(t)
+
+	// wire up
+	cs := &utils.MockConcertoService{}
+	ds, err := NewLabelService(cs)
+	assert.Nil(err, "Couldn't load label service")
+	assert.NotNil(ds, "Label service not instanced")
+
+	// convertMap
+	mapIn, err := utils.ItemConvertParams(*labelIn)
+	assert.Nil(err, "Label test data corrupted")
+
+	// to json
+	dOut, err := json.Marshal(labelIn)
+	assert.Nil(err, "Label test data corrupted")
+
+	// call service
+	cs.On("Put", fmt.Sprintf("/labels/%s", labelIn.ID), mapIn).Return(dOut, 499, nil)
+	labelOut, err := ds.UpdateLabel(mapIn, labelIn.ID)
+
+	assert.NotNil(err, "We are expecting an status code error")
+	assert.Nil(labelOut, "Expecting nil output")
+	assert.Contains(err.Error(), "499", "Error should contain http code 499")
+	return labelOut
+}
+
+// UpdateLabelFailJSONMocked test mocked function
+func UpdateLabelFailJSONMocked(t *testing.T, labelIn *types.Label) *types.Label {
+
+	assert := assert.New(t)
+
+	// wire up
+	cs := &utils.MockConcertoService{}
+	ds, err := NewLabelService(cs)
+	assert.Nil(err, "Couldn't load label service")
+	assert.NotNil(ds, "Label service not instanced")
+
+	// convertMap
+	mapIn, err := utils.ItemConvertParams(*labelIn)
+	assert.Nil(err, "Label test data corrupted")
+
+	// wrong json
+	dIn := []byte{10, 20, 30}
+
+	// call service
+	cs.On("Put", fmt.Sprintf("/labels/%s", labelIn.ID), mapIn).Return(dIn, 200, nil)
+	labelOut, err := ds.UpdateLabel(mapIn, labelIn.ID)
+
+	assert.NotNil(err, "We are expecting a marshalling error")
+	assert.Nil(labelOut, "Expecting nil output")
+	assert.Contains(err.Error(), "invalid character", "Error message should include the string 'invalid character'")
+
+	return labelOut
+}
+
+// DeleteLabelMocked test mocked function
+func DeleteLabelMocked(t *testing.T, labelIn *types.Label) {
+
+	assert := assert.New(t)
+
+	// wire up
+	cs := &utils.MockConcertoService{}
+	ds, err := NewLabelService(cs)
+	assert.Nil(err, "Couldn't load label service")
+	assert.NotNil(ds, "Label service not instanced")
+
+	// to json
+	dIn, err := json.Marshal(labelIn)
+	assert.Nil(err, "Label test data corrupted")
+
+	// call service
+	cs.On("Delete", fmt.Sprintf("/labels/%s", labelIn.ID)).Return(dIn, 200, nil)
+	err = ds.DeleteLabel(labelIn.ID)
+	assert.Nil(err, "Error deleting label")
+}
+
+// DeleteLabelFailErrMocked test mocked function
+func DeleteLabelFailErrMocked(t *testing.T, labelIn *types.Label) {
+
+	assert := assert.New(t)
+
+	// wire up
+	cs := &utils.MockConcertoService{}
+	ds, err := NewLabelService(cs)
+	assert.Nil(err, "Couldn't load label service")
+	assert.NotNil(ds, "Label service not instanced")
+
+	// to json
+	dIn, err := json.Marshal(labelIn)
+	assert.Nil(err, "Label test data corrupted")
+
+	// call service
+	cs.On("Delete", fmt.Sprintf("/labels/%s", labelIn.ID)).Return(dIn, 200, fmt.Errorf("mocked error"))
+	err = ds.DeleteLabel(labelIn.ID)
+
+	assert.NotNil(err, "We are expecting an error")
+	assert.Equal(err.Error(), "mocked error", "Error should be 'mocked error'")
+}
+
+// DeleteLabelFailStatusMocked test mocked function
+func DeleteLabelFailStatusMocked(t *testing.T, labelIn *types.Label) {
+
+	assert := assert.New(t)
+
+	// wire up
+	cs := &utils.MockConcertoService{}
+	ds, err := NewLabelService(cs)
+	assert.Nil(err, "Couldn't load label service")
+	assert.NotNil(ds, "Label service not instanced")
+
+	// to json
+	dIn, err := json.Marshal(labelIn)
+	assert.Nil(err, "Label test data corrupted")
+
+	// call service
+	cs.On("Delete", fmt.Sprintf("/labels/%s", labelIn.ID)).Return(dIn, 499, nil)
+	err = ds.DeleteLabel(labelIn.ID)
+
+	assert.NotNil(err, "We are expecting an status code error")
+	assert.Contains(err.Error(), "499", "Error should contain http code 499")
+}
+
+// DiscardLabelMocked test mocked function
+
+// GetLabeledResourceListMocked test mocked function
+func GetLabeledResourceListMocked(t *testing.T, labelIn *types.Label, labeledResourcesIn []*types.LabeledResource) []*types.LabeledResource {
+
+	assert := assert.New(t)
+
+	// wire up
+	cs := &utils.MockConcertoService{}
+	ds, err := NewLabelService(cs)
+	assert.Nil(err, "Couldn't load label service")
+	assert.NotNil(ds, "Label service not instanced")
+
+	// to json
+	dIn, err := json.Marshal(labeledResourcesIn)
+	assert.Nil(err, "Label test data corrupted")
+
+	// call service
+	cs.On("Get", fmt.Sprintf("/labels/%s/resources", labelIn.ID)).Return(dIn, 200, nil)
+	labeledResourcesOut, err := ds.GetLabeledResourceList(labelIn.ID)
+	assert.Nil(err, "Error getting labeled resources list")
+	assert.Equal(labeledResourcesIn, labeledResourcesOut, "GetLabeledResourceList returned different labeled resources")
+
+	return labeledResourcesOut
+}
+
+// GetLabeledResourceListFailErrMocked test mocked function
+func GetLabeledResourceListFailErrMocked(t *testing.T, labelIn *types.Label, labeledResourcesIn []*types.LabeledResource) []*types.LabeledResource {
+
+	assert := assert.New(t)
+
+	// wire up
+	cs := &utils.MockConcertoService{}
+	ds, err := NewLabelService(cs)
+	assert.Nil(err, "Couldn't load label service")
+	assert.NotNil(ds, "Label service not instanced")
+
+	// to json
+	dIn, err := json.Marshal(labeledResourcesIn)
+	assert.Nil(err, "Label test data corrupted")
+
+	// call service
+	cs.On("Get", fmt.Sprintf("/labels/%s/resources", labelIn.ID)).Return(dIn, 200, fmt.Errorf("mocked error"))
+	labeledResourcesOut, err := ds.GetLabeledResourceList(labelIn.ID)
+
+	assert.NotNil(err, "We are expecting an error")
+	assert.Nil(labeledResourcesOut, "Expecting nil output")
+	assert.Equal(err.Error(), "mocked error", "Error should be 'mocked error'")
+
+	return labeledResourcesOut
+}
+
+// GetLabeledResourceListFailStatusMocked test mocked function
+func GetLabeledResourceListFailStatusMocked(t *testing.T, labelIn *types.Label, labeledResourcesIn []*types.LabeledResource) []*types.LabeledResource {
+
+	assert := assert.New(t)
+
+	// wire up
+	cs := &utils.MockConcertoService{}
+	ds, err := NewLabelService(cs)
+	assert.Nil(err, "Couldn't load label service")
+	assert.NotNil(ds, "Label service not instanced")
+
+	// to json
+	dIn, err := json.Marshal(labeledResourcesIn)
+	assert.Nil(err, "Label test data corrupted")
+
+	// call service
+	cs.On("Get", fmt.Sprintf("/labels/%s/resources", labelIn.ID)).Return(dIn, 499, nil)
+	labeledResourcesOut, err := ds.GetLabeledResourceList(labelIn.ID)
+
+	assert.NotNil(err, "We are expecting an status code error")
+	assert.Nil(labeledResourcesOut, "Expecting nil output")
+	assert.Contains(err.Error(), "499", "Error should contain http code 499")
+
+	return labeledResourcesOut
+}
+
+// GetLabeledResourceListFailJSONMocked test mocked function
+func GetLabeledResourceListFailJSONMocked(t *testing.T, labelIn *types.Label, labeledResourcesIn []*types.LabeledResource) []*types.LabeledResource {
+
+	assert := assert.New(t)
+
+	// wire up
+	cs := &utils.MockConcertoService{}
+	ds, err := NewLabelService(cs)
+	assert.Nil(err, "Couldn't load label service")
+	assert.NotNil(ds, "Label service not instanced")
+
+	// wrong json
+	dIn := []byte{10, 20, 30}
+
+	// call service
+	cs.On("Get", fmt.Sprintf("/labels/%s/resources", labelIn.ID)).Return(dIn, 200, nil)
+	labeledResourcesOut, err := ds.GetLabeledResourceList(labelIn.ID)
+
+	assert.NotNil(err, "We are expecting a marshalling error")
+	assert.Nil(labeledResourcesOut, "Expecting nil output")
+	assert.Contains(err.Error(), "invalid character", "Error message should include the string 'invalid character'")
+
+	return labeledResourcesOut
+}
@@ -18,6 +18,7 @@ func TestGetLabelsList(t *testing.T) {
 	labelsIn := testdata.GetLabelData()
 	GetLabelListMocked(t, labelsIn)
 	GetLabelListMockedWithNamespace(t, testdata.GetLabelWithNamespaceData())
+	GetLabelListWithNamespacesMocked(t, testdata.GetLabelWithNamespaceData())
 	GetLabelListFailErrMocked(t, labelsIn)
 	GetLabelListFailStatusMocked(t, labelsIn)
 	GetLabelListFailJSONMocked(t, labelsIn)
@@ -52,3 +53,43 @@ func TestRemoveLabel(t *testing.T) {
 		RemoveLabelFailStatusMocked(t, labelIn)
 	}
 }
+
+func TestGetLabel(t *testing.T) {
+	labelsIn := testdata.GetLabelData()
+	for _, labelIn := range labelsIn {
+		GetLabelMocked(t, labelIn)
+		GetLabelFailErrMocked(t, labelIn)
+		GetLabelFailStatusMocked(t, labelIn)
+		GetLabelFailJSONMocked(t, labelIn)
+	}
+}
+
+func TestUpdateLabel(t *testing.T) {
+	labelsIn := testdata.GetLabelData()
+	for _, labelIn := range labelsIn {
+		UpdateLabelMocked(t, labelIn)
+		UpdateLabelFailErrMocked(t, labelIn)
+		UpdateLabelFailStatusMocked(t, labelIn)
+		UpdateLabelFailJSONMocked(t, labelIn)
+	}
+}
+
+func TestDeleteLabel(t *testing.T) {
+	labelsIn := testdata.GetLabelData()
+	for _, labelIn := range labelsIn {
+		DeleteLabelMocked(t, labelIn)
+		DeleteLabelFailErrMocked(t, labelIn)
+		DeleteLabelFailStatusMocked(t, labelIn)
+	}
+}
+
+func TestGetLabeledResourceList(t *testing.T) {
+	labelsIn := testdata.GetLabelData()
+	labeledResourcesIn := testdata.GetLabeledResourcesData()
+	for _, labelIn := range labelsIn {
+		GetLabeledResourceListMocked(t, labelIn, labeledResourcesIn)
+		GetLabeledResourceListFailErrMocked(t, labelIn, labeledResourcesIn)
+		GetLabeledResourceListFailStatusMocked(t, labelIn, labeledResourcesIn)
+		GetLabeledResourceListFailJSONMocked(t, labelIn, labeledResourcesIn)
+	}
+}
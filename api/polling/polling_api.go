@@ -8,6 +8,14 @@ import (
 	"github.com/ingrammicro/concerto/utils"
 )
 
+// BootstrapLogsPath is the path where the command logs are reported
+const BootstrapLogsPath = "/command_polling/bootstrap_logs"
+
+// CommandPath returns the path of the command with the given ID
+func CommandPath(ID string) string {
+	return fmt.Sprintf("/command_polling/commands/%s", ID)
+}
+
 // PollingService manages polling operations
 type PollingService struct {
 	concertoService utils.ConcertoService
@@ -61,7 +69,7 @@ func (p *PollingService) GetNextCommand() (command *types.PollingCommand, status
 func (p *PollingService) UpdateCommand(pollingCommandVector *map[string]interface{}, ID string) (command *types.PollingCommand, status int, err error) {
 	log.Debug("UpdateCommand")
 
-	data, status, err := p.concertoService.Put(fmt.Sprintf("/command_polling/commands/%s", ID), pollingCommandVector)
+	data, status, err := p.concertoService.Put(CommandPath(ID), pollingCommandVector)
 	if err != nil {
 		return nil, status, err
 	}
@@ -77,7 +85,7 @@ func (p *PollingService) UpdateCommand(pollingCommandVector *map[string]interfac
 func (p *PollingService) ReportBootstrapLog(PollingContinuousReportVector *map[string]interface{}) (command *types.PollingContinuousReport, status int, err error) {
 	log.Debug("ReportBootstrapLog")
 
-	data, status, err := p.concertoService.Post("/command_polling/bootstrap_logs", PollingContinuousReportVector)
+	data, status, err := p.concertoService.Post(BootstrapLogsPath, PollingContinuousReportVector)
 	if err != nil {
 		return nil, status, err
 	}
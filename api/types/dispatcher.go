@@ -11,12 +11,13 @@ type DispatcherScript struct {
 	Code            string   `json:"code"`
 	UUID            string   `json:"uuid"`
 	AttachmentPaths []string `json:"attachment_paths"`
+	Interpreter     string   `json:"interpreter"`
 }
 
 type ScriptConclusion struct {
-	UUID       string `json:"script_characterization_id"`
-	Output     string `json:"output"`
-	ExitCode   int    `json:"exit_code"`
-	StartedAt  string `json:"started_at"`
-	FinishedAt string `json:"finished_at"`
+	UUID       string `json:"script_characterization_id" header:"SCRIPT_CHARACTERIZATION_ID"`
+	Output     string `json:"output" header:"OUTPUT"`
+	ExitCode   int    `json:"exit_code" header:"EXIT_CODE"`
+	StartedAt  string `json:"started_at" header:"STARTED_AT"`
+	FinishedAt string `json:"finished_at" header:"FINISHED_AT"`
 }
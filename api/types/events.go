@@ -1,6 +1,10 @@
 package types
 
 import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -12,3 +16,95 @@ type Event struct {
 	Header      string    `json:"header" header:"HEADER"`
 	Description string    `json:"description" header:"DESCRIPTION"`
 }
+
+// EventFilter restricts a list of events. Its zero fields don't restrict it.
+type EventFilter struct {
+	Since  time.Time
+	Until  time.Time
+	Levels []string
+	Text   string
+}
+
+// ParseEventTime parses an absolute time (RFC 3339 or YYYY-MM-DD) or a duration before now, such as 90m, 1h or 2d
+func ParseEventTime(value string, now time.Time) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	if strings.HasSuffix(value, "d") {
+		if days, err := strconv.Atoi(strings.TrimSuffix(value, "d")); err == nil && days >= 0 {
+			return now.AddDate(0, 0, -days), nil
+		}
+	}
+	if d, err := time.ParseDuration(value); err == nil && d >= 0 {
+		return now.Add(-d), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q, it must be a date, a RFC 3339 time or a duration such as 1h or 2d", value)
+}
+
+// QueryString returns the time window and levels of the filter as query parameters, for the API to apply them
+func (f *EventFilter) QueryString() string {
+	if f == nil {
+		return ""
+	}
+	params := url.Values{}
+	if !f.Since.IsZero() {
+		params.Set("since", f.Since.UTC().Format(time.RFC3339))
+	}
+	if !f.Until.IsZero() {
+		params.Set("until", f.Until.UTC().Format(time.RFC3339))
+	}
+	if len(f.Levels) > 0 {
+		params.Set("level", strings.Join(f.Levels, ","))
+	}
+	if len(params) == 0 {
+		return ""
+	}
+	return "?" + params.Encode()
+}
+
+// Matches tells whether the event is within the time window, has one of the levels and contains the text,
+// either in its header or its description
+func (f *EventFilter) Matches(event *Event) bool {
+	if f == nil {
+		return true
+	}
+	if !f.Since.IsZero() && event.Timestamp.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && !event.Timestamp.Before(f.Until) {
+		return false
+	}
+	if len(f.Levels) > 0 {
+		found := false
+		for _, level := range f.Levels {
+			found = found || strings.EqualFold(level, event.Level)
+		}
+		if !found {
+			return false
+		}
+	}
+	if f.Text != "" {
+		text := strings.ToLower(f.Text)
+		if !strings.Contains(strings.ToLower(event.Header), text) && !strings.Contains(strings.ToLower(event.Description), text) {
+			return false
+		}
+	}
+	return true
+}
+
+// Filter returns the events matching the filter, as the API may ignore some of its query parameters
+func (f *EventFilter) Filter(events []*Event) []*Event {
+	if f == nil || events == nil {
+		return events
+	}
+	filtered := []*Event{}
+	for _, event := range events {
+		if f.Matches(event) {
+			filtered = append(filtered, event)
+		}
+	}
+	return filtered
+}
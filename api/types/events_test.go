@@ -0,0 +1,53 @@
+package types
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseEventTime(t *testing.T) {
+	assert := assert.New(t)
+
+	now := time.Date(2018, 6, 15, 12, 0, 0, 0, time.UTC)
+	tests := map[string]time.Time{
+		"1h":                   now.Add(-time.Hour),
+		"90m":                  now.Add(-90 * time.Minute),
+		"2d":                   now.AddDate(0, 0, -2),
+		"2018-06-01":           time.Date(2018, 6, 1, 0, 0, 0, 0, time.UTC),
+		"2018-06-01T10:30:00Z": time.Date(2018, 6, 1, 10, 30, 0, 0, time.UTC),
+	}
+	for value, expected := range tests {
+		parsed, err := ParseEventTime(value, now)
+		assert.Nil(err, "%s should be parsed", value)
+		assert.True(expected.Equal(parsed), "Unexpected time for %s: %v", value, parsed)
+	}
+
+	for _, value := range []string{"", "yesterday", "-1h", "1x"} {
+		_, err := ParseEventTime(value, now)
+		assert.NotNil(err, "%q should fail", value)
+	}
+}
+
+func TestEventFilter(t *testing.T) {
+	assert := assert.New(t)
+
+	events := []*Event{
+		{ID: "1", Timestamp: time.Date(2018, 6, 1, 0, 0, 0, 0, time.UTC), Level: "info", Header: "Server booted"},
+		{ID: "2", Timestamp: time.Date(2018, 6, 2, 0, 0, 0, 0, time.UTC), Level: "error", Description: "Server stalled"},
+		{ID: "3", Timestamp: time.Date(2018, 6, 3, 0, 0, 0, 0, time.UTC), Level: "warning", Header: "Volume detached"},
+	}
+	filter := &EventFilter{Since: events[1].Timestamp, Until: events[2].Timestamp}
+	assert.Equal(events[1:2], filter.Filter(events), "Unexpected time window")
+
+	filter = &EventFilter{Levels: []string{"ERROR", "warning"}, Text: "server"}
+	assert.Equal(events[1:2], filter.Filter(events), "Unexpected levels and text")
+
+	var noFilter *EventFilter
+	assert.Equal(events, noFilter.Filter(events), "Nil filter should not restrict events")
+	assert.Equal("", noFilter.QueryString(), "Nil filter should have no query parameters")
+
+	filter = &EventFilter{Since: events[0].Timestamp, Levels: []string{"error", "warning"}, Text: "ignored"}
+	assert.Equal("?level=error%2Cwarning&since=2018-06-01T00%3A00%3A00Z", filter.QueryString(), "Unexpected query parameters")
+}
@@ -0,0 +1,166 @@
+package types
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Label selector operators
+const (
+	LabelSelectorExists    = "exists"
+	LabelSelectorNotExists = "!"
+	LabelSelectorEquals    = "="
+	LabelSelectorNotEquals = "!="
+	LabelSelectorIn        = "in"
+	LabelSelectorNotIn     = "notin"
+)
+
+var (
+	labelSelectorKeyRegexp   = regexp.MustCompile(`^(?:[A-Za-z0-9._-]+:)?[A-Za-z0-9 .\s_-]+$`)
+	labelSelectorValueRegexp = regexp.MustCompile(`^[A-Za-z0-9 .\s_-]*$`)
+	labelSelectorSetRegexp   = regexp.MustCompile(`^(.+?)\s+(in|notin)\s*\((.*)\)$`)
+)
+
+// LabelRequirement is a condition over the labels with a given key
+type LabelRequirement struct {
+	Key      string
+	Operator string
+	Values   []string
+}
+
+// LabelSelector selects labelable resources by their labels. Every requirement must be met.
+type LabelSelector struct {
+	Requirements []*LabelRequirement
+}
+
+// ParseLabelSelector parses a comma separated list of requirements, each of them being one of:
+//
+//	key                       the resource has a label with the key
+//	!key                      the resource has no label with the key
+//	key=value, key==value     the resource has the label with the key and value
+//	key!=value                the resource doesn't have the label with the key and value
+//	key in (value1,value2)    the resource has a label with the key and any of the values
+//	key notin (value1,value2) the resource has no label with the key and any of the values
+//
+// Keys are given as [namespace:]name. A plain label name is therefore an existence check.
+func ParseLabelSelector(selector string) (*LabelSelector, error) {
+	ls := &LabelSelector{Requirements: []*LabelRequirement{}}
+	for _, term := range splitLabelSelector(selector) {
+		term = strings.TrimSpace(term)
+		if term == "" {
+			continue
+		}
+		requirement, err := parseLabelRequirement(term)
+		if err != nil {
+			return nil, err
+		}
+		ls.Requirements = append(ls.Requirements, requirement)
+	}
+	if len(ls.Requirements) == 0 {
+		return nil, fmt.Errorf("empty label selector")
+	}
+	return ls, nil
+}
+
+// splitLabelSelector splits the selector by the commas which are not within parentheses
+func splitLabelSelector(selector string) []string {
+	terms := []string{}
+	depth, start := 0, 0
+	for i, r := range selector {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				terms = append(terms, selector[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(terms, selector[start:])
+}
+
+// parseLabelRequirement parses a single requirement of a selector
+func parseLabelRequirement(term string) (*LabelRequirement, error) {
+	requirement := &LabelRequirement{}
+	if values := labelSelectorSetRegexp.FindStringSubmatch(term); len(values) > 0 {
+		requirement.Key, requirement.Operator = strings.TrimSpace(values[1]), values[2]
+		for _, value := range strings.Split(values[3], ",") {
+			requirement.Values = append(requirement.Values, strings.TrimSpace(value))
+		}
+	} else if strings.HasPrefix(term, "!") && !strings.Contains(term, "=") {
+		requirement.Key, requirement.Operator = strings.TrimSpace(term[1:]), LabelSelectorNotExists
+	} else if i := strings.Index(term, "!="); i >= 0 {
+		requirement.Key, requirement.Operator = strings.TrimSpace(term[:i]), LabelSelectorNotEquals
+		requirement.Values = []string{strings.TrimSpace(term[i+2:])}
+	} else if i := strings.Index(term, "="); i >= 0 {
+		requirement.Key, requirement.Operator = strings.TrimSpace(term[:i]), LabelSelectorEquals
+		requirement.Values = []string{strings.TrimSpace(strings.TrimPrefix(term[i+1:], "="))}
+	} else {
+		requirement.Key, requirement.Operator = term, LabelSelectorExists
+	}
+
+	if !labelSelectorKeyRegexp.MatchString(requirement.Key) {
+		return nil, fmt.Errorf("invalid label selector %q: invalid key %q", term, requirement.Key)
+	}
+	for _, value := range requirement.Values {
+		if !labelSelectorValueRegexp.MatchString(value) {
+			return nil, fmt.Errorf("invalid label selector %q: invalid value %q", term, value)
+		}
+	}
+	return requirement, nil
+}
+
+// UnknownKeys returns the keys required by the selector which none of the labels has, as those are likely typos
+func (ls *LabelSelector) UnknownKeys(labels []*Label) []string {
+	known := map[string]bool{}
+	for _, label := range labels {
+		known[label.Key()] = true
+	}
+	unknown := []string{}
+	for _, requirement := range ls.Requirements {
+		if !known[requirement.Key] {
+			unknown = append(unknown, requirement.Key)
+		}
+	}
+	return unknown
+}
+
+// Matches tells whether the labels meet every requirement of the selector
+func (ls *LabelSelector) Matches(labels []*Label) bool {
+	for _, requirement := range ls.Requirements {
+		if !requirement.Matches(labels) {
+			return false
+		}
+	}
+	return true
+}
+
+// Matches tells whether the labels meet the requirement
+func (lr *LabelRequirement) Matches(labels []*Label) bool {
+	found, valueFound := false, false
+	for _, label := range labels {
+		if label.Key() != lr.Key {
+			continue
+		}
+		found = true
+		for _, value := range lr.Values {
+			valueFound = valueFound || label.Value == value
+		}
+	}
+
+	switch lr.Operator {
+	case LabelSelectorExists:
+		return found
+	case LabelSelectorNotExists:
+		return !found
+	case LabelSelectorEquals, LabelSelectorIn:
+		return valueFound
+	case LabelSelectorNotEquals, LabelSelectorNotIn:
+		return !valueFound
+	}
+	return false
+}
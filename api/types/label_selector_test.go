@@ -0,0 +1,95 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseLabel(t *testing.T) {
+	assert := assert.New(t)
+
+	label, err := ParseLabel("acme:env=prod")
+	assert.Nil(err, "Qualified label should be parsed")
+	assert.Equal(&Label{Namespace: "acme", Name: "env", Value: "prod"}, label, "Unexpected label")
+	assert.Equal("acme:env=prod", label.QualifiedName(), "Unexpected qualified name")
+	assert.Equal("acme:env", label.Key(), "Unexpected key")
+
+	label, err = ParseLabel("my label")
+	assert.Nil(err, "Plain label should be parsed")
+	assert.Equal(&Label{Name: "my label"}, label, "Unexpected label")
+
+	_, err = ParseLabel("bad/label")
+	assert.NotNil(err, "Invalid label should fail")
+}
+
+func TestLabelSelectorMatches(t *testing.T) {
+	assert := assert.New(t)
+
+	labels := []*Label{
+		{Name: "env", Value: "prod"},
+		{Name: "tier", Value: "web"},
+		{Namespace: "acme", Name: "team", Value: "a"},
+		{Name: "managed"},
+	}
+	tests := map[string]bool{
+		"managed":                      true,
+		"managed,env=prod":             true,
+		"env==prod":                    true,
+		"env=dev":                      false,
+		"tier!=db":                     true,
+		"tier!=web":                    false,
+		"!deprecated":                  true,
+		"!managed":                     false,
+		"acme:team in (a, b)":          true,
+		"acme:team in (b,c)":           false,
+		"team in (a,b)":                false,
+		"acme:team notin (b,c),env":    true,
+		"env=prod,tier in (web,app)":   true,
+		"env=prod,acme:team notin (a)": false,
+	}
+	for selector, expected := range tests {
+		ls, err := ParseLabelSelector(selector)
+		assert.Nil(err, "Selector %s should be parsed", selector)
+		assert.Equal(expected, ls.Matches(labels), "Unexpected match of %s", selector)
+	}
+}
+
+func TestParseLabelSelectorErrors(t *testing.T) {
+	assert := assert.New(t)
+
+	for _, selector := range []string{"", " , ", "env=a/b", "!env=prod", "team in (a/b)"} {
+		_, err := ParseLabelSelector(selector)
+		assert.NotNil(err, "Selector %q should fail", selector)
+	}
+}
+
+func TestLabelSelectorUnknownKeys(t *testing.T) {
+	assert := assert.New(t)
+
+	labels := []*Label{{Name: "env", Value: "prod"}, {Namespace: "imco", Name: "managed"}}
+	selector, err := ParseLabelSelector("env=prod,!evn,imco:managed,managed,tier in (web)")
+	assert.Nil(err, "Selector should be parsed")
+	assert.Equal([]string{"evn", "managed", "tier"}, selector.UnknownKeys(labels), "Unexpected unknown keys")
+}
+
+func TestFillInLabelNames(t *testing.T) {
+	assert := assert.New(t)
+
+	labelNamesByID := map[string]string{"label-1": "env=prod", "label-2": "imco:managed", "label-3": "tier=web", "label-4": "team:owner=alice"}
+	lf := &LabelableFields{LabelIDs: []string{"label-1", "label-2"}}
+	lf.FillInLabelNames(labelNamesByID)
+	assert.Equal([]string{"env=prod"}, lf.Labels, "Internal labels should not be shown")
+
+	lf = &LabelableFields{LabelIDs: []string{"label-4"}}
+	lf.FillInLabelNames(labelNamesByID)
+	assert.Equal([]string{"team:owner=alice"}, lf.Labels, "Labels of user namespaces should be shown qualified")
+}
+
+func TestLabelInternal(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.True((&Label{Namespace: InternalLabelNamespace, Name: "managed"}).Internal(), "Labels of the platform namespace should be internal")
+	assert.False((&Label{Namespace: "team", Name: "owner", Value: "alice"}).Internal(), "Labels of user namespaces should not be internal")
+	assert.False((&Label{Name: "env", Value: "prod"}).Internal(), "Labels without namespace should not be internal")
+}
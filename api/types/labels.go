@@ -1,5 +1,13 @@
 package types
 
+import (
+	"fmt"
+	"regexp"
+)
+
+// InternalLabelNamespace is the namespace of the labels the platform sets on resources for its own use
+const InternalLabelNamespace = "imco"
+
 type Label struct {
 	ID           string `json:"id" header:"ID"`
 	Name         string `json:"name" header:"NAME"`
@@ -8,6 +16,42 @@ type Label struct {
 	Value        string `json:"value" header:"VALUE" show:"nolist"`
 }
 
+// QualifiedName returns the label as [namespace:]name[=value], which is how labels are given in flags
+func (l *Label) QualifiedName() string {
+	name := l.Name
+	if l.Namespace != "" {
+		name = l.Namespace + ":" + name
+	}
+	if l.Value != "" {
+		name = name + "=" + l.Value
+	}
+	return name
+}
+
+// Internal tells whether the label is set by the platform for its own use, rather than by users
+func (l *Label) Internal() bool {
+	return l.Namespace == InternalLabelNamespace
+}
+
+// Key returns the label as [namespace:]name, which is how labels are referenced in selectors
+func (l *Label) Key() string {
+	if l.Namespace != "" {
+		return l.Namespace + ":" + l.Name
+	}
+	return l.Name
+}
+
+var labelQualifiedNameRegexp = regexp.MustCompile(`^(?:([A-Za-z0-9._-]+):)?([A-Za-z0-9 .\s_-]+?)(?:=([A-Za-z0-9 .\s_-]*))?$`)
+
+// ParseLabel parses a label given as [namespace:]name[=value]
+func ParseLabel(qualifiedName string) (*Label, error) {
+	values := labelQualifiedNameRegexp.FindStringSubmatch(qualifiedName)
+	if len(values) == 0 {
+		return nil, fmt.Errorf("invalid label format: %v (Labels would be indicated as [namespace:]name[=value]; name and value must be composed of spaces, underscores, dots, dashes and/or lower/upper -case alphanumeric characters-, and namespace cannot contain spaces)", qualifiedName)
+	}
+	return &Label{Namespace: values[1], Name: values[2], Value: values[3]}, nil
+}
+
 type LabeledResource struct {
 	ID           string `json:"id" header:"ID"`
 	ResourceType string `json:"resource_type" header:"RESOURCE_TYPE"`
@@ -20,6 +64,7 @@ type LabelableFields struct {
 
 type Labelable interface {
 	FilterByLabelIDs(labelIDs []string) bool
+	FilterByLabelSelector(selector *LabelSelector, labelsByID map[string]*Label) bool
 	AssignLabelIDs(labelIDs []string)
 	FillInLabelNames(labelNamesByID map[string]string)
 }
@@ -40,6 +85,17 @@ func (lf *LabelableFields) FilterByLabelIDs(labelIDs []string) bool {
 	return true
 }
 
+// FilterByLabelSelector tells whether the labels, resolved through labelsByID, satisfy the selector
+func (lf *LabelableFields) FilterByLabelSelector(selector *LabelSelector, labelsByID map[string]*Label) bool {
+	labels := []*Label{}
+	for _, resourceLabelID := range lf.LabelIDs {
+		if label, found := labelsByID[resourceLabelID]; found {
+			labels = append(labels, label)
+		}
+	}
+	return selector.Matches(labels)
+}
+
 func (lf *LabelableFields) AssignLabelIDs(labelIDs []string) {
 	for _, lid := range labelIDs {
 		for _, resourceLabelID := range lf.LabelIDs {
@@ -50,8 +106,12 @@ func (lf *LabelableFields) AssignLabelIDs(labelIDs []string) {
 	}
 }
 
+// FillInLabelNames sets the names of the labels, as [namespace:]name[=value], leaving out the internal ones
 func (lf *LabelableFields) FillInLabelNames(labelNamesByID map[string]string) {
 	for lID, lName := range labelNamesByID {
+		if label, err := ParseLabel(lName); err == nil && label.Internal() {
+			continue
+		}
 		for _, resourceLabelID := range lf.LabelIDs {
 			if lID == resourceLabelID {
 				lf.Labels = append(lf.Labels, lName)
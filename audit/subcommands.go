@@ -9,14 +9,23 @@ import (
 func SubCommands() []cli.Command {
 	return []cli.Command{
 		{
-			Name:   "list-events",
-			Usage:  "Returns information about the events related to the account group.",
-			Action: cmd.EventList,
+			Name:    "list-events",
+			Aliases: []string{"list"},
+			Usage:   "Returns information about the events related to the account group.",
+			Action:  cmd.EventList,
+			Flags:   cmd.EventFilterFlags(),
 		},
 		{
 			Name:   "list-system-events",
 			Usage:  "Returns information about system-wide events.",
 			Action: cmd.SysEventList,
+			Flags:  cmd.EventFilterFlags(),
+		},
+		{
+			Name:   "forward",
+			Usage:  "Keeps delivering new events to syslog servers, JSON Lines files or webhooks.",
+			Action: cmd.EventForward,
+			Flags:  cmd.EventForwardFlags(),
 		},
 	}
 }
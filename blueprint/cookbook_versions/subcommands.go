@@ -15,7 +15,7 @@ func SubCommands() []cli.Command {
 			Flags: []cli.Flag{
 				cli.StringFlag{
 					Name:  "labels",
-					Usage: "A list of comma separated label as a query filter",
+					Usage: "A label selector as a query filter, such as env=prod,tier!=db,team in (a,b),!deprecated",
 				},
 			},
 		},
@@ -34,16 +34,39 @@ func SubCommands() []cli.Command {
 			Name:   "upload",
 			Usage:  "Uploads a new cookbook version",
 			Action: cmd.CookbookVersionUpload,
-			Flags: []cli.Flag{
+			Flags: append([]cli.Flag{
 				cli.StringFlag{
 					Name:  "filepath",
 					Usage: "path to cookbook version file",
 				},
 				cli.StringFlag{
 					Name:  "labels",
-					Usage: "A list of comma separated label names to be associated with cookbook version",
+					Usage: "A list of comma separated labels, as [namespace:]name[=value], to be associated with cookbook version",
 				},
-			},
+			}, cmd.WaitFlags("ready")...),
+		},
+		{
+			Name:   "package",
+			Usage:  "Validates the metadata of a local cookbook and packages it, optionally uploading it as a new cookbook version",
+			Action: cmd.CookbookVersionPackage,
+			Flags: append([]cli.Flag{
+				cli.StringFlag{
+					Name:  "dir",
+					Usage: "Directory of the cookbook, with its metadata.rb or metadata.json",
+				},
+				cli.StringFlag{
+					Name:  "output",
+					Usage: "Path of the package to write. By default, it is <name>-<version>.tar.gz in the current directory",
+				},
+				cli.BoolFlag{
+					Name:  "upload",
+					Usage: "Uploads the package as a new cookbook version",
+				},
+				cli.StringFlag{
+					Name:  "labels",
+					Usage: "A list of comma separated labels, as [namespace:]name[=value], to be associated with the uploaded cookbook version",
+				},
+			}, cmd.WaitFlags("ready")...),
 		},
 		{
 			Name:   "delete",
@@ -15,7 +15,7 @@ func SubCommands() []cli.Command {
 			Flags: []cli.Flag{
 				cli.StringFlag{
 					Name:  "labels",
-					Usage: "A list of comma separated label as a query filter",
+					Usage: "A label selector as a query filter, such as env=prod,tier!=db,team in (a,b),!deprecated",
 				},
 			},
 		},
@@ -53,7 +53,7 @@ func SubCommands() []cli.Command {
 				},
 				cli.StringFlag{
 					Name:  "labels",
-					Usage: "A list of comma separated label names to be associated with script",
+					Usage: "A list of comma separated labels, as [namespace:]name[=value], to be associated with script",
 				},
 			},
 		},
@@ -125,6 +125,61 @@ func SubCommands() []cli.Command {
 				},
 			},
 		},
+		{
+			Name:   "sync",
+			Usage:  "Creates, updates and deletes scripts and their attachments to match a directory with a subdirectory per script",
+			Action: cmd.ScriptSync,
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "dir",
+					Usage: "Directory with a subdirectory per script, holding script.yml (name, description, parameters and labels), the script file and an attachments directory",
+				},
+				cli.BoolFlag{
+					Name:  "prune",
+					Usage: "Deletes the scripts which are not in the directory",
+				},
+				cli.BoolFlag{
+					Name:  "check",
+					Usage: "Shows the changes without applying them, failing when there are any",
+				},
+				cli.BoolFlag{
+					Name:  "dry-run",
+					Usage: "Shows the changes without applying them",
+				},
+				cli.BoolFlag{
+					Name:  "yes",
+					Usage: "Applies the changes without confirmation",
+				},
+			},
+		},
+		{
+			Name:   "run",
+			Usage:  "Runs a script with its attachments and parameters, locally or in a container, as servers run it, exiting with the exit code of the script",
+			Action: cmd.ScriptRun,
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "id",
+					Usage: "Script Id",
+				},
+				cli.StringSliceFlag{
+					Name:  "param",
+					Usage: "A script parameter value, as KEY=VALUE. Can be given several times",
+				},
+				cli.StringFlag{
+					Name:  "interpreter",
+					Usage: "Interpreter of the script, one of sh, bash, python or pwsh. By default, the shebang line of the script is honoured",
+				},
+				cli.StringFlag{
+					Name:  "runner",
+					Usage: "Where the script is run: local, docker or podman",
+					Value: cmd.ScriptRunnerLocal,
+				},
+				cli.StringFlag{
+					Name:  "image",
+					Usage: "Image of the container the script is run in, for the docker and podman runners",
+				},
+			},
+		},
 		{
 			Name:   "add-label",
 			Usage:  "This action assigns a single label from a single labelable resource",
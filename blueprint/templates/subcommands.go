@@ -15,7 +15,7 @@ func SubCommands() []cli.Command {
 			Flags: []cli.Flag{
 				cli.StringFlag{
 					Name:  "labels",
-					Usage: "A list of comma separated label as a query filter",
+					Usage: "A label selector as a query filter, such as env=prod,tier!=db,team in (a,b),!deprecated",
 				},
 			},
 		},
@@ -61,7 +61,7 @@ func SubCommands() []cli.Command {
 				},
 				cli.StringFlag{
 					Name:  "labels",
-					Usage: "A list of comma separated label names to be associated with template",
+					Usage: "A list of comma separated labels, as [namespace:]name[=value], to be associated with template",
 				},
 			},
 		},
@@ -118,6 +118,80 @@ func SubCommands() []cli.Command {
 				},
 			},
 		},
+		{
+			Name:   "clone",
+			Usage:  "Creates a copy of a template, along with its script characterisations, cookbook versions and labels",
+			Action: cmd.TemplateClone,
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "id",
+					Usage: "Template Id",
+				},
+				cli.StringFlag{
+					Name:  "name",
+					Usage: "Name of the new template",
+				},
+				cli.StringFlag{
+					Name:  "labels",
+					Usage: "A list of comma separated label names to be associated with the new template, instead of the original ones",
+				},
+			},
+		},
+		{
+			Name:   "diff",
+			Usage:  "Compares two templates: attributes, run list, cookbook versions and script characterisations by type and order",
+			Action: cmd.TemplateDiff,
+			Flags: []cli.Flag{
+				cli.StringSliceFlag{
+					Name:  "id",
+					Usage: "Template Id, given once per template",
+				},
+			},
+		},
+		{
+			Name:   "export",
+			Usage:  "Writes a template, along with its script characterisations, cookbook versions and labels, to a versioned YAML or JSON document",
+			Action: cmd.TemplateExport,
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "id",
+					Usage: "Template Id",
+				},
+				cli.StringFlag{
+					Name:  "file, f",
+					Usage: "Document file to write, or \"-\" to write it to STDOUT",
+					Value: "-",
+				},
+				cli.StringFlag{
+					Name:  "format",
+					Usage: "Document format: yaml or json",
+					Value: "yaml",
+				},
+			},
+		},
+		{
+			Name:   "import",
+			Usage:  "Creates or updates, by name, the template of a document written by export",
+			Action: cmd.TemplateImport,
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "file, f",
+					Usage: "Document file (YAML or JSON), or \"-\" to read it from STDIN",
+				},
+				cli.StringFlag{
+					Name:  "name",
+					Usage: "Name of the template, instead of the one in the document",
+				},
+				cli.BoolFlag{
+					Name:  "dry-run",
+					Usage: "Shows the changes the import would apply, without applying them",
+				},
+				cli.BoolFlag{
+					Name:  "yes, y",
+					Usage: "Imports the template without asking for confirmation",
+				},
+			},
+		},
 		{
 			Name:   "list-template-scripts",
 			Usage:  "Shows the script characterisations of a template",
@@ -179,11 +179,12 @@ func start(c *cli.Context) error {
 	}
 	log.Debug("routine lines threshold: ", thresholdLines)
 	bootstrappingSvc, formatter := cmd.WireUpBootstrapping(c)
+	outbox := cmd.WireUpOutbox(c)
 
 	if config.BootstrapConfig.RunOnce {
-		return runBootstrapOnce(ctx, bootstrappingSvc, formatter, thresholdLines, interval, splay)
+		return runBootstrapOnce(ctx, bootstrappingSvc, outbox, formatter, thresholdLines, interval, splay)
 	}
-	return runBootstrapPeriodically(ctx, bootstrappingSvc, formatter, applyAfterIterations, thresholdLines, interval, splay)
+	return runBootstrapPeriodically(ctx, bootstrappingSvc, outbox, formatter, applyAfterIterations, thresholdLines, interval, splay)
 }
 
 // Stop the bootstrapping process
@@ -199,18 +200,23 @@ func stop(c *cli.Context) error {
 	return nil
 }
 
-func runBootstrapPeriodically(ctx context.Context, bootstrappingSvc *blueprint.BootstrappingService, formatter format.Formatter, applyAfterIterations, thresholdLines, interval, splay int) error {
+func runBootstrapPeriodically(ctx context.Context, bootstrappingSvc *blueprint.BootstrappingService, outbox *utils.Outbox, formatter format.Formatter, applyAfterIterations, thresholdLines, interval, splay int) error {
 	r := rand.New(rand.NewSource(time.Now().UnixNano()))
 	var blueprintConfig *types.BootstrappingConfiguration
 	var noPolicyfileApplicationIterations int
 	var lastPolicyfileApplicationErr, err error
 	for {
+		// deliver the reports queued while the platform was unreachable
+		if _, err = outbox.Flush(); err != nil {
+			formatter.PrintError("couldn't flush outbox", err)
+		}
+
 		var updated bool
 		blueprintConfig, updated, err = getBlueprintConfig(ctx, bootstrappingSvc, blueprintConfig, formatter)
 		if err == nil {
 			if updated || lastPolicyfileApplicationErr != nil || noPolicyfileApplicationIterations >= applyAfterIterations {
 				noPolicyfileApplicationIterations = -1
-				lastPolicyfileApplicationErr = applyPolicyfiles(ctx, bootstrappingSvc, blueprintConfig, formatter, thresholdLines)
+				lastPolicyfileApplicationErr = applyPolicyfiles(ctx, bootstrappingSvc, outbox, blueprintConfig, formatter, thresholdLines)
 			}
 		}
 		noPolicyfileApplicationIterations++
@@ -233,10 +239,10 @@ func runBootstrapPeriodically(ctx context.Context, bootstrappingSvc *blueprint.B
 	return nil
 }
 
-func runBootstrapOnce(ctx context.Context, bootstrappingSvc *blueprint.BootstrappingService, formatter format.Formatter, thresholdLines, interval, splay int) error {
+func runBootstrapOnce(ctx context.Context, bootstrappingSvc *blueprint.BootstrappingService, outbox *utils.Outbox, formatter format.Formatter, thresholdLines, interval, splay int) error {
 	blueprintConfig, _, err := getBlueprintConfig(ctx, bootstrappingSvc, nil, formatter)
 	if err == nil {
-		err = applyPolicyfiles(ctx, bootstrappingSvc, blueprintConfig, formatter, thresholdLines)
+		err = applyPolicyfiles(ctx, bootstrappingSvc, outbox, blueprintConfig, formatter, thresholdLines)
 	}
 	r := rand.New(rand.NewSource(time.Now().UnixNano()))
 	for i := 0; err != nil && i < 3; i++ {
@@ -253,7 +259,7 @@ func runBootstrapOnce(ctx context.Context, bootstrappingSvc *blueprint.Bootstrap
 		ticker.Stop()
 		blueprintConfig, _, err = getBlueprintConfig(ctx, bootstrappingSvc, nil, formatter)
 		if err == nil {
-			err = applyPolicyfiles(ctx, bootstrappingSvc, blueprintConfig, formatter, thresholdLines)
+			err = applyPolicyfiles(ctx, bootstrappingSvc, outbox, blueprintConfig, formatter, thresholdLines)
 		}
 	}
 	return err
@@ -288,7 +294,7 @@ func getBlueprintConfig(ctx context.Context, bootstrappingSvc *blueprint.Bootstr
 }
 
 // Subsidiary routine for commands processing
-func applyPolicyfiles(ctx context.Context, bootstrappingSvc *blueprint.BootstrappingService, blueprintConfig *types.BootstrappingConfiguration, formatter format.Formatter, thresholdLines int) error {
+func applyPolicyfiles(ctx context.Context, bootstrappingSvc *blueprint.BootstrappingService, outbox *utils.Outbox, blueprintConfig *types.BootstrappingConfiguration, formatter format.Formatter, thresholdLines int) error {
 	log.Debug("applyPolicyfiles")
 	err := generateWorkspaceDir()
 	if err != nil {
@@ -327,13 +333,13 @@ func applyPolicyfiles(ctx context.Context, bootstrappingSvc *blueprint.Bootstrap
 		return err
 	}
 	// Process tarballs policies
-	err = processPolicyfiles(bootstrappingSvc, bsProcess)
+	err = processPolicyfiles(bootstrappingSvc, outbox, bsProcess)
 	// Finishing time
 	bsProcess.finishedAt = time.Now().UTC()
 
 	// Inform the platform of applied changes via a `PUT /blueprint/applied_configuration` request with a JSON payload similar to
 	log.Debug("reporting applied policy files")
-	reportErr := reportAppliedConfiguration(bootstrappingSvc, bsProcess)
+	reportErr := reportAppliedConfiguration(bootstrappingSvc, outbox, bsProcess)
 	if reportErr != nil {
 		formatter.PrintError("couldn't report applied status for policy files", err)
 		return err
@@ -421,7 +427,7 @@ func saveAttributes(bsProcess *bootstrappingProcess) error {
 }
 
 // processPolicyfiles applies for each policy the required chef commands, reporting in bunches of N lines
-func processPolicyfiles(bootstrappingSvc *blueprint.BootstrappingService, bsProcess *bootstrappingProcess) error {
+func processPolicyfiles(bootstrappingSvc *blueprint.BootstrappingService, outbox *utils.Outbox, bsProcess *bootstrappingProcess) error {
 	log.Debug("processPolicyfiles")
 
 	for _, bsPolicyfile := range bsProcess.policyfiles {
@@ -444,25 +450,31 @@ func processPolicyfiles(bootstrappingSvc *blueprint.BootstrappingService, bsProc
 		// Custom method for chunks processing
 		fn := func(chunk string) error {
 			log.Debug("sendChunks")
-			err := utils.Retry(retriesNumber, time.Second, func() error {
-				log.Debug("Sending: ", chunk)
-
-				commandIn := map[string]interface{}{
-					"stdout": chunk,
-				}
-
-				_, statusCode, err := bootstrappingSvc.ReportBootstrappingLog(&commandIn)
-				switch {
-				// 0<100 error cases??
-				case statusCode == 0:
-					return fmt.Errorf("communication error %v %v", statusCode, err)
-				case statusCode >= 500:
-					return fmt.Errorf("server error %v %v", statusCode, err)
-				case statusCode >= 400:
-					return fmt.Errorf("client error %v %v", statusCode, err)
-				default:
-					return nil
-				}
+			commandIn := map[string]interface{}{
+				"stdout": chunk,
+			}
+
+			// chunks which cannot be sent are queued, so the buffer doesn't keep growing
+			_, err := outbox.Deliver("", "POST", blueprint.BootstrapLogsPath, &commandIn, func() (int, error) {
+				var statusCode int
+				err := utils.Retry(retriesNumber, time.Second, func() error {
+					log.Debug("Sending: ", chunk)
+
+					var err error
+					_, statusCode, err = bootstrappingSvc.ReportBootstrappingLog(&commandIn)
+					switch {
+					// 0<100 error cases??
+					case statusCode == 0:
+						return fmt.Errorf("communication error %v %v", statusCode, err)
+					case statusCode >= 500:
+						return fmt.Errorf("server error %v %v", statusCode, err)
+					case statusCode >= 400:
+						return fmt.Errorf("client error %v %v", statusCode, err)
+					default:
+						return nil
+					}
+				})
+				return statusCode, err
 			})
 
 			if err != nil {
@@ -492,7 +504,7 @@ func processPolicyfiles(bootstrappingSvc *blueprint.BootstrappingService, bsProc
 }
 
 // reportAppliedConfiguration Inform the platform of applied changes
-func reportAppliedConfiguration(bootstrappingSvc *blueprint.BootstrappingService, bsProcess *bootstrappingProcess) error {
+func reportAppliedConfiguration(bootstrappingSvc *blueprint.BootstrappingService, outbox *utils.Outbox, bsProcess *bootstrappingProcess) error {
 	log.Debug("reportAppliedConfiguration")
 
 	payload := map[string]interface{}{
@@ -501,5 +513,14 @@ func reportAppliedConfiguration(bootstrappingSvc *blueprint.BootstrappingService
 		"policyfile_revision_ids": bsProcess.appliedPolicyfileRevisionIDs,
 		"attribute_revision_id":   bsProcess.attributes.revisionID,
 	}
-	return bootstrappingSvc.ReportBootstrappingAppliedConfiguration(&payload)
+	_, err := outbox.Deliver("applied_configuration", "PUT", blueprint.AppliedConfigurationPath, &payload, func() (int, error) {
+		var status int
+		err := utils.Retry(retriesNumber, time.Second, func() error {
+			var err error
+			status, err = bootstrappingSvc.ReportBootstrappingAppliedConfiguration(&payload)
+			return err
+		})
+		return status, err
+	})
+	return err
 }
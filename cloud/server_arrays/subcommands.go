@@ -15,7 +15,7 @@ func SubCommands() []cli.Command {
 			Flags: []cli.Flag{
 				cli.StringFlag{
 					Name:  "labels",
-					Usage: "A list of comma separated label as a query filter",
+					Usage: "A label selector as a query filter, such as env=prod,tier!=db,team in (a,b),!deprecated",
 				},
 			},
 		},
@@ -73,7 +73,7 @@ func SubCommands() []cli.Command {
 				},
 				cli.StringFlag{
 					Name:  "labels",
-					Usage: "A list of comma separated label names to be associated with server array",
+					Usage: "A list of comma separated labels, as [namespace:]name[=value], to be associated with server array",
 				},
 			},
 		},
@@ -96,12 +96,12 @@ func SubCommands() []cli.Command {
 			Name:   "boot",
 			Usage:  "This action boots all the servers in the server array with the given id. The server array must be in an inactive state",
 			Action: cmd.ServerArrayBoot,
-			Flags: []cli.Flag{
+			Flags: append([]cli.Flag{
 				cli.StringFlag{
 					Name:  "id",
 					Usage: "Server Array Id",
 				},
-			},
+			}, cmd.WaitFlags("operational")...),
 		},
 		{
 			Name:   "shutdown",
@@ -140,6 +140,56 @@ func SubCommands() []cli.Command {
 				},
 			},
 		},
+		{
+			Name:   "roll",
+			Usage:  "This action replaces the servers of the server array with the given id in batches, keeping its capacity, so that they run its current template. When a batch fails, only its new servers are rolled back: previous batches stay replaced",
+			Action: cmd.ServerArrayRoll,
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "id",
+					Usage: "Server Array Id",
+				},
+				cli.IntFlag{
+					Name:  "batch-size",
+					Usage: "Number of servers replaced at the same time",
+					Value: cmd.DefaultRollBatchSize,
+				},
+				cli.BoolFlag{
+					Name:  "all",
+					Usage: "Replaces every server, not only the ones whose template differs from the server array one",
+				},
+				cli.StringFlag{
+					Name:  "health-script-id",
+					Usage: "Operational script, or script characterisation, which must succeed on every new server before the old ones are decommissioned",
+				},
+				cli.StringFlag{
+					Name:  "health-url",
+					Usage: "URL which must answer with a 2xx status for every new server before the old ones are decommissioned, such as http://{public_ip}:8080/health. The {public_ip}, {private_ip} and {fqdn} placeholders are replaced with the new server ones",
+				},
+				cli.IntFlag{
+					Name:  "health-timeout",
+					Usage: "Maximum time for a new server to pass the health check, in seconds",
+					Value: cmd.DefaultRollHealthTimeout,
+				},
+				cli.IntFlag{
+					Name:  "wait-timeout",
+					Usage: "Maximum time to wait for a server to become operational or inactive, in seconds",
+					Value: cmd.DefaultWaitTimeout,
+				},
+				cli.BoolFlag{
+					Name:  "no-rollback",
+					Usage: "Keeps the new servers of a failed batch, instead of decommissioning them",
+				},
+				cli.BoolFlag{
+					Name:  "dry-run",
+					Usage: "Shows the servers which would be replaced, by batch, without replacing them",
+				},
+				cli.BoolFlag{
+					Name:  "yes, y",
+					Usage: "Replaces the servers without asking for confirmation",
+				},
+			},
+		},
 		{
 			Name:   "list-servers",
 			Usage:  "This action list servers in server array with the given id",
@@ -204,5 +254,57 @@ func SubCommands() []cli.Command {
 				},
 			},
 		},
+		{
+			Name:  "bulk",
+			Usage: "Applies an action to every one of the server arrays matching the given labels",
+			Subcommands: []cli.Command{
+				{
+					Name:   "boot",
+					Usage:  "Boots every server array matching the given labels",
+					Action: cmd.ServerArrayBulk,
+					Flags:  cmd.BulkFlags(),
+				},
+				{
+					Name:   "shutdown",
+					Usage:  "Shuts down every server array matching the given labels",
+					Action: cmd.ServerArrayBulk,
+					Flags:  cmd.BulkFlags(),
+				},
+				{
+					Name:   "empty",
+					Usage:  "Empties every server array matching the given labels",
+					Action: cmd.ServerArrayBulk,
+					Flags:  cmd.BulkFlags(),
+				},
+				{
+					Name:   "delete",
+					Usage:  "Deletes every server array matching the given labels",
+					Action: cmd.ServerArrayBulk,
+					Flags:  cmd.BulkFlags(),
+				},
+				{
+					Name:   "add-label",
+					Usage:  "Assigns a single label to every server array matching the given labels",
+					Action: cmd.ServerArrayBulk,
+					Flags: append(cmd.BulkFlags(),
+						cli.StringFlag{
+							Name:  "label",
+							Usage: "Label name",
+						},
+					),
+				},
+				{
+					Name:   "remove-label",
+					Usage:  "Unassigns a single label from every server array matching the given labels",
+					Action: cmd.ServerArrayBulk,
+					Flags: append(cmd.BulkFlags(),
+						cli.StringFlag{
+							Name:  "label",
+							Usage: "Label name",
+						},
+					),
+				},
+			},
+		},
 	}
 }
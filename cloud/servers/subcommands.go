@@ -15,7 +15,7 @@ func SubCommands() []cli.Command {
 			Flags: []cli.Flag{
 				cli.StringFlag{
 					Name:  "labels",
-					Usage: "A list of comma separated label as a query filter",
+					Usage: "A label selector as a query filter, such as env=prod,tier!=db,team in (a,b),!deprecated",
 				},
 			},
 		},
@@ -61,7 +61,7 @@ func SubCommands() []cli.Command {
 				},
 				cli.StringFlag{
 					Name:  "labels",
-					Usage: "A list of comma separated label names to be associated with server",
+					Usage: "A list of comma separated labels, as [namespace:]name[=value], to be associated with server",
 				},
 			},
 		},
@@ -84,12 +84,12 @@ func SubCommands() []cli.Command {
 			Name:   "boot",
 			Usage:  "Boots a server with the given id",
 			Action: cmd.ServerBoot,
-			Flags: []cli.Flag{
+			Flags: append([]cli.Flag{
 				cli.StringFlag{
 					Name:  "id",
 					Usage: "Server Id",
 				},
-			},
+			}, cmd.WaitFlags("operational")...),
 		},
 		{
 			Name:   "reboot",
@@ -106,12 +106,12 @@ func SubCommands() []cli.Command {
 			Name:   "shutdown",
 			Usage:  "Shuts down a server with the given id",
 			Action: cmd.ServerShutdown,
-			Flags: []cli.Flag{
+			Flags: append([]cli.Flag{
 				cli.StringFlag{
 					Name:  "id",
 					Usage: "Server Id",
 				},
-			},
+			}, cmd.WaitFlags("inactive")...),
 		},
 		{
 			Name:   "override-server",
@@ -139,12 +139,12 @@ func SubCommands() []cli.Command {
 			Name:   "list-events",
 			Usage:  "This action returns information about the events related to the server with the given id.",
 			Action: cmd.EventsList,
-			Flags: []cli.Flag{
+			Flags: append([]cli.Flag{
 				cli.StringFlag{
 					Name:  "id",
 					Usage: "Server Id",
 				},
-			},
+			}, cmd.EventFilterFlags()...),
 		},
 		{
 			Name:   "list-operational-scripts",
@@ -172,6 +172,23 @@ func SubCommands() []cli.Command {
 				},
 			},
 		},
+		{
+			Name:  "fleet",
+			Usage: "Provides actions rolled out to many servers, waiting for their outcome",
+			Subcommands: []cli.Command{
+				{
+					Name:   "execute-script",
+					Usage:  "Executes an operational script on the servers matching the given labels, template or server array, in rolling batches, and summarises its outcome on every one of them",
+					Action: cmd.ServerFleetExecuteScript,
+					Flags: append(cmd.FleetFlags(),
+						cli.StringFlag{
+							Name:  "script-id",
+							Usage: "Identifier of the script to be executed, or of its characterisation",
+						},
+					),
+				},
+			},
+		},
 		{
 			Name:   "list-floating-ips",
 			Usage:  "This action returns information about the floating IPs attached to the server with the given id",
@@ -236,5 +253,74 @@ func SubCommands() []cli.Command {
 				},
 			},
 		},
+		{
+			Name:  "bulk",
+			Usage: "Applies an action to every one of the servers matching the given labels",
+			Subcommands: []cli.Command{
+				{
+					Name:   "boot",
+					Usage:  "Boots every server matching the given labels",
+					Action: cmd.ServerBulk,
+					Flags:  cmd.BulkFlags(),
+				},
+				{
+					Name:   "reboot",
+					Usage:  "Reboots every server matching the given labels",
+					Action: cmd.ServerBulk,
+					Flags:  cmd.BulkFlags(),
+				},
+				{
+					Name:   "shutdown",
+					Usage:  "Shuts down every server matching the given labels",
+					Action: cmd.ServerBulk,
+					Flags:  cmd.BulkFlags(),
+				},
+				{
+					Name:   "override-server",
+					Usage:  "Takes every server matching the given labels from a stalled state to the operational state, at the user's own risk",
+					Action: cmd.ServerBulk,
+					Flags:  cmd.BulkFlags(),
+				},
+				{
+					Name:   "delete",
+					Usage:  "Decommissions every server matching the given labels",
+					Action: cmd.ServerBulk,
+					Flags:  cmd.BulkFlags(),
+				},
+				{
+					Name:   "execute-script",
+					Usage:  "Executes an operational script in every server matching the given labels",
+					Action: cmd.ServerBulk,
+					Flags: append(cmd.BulkFlags(),
+						cli.StringFlag{
+							Name:  "script-id",
+							Usage: "Identifier of the script to be executed",
+						},
+					),
+				},
+				{
+					Name:   "add-label",
+					Usage:  "Assigns a single label to every server matching the given labels",
+					Action: cmd.ServerBulk,
+					Flags: append(cmd.BulkFlags(),
+						cli.StringFlag{
+							Name:  "label",
+							Usage: "Label name",
+						},
+					),
+				},
+				{
+					Name:   "remove-label",
+					Usage:  "Unassigns a single label from every server matching the given labels",
+					Action: cmd.ServerBulk,
+					Flags: append(cmd.BulkFlags(),
+						cli.StringFlag{
+							Name:  "label",
+							Usage: "Label name",
+						},
+					),
+				},
+			},
+		},
 	}
 }
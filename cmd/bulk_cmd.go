@@ -0,0 +1,349 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/codegangsta/cli"
+	"github.com/ingrammicro/concerto/api/types"
+	"github.com/ingrammicro/concerto/utils/format"
+)
+
+const (
+	DefaultBulkConcurrency = 4
+
+	bulkStatusDone    = "done"
+	bulkStatusFailed  = "failed"
+	bulkStatusPlanned = "dry-run"
+)
+
+// BulkResult is the outcome of a bulk action over a single resource
+type BulkResult struct {
+	ID     string `json:"id" header:"ID"`
+	Name   string `json:"name" header:"NAME"`
+	Action string `json:"action" header:"ACTION"`
+	Status string `json:"status" header:"STATUS"`
+	Error  string `json:"error,omitempty" header:"ERROR"`
+}
+
+// bulkTarget is a resource selected by a bulk action
+type bulkTarget struct {
+	ID   string
+	Name string
+}
+
+// bulkLabelsMapping loads the labels bulk actions are filtered by. Tests replace it to avoid reaching the API.
+var bulkLabelsMapping = LabelLoadsMapping
+
+// bulkAction applies an action to the resource with the given ID
+type bulkAction func(ID string) error
+
+// BulkFlags returns the flags shared by every bulk action
+func BulkFlags() []cli.Flag {
+	return []cli.Flag{
+		cli.StringFlag{
+			Name:  "labels",
+			Usage: "A label selector, such as env=prod,tier!=db. The action is applied to every resource matching it",
+		},
+		cli.BoolFlag{
+			Name:  "dry-run",
+			Usage: "Shows the resources which would be affected, without applying the action",
+		},
+		cli.BoolFlag{
+			Name:  "yes, y",
+			Usage: "Applies the action without asking for confirmation",
+		},
+		cli.IntFlag{
+			Name:  "concurrency",
+			Usage: "Maximum number of resources the action is applied to at the same time",
+			Value: DefaultBulkConcurrency,
+		},
+	}
+}
+
+// bulkFilter returns the labelables matching the label selector given in the labels flag, which is required.
+// Selectors referencing unknown label keys are rejected, so that a typo doesn't select the wrong resources.
+func bulkFilter(c *cli.Context, labelables []types.Labelable, formatter format.Formatter) []types.Labelable {
+	checkRequiredFlags(c, []string{"labels"}, formatter)
+
+	selector, err := types.ParseLabelSelector(c.String("labels"))
+	if err != nil {
+		formatter.PrintFatal("Invalid label selector", err)
+	}
+	labelIDsByName, _ := bulkLabelsMapping(c)
+	labels := []*types.Label{}
+	for _, label := range LabelsByID(labelIDsByName) {
+		labels = append(labels, label)
+	}
+	if unknown := selector.UnknownKeys(labels); len(unknown) > 0 {
+		formatter.PrintFatal("Invalid label selector", fmt.Errorf("unknown label keys: %s", strings.Join(unknown, ", ")))
+	}
+	return LabelFiltering(c, labelables, labelIDsByName)
+}
+
+// bulkLabelAction returns the add-label or remove-label action for resources of the given type
+func bulkLabelAction(c *cli.Context, resourceType string, formatter format.Formatter) bulkAction {
+	labelsSvc, _ := WireUpLabel(c)
+	checkRequiredFlags(c, []string{"label"}, formatter)
+	// the label is not resolved, as it would be created when missing
+	if c.Bool("dry-run") {
+		return nil
+	}
+
+	labelIDsByName, labelNamesByID := LabelLoadsMapping(c)
+	if c.Command.Name == "remove-label" {
+		labelID, found := labelIDsByName[c.String("label")]
+		if !found {
+			formatter.PrintFatal("Invalid label", fmt.Errorf("unknown label %s", c.String("label")))
+		}
+		return func(ID string) error {
+			return labelsSvc.RemoveLabel(labelID, resourceType, ID)
+		}
+	}
+
+	labelIDs := LabelResolution(c, c.String("label"), &labelNamesByID, &labelIDsByName)
+	if len(labelIDs) > 1 {
+		formatter.PrintFatal("Too many label names. Please, Use only one label name", fmt.Errorf("invalid parameter: %v - %v", c.String("label"), labelIDs))
+	}
+	return func(ID string) error {
+		labelIn := map[string]interface{}{
+			"resources": []interface{}{map[string]string{"id": ID, "resource_type": resourceType}},
+		}
+		_, err := labelsSvc.AddLabel(&labelIn, labelIDs[0])
+		return err
+	}
+}
+
+// confirmBulk asks the user to confirm the action over the targets
+func confirmBulk(action string, resourceType string, targets []*bulkTarget) bool {
+	fmt.Fprintf(os.Stderr, "Action %s will be applied to %d %s resources:\n", action, len(targets), resourceType)
+	for _, target := range targets {
+		fmt.Fprintf(os.Stderr, "\t%s\t%s\n", target.ID, target.Name)
+	}
+	fmt.Fprint(os.Stderr, "Do you want to continue? [y/N]: ")
+
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+// forEachConcurrently calls fn with every index below count, running at most concurrency calls at the same time,
+// or DefaultBulkConcurrency when it is not positive. It returns once every call has returned.
+func forEachConcurrently(concurrency int, count int, fn func(i int)) {
+	if concurrency <= 0 {
+		concurrency = DefaultBulkConcurrency
+	}
+	slots := make(chan bool, concurrency)
+	var wg sync.WaitGroup
+	for i := 0; i < count; i++ {
+		wg.Add(1)
+		slots <- true
+		go func(i int) {
+			defer func() {
+				<-slots
+				wg.Done()
+			}()
+			fn(i)
+		}(i)
+	}
+	wg.Wait()
+}
+
+// runBulk applies the action to every target, with bounded concurrency, and prints a result per target.
+// It is fatal for the action to fail on any of them.
+func runBulk(c *cli.Context, resourceType string, targets []*bulkTarget, action bulkAction, formatter format.Formatter) {
+	actionName := c.Command.Name
+	results := make([]*BulkResult, len(targets))
+	for i, target := range targets {
+		results[i] = &BulkResult{ID: target.ID, Name: target.Name, Action: actionName, Status: bulkStatusPlanned}
+	}
+
+	if c.Bool("dry-run") || len(targets) == 0 {
+		if err := formatter.PrintList(results); err != nil {
+			formatter.PrintFatal("Couldn't print/format result", err)
+		}
+		return
+	}
+	if !c.Bool("yes") && !confirmBulk(actionName, resourceType, targets) {
+		formatter.PrintFatal("Bulk action cancelled", fmt.Errorf("action %s has not been confirmed", actionName))
+	}
+
+	forEachConcurrently(c.Int("concurrency"), len(results), func(i int) {
+		if err := action(results[i].ID); err != nil {
+			results[i].Status = bulkStatusFailed
+			results[i].Error = err.Error()
+			return
+		}
+		results[i].Status = bulkStatusDone
+	})
+
+	if err := formatter.PrintList(results); err != nil {
+		formatter.PrintFatal("Couldn't print/format result", err)
+	}
+	failed := 0
+	for _, result := range results {
+		if result.Status == bulkStatusFailed {
+			failed++
+		}
+	}
+	if failed > 0 {
+		formatter.PrintFatal("Bulk action failed", fmt.Errorf("action %s failed on %d of %d resources", actionName, failed, len(results)))
+	}
+}
+
+// bulkResource describes the resources of a type bulk actions are applied to
+type bulkResource struct {
+	// resourceType is the type of the resources, as known by the labels API
+	resourceType string
+	// actions holds the actions by command name, besides add-label and remove-label, supported by every type
+	actions map[string]bulkAction
+	list    func() ([]types.Labelable, error)
+	target  func(labelable types.Labelable) *bulkTarget
+}
+
+// bulkApply applies the action named as the command to every resource matching the labels
+func bulkApply(c *cli.Context, resource *bulkResource, formatter format.Formatter) {
+	action, found := resource.actions[c.Command.Name]
+	switch {
+	case c.Command.Name == "add-label" || c.Command.Name == "remove-label":
+		action = bulkLabelAction(c, resource.resourceType, formatter)
+	case !found:
+		formatter.PrintFatal("Invalid bulk action", fmt.Errorf("unsupported action %s", c.Command.Name))
+	}
+
+	labelables, err := resource.list()
+	if err != nil {
+		formatter.PrintFatal(fmt.Sprintf("Couldn't receive %s data", strings.Replace(resource.resourceType, "_", " ", -1)), err)
+	}
+	targets := []*bulkTarget{}
+	for _, labelable := range bulkFilter(c, labelables, formatter) {
+		targets = append(targets, resource.target(labelable))
+	}
+
+	runBulk(c, resource.resourceType, targets, action, formatter)
+}
+
+// ServerBulk subcommand function applies an action to every server matching the labels
+func ServerBulk(c *cli.Context) error {
+	debugCmdFuncInfo(c)
+	serverSvc, formatter := WireUpServer(c)
+
+	in := &map[string]interface{}{}
+	if c.Command.Name == "execute-script" {
+		checkRequiredFlags(c, []string{"script-id"}, formatter)
+	}
+	bulkApply(c, &bulkResource{
+		resourceType: "server",
+		actions: map[string]bulkAction{
+			"boot":            func(ID string) error { _, err := serverSvc.BootServer(in, ID); return err },
+			"reboot":          func(ID string) error { _, err := serverSvc.RebootServer(in, ID); return err },
+			"shutdown":        func(ID string) error { _, err := serverSvc.ShutdownServer(in, ID); return err },
+			"override-server": func(ID string) error { _, err := serverSvc.OverrideServer(in, ID); return err },
+			"delete":          serverSvc.DeleteServer,
+			"execute-script": func(ID string) error {
+				_, err := serverSvc.ExecuteOperationalScript(in, ID, c.String("script-id"))
+				return err
+			},
+		},
+		list: func() ([]types.Labelable, error) {
+			servers, err := serverSvc.GetServerList()
+			labelables := make([]types.Labelable, len(servers))
+			for i := 0; i < len(servers); i++ {
+				labelables[i] = types.Labelable(servers[i])
+			}
+			return labelables, err
+		},
+		target: func(labelable types.Labelable) *bulkTarget {
+			server := labelable.(*types.Server)
+			return &bulkTarget{ID: server.ID, Name: server.Name}
+		},
+	}, formatter)
+	return nil
+}
+
+// ServerArrayBulk subcommand function applies an action to every server array matching the labels
+func ServerArrayBulk(c *cli.Context) error {
+	debugCmdFuncInfo(c)
+	serverArraySvc, formatter := WireUpServerArray(c)
+
+	in := &map[string]interface{}{}
+	bulkApply(c, &bulkResource{
+		resourceType: "server_array",
+		actions: map[string]bulkAction{
+			"boot":     func(ID string) error { _, err := serverArraySvc.BootServerArray(in, ID); return err },
+			"shutdown": func(ID string) error { _, err := serverArraySvc.ShutdownServerArray(in, ID); return err },
+			"empty":    func(ID string) error { _, err := serverArraySvc.EmptyServerArray(in, ID); return err },
+			"delete":   serverArraySvc.DeleteServerArray,
+		},
+		list: func() ([]types.Labelable, error) {
+			serverArrays, err := serverArraySvc.GetServerArrayList()
+			labelables := make([]types.Labelable, len(serverArrays))
+			for i := 0; i < len(serverArrays); i++ {
+				labelables[i] = types.Labelable(serverArrays[i])
+			}
+			return labelables, err
+		},
+		target: func(labelable types.Labelable) *bulkTarget {
+			serverArray := labelable.(*types.ServerArray)
+			return &bulkTarget{ID: serverArray.ID, Name: serverArray.Name}
+		},
+	}, formatter)
+	return nil
+}
+
+// VolumeBulk subcommand function applies an action to every volume matching the labels
+func VolumeBulk(c *cli.Context) error {
+	debugCmdFuncInfo(c)
+	volumeSvc, formatter := WireUpVolume(c)
+
+	bulkApply(c, &bulkResource{
+		resourceType: "volume",
+		actions: map[string]bulkAction{
+			"detach": volumeSvc.DetachVolume,
+			"delete": volumeSvc.DeleteVolume,
+		},
+		list: func() ([]types.Labelable, error) {
+			volumes, err := volumeSvc.GetVolumeList("")
+			labelables := make([]types.Labelable, len(volumes))
+			for i := 0; i < len(volumes); i++ {
+				labelables[i] = types.Labelable(volumes[i])
+			}
+			return labelables, err
+		},
+		target: func(labelable types.Labelable) *bulkTarget {
+			volume := labelable.(*types.Volume)
+			return &bulkTarget{ID: volume.ID, Name: volume.Name}
+		},
+	}, formatter)
+	return nil
+}
+
+// FloatingIPBulk subcommand function applies an action to every floating IP matching the labels
+func FloatingIPBulk(c *cli.Context) error {
+	debugCmdFuncInfo(c)
+	floatingIPSvc, formatter := WireUpFloatingIP(c)
+
+	bulkApply(c, &bulkResource{
+		resourceType: "floating_ip",
+		actions: map[string]bulkAction{
+			"detach": floatingIPSvc.DetachFloatingIP,
+			"delete": floatingIPSvc.DeleteFloatingIP,
+		},
+		list: func() ([]types.Labelable, error) {
+			floatingIPs, err := floatingIPSvc.GetFloatingIPList("")
+			labelables := make([]types.Labelable, len(floatingIPs))
+			for i := 0; i < len(floatingIPs); i++ {
+				labelables[i] = types.Labelable(floatingIPs[i])
+			}
+			return labelables, err
+		},
+		target: func(labelable types.Labelable) *bulkTarget {
+			floatingIP := labelable.(*types.FloatingIP)
+			return &bulkTarget{ID: floatingIP.ID, Name: floatingIP.Name}
+		},
+	}, formatter)
+	return nil
+}
@@ -0,0 +1,200 @@
+package cmd
+
+import (
+	"flag"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/codegangsta/cli"
+	"github.com/ingrammicro/concerto/api/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// testFormatter keeps the printed lists, and panics on fatal errors so that tests can recover them
+type testFormatter struct {
+	lists []interface{}
+}
+
+func (f *testFormatter) PrintItem(item interface{}) error {
+	return nil
+}
+
+func (f *testFormatter) PrintList(items interface{}) error {
+	f.lists = append(f.lists, items)
+	return nil
+}
+
+func (f *testFormatter) PrintListRows(items interface{}, headers bool) error {
+	return f.PrintList(items)
+}
+
+func (f *testFormatter) PrintError(context string, err error) {
+}
+
+func (f *testFormatter) PrintFatal(context string, err error) {
+	panic(fmt.Errorf("%s: %v", context, err))
+}
+
+// fatal runs fn, returning the fatal error it prints, if any
+func fatal(fn func()) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = r.(error)
+		}
+	}()
+	fn()
+	return nil
+}
+
+// testBulkContext returns the context of the bulk command with the given flags set
+func testBulkContext(t *testing.T, command string, flags map[string]string) *cli.Context {
+	set := flag.NewFlagSet(command, flag.ContinueOnError)
+	for _, f := range BulkFlags() {
+		f.Apply(set)
+	}
+	for name, value := range flags {
+		assert.Nil(t, set.Set(name, value), "Couldn't set flag %s", name)
+	}
+	c := cli.NewContext(nil, set, nil)
+	c.Command = cli.Command{Name: command}
+	return c
+}
+
+func testBulkTargets(count int) []*bulkTarget {
+	targets := []*bulkTarget{}
+	for i := 1; i <= count; i++ {
+		targets = append(targets, &bulkTarget{ID: fmt.Sprintf("server-%d", i), Name: fmt.Sprintf("web-%d", i)})
+	}
+	return targets
+}
+
+func TestRunBulkDryRun(t *testing.T) {
+	assert := assert.New(t)
+
+	formatter := &testFormatter{}
+	c := testBulkContext(t, "reboot", map[string]string{"dry-run": "true"})
+	applied := false
+	runBulk(c, "server", testBulkTargets(2), func(ID string) error { applied = true; return nil }, formatter)
+	assert.False(applied, "Action should not be applied on dry runs")
+	if assert.Len(formatter.lists, 1, "Results should be printed") {
+		results := formatter.lists[0].([]*BulkResult)
+		assert.Equal([]*BulkResult{
+			{ID: "server-1", Name: "web-1", Action: "reboot", Status: bulkStatusPlanned},
+			{ID: "server-2", Name: "web-2", Action: "reboot", Status: bulkStatusPlanned},
+		}, results, "Unexpected results")
+	}
+}
+
+func TestRunBulk(t *testing.T) {
+	assert := assert.New(t)
+
+	formatter := &testFormatter{}
+	c := testBulkContext(t, "reboot", map[string]string{"yes": "true", "concurrency": "2"})
+	var mutex sync.Mutex
+	active, maxActive := 0, 0
+	applied := map[string]bool{}
+	action := func(ID string) error {
+		mutex.Lock()
+		active++
+		if active > maxActive {
+			maxActive = active
+		}
+		applied[ID] = true
+		mutex.Unlock()
+		time.Sleep(20 * time.Millisecond)
+		mutex.Lock()
+		active--
+		mutex.Unlock()
+		return nil
+	}
+	runBulk(c, "server", testBulkTargets(5), action, formatter)
+	assert.Len(applied, 5, "Action should be applied to every target")
+	assert.Equal(2, maxActive, "Action should be applied to 2 targets at the same time")
+	if assert.Len(formatter.lists, 1, "Results should be printed") {
+		for _, result := range formatter.lists[0].([]*BulkResult) {
+			assert.Equal(bulkStatusDone, result.Status, "Unexpected status of %s", result.ID)
+		}
+	}
+}
+
+func TestRunBulkFailure(t *testing.T) {
+	assert := assert.New(t)
+
+	formatter := &testFormatter{}
+	c := testBulkContext(t, "reboot", map[string]string{"yes": "true"})
+	action := func(ID string) error {
+		if ID == "server-2" {
+			return fmt.Errorf("server is busy")
+		}
+		return nil
+	}
+	err := fatal(func() { runBulk(c, "server", testBulkTargets(3), action, formatter) })
+	if assert.NotNil(err, "Bulk action should fail") {
+		assert.Contains(err.Error(), "failed on 1 of 3 resources", "Unexpected error")
+	}
+	if assert.Len(formatter.lists, 1, "Results should be printed before failing") {
+		results := formatter.lists[0].([]*BulkResult)
+		assert.Equal(bulkStatusDone, results[0].Status, "Unexpected status")
+		assert.Equal(bulkStatusFailed, results[1].Status, "Unexpected status")
+		assert.Equal("server is busy", results[1].Error, "Unexpected error")
+		assert.Equal(bulkStatusDone, results[2].Status, "Unexpected status")
+	}
+}
+
+// testLabelsMapping replaces the labels mapping of bulk filters until the returned function is called
+func testLabelsMapping(labelIDsByName map[string]string) func() {
+	original := bulkLabelsMapping
+	bulkLabelsMapping = func(c *cli.Context) (map[string]string, map[string]string) {
+		labelNamesByID := map[string]string{}
+		for name, ID := range labelIDsByName {
+			labelNamesByID[ID] = name
+		}
+		return labelIDsByName, labelNamesByID
+	}
+	return func() { bulkLabelsMapping = original }
+}
+
+func TestBulkFilter(t *testing.T) {
+	assert := assert.New(t)
+	defer testLabelsMapping(map[string]string{"env=prod": "label-1", "env=staging": "label-2", "tier=web": "label-3", "imco:managed": "label-4"})()
+
+	servers := []types.Labelable{
+		&types.Server{ID: "server-1", LabelableFields: types.LabelableFields{LabelIDs: []string{"label-1", "label-3"}}},
+		&types.Server{ID: "server-2", LabelableFields: types.LabelableFields{LabelIDs: []string{"label-1"}}},
+		&types.Server{ID: "server-3", LabelableFields: types.LabelableFields{LabelIDs: []string{"label-2", "label-3"}}},
+		&types.Server{ID: "server-4", LabelableFields: types.LabelableFields{LabelIDs: []string{"label-4"}}},
+	}
+	tests := map[string][]string{
+		"env=prod":          {"server-1", "server-2"},
+		"env=prod,tier=web": {"server-1"},
+		"env!=prod":         {"server-3", "server-4"},
+		"tier":              {"server-1", "server-3"},
+		"!tier":             {"server-2", "server-4"},
+		"env=test":          {},
+		"imco:managed":      {"server-4"},
+	}
+	for selector, IDs := range tests {
+		c := testBulkContext(t, "reboot", map[string]string{"labels": selector})
+		matching := []string{}
+		for _, labelable := range bulkFilter(c, servers, &testFormatter{}) {
+			matching = append(matching, labelable.(*types.Server).ID)
+		}
+		assert.Equal(IDs, matching, "Unexpected servers matching %s", selector)
+	}
+}
+
+func TestBulkFilterUnknownKeys(t *testing.T) {
+	assert := assert.New(t)
+	defer testLabelsMapping(map[string]string{"env=prod": "label-1", "imco:managed": "label-2"})()
+
+	servers := []types.Labelable{&types.Server{ID: "server-1", LabelableFields: types.LabelableFields{LabelIDs: []string{"label-1"}}}}
+	for _, selector := range []string{"evn=prod", "!evn", "env=prod,tier in (web)", "managed"} {
+		c := testBulkContext(t, "reboot", map[string]string{"labels": selector})
+		err := fatal(func() { bulkFilter(c, servers, &testFormatter{}) })
+		assert.NotNil(err, "Selector %s should be rejected", selector)
+	}
+	c := testBulkContext(t, "reboot", map[string]string{"labels": "env=staging"})
+	assert.Nil(fatal(func() { bulkFilter(c, servers, &testFormatter{}) }), "Unknown values of known keys should be accepted")
+}
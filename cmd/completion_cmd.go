@@ -0,0 +1,271 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/codegangsta/cli"
+	"github.com/ingrammicro/concerto/utils"
+	"github.com/ingrammicro/concerto/utils/format"
+)
+
+const (
+	// CompleteCommandName is the hidden command the completion scripts call to get the candidates
+	CompleteCommandName = "__complete"
+
+	completionCacheTTL = 30 * time.Second
+)
+
+// completionScripts are the completion scripts by shell. %[1]s is the program name, %[2]s the complete command.
+// Every candidate is printed as value<TAB>description.
+var completionScripts = map[string]string{
+	"bash": `# bash completion for %[1]s
+_%[1]s_complete() {
+	local IFS=$'\n'
+	COMPREPLY=($(%[1]s %[2]s "${COMP_WORDS[@]:1:$COMP_CWORD}" 2>/dev/null | cut -f1))
+}
+complete -o default -F _%[1]s_complete %[1]s
+`,
+	"zsh": `#compdef %[1]s
+# zsh completion for %[1]s
+_%[1]s() {
+	local -a candidates described
+	local line
+	candidates=("${(@f)$(%[1]s %[2]s "${(@)words[2,CURRENT]}" 2>/dev/null)}")
+	for line in "${candidates[@]}"; do
+		[[ -n "$line" ]] || continue
+		described+=("${${line%%%%$'\t'*}//:/\\:}:${line#*$'\t'}")
+	done
+	_describe '%[1]s' described
+}
+compdef _%[1]s %[1]s
+`,
+	"fish": `# fish completion for %[1]s
+function __%[1]s_complete
+	set -l tokens (commandline -opc) (commandline -ct)
+	%[1]s %[2]s $tokens[2..-1] 2>/dev/null
+end
+complete -c %[1]s -f -a '(__%[1]s_complete)'
+`,
+	"powershell": `# powershell completion for %[1]s
+Register-ArgumentCompleter -Native -CommandName %[1]s -ScriptBlock {
+	param($wordToComplete, $commandAst, $cursorPosition)
+	$words = @($commandAst.CommandElements | Select-Object -Skip 1 | ForEach-Object { $_.ToString() })
+	if ($wordToComplete -eq '') { $words += '""' }
+	& %[1]s %[2]s @words 2>$null | ForEach-Object {
+		$value, $description = $_ -split "` + "`" + `t", 2
+		if (-not $description) { $description = $value }
+		[System.Management.Automation.CompletionResult]::new($value, $value, 'ParameterValue', $description)
+	}
+}
+`,
+}
+
+// completionCandidate is a possible completion of the current word
+type completionCandidate struct {
+	Value       string
+	Description string
+}
+
+// completionState is the position reached while walking the command line
+type completionState struct {
+	commands []cli.Command
+	flags    []cli.Flag
+	group    string
+	leaf     *cli.Command
+}
+
+// Completion subcommand function prints the completion script for a shell
+func Completion(c *cli.Context) error {
+	debugCmdFuncInfo(c)
+	formatter := format.GetFormatter()
+
+	shells := []string{}
+	for shell := range completionScripts {
+		shells = append(shells, shell)
+	}
+	sort.Strings(shells)
+
+	script, found := completionScripts[c.Args().First()]
+	if !found {
+		formatter.PrintFatal("Invalid shell", fmt.Errorf("shell must be one of %s", strings.Join(shells, ", ")))
+	}
+	fmt.Printf(script, c.App.Name, CompleteCommandName)
+	return nil
+}
+
+// Complete subcommand function prints the candidates completing the last of the given words, one per line
+func Complete(c *cli.Context) error {
+	words := []string(c.Args())
+	if len(words) == 0 {
+		words = []string{""}
+	}
+	for _, candidate := range completeWords(c, c.App.Commands, c.App.Flags, words) {
+		fmt.Printf("%s\t%s\n", candidate.Value, candidate.Description)
+	}
+	return nil
+}
+
+// flagNames returns all the names of a flag, long and short
+func flagNames(flag cli.Flag) []string {
+	names := []string{}
+	for _, name := range strings.Split(flag.GetName(), ",") {
+		names = append(names, strings.TrimSpace(name))
+	}
+	return names
+}
+
+// flagUsage returns the usage of a flag, if any
+func flagUsage(flag cli.Flag) string {
+	usage := reflect.Indirect(reflect.ValueOf(flag)).FieldByName("Usage")
+	if usage.IsValid() && usage.Kind() == reflect.String {
+		return usage.String()
+	}
+	return ""
+}
+
+// findFlag returns the flag named as the given command line word, such as --id or -f
+func findFlag(flags []cli.Flag, word string) cli.Flag {
+	name := strings.TrimLeft(word, "-")
+	for _, flag := range flags {
+		if utils.Contains(flagNames(flag), name) {
+			return flag
+		}
+	}
+	return nil
+}
+
+// takesValue tells whether the flag is followed by a value
+func takesValue(flag cli.Flag) bool {
+	switch flag.(type) {
+	case cli.BoolFlag, cli.BoolTFlag:
+		return false
+	}
+	return true
+}
+
+// completeWords returns the candidates completing the last word, given the words preceding it
+func completeWords(c *cli.Context, commands []cli.Command, globalFlags []cli.Flag, words []string) []*completionCandidate {
+	state := &completionState{commands: commands, flags: globalFlags}
+	current, previous := words[len(words)-1], ""
+
+	for i := 0; i < len(words)-1; i++ {
+		word := words[i]
+		if strings.HasPrefix(word, "-") {
+			if flag := findFlag(state.flags, word); flag != nil && takesValue(flag) && !strings.Contains(word, "=") {
+				if i == len(words)-2 {
+					previous = word
+				}
+				i++
+			}
+			continue
+		}
+		for j := range state.commands {
+			command := state.commands[j]
+			if command.Hidden || !command.HasName(word) {
+				continue
+			}
+			if len(command.Subcommands) > 0 {
+				state.group = command.Name
+				state.commands, state.flags = command.Subcommands, command.Flags
+			} else {
+				state.leaf = &command
+				state.commands, state.flags = nil, command.Flags
+			}
+			break
+		}
+	}
+
+	if previous != "" {
+		return completeFlagValue(c, state, flagNames(findFlag(state.flags, previous))[0], current)
+	}
+
+	candidates := []*completionCandidate{}
+	if strings.HasPrefix(current, "-") || len(state.commands) == 0 {
+		for _, flag := range state.flags {
+			name := "--" + flagNames(flag)[0]
+			if strings.HasPrefix(name, current) {
+				candidates = append(candidates, &completionCandidate{Value: name, Description: flagUsage(flag)})
+			}
+		}
+		return candidates
+	}
+	for _, command := range state.commands {
+		if !command.Hidden && strings.HasPrefix(command.Name, current) {
+			candidates = append(candidates, &completionCandidate{Value: command.Name, Description: command.Usage})
+		}
+	}
+	return candidates
+}
+
+// completeFlagValue returns the resources which the ID flag may refer to, by ID and by name
+func completeFlagValue(c *cli.Context, state *completionState, flagName string, current string) []*completionCandidate {
+	candidates := []*completionCandidate{}
+	if state.leaf == nil {
+		return candidates
+	}
+	names := []string{}
+	for _, flag := range state.leaf.Flags {
+		names = append(names, flagNames(flag)[0])
+	}
+	kind, found := idFlagKindsOf(state.group, names)[flagName]
+	if !found {
+		return candidates
+	}
+
+	resources, err := completionResources(c, kind)
+	if err != nil {
+		log.Debugf("Couldn't complete %s: %v", kind, err)
+		return candidates
+	}
+	for _, resource := range resources {
+		if strings.HasPrefix(resource.ID, current) {
+			candidates = append(candidates, &completionCandidate{Value: resource.ID, Description: resource.Name})
+		}
+	}
+	for _, resource := range resources {
+		if resource.Name != "" && strings.HasPrefix(resource.Name, current) {
+			candidates = append(candidates, &completionCandidate{Value: resource.Name, Description: resource.ID})
+		}
+	}
+	return candidates
+}
+
+// completionResources lists the resources of a kind, keeping them in a short-lived cache as completion is
+// requested on every key press
+func completionResources(c *cli.Context, kind string) ([]*utils.NamedResource, error) {
+	config, err := utils.GetConcertoConfig()
+	if err != nil {
+		return nil, err
+	}
+	if !config.IsConfigReady() {
+		return nil, fmt.Errorf("configuration is incomplete")
+	}
+
+	cacheFile := filepath.Join(utils.ProfileCacheDir(config, "completion"), kind+".json")
+	if info, err := os.Stat(cacheFile); err == nil && !config.NoCache && time.Since(info.ModTime()) < completionCacheTTL {
+		resources := []*utils.NamedResource{}
+		if data, err := ioutil.ReadFile(cacheFile); err == nil && json.Unmarshal(data, &resources) == nil {
+			return resources, nil
+		}
+	}
+
+	resources, err := resourceListers[kind](c)
+	if err != nil {
+		return nil, err
+	}
+	if data, err := json.Marshal(resources); err == nil && os.MkdirAll(filepath.Dir(cacheFile), 0700) == nil {
+		if err = utils.WriteFileAtomic(cacheFile, data); err != nil {
+			log.Debugf("Couldn't cache %s completion: %v", kind, err)
+		}
+	}
+	return resources, nil
+}
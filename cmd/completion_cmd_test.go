@@ -0,0 +1,127 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/codegangsta/cli"
+	"github.com/stretchr/testify/assert"
+)
+
+// testCompletionCommands returns a command tree with value-taking flags and hidden commands
+func testCompletionCommands() ([]cli.Command, []cli.Flag) {
+	globalFlags := []cli.Flag{
+		cli.BoolFlag{Name: "debug, D", Usage: "Enable debug mode"},
+		cli.StringFlag{Name: "config, c", Usage: "Config file"},
+	}
+	commands := []cli.Command{
+		{
+			Name:  "servers",
+			Usage: "Manages servers",
+			Subcommands: []cli.Command{
+				{Name: "list", Usage: "Lists servers", Flags: []cli.Flag{cli.StringFlag{Name: "labels", Usage: "A label selector"}}},
+				{Name: "show", Usage: "Shows a server", Flags: []cli.Flag{cli.StringFlag{Name: "id", Usage: "Server Id"}}},
+				{Name: "internal", Usage: "Internal", Hidden: true, Flags: []cli.Flag{cli.StringFlag{Name: "secret"}}},
+			},
+		},
+		{
+			Name:  "templates",
+			Usage: "Manages templates",
+			Subcommands: []cli.Command{
+				{Name: "update-template-script", Usage: "Updates a template script", Flags: []cli.Flag{
+					cli.StringFlag{Name: "template-id", Usage: "Template Id"},
+					cli.StringFlag{Name: "id", Usage: "Template script Id"},
+					cli.BoolFlag{Name: "force", Usage: "Forces the update"},
+				}},
+			},
+		},
+		{Name: "complete", Usage: "Completes", Hidden: true},
+	}
+	return commands, globalFlags
+}
+
+func TestCompleteWords(t *testing.T) {
+	assert := assert.New(t)
+
+	tests := []struct {
+		words  []string
+		values []string
+	}{
+		{[]string{""}, []string{"servers", "templates"}},
+		{[]string{"se"}, []string{"servers"}},
+		{[]string{"x"}, []string{}},
+		{[]string{"-"}, []string{"--debug", "--config"}},
+		{[]string{"--c"}, []string{"--config"}},
+		{[]string{"servers", ""}, []string{"list", "show"}},
+		{[]string{"servers", "s"}, []string{"show"}},
+		// values of flags are not taken as commands
+		{[]string{"--config", "servers", ""}, []string{"servers", "templates"}},
+		{[]string{"-c", "servers", "servers", ""}, []string{"list", "show"}},
+		{[]string{"--config=servers", "servers", ""}, []string{"list", "show"}},
+		{[]string{"--debug", "servers", ""}, []string{"list", "show"}},
+		{[]string{"-D", "servers", ""}, []string{"list", "show"}},
+		// hidden commands are neither offered nor entered
+		{[]string{"servers", "internal", ""}, []string{"list", "show"}},
+		{[]string{"servers", "i"}, []string{}},
+		{[]string{"complete", ""}, []string{"servers", "templates"}},
+		// flags are offered on commands without subcommands
+		{[]string{"servers", "show", ""}, []string{"--id"}},
+		{[]string{"servers", "list", "--"}, []string{"--labels"}},
+		{[]string{"templates", "update-template-script", "--force", "--"}, []string{"--template-id", "--id", "--force"}},
+		{[]string{"templates", "update-template-script", "--template-id", "abc", "--i"}, []string{"--id"}},
+		// values of flags which do not refer to resources are not completed
+		{[]string{"servers", "list", "--labels", ""}, []string{}},
+		{[]string{"--config", ""}, []string{}},
+	}
+	commands, globalFlags := testCompletionCommands()
+	for _, test := range tests {
+		values := []string{}
+		for _, candidate := range completeWords(nil, commands, globalFlags, test.words) {
+			values = append(values, candidate.Value)
+		}
+		assert.Equal(test.values, values, "Unexpected candidates for %q", test.words)
+	}
+}
+
+func TestCompleteWordsDescriptions(t *testing.T) {
+	assert := assert.New(t)
+
+	commands, globalFlags := testCompletionCommands()
+	candidates := completeWords(nil, commands, globalFlags, []string{"servers", "sh"})
+	if assert.Len(candidates, 1, "Unexpected candidates") {
+		assert.Equal(&completionCandidate{Value: "show", Description: "Shows a server"}, candidates[0], "Commands should be described by their usage")
+	}
+	candidates = completeWords(nil, commands, globalFlags, []string{"servers", "show", "--"})
+	if assert.Len(candidates, 1, "Unexpected candidates") {
+		assert.Equal(&completionCandidate{Value: "--id", Description: "Server Id"}, candidates[0], "Flags should be described by their usage")
+	}
+}
+
+func TestIDFlagKindsOf(t *testing.T) {
+	assert := assert.New(t)
+
+	tests := []struct {
+		group     string
+		flagNames []string
+		kinds     map[string]string
+	}{
+		{"servers", []string{"id", "name"}, map[string]string{"id": "server"}},
+		{"servers", []string{"name"}, map[string]string{}},
+		{"templates", []string{"id"}, map[string]string{"id": "template"}},
+		// the id flag refers to a child of the resource given as the group ID flag
+		{"templates", []string{"template-id", "id"}, map[string]string{"template-id": "template"}},
+		{"servers", []string{"id", "template-id", "cloud-account-id", "server-plan-id"}, map[string]string{
+			"id":               "server",
+			"template-id":      "template",
+			"cloud-account-id": "cloud-account",
+			"server-plan-id":   "server-plan",
+		}},
+		// flags of unknown kinds are not resolved
+		{"servers", []string{"event-id", "id-file"}, map[string]string{}},
+		{"plans", []string{"id"}, map[string]string{"id": "storage-plan"}},
+		{"events", []string{"id"}, map[string]string{}},
+		{"", []string{"vpc-id"}, map[string]string{"vpc-id": "vpc"}},
+	}
+	for _, test := range tests {
+		assert.Equal(test.kinds, idFlagKindsOf(test.group, test.flagNames), "Unexpected kinds of %s %v", test.group, test.flagNames)
+	}
+}
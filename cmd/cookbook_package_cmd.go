@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	"github.com/codegangsta/cli"
+	"github.com/ingrammicro/concerto/utils/cookbook"
+)
+
+// CookbookPackage is a cookbook archive built from a local directory
+type CookbookPackage struct {
+	Name         string `json:"name" header:"NAME"`
+	Version      string `json:"version" header:"VERSION"`
+	Dependencies string `json:"dependencies" header:"DEPENDENCIES"`
+	FilePath     string `json:"file_path" header:"FILE_PATH"`
+	Files        int    `json:"files" header:"FILES"`
+	SHA256       string `json:"sha256" header:"SHA256" show:"nolist"`
+}
+
+// cookbookDependencies returns the dependencies as a sorted, comma separated list of name and constraint
+func cookbookDependencies(metadata *cookbook.Metadata) string {
+	dependencies := []string{}
+	for name, constraint := range metadata.Dependencies {
+		dependencies = append(dependencies, fmt.Sprintf("%s %s", name, constraint))
+	}
+	sort.Strings(dependencies)
+	return strings.Join(dependencies, ",")
+}
+
+// CookbookVersionPackage subcommand function validates the cookbook in a directory and packages it, optionally
+// uploading the package
+func CookbookVersionPackage(c *cli.Context) error {
+	debugCmdFuncInfo(c)
+	svc, formatter := WireUpCookbookVersion(c)
+
+	checkRequiredFlags(c, []string{"dir"}, formatter)
+	metadata, err := cookbook.ReadMetadata(c.String("dir"))
+	if err != nil {
+		formatter.PrintFatal("Invalid cookbook", err)
+	}
+
+	cookbookVersions, err := svc.GetCookbookVersionList()
+	if err != nil {
+		formatter.PrintFatal("Couldn't receive cookbook versions data", err)
+	}
+	for _, cookbookVersion := range cookbookVersions {
+		if cookbookVersion.Name == metadata.Name && cookbookVersion.Version == metadata.Version {
+			formatter.PrintFatal("Invalid cookbook",
+				fmt.Errorf("cookbook %s version %s already exists with ID %s", metadata.Name, metadata.Version, cookbookVersion.ID))
+		}
+	}
+
+	var archive bytes.Buffer
+	files, err := cookbook.Package(c.String("dir"), metadata.Name, &archive)
+	if err != nil {
+		formatter.PrintFatal("Couldn't package cookbook", err)
+	}
+	filePath := c.String("output")
+	if filePath == "" {
+		filePath = fmt.Sprintf("%s-%s.tar.gz", metadata.Name, metadata.Version)
+	}
+	if err = ioutil.WriteFile(filePath, archive.Bytes(), 0644); err != nil {
+		formatter.PrintFatal("Couldn't write cookbook package", err)
+	}
+
+	if c.Bool("upload") {
+		labelIDsByName, labelNamesByID := LabelLoadsMapping(c)
+		cookbookVersion := uploadCookbookVersionFile(c, svc, formatter, filePath, labelIDsByName, labelNamesByID)
+		cookbookVersion.FillInLabelNames(labelNamesByID)
+		if err = formatter.PrintItem(*cookbookVersion); err != nil {
+			formatter.PrintFatal("Couldn't print/format result", err)
+		}
+		return nil
+	}
+
+	sum := sha256.Sum256(archive.Bytes())
+	cookbookPackage := CookbookPackage{
+		Name:         metadata.Name,
+		Version:      metadata.Version,
+		Dependencies: cookbookDependencies(metadata),
+		FilePath:     filePath,
+		Files:        len(files),
+		SHA256:       hex.EncodeToString(sum[:]),
+	}
+	if err = formatter.PrintItem(cookbookPackage); err != nil {
+		formatter.PrintFatal("Couldn't print/format result", err)
+	}
+	return nil
+}
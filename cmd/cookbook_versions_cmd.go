@@ -95,8 +95,28 @@ func CookbookVersionUpload(c *cli.Context) error {
 		formatter.PrintFatal("Invalid file path", fmt.Errorf("no such file or directory: %s", sourceFilePath))
 	}
 
-	cbIn := map[string]interface{}{}
 	labelIDsByName, labelNamesByID := LabelLoadsMapping(c)
+	cookbookVersion := uploadCookbookVersionFile(c, svc, formatter, sourceFilePath, labelIDsByName, labelNamesByID)
+
+	cookbookVersion.FillInLabelNames(labelNamesByID)
+	if err := formatter.PrintItem(*cookbookVersion); err != nil {
+		formatter.PrintFatal("Couldn't print/format result", err)
+	}
+
+	return nil
+}
+
+// uploadCookbookVersionFile creates a cookbook version, uploads the file to it and processes it, waiting for it
+// when asked to. The cookbook version is deleted when the upload or the processing request fail.
+func uploadCookbookVersionFile(
+	c *cli.Context,
+	svc *blueprint.CookbookVersionService,
+	formatter format.Formatter,
+	sourceFilePath string,
+	labelIDsByName map[string]string,
+	labelNamesByID map[string]string,
+) *types.CookbookVersion {
+	cbIn := map[string]interface{}{}
 	if c.IsSet("labels") {
 		cbIn["label_ids"] = LabelResolution(c, c.String("labels"), &labelNamesByID, &labelIDsByName)
 	}
@@ -121,13 +141,11 @@ func CookbookVersionUpload(c *cli.Context) error {
 		cleanCookbookVersion(c, cookbookVersionID)
 		formatter.PrintFatal("Couldn't process cookbook version", err)
 	}
-
-	cookbookVersion.FillInLabelNames(labelNamesByID)
-	if err = formatter.PrintItem(*cookbookVersion); err != nil {
-		formatter.PrintFatal("Couldn't print/format result", err)
+	if c.Bool("wait") {
+		cookbookVersion = waitForFlaggedState(c, "cookbook_version", cookbookVersion.ID, formatter).(*types.CookbookVersion)
 	}
 
-	return nil
+	return cookbookVersion
 }
 
 // cleanCookbookVersion deletes CookbookVersion. Ideally for cleaning at uploading error cases
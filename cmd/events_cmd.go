@@ -1,8 +1,13 @@
 package cmd
 
 import (
+	"sort"
+	"strings"
+	"time"
+
 	"github.com/codegangsta/cli"
 	"github.com/ingrammicro/concerto/api/audit"
+	"github.com/ingrammicro/concerto/api/types"
 	"github.com/ingrammicro/concerto/utils"
 	"github.com/ingrammicro/concerto/utils/format"
 )
@@ -28,18 +33,139 @@ func WireUpEvent(c *cli.Context) (ns *audit.EventService, f format.Formatter) {
 	return ns, f
 }
 
-// EventList subcommand function
-func EventList(c *cli.Context) error {
-	debugCmdFuncInfo(c)
-	eventSvc, formatter := WireUpEvent(c)
+// DefaultEventFollowInterval is the time between polls when following events, in seconds
+const DefaultEventFollowInterval = 10
 
-	events, err := eventSvc.GetEventList()
+// EventFilterFlags returns the flags filtering and following a list of events
+func EventFilterFlags() []cli.Flag {
+	return []cli.Flag{
+		cli.StringFlag{
+			Name:  "since",
+			Usage: "Shows the events from the given time, either a date, a RFC 3339 time or a duration before now, such as 1h or 2d",
+		},
+		cli.StringFlag{
+			Name:  "until",
+			Usage: "Shows the events before the given time, either a date, a RFC 3339 time or a duration before now, such as 1h or 2d",
+		},
+		cli.StringFlag{
+			Name:  "level",
+			Usage: "A list of comma separated levels of the events to show",
+		},
+		cli.StringFlag{
+			Name:  "text",
+			Usage: "Shows the events whose header or description contains the text",
+		},
+		cli.BoolFlag{
+			Name:  "follow",
+			Usage: "Keeps polling for new events, showing them as they appear",
+		},
+		cli.IntFlag{
+			Name:  "interval",
+			Usage: "Time between polls when following events, in seconds",
+			Value: DefaultEventFollowInterval,
+		},
+	}
+}
+
+// eventFilterFromFlags returns the filter given in the flags returned by EventFilterFlags
+func eventFilterFromFlags(c *cli.Context, formatter format.Formatter) *types.EventFilter {
+	filter := &types.EventFilter{Text: c.String("text")}
+	now := time.Now()
+	var err error
+	if c.IsSet("since") {
+		if filter.Since, err = types.ParseEventTime(c.String("since"), now); err != nil {
+			formatter.PrintFatal("Invalid since flag", err)
+		}
+	}
+	if c.IsSet("until") {
+		if filter.Until, err = types.ParseEventTime(c.String("until"), now); err != nil {
+			formatter.PrintFatal("Invalid until flag", err)
+		}
+	}
+	for _, level := range strings.Split(c.String("level"), ",") {
+		if level = strings.TrimSpace(level); level != "" {
+			filter.Levels = append(filter.Levels, level)
+		}
+	}
+	return filter
+}
+
+// eventFollower keeps track of the events already shown while following them
+type eventFollower struct {
+	filter *types.EventFilter
+	// seen holds the time of the events shown, by ID
+	seen map[string]time.Time
+}
+
+// next returns the events which haven't been seen yet, sorted by time. Later polls only ask for events from the
+// latest one seen, which might share its timestamp with others, so earlier events are forgotten.
+func (ef *eventFollower) next(events []*types.Event) []*types.Event {
+	unseen := []*types.Event{}
+	for _, event := range events {
+		if _, found := ef.seen[event.ID]; !found {
+			ef.seen[event.ID] = event.Timestamp
+			unseen = append(unseen, event)
+		}
+		if event.Timestamp.After(ef.filter.Since) {
+			ef.filter.Since = event.Timestamp
+		}
+	}
+	for ID, timestamp := range ef.seen {
+		if timestamp.Before(ef.filter.Since) {
+			delete(ef.seen, ID)
+		}
+	}
+	sort.SliceStable(unseen, func(i, j int) bool { return unseen[i].Timestamp.Before(unseen[j].Timestamp) })
+	return unseen
+}
+
+// listEvents prints the events matching the filter given in the flags. When following them, it keeps polling
+// and prints the events which haven't been seen yet, until the end of the time window, if any.
+func listEvents(c *cli.Context, get func(filter *types.EventFilter) ([]*types.Event, error), formatter format.Formatter) {
+	filter := eventFilterFromFlags(c, formatter)
+	events, err := get(filter)
 	if err != nil {
 		formatter.PrintFatal("Couldn't receive event data", err)
 	}
-	if err = formatter.PrintList(events); err != nil {
-		formatter.PrintFatal("Couldn't print/format result", err)
+	if !c.Bool("follow") {
+		if err = formatter.PrintList(events); err != nil {
+			formatter.PrintFatal("Couldn't print/format result", err)
+		}
+		return
+	}
+
+	interval := c.Int("interval")
+	if interval <= 0 {
+		interval = DefaultEventFollowInterval
+	}
+	follower := &eventFollower{filter: filter, seen: map[string]time.Time{}}
+	headers := true
+	for {
+		// headers are printed once, even if there are no events yet
+		if unseen := follower.next(events); len(unseen) > 0 || headers {
+			if err = formatter.PrintListRows(unseen, headers); err != nil {
+				formatter.PrintFatal("Couldn't print/format result", err)
+			}
+			headers = false
+		}
+
+		if !filter.Until.IsZero() && time.Now().After(filter.Until) {
+			return
+		}
+		time.Sleep(time.Duration(interval) * time.Second)
+		if events, err = get(filter); err != nil {
+			formatter.PrintError("Couldn't receive event data", err)
+			events = nil
+		}
 	}
+}
+
+// EventList subcommand function
+func EventList(c *cli.Context) error {
+	debugCmdFuncInfo(c)
+	eventSvc, formatter := WireUpEvent(c)
+
+	listEvents(c, eventSvc.GetEventListWithFilter, formatter)
 	return nil
 }
 
@@ -48,12 +174,6 @@ func SysEventList(c *cli.Context) error {
 	debugCmdFuncInfo(c)
 	eventSvc, formatter := WireUpEvent(c)
 
-	events, err := eventSvc.GetSysEventList()
-	if err != nil {
-		formatter.PrintFatal("Couldn't receive system event data", err)
-	}
-	if err = formatter.PrintList(events); err != nil {
-		formatter.PrintFatal("Couldn't print/format result", err)
-	}
+	listEvents(c, eventSvc.GetSysEventListWithFilter, formatter)
 	return nil
 }
@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ingrammicro/concerto/api/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEventFollower(t *testing.T) {
+	assert := assert.New(t)
+
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	event := func(ID string, seconds int) *types.Event {
+		return &types.Event{ID: ID, Timestamp: start.Add(time.Duration(seconds) * time.Second)}
+	}
+	IDs := func(events []*types.Event) []string {
+		IDs := []string{}
+		for _, event := range events {
+			IDs = append(IDs, event.ID)
+		}
+		return IDs
+	}
+
+	follower := &eventFollower{filter: &types.EventFilter{Since: start}, seen: map[string]time.Time{}}
+	assert.Equal([]string{"event-1", "event-2", "event-3"}, IDs(follower.next([]*types.Event{event("event-3", 20), event("event-1", 0), event("event-2", 10)})), "Events should be sorted by time")
+	assert.Equal(start.Add(20*time.Second), follower.filter.Since, "Filter should move to the latest event")
+	assert.Equal(map[string]time.Time{"event-3": start.Add(20 * time.Second)}, follower.seen, "Only the events at the latest time should be remembered")
+
+	// the next poll returns the events from the latest time, including the one already seen
+	assert.Equal([]string{"event-4"}, IDs(follower.next([]*types.Event{event("event-3", 20), event("event-4", 20)})), "Seen events should not be returned again")
+	assert.Len(follower.seen, 2, "Events sharing the latest time should be remembered")
+	assert.Empty(follower.next([]*types.Event{event("event-3", 20), event("event-4", 20)}), "There should be no new events")
+
+	assert.Equal([]string{"event-5"}, IDs(follower.next([]*types.Event{event("event-4", 20), event("event-5", 30)})), "Unexpected new events")
+	assert.Len(follower.seen, 1, "Earlier events should be forgotten")
+}
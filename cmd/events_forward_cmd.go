@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/codegangsta/cli"
+	"github.com/ingrammicro/concerto/api/types"
+	"github.com/ingrammicro/concerto/utils"
+	"github.com/ingrammicro/concerto/utils/forward"
+)
+
+// DefaultEventForwardInterval is the time between polls when forwarding events, in seconds
+const DefaultEventForwardInterval = 30
+
+// EventForwardFlags returns the flags of the events forward command
+func EventForwardFlags() []cli.Flag {
+	return []cli.Flag{
+		cli.StringSliceFlag{
+			Name: "sink",
+			Usage: "Where to deliver the events, given once per sink: syslog+udp://host:port, syslog+tcp://host:port, " +
+				"syslog+tls://host:port[?ca-cert=file], file:///path/events.jsonl[?max-size=bytes&max-files=n] or an http(s) webhook URL",
+		},
+		cli.BoolFlag{
+			Name:  "system",
+			Usage: "Forwards the system-wide events instead of the account group ones",
+		},
+		cli.StringFlag{
+			Name:  "state-file",
+			Usage: "File keeping track of the events already forwarded. By default, it is kept in the configuration directory",
+		},
+		cli.StringFlag{
+			Name:  "since",
+			Usage: "Forwards the events from the given time when there is no state yet, either a date, a RFC 3339 time or a duration before now, such as 1h or 2d. By default, only new events are forwarded",
+		},
+		cli.IntFlag{
+			Name:  "interval",
+			Usage: "Time between polls for new events, in seconds",
+			Value: DefaultEventForwardInterval,
+		},
+		cli.BoolFlag{
+			Name:  "once",
+			Usage: "Forwards the pending events and exits, instead of polling for new ones",
+		},
+		cli.StringFlag{
+			Name:   "webhook-secret",
+			Usage:  "Key signing the webhook requests with HMAC-SHA256, in the " + forward.SignatureHeader + " header",
+			EnvVar: "CONCERTO_WEBHOOK_SECRET",
+		},
+		cli.StringFlag{
+			Name:  "syslog-facility",
+			Usage: "Facility of the syslog messages",
+			Value: "local0",
+		},
+	}
+}
+
+// EventForward subcommand function
+func EventForward(c *cli.Context) error {
+	debugCmdFuncInfo(c)
+	eventSvc, formatter := WireUpEvent(c)
+
+	if len(c.StringSlice("sink")) == 0 {
+		formatter.PrintFatal("Incorrect usage.", fmt.Errorf("please use parameter --sink"))
+	}
+	options := &forward.SinkOptions{WebhookSecret: c.String("webhook-secret"), SyslogFacility: c.String("syslog-facility")}
+	sinks := []forward.Sink{}
+	for _, rawURL := range c.StringSlice("sink") {
+		sink, err := forward.NewSink(rawURL, options)
+		if err != nil {
+			formatter.PrintFatal("Couldn't create sink", err)
+		}
+		sinks = append(sinks, sink)
+	}
+
+	get, stateName := eventSvc.GetEventListWithFilter, "events.json"
+	if c.Bool("system") {
+		get, stateName = eventSvc.GetSysEventListWithFilter, "system-events.json"
+	}
+	statePath := c.String("state-file")
+	if statePath == "" {
+		config, err := utils.GetConcertoConfig()
+		if err != nil {
+			formatter.PrintFatal("Couldn't wire up config", err)
+		}
+		statePath = filepath.Join(utils.ProfileStateDir(config, "forward"), stateName)
+	}
+	start := time.Now()
+	if c.IsSet("since") {
+		var err error
+		if start, err = types.ParseEventTime(c.String("since"), start); err != nil {
+			formatter.PrintFatal("Invalid since flag", err)
+		}
+	}
+
+	forwarder, err := forward.NewForwarder(get, sinks, statePath, start)
+	if err != nil {
+		formatter.PrintFatal("Couldn't create forwarder", err)
+	}
+	defer forwarder.Close()
+
+	if c.Bool("once") {
+		n, err := forwarder.Poll()
+		if err != nil {
+			formatter.PrintFatal("Couldn't forward events", err)
+		}
+		log.Infof("Forwarded %d events", n)
+		return nil
+	}
+
+	interval := c.Int("interval")
+	if interval <= 0 {
+		interval = DefaultEventForwardInterval
+	}
+	stop := make(chan struct{})
+	go func() {
+		gracefulStop := make(chan os.Signal, 1)
+		signal.Notify(gracefulStop, syscall.SIGTERM, syscall.SIGINT)
+		log.Debug("Ending, signal detected:", <-gracefulStop)
+		close(stop)
+	}()
+	log.Infof("Forwarding events to %d sinks every %d seconds, keeping state in %s", len(sinks), interval, statePath)
+	forwarder.Run(time.Duration(interval)*time.Second, stop)
+	return nil
+}
@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/codegangsta/cli"
+	"github.com/ingrammicro/concerto/api/types"
+	"github.com/ingrammicro/concerto/utils/manifest"
+	"gopkg.in/yaml.v2"
+)
+
+// manifestExportKinds returns the kinds given as comma separated manifest sections, or every kind when empty
+func manifestExportKinds(resourceTypes string) ([]*manifest.Kind, error) {
+	if resourceTypes == "" {
+		return manifest.Kinds, nil
+	}
+	selected := map[string]bool{}
+	for _, name := range strings.Split(resourceTypes, ",") {
+		name = strings.TrimSpace(name)
+		if manifest.KindByName(name) == nil {
+			return nil, fmt.Errorf("unknown resource type %s", name)
+		}
+		selected[name] = true
+	}
+	kinds := []*manifest.Kind{}
+	for _, kind := range manifest.Kinds {
+		if selected[kind.Name] {
+			kinds = append(kinds, kind)
+		}
+	}
+	return kinds, nil
+}
+
+// export returns the manifest sections declaring the current resources of the given kinds.
+// When a label selector is given, only the resources matching it are exported.
+func (e *manifestEngine) export(kinds []*manifest.Kind, selector *types.LabelSelector) (map[string]interface{}, error) {
+	needed := map[string]bool{}
+	for _, kind := range kinds {
+		needed[kind.Name] = true
+		for _, refKind := range kind.Refs {
+			needed[refKind] = true
+		}
+	}
+	if err := e.loadKinds(needed); err != nil {
+		return nil, err
+	}
+	exporter := &manifest.Exporter{State: e.state, Backend: e}
+	return exporter.Export(kinds, selector)
+}
+
+// ManifestExport subcommand function
+func ManifestExport(c *cli.Context) error {
+	debugCmdFuncInfo(c)
+	engine, formatter := wireUpManifest(c)
+
+	outputFormat := c.String("format")
+	if outputFormat != "yaml" && outputFormat != "json" {
+		formatter.PrintFatal("Invalid format", fmt.Errorf("format must be yaml or json"))
+	}
+	kinds, err := manifestExportKinds(c.String("resource-types"))
+	if err != nil {
+		formatter.PrintFatal("Invalid resource types", err)
+	}
+	var selector *types.LabelSelector
+	if c.String("labels") != "" {
+		if selector, err = types.ParseLabelSelector(c.String("labels")); err != nil {
+			formatter.PrintFatal("Invalid label selector", err)
+		}
+	}
+
+	sections, err := engine.export(kinds, selector)
+	if err != nil {
+		formatter.PrintFatal("Couldn't export resources", err)
+	}
+
+	var data []byte
+	if outputFormat == "json" {
+		data, err = json.MarshalIndent(sections, "", "  ")
+		data = append(data, '\n')
+	} else {
+		data, err = yaml.Marshal(sections)
+	}
+	if err != nil {
+		formatter.PrintFatal("Couldn't format manifest", err)
+	}
+
+	if c.String("file") == "" || c.String("file") == "-" {
+		_, err = os.Stdout.Write(data)
+	} else {
+		err = ioutil.WriteFile(c.String("file"), data, 0600)
+	}
+	if err != nil {
+		formatter.PrintFatal("Couldn't write manifest", err)
+	}
+	return nil
+}
@@ -0,0 +1,332 @@
+package cmd
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/codegangsta/cli"
+	"github.com/ingrammicro/concerto/api/cloud"
+	"github.com/ingrammicro/concerto/api/types"
+	"github.com/ingrammicro/concerto/utils/format"
+)
+
+const (
+	// DefaultFleetTimeout is the time given to each server to conclude a script execution, in seconds
+	DefaultFleetTimeout = 600
+
+	fleetStatusSucceeded = "succeeded"
+	fleetStatusFailed    = "failed"
+	fleetStatusTimeout   = "timeout"
+	fleetStatusSkipped   = "skipped"
+)
+
+// exitCodeRegexp matches the exit code reported by a script conclusion event.
+//
+// Execution conclusions are tracked through server events, as the API has no endpoint to read back the conclusions
+// agents report. A conclusion event is expected to be later than the event triggering the execution, to name the
+// script characterization in its header or description, and to report the exit code as "exit code: N" on a line of
+// its own. The rest of the description is the output of the script:
+//
+//	Header:      Script characterization 5aabb7551de0240abb000070 concluded
+//	Description: Exit code: 1
+//	             <script output>
+//
+// testdata.GetScriptConclusionEventData holds events of that shape.
+var exitCodeRegexp = regexp.MustCompile(`(?i)exit[ _]code:?\s*(-?\d+)`)
+
+// ScriptExecutionResult is the outcome of an operational script execution on a server of the fleet
+type ScriptExecutionResult struct {
+	ServerID   string    `json:"server_id" header:"SERVER_ID"`
+	ServerName string    `json:"server_name" header:"SERVER_NAME"`
+	Status     string    `json:"status" header:"STATUS"`
+	StartedAt  time.Time `json:"started_at,omitempty" header:"STARTED_AT"`
+	FinishedAt time.Time `json:"finished_at,omitempty" header:"FINISHED_AT"`
+	Output     string    `json:"output,omitempty" header:"OUTPUT"`
+}
+
+// FleetFlags returns the flags selecting the servers of a fleet action and how it is rolled out
+func FleetFlags() []cli.Flag {
+	return append(BulkFlags(),
+		cli.StringFlag{
+			Name:  "template-id",
+			Usage: "Applies the action to the servers of the given template",
+		},
+		cli.StringFlag{
+			Name:  "server-array-id",
+			Usage: "Applies the action to the servers of the given server array",
+		},
+		cli.IntFlag{
+			Name:  "batch-size",
+			Usage: "Number of servers of each rolling batch. The next batch starts once the previous one has finished. By default, there is a single batch",
+		},
+		cli.IntFlag{
+			Name:  "max-failures",
+			Usage: "Number of failures tolerated before aborting the rest of the servers. A negative value never aborts",
+		},
+		cli.IntFlag{
+			Name:  "timeout",
+			Usage: "Maximum time to wait for the action to finish on each server, in seconds",
+			Value: DefaultFleetTimeout,
+		},
+	)
+}
+
+// fleetTargets returns the servers matching every selector given in the flags, at least one of labels,
+// template-id or server-array-id
+func fleetTargets(c *cli.Context, serverSvc *cloud.ServerService, formatter format.Formatter) []*types.Server {
+	checkRequiredFlagsOr(c, []string{"labels", "template-id", "server-array-id"}, formatter)
+
+	servers, err := serverSvc.GetServerList()
+	if err != nil {
+		formatter.PrintFatal("Couldn't receive server data", err)
+	}
+	if c.String("labels") != "" {
+		labelables := make([]types.Labelable, len(servers))
+		for i := 0; i < len(servers); i++ {
+			labelables[i] = types.Labelable(servers[i])
+		}
+		servers = []*types.Server{}
+		for _, labelable := range bulkFilter(c, labelables, formatter) {
+			servers = append(servers, labelable.(*types.Server))
+		}
+	}
+
+	inArray := map[string]bool{}
+	if c.String("server-array-id") != "" {
+		serverArraySvc, _ := WireUpServerArray(c)
+		members, err := serverArraySvc.GetServerArrayServerList(c.String("server-array-id"))
+		if err != nil {
+			formatter.PrintFatal("Couldn't receive server array servers data", err)
+		}
+		for _, member := range members {
+			inArray[member.ID] = true
+		}
+	}
+
+	targets := []*types.Server{}
+	for _, server := range servers {
+		if c.String("template-id") != "" && server.TemplateID != c.String("template-id") {
+			continue
+		}
+		if c.String("server-array-id") != "" && !inArray[server.ID] {
+			continue
+		}
+		targets = append(targets, server)
+	}
+	return targets
+}
+
+// fleetBatches splits the servers into batches of the given size, or a single batch when it isn't positive
+func fleetBatches(servers []*types.Server, size int) [][]*types.Server {
+	if size <= 0 || size > len(servers) {
+		size = len(servers)
+	}
+	batches := [][]*types.Server{}
+	for start := 0; start < len(servers); start += size {
+		end := start + size
+		if end > len(servers) {
+			end = len(servers)
+		}
+		batches = append(batches, servers[start:end])
+	}
+	return batches
+}
+
+// scriptExecutor executes an operational script on servers and waits for its conclusion, which the agent
+// reports for the script characterization, and which the platform records as a server event
+type scriptExecutor struct {
+	serverSvc *cloud.ServerService
+	scriptID  string
+	timeout   time.Duration
+}
+
+// characterization returns the operational script characterization of the server, given either its ID or
+// the ID of its script, as the characterizations of a script differ among templates
+func (e *scriptExecutor) characterization(serverID string) (*types.ScriptChar, error) {
+	scripts, err := e.serverSvc.GetOperationalScriptsList(serverID)
+	if err != nil {
+		return nil, err
+	}
+	for _, script := range scripts {
+		if script.ID == e.scriptID || script.ScriptID == e.scriptID {
+			return script, nil
+		}
+	}
+	return nil, fmt.Errorf("there is no operational script %s for the server", e.scriptID)
+}
+
+// conclusionEvent returns the event concluding the execution of the script characterization triggered by the
+// given event, if any, along with the exit code it reports. Conclusions are reported by script characterization,
+// so only later events mentioning the characterization along with an exit code conclude the execution: other
+// events about the script, such as further executions being requested, don't.
+func conclusionEvent(events []*types.Event, trigger *types.Event, characterizationID string) (*types.Event, int) {
+	for _, event := range events {
+		if event.ID == trigger.ID || event.Timestamp.Before(trigger.Timestamp) {
+			continue
+		}
+		text := event.Header + "\n" + event.Description
+		if !strings.Contains(text, characterizationID) {
+			continue
+		}
+		values := exitCodeRegexp.FindStringSubmatch(text)
+		if len(values) == 0 {
+			continue
+		}
+		exitCode, err := strconv.Atoi(values[1])
+		if err != nil {
+			continue
+		}
+		return event, exitCode
+	}
+	return nil, 0
+}
+
+// conclusionOutput returns the output of the script reported by a conclusion event: its description, but for the
+// exit code line
+func conclusionOutput(event *types.Event) string {
+	lines := []string{}
+	for _, line := range strings.Split(event.Description, "\n") {
+		if !exitCodeRegexp.MatchString(line) {
+			lines = append(lines, line)
+		}
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+// execute runs the script on the server, updating the result until the execution concludes or times out
+func (e *scriptExecutor) execute(result *ScriptExecutionResult) {
+	fail := func(status string, err error) {
+		result.Status, result.Output, result.FinishedAt = status, err.Error(), time.Now()
+	}
+
+	script, err := e.characterization(result.ServerID)
+	if err != nil {
+		fail(fleetStatusFailed, err)
+		return
+	}
+
+	trigger, err := e.serverSvc.ExecuteOperationalScript(&map[string]interface{}{}, result.ServerID, script.ID)
+	if err != nil {
+		fail(fleetStatusFailed, err)
+		return
+	}
+	result.StartedAt = trigger.Timestamp
+
+	deadline := time.Now().Add(e.timeout)
+	for time.Now().Before(deadline) {
+		time.Sleep(waitInterval)
+		events, err := e.serverSvc.GetEventsListWithFilter(result.ServerID, &types.EventFilter{Since: trigger.Timestamp})
+		if err != nil {
+			continue
+		}
+		if event, exitCode := conclusionEvent(events, trigger, script.ID); event != nil {
+			result.Status, result.FinishedAt = fleetStatusSucceeded, event.Timestamp
+			if exitCode != 0 {
+				result.Status = fleetStatusFailed
+			}
+			result.Output = conclusionOutput(event)
+			return
+		}
+	}
+	fail(fleetStatusTimeout, fmt.Errorf("no event concluding script characterization %s with an exit code was received in %s", script.ID, e.timeout))
+}
+
+// ServerFleetExecuteScript subcommand function executes an operational script on the selected servers, in
+// rolling batches, and waits for the outcome on every one of them
+func ServerFleetExecuteScript(c *cli.Context) error {
+	debugCmdFuncInfo(c)
+	serverSvc, formatter := WireUpServer(c)
+
+	checkRequiredFlags(c, []string{"script-id"}, formatter)
+	servers := fleetTargets(c, serverSvc, formatter)
+
+	results := make([]*ScriptExecutionResult, len(servers))
+	targets := make([]*bulkTarget, len(servers))
+	for i, server := range servers {
+		results[i] = &ScriptExecutionResult{ServerID: server.ID, ServerName: server.Name, Status: bulkStatusPlanned}
+		targets[i] = &bulkTarget{ID: server.ID, Name: server.Name}
+		if server.State != "operational" {
+			results[i].Status, results[i].Output = fleetStatusSkipped, fmt.Sprintf("server is %s", server.State)
+		}
+	}
+	if c.Bool("dry-run") || len(servers) == 0 {
+		if err := formatter.PrintList(results); err != nil {
+			formatter.PrintFatal("Couldn't print/format result", err)
+		}
+		return nil
+	}
+	if !c.Bool("yes") && !confirmBulk(c.Command.Name, "server", targets) {
+		formatter.PrintFatal("Fleet action cancelled", fmt.Errorf("action %s has not been confirmed", c.Command.Name))
+	}
+
+	timeout := c.Int("timeout")
+	if timeout <= 0 {
+		timeout = DefaultFleetTimeout
+	}
+	concurrency := c.Int("concurrency")
+	if concurrency <= 0 {
+		concurrency = DefaultBulkConcurrency
+	}
+	executor := &scriptExecutor{
+		serverSvc: serverSvc,
+		scriptID:  c.String("script-id"),
+		timeout:   time.Duration(timeout) * time.Second,
+	}
+
+	maxFailures := c.Int("max-failures")
+	failures := 0
+	var mutex sync.Mutex
+	// aborted tells whether the failures exceed the threshold, returning how many there are
+	aborted := func() (bool, int) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return maxFailures >= 0 && failures > maxFailures, failures
+	}
+
+	resultsByID := map[string]*ScriptExecutionResult{}
+	for _, result := range results {
+		resultsByID[result.ServerID] = result
+	}
+	for _, batch := range fleetBatches(servers, c.Int("batch-size")) {
+		slots := make(chan bool, concurrency)
+		var wg sync.WaitGroup
+		for _, server := range batch {
+			result := resultsByID[server.ID]
+			if result.Status == fleetStatusSkipped {
+				continue
+			}
+			slots <- true
+			if abort, failed := aborted(); abort {
+				<-slots
+				result.Status, result.Output = fleetStatusSkipped, fmt.Sprintf("aborted after %d failures", failed)
+				continue
+			}
+			wg.Add(1)
+			go func(result *ScriptExecutionResult) {
+				defer func() {
+					<-slots
+					wg.Done()
+				}()
+				executor.execute(result)
+				if result.Status != fleetStatusSucceeded {
+					mutex.Lock()
+					failures++
+					mutex.Unlock()
+				}
+			}(result)
+		}
+		wg.Wait()
+	}
+
+	if err := formatter.PrintList(results); err != nil {
+		formatter.PrintFatal("Couldn't print/format result", err)
+	}
+	if failures > 0 {
+		formatter.PrintFatal("Fleet action failed", fmt.Errorf("script %s failed on %d of %d servers", executor.scriptID, failures, len(results)))
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ingrammicro/concerto/api/types"
+	"github.com/ingrammicro/concerto/testdata"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFleetBatches(t *testing.T) {
+	assert := assert.New(t)
+
+	servers := []*types.Server{}
+	for i := 1; i <= 5; i++ {
+		servers = append(servers, &types.Server{ID: fmt.Sprintf("server-%d", i)})
+	}
+
+	tests := []struct {
+		size  int
+		sizes []int
+	}{
+		{1, []int{1, 1, 1, 1, 1}},
+		{2, []int{2, 2, 1}},
+		{5, []int{5}},
+		{10, []int{5}},
+		{0, []int{5}},
+		{-1, []int{5}},
+	}
+	for _, test := range tests {
+		sizes := []int{}
+		for _, batch := range fleetBatches(servers, test.size) {
+			sizes = append(sizes, len(batch))
+		}
+		assert.Equal(test.sizes, sizes, "Unexpected batches of size %d", test.size)
+	}
+
+	batches := fleetBatches(servers, 2)
+	assert.Equal("server-3", batches[1][0].ID, "Batches should keep the servers order")
+	assert.Empty(fleetBatches([]*types.Server{}, 2), "There should be no batches without servers")
+}
+
+func TestConclusionEvent(t *testing.T) {
+	assert := assert.New(t)
+
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	trigger := &types.Event{ID: "event-1", Timestamp: start, Header: "Execution of script char-1 requested"}
+	event := func(ID string, seconds int, header string, description string) *types.Event {
+		return &types.Event{ID: ID, Timestamp: start.Add(time.Duration(seconds) * time.Second), Header: header, Description: description}
+	}
+
+	tests := []struct {
+		events   []*types.Event
+		ID       string
+		exitCode int
+	}{
+		{[]*types.Event{trigger}, "", 0},
+		{[]*types.Event{trigger, event("event-2", 10, "Script char-1 finished", "Exit code: 0")}, "event-2", 0},
+		{[]*types.Event{trigger, event("event-2", 10, "Script char-1 failed", "exit_code 3")}, "event-2", 3},
+		// earlier conclusions belong to previous executions
+		{[]*types.Event{event("event-0", -10, "Script char-1 finished", "Exit code: 1"), trigger}, "", 0},
+		// events mentioning the script without concluding it
+		{[]*types.Event{trigger, event("event-2", 10, "Execution of script char-1 requested", "")}, "", 0},
+		// conclusions of other characterizations of the same script
+		{[]*types.Event{trigger, event("event-2", 10, "Script char-2 finished", "Exit code: 0")}, "", 0},
+		{[]*types.Event{
+			trigger,
+			event("event-2", 5, "Script char-2 finished", "Exit code: 0"),
+			event("event-3", 10, "Script char-1 finished", "Exit code: 2"),
+		}, "event-3", 2},
+	}
+	for i, test := range tests {
+		conclusion, exitCode := conclusionEvent(test.events, trigger, "char-1")
+		if test.ID == "" {
+			assert.Nil(conclusion, "Test %d should have no conclusion", i)
+			continue
+		}
+		if assert.NotNil(conclusion, "Test %d should have a conclusion", i) {
+			assert.Equal(test.ID, conclusion.ID, "Unexpected conclusion in test %d", i)
+			assert.Equal(test.exitCode, exitCode, "Unexpected exit code in test %d", i)
+		}
+	}
+}
+
+func TestConclusionEventData(t *testing.T) {
+	assert := assert.New(t)
+
+	events := testdata.GetScriptConclusionEventData()
+	conclusion, exitCode := conclusionEvent(events, events[0], "5aabb7551de0240abb000070")
+	if assert.NotNil(conclusion, "Execution should be concluded") {
+		assert.Equal(events[2].ID, conclusion.ID, "Unexpected conclusion")
+		assert.Equal(1, exitCode, "Unexpected exit code")
+		assert.Equal("Installing nginx\nE: Unable to locate package nginx", conclusionOutput(conclusion), "Output should be the script output")
+	}
+	conclusion, _ = conclusionEvent(events, events[1], "5aabb7551de0240abb000071")
+	assert.Nil(conclusion, "Execution of another characterization should not be concluded")
+}
+
+func TestConclusionOutput(t *testing.T) {
+	assert := assert.New(t)
+
+	tests := map[string]string{
+		"":                             "",
+		"Exit code: 0":                 "",
+		"Exit code: 0\ndone\n":         "done",
+		"started\nexit_code 2\nfailed": "started\nfailed",
+		"no exit code reported here":   "no exit code reported here",
+		"plain output":                 "plain output",
+	}
+	for description, output := range tests {
+		assert.Equal(output, conclusionOutput(&types.Event{Header: "Script char-1 concluded", Description: description}), "Unexpected output of %q", description)
+	}
+}
@@ -151,6 +151,10 @@ func FloatingIPAttach(c *cli.Context) error {
 	if err != nil {
 		formatter.PrintFatal("Couldn't attach floating IP", err)
 	}
+	if c.Bool("wait") {
+		// the attached server is shown once the attachment completes
+		waitForFlaggedState(c, "floating_ip", c.String("id"), formatter)
+	}
 
 	_, labelNamesByID := LabelLoadsMapping(c)
 	server.FillInLabelNames(labelNamesByID)
@@ -16,7 +16,7 @@ func WireUpGenericImage(c *cli.Context) (ns *cloud.GenericImageService, f format
 	if err != nil {
 		f.PrintFatal("Couldn't wire up config", err)
 	}
-	hcs, err := utils.NewHTTPConcertoService(config)
+	hcs, err := utils.NewHTTPConcertoServiceWithCache(config)
 	if err != nil {
 		f.PrintFatal("Couldn't wire up concerto service", err)
 	}
@@ -2,7 +2,7 @@ package cmd
 
 import (
 	"fmt"
-	"regexp"
+	"sort"
 	"strings"
 
 	"github.com/codegangsta/cli"
@@ -21,7 +21,7 @@ func WireUpLabel(c *cli.Context) (ds *labels.LabelService, f format.Formatter) {
 	if err != nil {
 		f.PrintFatal("Couldn't wire up config", err)
 	}
-	hcs, err := utils.NewHTTPConcertoService(config)
+	hcs, err := utils.NewHTTPConcertoServiceWithCache(config)
 	if err != nil {
 		f.PrintFatal("Couldn't wire up concerto service", err)
 	}
@@ -38,7 +38,13 @@ func LabelList(c *cli.Context) error {
 	debugCmdFuncInfo(c)
 
 	labelsSvc, formatter := WireUpLabel(c)
-	labels, err := labelsSvc.GetLabelList()
+	var labels []*types.Label
+	var err error
+	if c.Bool("all") {
+		labels, err = labelsSvc.GetLabelListWithNamespaces()
+	} else {
+		labels, err = labelsSvc.GetLabelList()
+	}
 	if err != nil {
 		formatter.PrintFatal("Couldn't receive labels data", err)
 	}
@@ -66,21 +72,20 @@ func LabelCreate(c *cli.Context) error {
 }
 
 // LabelFiltering subcommand function receives a collection of references to labelable objects
-// Evaluates the matching of assigned labels with the labels requested for filtering.
+// Evaluates the matching of assigned labels with the label selector requested for filtering.
 func LabelFiltering(c *cli.Context, items []types.Labelable, labelIDsByName map[string]string) []types.Labelable {
 	debugCmdFuncInfo(c)
 
 	if c.String("labels") != "" {
-		_, formatter := WireUpLabel(c)
-		labelNamesIn := LabelsUnifyInputNames(c.String("labels"), formatter)
-		var filteringLabelIDs []string
-		for _, name := range labelNamesIn {
-			id := labelIDsByName[name]
-			filteringLabelIDs = append(filteringLabelIDs, id)
+		formatter := format.GetFormatter()
+		selector, err := types.ParseLabelSelector(c.String("labels"))
+		if err != nil {
+			formatter.PrintFatal("Invalid label selector", err)
 		}
+		labelsByID := LabelsByID(labelIDsByName)
 		var result []types.Labelable
 		for _, item := range items {
-			if item.FilterByLabelIDs(filteringLabelIDs) {
+			if item.FilterByLabelSelector(selector, labelsByID) {
 				result = append(result, item)
 			}
 		}
@@ -90,6 +95,18 @@ func LabelFiltering(c *cli.Context, items []types.Labelable, labelIDsByName map[
 	return items
 }
 
+// LabelsByID resolves the labels from the mapping returned by LabelLoadsMapping
+func LabelsByID(labelIDsByName map[string]string) map[string]*types.Label {
+	labelsByID := make(map[string]*types.Label)
+	for name, id := range labelIDsByName {
+		if label, err := types.ParseLabel(name); err == nil {
+			label.ID = id
+			labelsByID[id] = label
+		}
+	}
+	return labelsByID
+}
+
 // LabelAssignNamesForIDs subcommand function receives a collection of references to labelables objects
 // Resolves the Labels names associated to a each resource from given Labels ids, loading object with respective labels names
 func LabelAssignNamesForIDs(c *cli.Context, items []types.Labelable, labelNamesByID map[string]string) {
@@ -100,11 +117,12 @@ func LabelAssignNamesForIDs(c *cli.Context, items []types.Labelable, labelNamesB
 }
 
 // LabelLoadsMapping subcommand function retrieves the current label list in IMCO; then prepares two mapping structures (Name <-> ID and ID <-> Name)
+// Labels are named as [namespace:]name[=value]
 func LabelLoadsMapping(c *cli.Context) (map[string]string, map[string]string) {
 	debugCmdFuncInfo(c)
 
 	labelsSvc, formatter := WireUpLabel(c)
-	labels, err := labelsSvc.GetLabelList()
+	labels, err := labelsSvc.GetLabelListWithNamespaces()
 	if err != nil {
 		formatter.PrintFatal("Couldn't receive labels data", err)
 	}
@@ -113,20 +131,19 @@ func LabelLoadsMapping(c *cli.Context) (map[string]string, map[string]string) {
 	labelNamesByID := make(map[string]string)
 
 	for _, label := range labels {
-		labelIDsByName[label.Name] = label.ID
-		labelNamesByID[label.ID] = label.Name
+		labelIDsByName[label.QualifiedName()] = label.ID
+		labelNamesByID[label.ID] = label.QualifiedName()
 	}
 	return labelIDsByName, labelNamesByID
 }
 
 // LabelsUnifyInputNames subcommand function evaluates the received labels names (comma separated string).
-// Validates, remove duplicates and resolves a slice with unique label names.
+// Validates, remove duplicates and resolves a slice with unique label names, given as [namespace:]name[=value].
 func LabelsUnifyInputNames(labelsNames string, formatter format.Formatter) []string {
 	labelNamesIn := utils.RemoveDuplicates(strings.Split(labelsNames, ","))
 	for _, c := range labelNamesIn {
-		matched := regexp.MustCompile(`^[A-Za-z0-9 .\s_-]+$`).MatchString(c)
-		if !matched {
-			formatter.PrintFatal("Invalid label name ", fmt.Errorf("invalid label format: %v (Labels would be indicated with their name, which must satisfy to be composed of spaces, underscores, dots, dashes and/or lower/upper -case alphanumeric characters-)", c))
+		if _, err := types.ParseLabel(c); err != nil {
+			formatter.PrintFatal("Invalid label name ", err)
 		}
 	}
 	return labelNamesIn
@@ -147,8 +164,15 @@ func LabelResolution(c *cli.Context, labelsNames string, labelNamesByID *map[str
 	for _, name := range labelNamesIn {
 		// check if the label already exists in IMCO, creates it if it does not exist
 		if (*labelIDsByName)[name] == "" {
+			label, _ := types.ParseLabel(name)
 			labelPayload := make(map[string]interface{})
-			labelPayload["name"] = name
+			labelPayload["name"] = label.Name
+			if label.Namespace != "" {
+				labelPayload["namespace"] = label.Namespace
+			}
+			if label.Value != "" {
+				labelPayload["value"] = label.Value
+			}
 			newLabel, err := labelsSvc.CreateLabel(&labelPayload)
 			if err != nil {
 				formatter.PrintFatal("Couldn't create label", err)
@@ -218,3 +242,145 @@ func LabelRemove(c *cli.Context) error {
 	}
 	return nil
 }
+
+// labelIDFromFlags resolves the label given either by its id or by its name
+func labelIDFromFlags(c *cli.Context, formatter format.Formatter) string {
+	checkRequiredFlagsOr(c, []string{"id", "label"}, formatter)
+	if c.IsSet("id") {
+		return c.String("id")
+	}
+	labelIDsByName, _ := LabelLoadsMapping(c)
+	labelID, found := labelIDsByName[c.String("label")]
+	if !found {
+		formatter.PrintFatal("Couldn't receive label data", fmt.Errorf("unknown label %s", c.String("label")))
+	}
+	return labelID
+}
+
+// LabelShow subcommand function
+func LabelShow(c *cli.Context) error {
+	debugCmdFuncInfo(c)
+
+	labelsSvc, formatter := WireUpLabel(c)
+	label, err := labelsSvc.GetLabel(labelIDFromFlags(c, formatter))
+	if err != nil {
+		formatter.PrintFatal("Couldn't receive label data", err)
+	}
+	if err = formatter.PrintItem(*label); err != nil {
+		formatter.PrintFatal("Couldn't print/format result", err)
+	}
+	return nil
+}
+
+// LabelUpdate subcommand function renames a label. The new name can also set its value, as name=value
+func LabelUpdate(c *cli.Context) error {
+	debugCmdFuncInfo(c)
+
+	labelsSvc, formatter := WireUpLabel(c)
+	labelID := labelIDFromFlags(c, formatter)
+	checkRequiredFlags(c, []string{"name"}, formatter)
+
+	label, err := types.ParseLabel(c.String("name"))
+	if err != nil {
+		formatter.PrintFatal("Invalid label name ", err)
+	}
+	labelIn := map[string]interface{}{
+		"name": label.Name,
+	}
+	if label.Namespace != "" {
+		labelIn["namespace"] = label.Namespace
+	}
+	if label.Value != "" {
+		labelIn["value"] = label.Value
+	}
+
+	label, err = labelsSvc.UpdateLabel(&labelIn, labelID)
+	if err != nil {
+		formatter.PrintFatal("Couldn't update label", err)
+	}
+	if err = formatter.PrintItem(*label); err != nil {
+		formatter.PrintFatal("Couldn't print/format result", err)
+	}
+	return nil
+}
+
+// LabelDelete subcommand function
+func LabelDelete(c *cli.Context) error {
+	debugCmdFuncInfo(c)
+
+	labelsSvc, formatter := WireUpLabel(c)
+	err := labelsSvc.DeleteLabel(labelIDFromFlags(c, formatter))
+	if err != nil {
+		formatter.PrintFatal("Couldn't delete label", err)
+	}
+	return nil
+}
+
+// LabelResourceList subcommand function lists the resources of any type carrying a label, grouped by resource type
+func LabelResourceList(c *cli.Context) error {
+	debugCmdFuncInfo(c)
+
+	labelsSvc, formatter := WireUpLabel(c)
+	labeledResources, err := labelsSvc.GetLabeledResourceList(labelIDFromFlags(c, formatter))
+	if err != nil {
+		formatter.PrintFatal("Couldn't receive labeled resources data", err)
+	}
+
+	sort.SliceStable(labeledResources, func(i, j int) bool {
+		if labeledResources[i].ResourceType != labeledResources[j].ResourceType {
+			return labeledResources[i].ResourceType < labeledResources[j].ResourceType
+		}
+		return labeledResources[i].ID < labeledResources[j].ID
+	})
+	if err = formatter.PrintList(labeledResources); err != nil {
+		formatter.PrintFatal("Couldn't print/format result", err)
+	}
+	return nil
+}
+
+// labelOrphans returns the labels, other than the internal ones, which are not assigned to any resource, looking
+// up the resources of at most concurrency labels at the same time
+func labelOrphans(labels []*types.Label, labeledResources func(ID string) ([]*types.LabeledResource, error), concurrency int) ([]*bulkTarget, error) {
+	candidates := []*types.Label{}
+	for _, label := range labels {
+		if !label.Internal() {
+			candidates = append(candidates, label)
+		}
+	}
+
+	assigned := make([]bool, len(candidates))
+	errs := make([]error, len(candidates))
+	forEachConcurrently(concurrency, len(candidates), func(i int) {
+		resources, err := labeledResources(candidates[i].ID)
+		assigned[i], errs[i] = len(resources) > 0, err
+	})
+
+	orphans := []*bulkTarget{}
+	for i, label := range candidates {
+		if errs[i] != nil {
+			return nil, fmt.Errorf("cannot receive resources of label %s: %v", label.QualifiedName(), errs[i])
+		}
+		if !assigned[i] {
+			orphans = append(orphans, &bulkTarget{ID: label.ID, Name: label.QualifiedName()})
+		}
+	}
+	return orphans, nil
+}
+
+// LabelPrune subcommand function deletes the labels which are not assigned to any resource
+func LabelPrune(c *cli.Context) error {
+	debugCmdFuncInfo(c)
+
+	labelsSvc, formatter := WireUpLabel(c)
+	labels, err := labelsSvc.GetLabelListWithNamespaces()
+	if err != nil {
+		formatter.PrintFatal("Couldn't receive labels data", err)
+	}
+	orphans, err := labelOrphans(labels, labelsSvc.GetLabeledResourceList, c.Int("concurrency"))
+	if err != nil {
+		formatter.PrintFatal("Couldn't receive labeled resources data", err)
+	}
+
+	runBulk(c, "label", orphans, labelsSvc.DeleteLabel, formatter)
+	return nil
+}
@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ingrammicro/concerto/api/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLabelOrphans(t *testing.T) {
+	assert := assert.New(t)
+
+	labels := []*types.Label{
+		{ID: "label-1", Name: "env", Value: "prod"},
+		{ID: "label-2", Name: "env", Value: "staging"},
+		{ID: "label-3", Namespace: "team", Name: "owner", Value: "alice"},
+		{ID: "label-4", Namespace: "team", Name: "owner", Value: "bob"},
+		{ID: "label-5", Namespace: types.InternalLabelNamespace, Name: "managed"},
+	}
+	resourcesByLabelID := map[string][]*types.LabeledResource{
+		"label-1": {{ID: "server-1", ResourceType: "server"}},
+		"label-3": {{ID: "template-1", ResourceType: "template"}, {ID: "server-1", ResourceType: "server"}},
+	}
+
+	var mutex sync.Mutex
+	active, maxActive := 0, 0
+	lookedUp := []string{}
+	labeledResources := func(ID string) ([]*types.LabeledResource, error) {
+		mutex.Lock()
+		active++
+		if active > maxActive {
+			maxActive = active
+		}
+		lookedUp = append(lookedUp, ID)
+		mutex.Unlock()
+		time.Sleep(20 * time.Millisecond)
+		mutex.Lock()
+		active--
+		mutex.Unlock()
+		return resourcesByLabelID[ID], nil
+	}
+
+	orphans, err := labelOrphans(labels, labeledResources, 2)
+	assert.Nil(err, "Couldn't select orphan labels")
+	assert.Equal([]*bulkTarget{
+		{ID: "label-2", Name: "env=staging"},
+		{ID: "label-4", Name: "team:owner=bob"},
+	}, orphans, "Labels assigned to no resource, including namespaced ones, should be orphans")
+	assert.ElementsMatch([]string{"label-1", "label-2", "label-3", "label-4"}, lookedUp, "Internal labels should not be looked up")
+	assert.Equal(2, maxActive, "Resources of 2 labels should be looked up at the same time")
+
+	_, err = labelOrphans(labels, func(ID string) ([]*types.LabeledResource, error) {
+		if ID == "label-3" {
+			return nil, fmt.Errorf("server error")
+		}
+		return nil, nil
+	}, 2)
+	assert.NotNil(err, "Orphans should not be selected when resources cannot be looked up")
+}
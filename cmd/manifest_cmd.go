@@ -0,0 +1,755 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/codegangsta/cli"
+	"github.com/ingrammicro/concerto/api/blueprint"
+	"github.com/ingrammicro/concerto/api/labels"
+	"github.com/ingrammicro/concerto/api/types"
+	"github.com/ingrammicro/concerto/utils"
+	"github.com/ingrammicro/concerto/utils/format"
+	"github.com/ingrammicro/concerto/utils/manifest"
+)
+
+// manifestKindOps are the API operations over a kind of manifest resource
+type manifestKindOps struct {
+	list   func(parentID string) (interface{}, error)
+	create func(payload *map[string]interface{}, parentID string) (interface{}, error)
+	update func(payload *map[string]interface{}, ID string) (interface{}, error)
+	delete func(ID string) error
+	attach func(payload *map[string]interface{}, ID string) error
+	detach func(ID string) error
+}
+
+// manifestEngine plans and applies manifests against the current API state
+type manifestEngine struct {
+	c           *cli.Context
+	ops         map[string]*manifestKindOps
+	templateSvc *blueprint.TemplateService
+	scriptSvc   *blueprint.ScriptService
+	labelsSvc   *labels.LabelService
+
+	state   *manifest.State
+	planner *manifest.Planner
+
+	labelIDsByName  map[string]string
+	labelNamesByID  map[string]string
+	scriptIDsByName map[string]string
+
+	cookbookVersionsByID map[string]string
+}
+
+// wireUpManifest prepares the resources required to plan and apply manifests
+func wireUpManifest(c *cli.Context) (*manifestEngine, format.Formatter) {
+	sshProfileSvc, formatter := WireUpSSHProfile(c)
+	firewallProfileSvc, _ := WireUpFirewallProfile(c)
+	vpcSvc, _ := WireUpVPC(c)
+	subnetSvc, _ := WireUpSubnet(c)
+	vpnSvc, _ := WireUpVPN(c)
+	templateSvc, _ := WireUpTemplate(c)
+	serverArraySvc, _ := WireUpServerArray(c)
+	serverSvc, _ := WireUpServer(c)
+	volumeSvc, _ := WireUpVolume(c)
+	floatingIPSvc, _ := WireUpFloatingIP(c)
+	scriptSvc, _ := WireUpScript(c)
+	labelsSvc, _ := WireUpLabel(c)
+
+	ops := map[string]*manifestKindOps{
+		"ssh_profiles": {
+			list: func(string) (interface{}, error) { return sshProfileSvc.GetSSHProfileList() },
+			create: func(payload *map[string]interface{}, _ string) (interface{}, error) {
+				return sshProfileSvc.CreateSSHProfile(payload)
+			},
+			update: func(payload *map[string]interface{}, ID string) (interface{}, error) {
+				return sshProfileSvc.UpdateSSHProfile(payload, ID)
+			},
+			delete: sshProfileSvc.DeleteSSHProfile,
+		},
+		"firewall_profiles": {
+			list: func(string) (interface{}, error) { return firewallProfileSvc.GetFirewallProfileList() },
+			create: func(payload *map[string]interface{}, _ string) (interface{}, error) {
+				return firewallProfileSvc.CreateFirewallProfile(payload)
+			},
+			update: func(payload *map[string]interface{}, ID string) (interface{}, error) {
+				return firewallProfileSvc.UpdateFirewallProfile(payload, ID)
+			},
+			delete: firewallProfileSvc.DeleteFirewallProfile,
+		},
+		"vpcs": {
+			list: func(string) (interface{}, error) { return vpcSvc.GetVPCList() },
+			create: func(payload *map[string]interface{}, _ string) (interface{}, error) {
+				return vpcSvc.CreateVPC(payload)
+			},
+			update: func(payload *map[string]interface{}, ID string) (interface{}, error) {
+				return vpcSvc.UpdateVPC(payload, ID)
+			},
+			delete: vpcSvc.DeleteVPC,
+		},
+		"subnets": {
+			list: func(vpcID string) (interface{}, error) { return subnetSvc.GetSubnetList(vpcID) },
+			create: func(payload *map[string]interface{}, vpcID string) (interface{}, error) {
+				return subnetSvc.CreateSubnet(payload, vpcID)
+			},
+			update: func(payload *map[string]interface{}, ID string) (interface{}, error) {
+				return subnetSvc.UpdateSubnet(payload, ID)
+			},
+			delete: subnetSvc.DeleteSubnet,
+		},
+		"vpns": {
+			list: func(vpcID string) (interface{}, error) {
+				vpn, err := vpnSvc.GetVPN(vpcID)
+				if err != nil {
+					return nil, err
+				}
+				return []*types.Vpn{vpn}, nil
+			},
+			create: func(payload *map[string]interface{}, vpcID string) (interface{}, error) {
+				return vpnSvc.CreateVPN(payload, vpcID)
+			},
+			delete: vpnSvc.DeleteVPN,
+		},
+		"templates": {
+			list: func(string) (interface{}, error) { return templateSvc.GetTemplateList() },
+			create: func(payload *map[string]interface{}, _ string) (interface{}, error) {
+				return templateSvc.CreateTemplate(payload)
+			},
+			update: func(payload *map[string]interface{}, ID string) (interface{}, error) {
+				return templateSvc.UpdateTemplate(payload, ID)
+			},
+			delete: templateSvc.DeleteTemplate,
+		},
+		"server_arrays": {
+			list: func(string) (interface{}, error) { return serverArraySvc.GetServerArrayList() },
+			create: func(payload *map[string]interface{}, _ string) (interface{}, error) {
+				return serverArraySvc.CreateServerArray(payload)
+			},
+			update: func(payload *map[string]interface{}, ID string) (interface{}, error) {
+				return serverArraySvc.UpdateServerArray(payload, ID)
+			},
+			delete: serverArraySvc.DeleteServerArray,
+		},
+		"servers": {
+			list: func(string) (interface{}, error) { return serverSvc.GetServerList() },
+			create: func(payload *map[string]interface{}, _ string) (interface{}, error) {
+				return serverSvc.CreateServer(payload)
+			},
+			update: func(payload *map[string]interface{}, ID string) (interface{}, error) {
+				return serverSvc.UpdateServer(payload, ID)
+			},
+			delete: serverSvc.DeleteServer,
+		},
+		"volumes": {
+			list: func(string) (interface{}, error) { return volumeSvc.GetVolumeList("") },
+			create: func(payload *map[string]interface{}, _ string) (interface{}, error) {
+				return volumeSvc.CreateVolume(payload)
+			},
+			update: func(payload *map[string]interface{}, ID string) (interface{}, error) {
+				return volumeSvc.UpdateVolume(payload, ID)
+			},
+			delete: volumeSvc.DeleteVolume,
+			attach: func(payload *map[string]interface{}, ID string) error {
+				_, err := volumeSvc.AttachVolume(payload, ID)
+				return err
+			},
+			detach: volumeSvc.DetachVolume,
+		},
+		"floating_ips": {
+			list: func(string) (interface{}, error) { return floatingIPSvc.GetFloatingIPList("") },
+			create: func(payload *map[string]interface{}, _ string) (interface{}, error) {
+				return floatingIPSvc.CreateFloatingIP(payload)
+			},
+			update: func(payload *map[string]interface{}, ID string) (interface{}, error) {
+				return floatingIPSvc.UpdateFloatingIP(payload, ID)
+			},
+			delete: floatingIPSvc.DeleteFloatingIP,
+			attach: func(payload *map[string]interface{}, ID string) error {
+				_, err := floatingIPSvc.AttachFloatingIP(payload, ID)
+				return err
+			},
+			detach: floatingIPSvc.DetachFloatingIP,
+		},
+	}
+
+	e := &manifestEngine{
+		c:           c,
+		ops:         ops,
+		templateSvc: templateSvc,
+		scriptSvc:   scriptSvc,
+		labelsSvc:   labelsSvc,
+		state:       manifest.NewState(),
+	}
+	e.planner = &manifest.Planner{State: e.state, Backend: e}
+
+	// labels are managed by their qualified name, and the mappings used to assign them are kept up to date
+	ops["labels"] = &manifestKindOps{
+		list: func(string) (interface{}, error) {
+			labels, err := labelsSvc.GetLabelListWithNamespaces()
+			if err != nil {
+				return nil, err
+			}
+			objects := []interface{}{}
+			for _, label := range labels {
+				objects = append(objects, labelObject(label))
+			}
+			return objects, nil
+		},
+		create: func(payload *map[string]interface{}, _ string) (interface{}, error) {
+			name, _ := (*payload)["name"].(string)
+			label, err := types.ParseLabel(name)
+			if err != nil {
+				return nil, err
+			}
+			labelIn := map[string]interface{}{"name": label.Name}
+			if label.Namespace != "" {
+				labelIn["namespace"] = label.Namespace
+			}
+			if label.Value != "" {
+				labelIn["value"] = label.Value
+			}
+			if label, err = labelsSvc.CreateLabel(&labelIn); err != nil {
+				return nil, err
+			}
+			e.loadLabels()
+			e.labelIDsByName[label.QualifiedName()] = label.ID
+			e.labelNamesByID[label.ID] = label.QualifiedName()
+			return labelObject(label), nil
+		},
+		delete: labelsSvc.DeleteLabel,
+	}
+	return e, formatter
+}
+
+// loadState retrieves the current state of every kind the resources declare or reference
+func (e *manifestEngine) loadState(resources []*manifest.Resource) error {
+	needed := map[string]bool{}
+	for _, resource := range resources {
+		needed[resource.Kind.Name] = true
+		for _, kind := range resource.Kind.Refs {
+			needed[kind] = true
+		}
+	}
+	return e.loadKinds(needed)
+}
+
+// loadKinds retrieves the current objects of the given kinds, and their parents
+func (e *manifestEngine) loadKinds(needed map[string]bool) error {
+	// child kinds are listed by parent
+	for _, kind := range manifest.Kinds {
+		if needed[kind.Name] && kind.Parent != "" {
+			needed[kind.ParentKind()] = true
+		}
+	}
+
+	for _, kind := range manifest.Kinds {
+		if !needed[kind.Name] {
+			continue
+		}
+		e.state.Clear(kind.Name)
+
+		parents := []*manifest.Entry{{}}
+		if kind.Parent != "" {
+			parents = e.state.Entries(kind.ParentKind())
+		}
+		for _, parent := range parents {
+			parentID := ""
+			if parent.Object != nil {
+				if kind.ParentFlag != "" && parent.Object[kind.ParentFlag] != true {
+					continue
+				}
+				if parentID, _ = parent.Object["id"].(string); parentID == "" {
+					return fmt.Errorf("%s has no ID", parent.Address())
+				}
+			}
+			items, err := e.ops[kind.Name].list(parentID)
+			if err != nil {
+				return fmt.Errorf("cannot receive %s data: %v", kind.Name, err)
+			}
+			objects, _ := manifest.JSONValue(items).([]interface{})
+			for _, item := range objects {
+				if object, ok := item.(map[string]interface{}); ok {
+					e.state.Add(kind, parent.Name, object)
+				}
+			}
+		}
+	}
+
+	e.loadLabels()
+	return nil
+}
+
+// loadLabels retrieves the label mappings used to assign labels by name, only once
+func (e *manifestEngine) loadLabels() {
+	if e.labelIDsByName == nil {
+		e.labelIDsByName, e.labelNamesByID = LabelLoadsMapping(e.c)
+	}
+}
+
+// labelObject returns the label as a manifest object, named by its qualified name
+func labelObject(label *types.Label) map[string]interface{} {
+	return map[string]interface{}{"id": label.ID, "name": label.QualifiedName(), "namespace": label.Namespace}
+}
+
+// CookbookVersions converts cookbook versions given as in --cookbook-versions flag
+func (e *manifestEngine) CookbookVersions(cookbookVersions []string) (map[string]interface{}, error) {
+	return convertFlagParamsToCookbookVersions(e.c, strings.Join(cookbookVersions, ","))
+}
+
+// CookbookVersion returns the version of the uploaded cookbook version with the given ID
+func (e *manifestEngine) CookbookVersion(versionID string) (string, error) {
+	if e.cookbookVersionsByID == nil {
+		svc, _ := WireUpCookbookVersion(e.c)
+		cbvs, err := svc.GetCookbookVersionList()
+		if err != nil {
+			return "", fmt.Errorf("cannot receive uploaded cookbook versions data: %v", err)
+		}
+		e.cookbookVersionsByID = map[string]string{}
+		for _, cbv := range cbvs {
+			e.cookbookVersionsByID[cbv.ID] = cbv.Version
+		}
+	}
+	version, found := e.cookbookVersionsByID[versionID]
+	if !found {
+		return "", fmt.Errorf("unknown cookbook version %s", versionID)
+	}
+	return version, nil
+}
+
+// loadScripts retrieves the scripts IDs by name, only once. Names shared by several scripts map to an empty ID
+func (e *manifestEngine) loadScripts() error {
+	if e.scriptIDsByName != nil {
+		return nil
+	}
+	scripts, err := e.scriptSvc.GetScriptList()
+	if err != nil {
+		return fmt.Errorf("cannot receive scripts data: %v", err)
+	}
+	e.scriptIDsByName = map[string]string{}
+	for _, script := range scripts {
+		if _, found := e.scriptIDsByName[script.Name]; found {
+			e.scriptIDsByName[script.Name] = ""
+			continue
+		}
+		e.scriptIDsByName[script.Name] = script.ID
+	}
+	return nil
+}
+
+// ScriptID resolves a script by its name
+func (e *manifestEngine) ScriptID(name string) (string, error) {
+	if err := e.loadScripts(); err != nil {
+		return "", err
+	}
+	ID, found := e.scriptIDsByName[name]
+	if !found {
+		return "", fmt.Errorf("unknown script %s", name)
+	}
+	if ID == "" {
+		return "", fmt.Errorf("there are several scripts named %s, use script_id instead", name)
+	}
+	return ID, nil
+}
+
+// ScriptName returns the name of the script with the given ID, or none when several scripts share it
+func (e *manifestEngine) ScriptName(ID string) (string, error) {
+	if err := e.loadScripts(); err != nil {
+		return "", err
+	}
+	for name, scriptID := range e.scriptIDsByName {
+		if scriptID == ID {
+			return name, nil
+		}
+	}
+	return "", nil
+}
+
+// TemplateScripts returns the scripts of a template, by type and in execution order
+func (e *manifestEngine) TemplateScripts(templateID string) (map[string][]*types.TemplateScript, error) {
+	scripts := map[string][]*types.TemplateScript{}
+	for _, scriptType := range manifest.TemplateScriptTypes {
+		templateScripts, err := e.templateSvc.GetTemplateScriptList(templateID, scriptType)
+		if err != nil {
+			return nil, fmt.Errorf("cannot receive template scripts data: %v", err)
+		}
+		sort.SliceStable(templateScripts, func(i, j int) bool {
+			return templateScripts[i].ExecutionOrder < templateScripts[j].ExecutionOrder
+		})
+		scripts[scriptType] = templateScripts
+	}
+	return scripts, nil
+}
+
+// LabelNames returns the names of the labels assigned to an object, sorted
+func (e *manifestEngine) LabelNames(object map[string]interface{}) []string {
+	names := []string{}
+	IDs, _ := object["label_ids"].([]interface{})
+	for _, ID := range IDs {
+		if name, found := e.labelNamesByID[fmt.Sprintf("%v", ID)]; found {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// plan computes the steps required to converge the current state to the declared resources
+func (e *manifestEngine) plan(resources []*manifest.Resource) ([]*manifest.Step, error) {
+	if err := e.loadState(resources); err != nil {
+		return nil, err
+	}
+	return e.planner.Plan(resources)
+}
+
+// apply executes the planned steps in order, stopping at the first failure
+func (e *manifestEngine) apply(resources []*manifest.Resource, steps []*manifest.Step) error {
+	if err := manifest.Conflict(steps); err != nil {
+		return err
+	}
+
+	for i, step := range steps {
+		if step.Action == manifest.ActionNoop {
+			step.Status = "unchanged"
+			continue
+		}
+		if err := e.applyResource(resources[i], step); err != nil {
+			step.Status = "failed"
+			return fmt.Errorf("cannot %s %s/%s: %v", step.Action, step.Kind, step.Name, err)
+		}
+	}
+	return nil
+}
+
+// applyResource creates or updates a resource, along with its labels, scripts and attachment
+func (e *manifestEngine) applyResource(resource *manifest.Resource, step *manifest.Step) error {
+	kind := resource.Kind
+	ops := e.ops[kind.Name]
+
+	// references are resolved again, as resources created by previous steps have an ID now
+	payload, err := e.planner.DesiredPayload(resource)
+	if err != nil {
+		return err
+	}
+	for attribute, value := range payload {
+		if value == manifest.KnownAfterApply {
+			return fmt.Errorf("%s is not known yet", attribute)
+		}
+	}
+	parentID, _, err := e.state.ResolveRef(resource, kind.Parent)
+	if err != nil {
+		return err
+	}
+	attachID, _, err := e.state.ResolveRef(resource, kind.Attach)
+	if err != nil {
+		return err
+	}
+	labels, manageLabels := resource.Labels()
+
+	object, err := e.state.Lookup(resource)
+	if err != nil {
+		return err
+	}
+	if object == nil {
+		if manageLabels && len(labels) > 0 {
+			payload["label_ids"] = LabelResolution(e.c, strings.Join(labels, ","), &e.labelNamesByID, &e.labelIDsByName)
+		}
+		item, err := ops.create(&payload, parentID)
+		if err != nil {
+			return err
+		}
+		object = manifest.Object(item)
+		if step.ID, _ = object["id"].(string); step.ID == "" {
+			return fmt.Errorf("no ID received for the created resource")
+		}
+		step.Status = "created"
+	} else {
+		changed := map[string]interface{}{}
+		for attribute, value := range payload {
+			if !utils.Contains(kind.Secrets, attribute) && !manifest.ValueEqual(attribute, value, object[attribute]) {
+				changed[attribute] = value
+			}
+		}
+		if len(changed) > 0 {
+			item, err := ops.update(&changed, step.ID)
+			if err != nil {
+				return err
+			}
+			object = manifest.Object(item)
+		}
+		if manageLabels {
+			if err = e.syncLabels(object, labels); err != nil {
+				return err
+			}
+		}
+		step.Status = "updated"
+	}
+	e.state.Set(resource, object)
+
+	if resource.ManagesAttachment() {
+		if err = e.syncAttachment(kind, object, attachID); err != nil {
+			return err
+		}
+	}
+	if kind.Name == "templates" {
+		if err = e.syncScripts(resource, step.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// syncLabels adds and removes the labels of an existing object to match the declared ones
+func (e *manifestEngine) syncLabels(object map[string]interface{}, labels []string) error {
+	ID, _ := object["id"].(string)
+	resourceType, _ := object["resource_type"].(string)
+	current := e.LabelNames(object)
+
+	declared := map[string]bool{}
+	for _, name := range labels {
+		declared[name] = true
+	}
+	assigned := map[string]bool{}
+	for _, name := range current {
+		assigned[name] = true
+		if !declared[name] {
+			if err := e.labelsSvc.RemoveLabel(e.labelIDsByName[name], resourceType, ID); err != nil {
+				return fmt.Errorf("cannot remove label %s: %v", name, err)
+			}
+		}
+	}
+	for _, name := range labels {
+		if assigned[name] {
+			continue
+		}
+		labelIDs := LabelResolution(e.c, name, &e.labelNamesByID, &e.labelIDsByName)
+		labelIn := map[string]interface{}{
+			"resources": []interface{}{map[string]string{"id": ID, "resource_type": resourceType}},
+		}
+		if _, err := e.labelsSvc.AddLabel(&labelIn, labelIDs[0]); err != nil {
+			return fmt.Errorf("cannot add label %s: %v", name, err)
+		}
+	}
+	return nil
+}
+
+// syncAttachment attaches the object to the declared server, detaching it from any other first
+func (e *manifestEngine) syncAttachment(kind *manifest.Kind, object map[string]interface{}, serverID string) error {
+	ID, _ := object["id"].(string)
+	current, _ := object["attached_server_id"].(string)
+	if current == serverID {
+		return nil
+	}
+	ops := e.ops[kind.Name]
+	if current != "" {
+		if err := ops.detach(ID); err != nil {
+			return fmt.Errorf("cannot detach from server %s: %v", current, err)
+		}
+	}
+	if serverID != "" {
+		attachIn := map[string]interface{}{"attached_server_id": serverID}
+		if err := ops.attach(&attachIn, ID); err != nil {
+			return fmt.Errorf("cannot attach to server %s: %v", serverID, err)
+		}
+	}
+	object["attached_server_id"] = serverID
+	return nil
+}
+
+// syncScripts replaces the template scripts of every type which differs from the declared ones
+func (e *manifestEngine) syncScripts(resource *manifest.Resource, templateID string) error {
+	desired, manage, err := e.planner.DesiredScripts(resource)
+	if err != nil || !manage {
+		return err
+	}
+	current, err := e.TemplateScripts(templateID)
+	if err != nil {
+		return err
+	}
+
+	for _, scriptType := range manifest.ChangedScriptTypes(desired, current) {
+		for _, ts := range current[scriptType] {
+			if err := e.templateSvc.DeleteTemplateScript(templateID, ts.ID); err != nil {
+				return fmt.Errorf("cannot delete template script %s: %v", ts.ID, err)
+			}
+		}
+		for _, script := range desired[scriptType] {
+			templateScriptIn := map[string]interface{}{
+				"type":      script.Type,
+				"script_id": script.ScriptID,
+			}
+			if script.ParameterValues != nil {
+				templateScriptIn["parameter_values"] = script.ParameterValues
+			}
+			if _, err := e.templateSvc.CreateTemplateScript(&templateScriptIn, templateID); err != nil {
+				return fmt.Errorf("cannot create template script: %v", err)
+			}
+		}
+	}
+	return nil
+}
+
+// planDeletion computes the steps required to delete the declared resources which exist, in reverse dependency order
+func (e *manifestEngine) planDeletion(resources []*manifest.Resource) ([]*manifest.Resource, []*manifest.Step, error) {
+	if err := e.loadState(resources); err != nil {
+		return nil, nil, err
+	}
+
+	existing := []*manifest.Resource{}
+	steps := []*manifest.Step{}
+	for i := len(resources) - 1; i >= 0; i-- {
+		object, err := e.state.Lookup(resources[i])
+		if err != nil {
+			return nil, nil, err
+		}
+		if object == nil {
+			continue
+		}
+		ID, _ := object["id"].(string)
+		if ID == "" {
+			return nil, nil, fmt.Errorf("%s has no ID", resources[i])
+		}
+		existing = append(existing, resources[i])
+		steps = append(steps, &manifest.Step{
+			Action: manifest.ActionDelete,
+			Kind:   resources[i].Kind.Name,
+			Name:   resources[i].QualifiedName(),
+			ID:     ID,
+		})
+	}
+	return existing, steps, nil
+}
+
+// deleteResources executes the deletion steps in order, stopping at the first failure
+func (e *manifestEngine) deleteResources(resources []*manifest.Resource, steps []*manifest.Step) error {
+	for i, step := range steps {
+		kind := resources[i].Kind
+		object, err := e.state.Lookup(resources[i])
+		if err == nil && kind.Attach != "" {
+			err = e.syncAttachment(kind, object, "")
+		}
+		if err != nil {
+			step.Status = "failed"
+			return fmt.Errorf("cannot delete %s/%s: %v", step.Kind, step.Name, err)
+		}
+		// resources named after their parent are deleted through it
+		ID := step.ID
+		if kind.NamedByParent {
+			ID, _ = object[kind.Parent+"_id"].(string)
+		}
+		if err := e.ops[kind.Name].delete(ID); err != nil {
+			step.Status = "failed"
+			return fmt.Errorf("cannot delete %s/%s: %v", step.Kind, step.Name, err)
+		}
+		step.Status = "deleted"
+	}
+	return nil
+}
+
+// printManifestSteps prints the steps, using the formatter
+func printManifestSteps(formatter format.Formatter, steps []*manifest.Step) {
+	if err := formatter.PrintList(steps); err != nil {
+		formatter.PrintFatal("Couldn't print/format result", err)
+	}
+}
+
+// manifestStepTargets returns the resources changed by the steps, to be confirmed
+func manifestStepTargets(steps []*manifest.Step) []*bulkTarget {
+	targets := []*bulkTarget{}
+	for _, step := range steps {
+		if step.Action != manifest.ActionNoop {
+			targets = append(targets, &bulkTarget{ID: step.ID, Name: fmt.Sprintf("%s %s/%s", step.Action, step.Kind, step.Name)})
+		}
+	}
+	return targets
+}
+
+// confirmManifestSteps prints the planned steps and asks the user to confirm them, unless the yes flag is given.
+// It is fatal not to confirm them.
+func confirmManifestSteps(c *cli.Context, steps []*manifest.Step, formatter format.Formatter) {
+	if c.Bool("yes") {
+		return
+	}
+	printManifestSteps(formatter, steps)
+	if !confirmBulk(c.Command.Name, "manifest", manifestStepTargets(steps)) {
+		formatter.PrintFatal("Manifest changes cancelled", fmt.Errorf("action %s has not been confirmed", c.Command.Name))
+	}
+}
+
+// ManifestPlan subcommand function
+func ManifestPlan(c *cli.Context) error {
+	debugCmdFuncInfo(c)
+	engine, formatter := wireUpManifest(c)
+	checkRequiredFlags(c, []string{"file"}, formatter)
+
+	resources, err := manifest.Read(c.String("file"))
+	if err != nil {
+		formatter.PrintFatal("Couldn't read manifest", err)
+	}
+	steps, err := engine.plan(resources)
+	if err != nil {
+		formatter.PrintFatal("Couldn't plan manifest", err)
+	}
+	printManifestSteps(formatter, steps)
+	return nil
+}
+
+// ManifestApply subcommand function
+func ManifestApply(c *cli.Context) error {
+	debugCmdFuncInfo(c)
+	engine, formatter := wireUpManifest(c)
+	checkRequiredFlags(c, []string{"file"}, formatter)
+
+	resources, err := manifest.Read(c.String("file"))
+	if err != nil {
+		formatter.PrintFatal("Couldn't read manifest", err)
+	}
+	steps, err := engine.plan(resources)
+	if err != nil {
+		formatter.PrintFatal("Couldn't plan manifest", err)
+	}
+	if err = manifest.Conflict(steps); err != nil {
+		printManifestSteps(formatter, steps)
+		formatter.PrintFatal("Couldn't apply manifest", err)
+	}
+	if c.Bool("dry-run") || len(manifestStepTargets(steps)) == 0 {
+		printManifestSteps(formatter, steps)
+		return nil
+	}
+	confirmManifestSteps(c, steps, formatter)
+
+	if err = engine.apply(resources, steps); err != nil {
+		printManifestSteps(formatter, steps)
+		formatter.PrintFatal("Couldn't apply manifest", err)
+	}
+	printManifestSteps(formatter, steps)
+	return nil
+}
+
+// ManifestDelete subcommand function
+func ManifestDelete(c *cli.Context) error {
+	debugCmdFuncInfo(c)
+	engine, formatter := wireUpManifest(c)
+	checkRequiredFlags(c, []string{"file"}, formatter)
+
+	resources, err := manifest.Read(c.String("file"))
+	if err != nil {
+		formatter.PrintFatal("Couldn't read manifest", err)
+	}
+	existing, steps, err := engine.planDeletion(resources)
+	if err != nil {
+		formatter.PrintFatal("Couldn't plan manifest deletion", err)
+	}
+	if c.Bool("dry-run") || len(steps) == 0 {
+		printManifestSteps(formatter, steps)
+		return nil
+	}
+	confirmManifestSteps(c, steps, formatter)
+
+	if err = engine.deleteResources(existing, steps); err != nil {
+		printManifestSteps(formatter, steps)
+		formatter.PrintFatal("Couldn't delete manifest resources", err)
+	}
+	printManifestSteps(formatter, steps)
+	return nil
+}
@@ -0,0 +1,36 @@
+package cmd
+
+import (
+	"path/filepath"
+
+	"github.com/codegangsta/cli"
+	"github.com/ingrammicro/concerto/utils"
+	"github.com/ingrammicro/concerto/utils/format"
+)
+
+// OutboxDir returns the directory where the agent reports pending to be delivered are stored
+func OutboxDir(config *utils.Config) string {
+	return filepath.Join(config.ConfLocation, "outbox")
+}
+
+// WireUpOutbox prepares the outbox used to queue agent reports while API is unreachable.
+// It returns nil when the outbox cannot be used, so reports are sent without queuing.
+func WireUpOutbox(c *cli.Context) (ob *utils.Outbox) {
+
+	formatter := format.GetFormatter()
+	config, err := utils.GetConcertoConfig()
+	if err != nil {
+		formatter.PrintFatal("Couldn't wire up config", err)
+	}
+	hcs, err := utils.NewHTTPConcertoService(config)
+	if err != nil {
+		formatter.PrintFatal("Couldn't wire up concerto service", err)
+	}
+	ob, err = utils.NewOutbox(OutboxDir(config), hcs)
+	if err != nil {
+		formatter.PrintError("Couldn't wire up outbox, reports won't be queued", err)
+		return nil
+	}
+
+	return ob
+}
@@ -0,0 +1,297 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/codegangsta/cli"
+	"github.com/ingrammicro/concerto/utils"
+	"github.com/ingrammicro/concerto/utils/format"
+)
+
+// resourceLister retrieves the resources of a kind which an ID flag may refer to
+type resourceLister func(c *cli.Context) ([]*utils.NamedResource, error)
+
+// named adapts a list service call to a resourceLister
+func named(items interface{}, err error) ([]*utils.NamedResource, error) {
+	if err != nil {
+		return nil, err
+	}
+	return utils.NamedResources(items), nil
+}
+
+// resourceListers are the listers of the resources referred to by ID flags, by flag name without the -id suffix
+var resourceListers = map[string]resourceLister{
+	"app": func(c *cli.Context) ([]*utils.NamedResource, error) {
+		svc, _ := WireUpApp(c)
+		return named(svc.GetAppList())
+	},
+	"cloud-account": func(c *cli.Context) ([]*utils.NamedResource, error) {
+		svc, _ := WireUpCloudAccount(c)
+		return named(svc.GetCloudAccountList())
+	},
+	"cloud-provider": func(c *cli.Context) ([]*utils.NamedResource, error) {
+		svc, _ := WireUpCloudProvider(c)
+		return named(svc.GetCloudProviderList())
+	},
+	"cookbook-version": func(c *cli.Context) ([]*utils.NamedResource, error) {
+		svc, _ := WireUpCookbookVersion(c)
+		return named(svc.GetCookbookVersionList())
+	},
+	"firewall-profile": func(c *cli.Context) ([]*utils.NamedResource, error) {
+		svc, _ := WireUpFirewallProfile(c)
+		return named(svc.GetFirewallProfileList())
+	},
+	"floating-ip": func(c *cli.Context) ([]*utils.NamedResource, error) {
+		svc, _ := WireUpFloatingIP(c)
+		return named(svc.GetFloatingIPList(""))
+	},
+	"generic-image": func(c *cli.Context) ([]*utils.NamedResource, error) {
+		svc, _ := WireUpGenericImage(c)
+		return named(svc.GetGenericImageList())
+	},
+	"label": func(c *cli.Context) ([]*utils.NamedResource, error) {
+		svc, _ := WireUpLabel(c)
+		labels, err := svc.GetLabelListWithNamespaces()
+		if err != nil {
+			return nil, err
+		}
+		resources := []*utils.NamedResource{}
+		for _, label := range labels {
+			resources = append(resources, &utils.NamedResource{ID: label.ID, Name: label.QualifiedName()})
+		}
+		return resources, nil
+	},
+	"location": func(c *cli.Context) ([]*utils.NamedResource, error) {
+		svc, _ := WireUpLocation(c)
+		return named(svc.GetLocationList())
+	},
+	"script": func(c *cli.Context) ([]*utils.NamedResource, error) {
+		svc, _ := WireUpScript(c)
+		return named(svc.GetScriptList())
+	},
+	"server": func(c *cli.Context) ([]*utils.NamedResource, error) {
+		svc, _ := WireUpServer(c)
+		return named(svc.GetServerList())
+	},
+	"server-array": func(c *cli.Context) ([]*utils.NamedResource, error) {
+		svc, _ := WireUpServerArray(c)
+		return named(svc.GetServerArrayList())
+	},
+	"server-plan": func(c *cli.Context) ([]*utils.NamedResource, error) {
+		svc, _ := WireUpServerPlan(c)
+		return listByCloudProvider(c, func(providerID string) (interface{}, error) {
+			return svc.GetServerPlanList(providerID)
+		})
+	},
+	"ssh-profile": func(c *cli.Context) ([]*utils.NamedResource, error) {
+		svc, _ := WireUpSSHProfile(c)
+		return named(svc.GetSSHProfileList())
+	},
+	"storage-plan": func(c *cli.Context) ([]*utils.NamedResource, error) {
+		svc, _ := WireUpCloudProvider(c)
+		return listByCloudProvider(c, func(providerID string) (interface{}, error) {
+			return svc.GetServerStoragePlanList(providerID)
+		})
+	},
+	"subnet": func(c *cli.Context) ([]*utils.NamedResource, error) {
+		svc, _ := WireUpSubnet(c)
+		return listByVPC(c, func(vpcID string) (interface{}, error) {
+			return svc.GetSubnetList(vpcID)
+		})
+	},
+	"template": func(c *cli.Context) ([]*utils.NamedResource, error) {
+		svc, _ := WireUpTemplate(c)
+		return named(svc.GetTemplateList())
+	},
+	"volume": func(c *cli.Context) ([]*utils.NamedResource, error) {
+		svc, _ := WireUpVolume(c)
+		return named(svc.GetVolumeList(""))
+	},
+	"vpc": func(c *cli.Context) ([]*utils.NamedResource, error) {
+		svc, _ := WireUpVPC(c)
+		return named(svc.GetVPCList())
+	},
+	"vpn-plan": func(c *cli.Context) ([]*utils.NamedResource, error) {
+		svc, _ := WireUpVPN(c)
+		return listByVPC(c, func(vpcID string) (interface{}, error) {
+			return svc.GetVPNPlanList(vpcID)
+		})
+	},
+}
+
+// resourceGroups are the kinds of resource managed by each command group, which its id flag refers to
+var resourceGroups = map[string]string{
+	"apps":              "app",
+	"cloud-accounts":    "cloud-account",
+	"cloud-providers":   "cloud-provider",
+	"cookbook-versions": "cookbook-version",
+	"firewall-profiles": "firewall-profile",
+	"floating-ips":      "floating-ip",
+	"generic-images":    "generic-image",
+	"labels":            "label",
+	"locations":         "location",
+	"plans":             "storage-plan",
+	"scripts":           "script",
+	"server-arrays":     "server-array",
+	"server-plans":      "server-plan",
+	"servers":           "server",
+	"ssh-profiles":      "ssh-profile",
+	"subnets":           "subnet",
+	"templates":         "template",
+	"volumes":           "volume",
+	"vpcs":              "vpc",
+}
+
+// parentIDFlags are resolved before the rest, as other listers are scoped by them
+var parentIDFlags = []string{"cloud-provider-id", "vpc-id"}
+
+// listByCloudProvider lists the resources of the cloud provider given in the cloud-provider-id flag, or of all of them
+func listByCloudProvider(c *cli.Context, list func(providerID string) (interface{}, error)) ([]*utils.NamedResource, error) {
+	providerIDs := []string{c.String("cloud-provider-id")}
+	if providerIDs[0] == "" {
+		svc, _ := WireUpCloudProvider(c)
+		cloudProviders, err := svc.GetCloudProviderList()
+		if err != nil {
+			return nil, err
+		}
+		providerIDs = []string{}
+		for _, cloudProvider := range cloudProviders {
+			providerIDs = append(providerIDs, cloudProvider.ID)
+		}
+	}
+	return listByParents(providerIDs, list)
+}
+
+// listByVPC lists the resources of the VPC given in the vpc-id flag, or of all of them
+func listByVPC(c *cli.Context, list func(vpcID string) (interface{}, error)) ([]*utils.NamedResource, error) {
+	vpcIDs := []string{c.String("vpc-id")}
+	if vpcIDs[0] == "" {
+		svc, _ := WireUpVPC(c)
+		vpcs, err := svc.GetVPCList()
+		if err != nil {
+			return nil, err
+		}
+		vpcIDs = []string{}
+		for _, vpc := range vpcs {
+			vpcIDs = append(vpcIDs, vpc.ID)
+		}
+	}
+	return listByParents(vpcIDs, list)
+}
+
+func listByParents(parentIDs []string, list func(parentID string) (interface{}, error)) ([]*utils.NamedResource, error) {
+	resources := []*utils.NamedResource{}
+	for _, parentID := range parentIDs {
+		items, err := named(list(parentID))
+		if err != nil {
+			return nil, err
+		}
+		resources = append(resources, items...)
+	}
+	return resources, nil
+}
+
+// idFlagKindsOf returns the kind of resource referred to by each ID flag of a command of the given group
+func idFlagKindsOf(group string, flagNames []string) map[string]string {
+	kinds := map[string]string{}
+	for _, name := range flagNames {
+		if kind := strings.TrimSuffix(name, "-id"); kind != name {
+			if _, found := resourceListers[kind]; found {
+				kinds[name] = kind
+			}
+		}
+	}
+	// the id flag refers to the resource of the command group, unless the command is about one of its children,
+	// as in "templates update-template-script --template-id ... --id ..."
+	if kind, found := resourceGroups[group]; found && utils.Contains(flagNames, "id") {
+		if _, child := kinds[kind+"-id"]; !child {
+			kinds["id"] = kind
+		}
+	}
+	return kinds
+}
+
+// idFlagKinds returns the kind of resource referred to by each ID flag of the command, in resolution order
+func idFlagKinds(c *cli.Context) ([]string, map[string]string) {
+	groupNames := strings.Fields(c.App.Name)
+	kinds := idFlagKindsOf(groupNames[len(groupNames)-1], c.FlagNames())
+
+	names := []string{}
+	for _, name := range parentIDFlags {
+		if _, found := kinds[name]; found {
+			names = append(names, name)
+		}
+	}
+	others := []string{}
+	for name := range kinds {
+		if !utils.Contains(parentIDFlags, name) {
+			others = append(others, name)
+		}
+	}
+	sort.Strings(others)
+	return append(names, others...), kinds
+}
+
+// ResolveIDFlags replaces the names and ID prefixes given in the ID flags of the command by the IDs they refer to
+func ResolveIDFlags(c *cli.Context) error {
+	names, kinds := idFlagKinds(c)
+	for _, name := range names {
+		value := c.String(name)
+		if !c.IsSet(name) || value == "" || utils.IsFullID(value) || isSliceFlag(c, name) {
+			continue
+		}
+
+		kind := kinds[name]
+		candidates, err := resourceListers[kind](c)
+		if err != nil {
+			return fmt.Errorf("couldn't resolve --%s: %v", name, err)
+		}
+		ID, err := utils.ResolveID(strings.Replace(kind, "-", " ", -1), value, candidates)
+		if err != nil {
+			return fmt.Errorf("couldn't resolve --%s: %v", name, err)
+		}
+		if ID != value {
+			log.Debugf("Resolved --%s %s to %s", name, value, ID)
+			if err = c.Set(name, ID); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// isSliceFlag tells whether the command flag takes several values, which are resolved by the command itself
+func isSliceFlag(c *cli.Context, name string) bool {
+	for _, flag := range c.Command.Flags {
+		if f, ok := flag.(cli.StringSliceFlag); ok && f.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// WithIDResolution returns the commands, resolving the ID flags of every one of them before running it
+func WithIDResolution(commands []cli.Command) []cli.Command {
+	resolved := make([]cli.Command, len(commands))
+	for i, command := range commands {
+		if len(command.Subcommands) > 0 {
+			command.Subcommands = WithIDResolution(command.Subcommands)
+		} else {
+			before := command.Before
+			command.Before = func(c *cli.Context) error {
+				if err := ResolveIDFlags(c); err != nil {
+					format.GetFormatter().PrintFatal("Invalid resource reference", err)
+				}
+				if before != nil {
+					return before(c)
+				}
+				return nil
+			}
+		}
+		resolved[i] = command
+	}
+	return resolved
+}
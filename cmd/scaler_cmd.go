@@ -0,0 +1,236 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/codegangsta/cli"
+	"github.com/ingrammicro/concerto/api/cloud"
+	"github.com/ingrammicro/concerto/api/types"
+	"github.com/ingrammicro/concerto/utils"
+	"github.com/ingrammicro/concerto/utils/format"
+	"github.com/ingrammicro/concerto/utils/scaler"
+)
+
+// scalerState keeps the time of the last size change of every server array, by ID, for the cooldowns
+type scalerState struct {
+	LastChanges map[string]time.Time `json:"last_changes"`
+}
+
+// scalerRun reconciles the size of the server arrays of a policy
+type scalerRun struct {
+	policy         *scaler.Policy
+	serverArraySvc *cloud.ServerArrayService
+	roller         *serverRoller
+	statePath      string
+	state          *scalerState
+	decisionLog    string
+	dryRun         bool
+}
+
+func (s *scalerRun) loadState() error {
+	s.state = &scalerState{LastChanges: map[string]time.Time{}}
+	data, err := ioutil.ReadFile(s.statePath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err = json.Unmarshal(data, s.state); err != nil {
+		return fmt.Errorf("invalid scaler state %s: %v", s.statePath, err)
+	}
+	if s.state.LastChanges == nil {
+		s.state.LastChanges = map[string]time.Time{}
+	}
+	return nil
+}
+
+func (s *scalerRun) saveState() error {
+	data, err := json.Marshal(s.state)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(s.statePath), 0700); err != nil {
+		return err
+	}
+	return utils.WriteFileAtomic(s.statePath, data)
+}
+
+// logDecision appends the decision to the decision log, as a JSON line
+func (s *scalerRun) logDecision(decision *scaler.Decision) error {
+	if s.decisionLog == "" {
+		return nil
+	}
+	line, err := json.Marshal(decision)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(s.decisionLog, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.Write(append(line, '\n'))
+	return err
+}
+
+// shrinkCandidates returns the servers to decommission first: the ones which aren't operational, and then the
+// latest ones
+func shrinkCandidates(servers []*types.Server) []*types.Server {
+	candidates := []*types.Server{}
+	for _, server := range servers {
+		if server.State != "operational" {
+			candidates = append(candidates, server)
+		}
+	}
+	for i := len(servers) - 1; i >= 0; i-- {
+		if servers[i].State == "operational" {
+			candidates = append(candidates, servers[i])
+		}
+	}
+	return candidates
+}
+
+// resize applies the decision to the server array
+func (s *scalerRun) resize(decision *scaler.Decision, servers []*types.Server) error {
+	if decision.Action == scaler.ActionEnlarge {
+		for added := decision.Current; added < decision.Desired; added += maxEnlargeSize {
+			count := decision.Desired - added
+			if count > maxEnlargeSize {
+				count = maxEnlargeSize
+			}
+			if _, err := s.serverArraySvc.EnlargeServerArray(&map[string]interface{}{"size": count}, decision.ServerArrayID); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	for _, server := range shrinkCandidates(servers)[:decision.Current-decision.Desired] {
+		log.Infof("Decommissioning server %s (%s) of server array %s", server.ID, server.Name, decision.ServerArrayID)
+		if err := s.roller.decommission(server); err != nil {
+			return fmt.Errorf("couldn't decommission server %s: %v", server.ID, err)
+		}
+	}
+	return nil
+}
+
+// reconcile decides the size of the server array and applies it, unless in dry run
+func (s *scalerRun) reconcile(policy *scaler.ArrayPolicy, now time.Time) *scaler.Decision {
+	servers, err := s.serverArraySvc.GetServerArrayServerList(policy.ID)
+	if err != nil {
+		return &scaler.Decision{Timestamp: now, ServerArrayID: policy.ID, Action: scaler.ActionNone, Error: err.Error()}
+	}
+	active := []*types.Server{}
+	for _, server := range servers {
+		if server.State != "decommissioning" {
+			active = append(active, server)
+		}
+	}
+
+	var metric *float64
+	var metricErr error
+	if policy.Metric != nil {
+		var value float64
+		if value, metricErr = policy.Metric.Read(); metricErr == nil {
+			metric = &value
+		}
+	}
+	decision := policy.Decide(len(active), metric, s.state.LastChanges[policy.ID], now)
+	if metricErr != nil {
+		decision.Error = metricErr.Error()
+	}
+	if decision.Action != scaler.ActionEnlarge && decision.Action != scaler.ActionShrink {
+		return decision
+	}
+	if s.dryRun {
+		decision.DryRun = true
+		return decision
+	}
+
+	if err = s.resize(decision, active); err != nil {
+		decision.Error = err.Error()
+	}
+	s.state.LastChanges[policy.ID] = now
+	return decision
+}
+
+// reconcileAll reconciles every server array of the policy, logging and printing the decisions
+func (s *scalerRun) reconcileAll(formatter format.Formatter) {
+	decisions := []*scaler.Decision{}
+	for _, policy := range s.policy.ServerArrays {
+		decision := s.reconcile(policy, time.Now())
+		if decision.Error != "" {
+			log.Errorf("Scaling server array %s: %s", policy.ID, decision.Error)
+		}
+		if err := s.logDecision(decision); err != nil {
+			log.Errorf("Couldn't write decision log: %v", err)
+		}
+		decisions = append(decisions, decision)
+	}
+	if !s.dryRun {
+		if err := s.saveState(); err != nil {
+			log.Errorf("Couldn't save scaler state: %v", err)
+		}
+	}
+	if err := formatter.PrintList(decisions); err != nil {
+		formatter.PrintFatal("Couldn't print/format result", err)
+	}
+}
+
+// ScalerRun subcommand function keeps reconciling the size of the server arrays of a policy file
+func ScalerRun(c *cli.Context) error {
+	debugCmdFuncInfo(c)
+	serverArraySvc, formatter := WireUpServerArray(c)
+	serverSvc, _ := WireUpServer(c)
+
+	checkRequiredFlags(c, []string{"policy-file"}, formatter)
+	policy, err := scaler.LoadPolicy(c.String("policy-file"))
+	if err != nil {
+		formatter.PrintFatal("Couldn't load scaling policy", err)
+	}
+
+	statePath := c.String("state-file")
+	if statePath == "" {
+		config, err := utils.GetConcertoConfig()
+		if err != nil {
+			formatter.PrintFatal("Couldn't wire up config", err)
+		}
+		statePath = filepath.Join(utils.ProfileStateDir(config, "scaler"), "state.json")
+	}
+	run := &scalerRun{
+		policy:         policy,
+		serverArraySvc: serverArraySvc,
+		roller:         &serverRoller{serverSvc: serverSvc, waitTimeout: DefaultWaitTimeout * time.Second},
+		statePath:      statePath,
+		decisionLog:    c.String("decision-log"),
+		dryRun:         c.Bool("dry-run"),
+	}
+	if err = run.loadState(); err != nil {
+		formatter.PrintFatal("Couldn't load scaler state", err)
+	}
+
+	if c.Bool("once") {
+		run.reconcileAll(formatter)
+		return nil
+	}
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, syscall.SIGTERM, syscall.SIGINT)
+	for {
+		run.reconcileAll(formatter)
+		select {
+		case sig := <-stop:
+			log.Debug("Ending, signal detected:", sig)
+			return nil
+		case <-time.After(time.Duration(policy.Interval) * time.Second):
+		}
+	}
+}
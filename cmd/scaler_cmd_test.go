@@ -0,0 +1,157 @@
+package cmd
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ingrammicro/concerto/api/cloud"
+	"github.com/ingrammicro/concerto/api/types"
+	"github.com/ingrammicro/concerto/utils"
+	"github.com/ingrammicro/concerto/utils/scaler"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// testScalerRun returns a scaler run over a mocked API, keeping its state in a temporary directory
+func testScalerRun(t *testing.T) (*scalerRun, *utils.MockConcertoService, string) {
+	roller, cs := testRoller(t)
+	serverArraySvc, err := cloud.NewServerArrayService(cs)
+	assert.Nil(t, err, "Couldn't load server array service")
+	dir, err := ioutil.TempDir("", "cio-scaler-test")
+	assert.Nil(t, err, "Couldn't create directory")
+	return &scalerRun{
+		policy:         &scaler.Policy{},
+		serverArraySvc: serverArraySvc,
+		roller:         roller,
+		statePath:      filepath.Join(dir, "state.json"),
+		state:          &scalerState{LastChanges: map[string]time.Time{}},
+	}, cs, dir
+}
+
+// onArrayServers mocks the servers of the array, in the given states, as listed by the next call
+func onArrayServers(t *testing.T, cs *utils.MockConcertoService, states map[string]string) {
+	servers := []*types.Server{}
+	for _, ID := range []string{"server-1", "server-2", "server-3", "server-4"} {
+		if state, found := states[ID]; found {
+			servers = append(servers, &types.Server{ID: ID, Name: ID, State: state})
+		}
+	}
+	data, err := json.Marshal(servers)
+	assert.Nil(t, err, "Server test data corrupted")
+	cs.On("Get", "/cloud/server_arrays/array-1/servers").Return(data, 200, nil).Once()
+}
+
+func TestShrinkCandidates(t *testing.T) {
+	assert := assert.New(t)
+
+	servers := []*types.Server{
+		{ID: "server-1", State: "operational"},
+		{ID: "server-2", State: "stalled"},
+		{ID: "server-3", State: "operational"},
+		{ID: "server-4", State: "inactive"},
+		{ID: "server-5", State: "operational"},
+	}
+	IDs := []string{}
+	for _, server := range shrinkCandidates(servers) {
+		IDs = append(IDs, server.ID)
+	}
+	assert.Equal([]string{"server-2", "server-4", "server-5", "server-3", "server-1"}, IDs,
+		"Servers which aren't operational should be decommissioned first, then the latest ones")
+	assert.Empty(shrinkCandidates([]*types.Server{}), "There should be no candidates without servers")
+}
+
+func TestScalerResizeEnlarge(t *testing.T) {
+	assert := assert.New(t)
+
+	run, cs, dir := testScalerRun(t)
+	defer os.RemoveAll(dir)
+	cs.On("Post", "/cloud/server_arrays/array-1/servers", &map[string]interface{}{"size": maxEnlargeSize}).Return([]byte("{}"), 200, nil).Twice()
+	cs.On("Post", "/cloud/server_arrays/array-1/servers", &map[string]interface{}{"size": 2}).Return([]byte("{}"), 200, nil).Once()
+
+	decision := &scaler.Decision{ServerArrayID: "array-1", Current: 3, Desired: 3 + 2*maxEnlargeSize + 2, Action: scaler.ActionEnlarge}
+	assert.Nil(run.resize(decision, nil), "Couldn't enlarge server array")
+	cs.AssertExpectations(t)
+	cs.AssertNumberOfCalls(t, "Post", 3)
+}
+
+func TestScalerResizeShrink(t *testing.T) {
+	assert := assert.New(t)
+
+	run, cs, dir := testScalerRun(t)
+	defer os.RemoveAll(dir)
+	onDecommission(t, cs, "server-2", "stalled")
+	onDecommission(t, cs, "server-4", "operational")
+
+	servers := []*types.Server{
+		{ID: "server-1", State: "operational"},
+		{ID: "server-2", State: "stalled"},
+		{ID: "server-3", State: "operational"},
+		{ID: "server-4", State: "operational"},
+	}
+	decision := &scaler.Decision{ServerArrayID: "array-1", Current: 4, Desired: 2, Action: scaler.ActionShrink}
+	assert.Nil(run.resize(decision, servers), "Couldn't shrink server array")
+	// only the servers decommissioned are deleted, as the mock would fail on any other
+	cs.AssertExpectations(t)
+	cs.AssertNumberOfCalls(t, "Delete", 2)
+}
+
+func TestScalerReconcileCooldown(t *testing.T) {
+	assert := assert.New(t)
+
+	run, cs, dir := testScalerRun(t)
+	defer os.RemoveAll(dir)
+	policy := &scaler.ArrayPolicy{ID: "array-1", Min: 3, Max: 10, Cooldown: 300}
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	// servers being decommissioned don't count
+	onArrayServers(t, cs, map[string]string{"server-1": "operational", "server-2": "operational", "server-3": "decommissioning"})
+	cs.On("Post", "/cloud/server_arrays/array-1/servers", &map[string]interface{}{"size": 1}).Return([]byte("{}"), 200, nil).Once()
+	decision := run.reconcile(policy, now)
+	assert.Equal(scaler.ActionEnlarge, decision.Action, "Array below its minimum should be enlarged")
+	assert.Equal(2, decision.Current, "Unexpected current size")
+	assert.Empty(decision.Error, "Unexpected error")
+	assert.Equal(now, run.state.LastChanges["array-1"], "Size change should be recorded")
+
+	// the new server isn't listed yet, but the array is cooling down
+	onArrayServers(t, cs, map[string]string{"server-1": "operational", "server-2": "operational"})
+	decision = run.reconcile(policy, now.Add(time.Minute))
+	assert.Equal(scaler.ActionCooldown, decision.Action, "Array should not be resized while cooling down")
+	assert.Equal(now, run.state.LastChanges["array-1"], "Cooldowns should not be recorded as size changes")
+
+	onArrayServers(t, cs, map[string]string{"server-1": "operational", "server-2": "operational", "server-3": "operational"})
+	decision = run.reconcile(policy, now.Add(10*time.Minute))
+	assert.Equal(scaler.ActionNone, decision.Action, "Array within bounds should be kept")
+	assert.Equal(now, run.state.LastChanges["array-1"], "Size should not be recorded as changed")
+	cs.AssertExpectations(t)
+}
+
+func TestScalerReconcileDryRun(t *testing.T) {
+	assert := assert.New(t)
+
+	run, cs, dir := testScalerRun(t)
+	defer os.RemoveAll(dir)
+	run.dryRun = true
+	run.policy.ServerArrays = []*scaler.ArrayPolicy{{ID: "array-1", Min: 1, Max: 2}}
+
+	onArrayServers(t, cs, map[string]string{"server-1": "operational", "server-2": "operational", "server-3": "operational", "server-4": "stalled"})
+	formatter := &testFormatter{}
+	run.reconcileAll(formatter)
+	if assert.Len(formatter.lists, 1, "Decisions should be printed") {
+		decisions := formatter.lists[0].([]*scaler.Decision)
+		if assert.Len(decisions, 1, "Unexpected decisions") {
+			assert.Equal(scaler.ActionShrink, decisions[0].Action, "Array above its maximum should be shrunk")
+			assert.Equal(2, decisions[0].Desired, "Unexpected desired size")
+			assert.True(decisions[0].DryRun, "Decision should be marked as dry run")
+		}
+	}
+	assert.Empty(run.state.LastChanges, "Dry runs should not record size changes")
+	assert.False(utils.FileExists(run.statePath), "Dry runs should not save the state")
+	// no server is shut down nor deleted
+	cs.AssertNotCalled(t, "Put", mock.Anything, mock.Anything)
+	cs.AssertNotCalled(t, "Delete", mock.Anything)
+	cs.AssertExpectations(t)
+}
@@ -0,0 +1,172 @@
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/codegangsta/cli"
+	"github.com/ingrammicro/concerto/api/blueprint"
+	"github.com/ingrammicro/concerto/api/types"
+	"github.com/ingrammicro/concerto/utils"
+)
+
+// Script runners
+const (
+	ScriptRunnerLocal  = "local"
+	ScriptRunnerDocker = "docker"
+	ScriptRunnerPodman = "podman"
+)
+
+// scriptRunParameters returns the parameter values given as KEY=VALUE, which must be parameters of the script
+func scriptRunParameters(script *types.Script, params []string) (map[string]string, error) {
+	declared := map[string]bool{}
+	for _, name := range script.Parameters {
+		declared[name] = true
+	}
+	parameters := map[string]string{}
+	for _, param := range params {
+		i := strings.Index(param, "=")
+		if i <= 0 {
+			return nil, fmt.Errorf("invalid parameter %q, KEY=VALUE expected", param)
+		}
+		name := param[:i]
+		if !declared[name] {
+			return nil, fmt.Errorf("script %s has no parameter %s", script.ID, name)
+		}
+		parameters[name] = param[i+1:]
+	}
+	for _, name := range script.Parameters {
+		if _, ok := parameters[name]; !ok {
+			log.Warnf("No value given for parameter %s", name)
+		}
+	}
+	return parameters, nil
+}
+
+// scriptRunnerArgs returns the command line prefix running the script in a container of the image, with the
+// working directory mounted at the same path and the environment of the script
+func scriptRunnerArgs(runner string, image string, path string, parameters map[string]string) ([]string, error) {
+	switch runner {
+	case "", ScriptRunnerLocal:
+		return nil, nil
+	case ScriptRunnerDocker, ScriptRunnerPodman:
+		if image == "" {
+			return nil, fmt.Errorf("an image is required to run the script with %s", runner)
+		}
+		names := []string{"ATTACHMENT_DIR"}
+		for name := range parameters {
+			names = append(names, name)
+		}
+		sort.Strings(names[1:])
+		args := []string{runner, "run", "--rm", "-v", fmt.Sprintf("%s:%s", path, path), "-w", path}
+		for _, name := range names {
+			// values are taken from the environment of the runner
+			args = append(args, "-e", name)
+		}
+		return append(args, image), nil
+	}
+	return nil, fmt.Errorf("unsupported runner %q, use one of [ %s | %s | %s ]", runner, ScriptRunnerLocal, ScriptRunnerDocker, ScriptRunnerPodman)
+}
+
+// downloadScriptAttachments gets the attachments of the script into attachmentDir
+func downloadScriptAttachments(scriptSvc *blueprint.ScriptService, attachmentSvc *blueprint.AttachmentService, scriptID string, attachmentDir string) error {
+	attachments, err := scriptSvc.ListScriptAttachments(scriptID)
+	if err != nil {
+		return err
+	}
+	for _, attachment := range attachments {
+		realFileName, err := downloadScriptAttachment(attachmentSvc, attachment, attachmentDir)
+		if err != nil {
+			return err
+		}
+		log.Infof("\t - %s --> %s", attachment.Name, realFileName)
+	}
+	return nil
+}
+
+// downloadScriptAttachment gets an attachment of a script into dir, named as the attachment
+func downloadScriptAttachment(attachmentSvc *blueprint.AttachmentService, attachment *types.Attachment, dir string) (string, error) {
+	var err error
+	if attachment.DownloadURL == "" {
+		if attachment, err = attachmentSvc.GetAttachment(attachment.ID); err != nil {
+			return "", err
+		}
+	}
+	if attachment.Name == "" || filepath.Base(attachment.Name) != attachment.Name {
+		return "", fmt.Errorf("attachment %s has an invalid name %q", attachment.ID, attachment.Name)
+	}
+	realFileName, status, err := attachmentSvc.DownloadAttachment(attachment.DownloadURL, filepath.Join(dir, attachment.Name))
+	if err == nil && status != 200 {
+		err = fmt.Errorf("obtained non-ok response %d", status)
+	}
+	if err != nil {
+		return "", fmt.Errorf("couldn't download attachment %s: %v", attachment.Name, err)
+	}
+	return realFileName, nil
+}
+
+// ScriptRun subcommand function runs a script with its attachments and the given parameters, as the agent does
+// on servers, but locally or in a container
+func ScriptRun(c *cli.Context) error {
+	debugCmdFuncInfo(c)
+	scriptSvc, formatter := WireUpScript(c)
+	attachmentSvc, _ := WireUpAttachment(c)
+
+	checkRequiredFlags(c, []string{"id"}, formatter)
+	script, err := scriptSvc.GetScript(c.String("id"))
+	if err != nil {
+		formatter.PrintFatal("Couldn't receive script data", err)
+	}
+	parameters, err := scriptRunParameters(script, c.StringSlice("param"))
+	if err != nil {
+		formatter.PrintFatal("Invalid parameters", err)
+	}
+
+	path, err := ioutil.TempDir("", "cio")
+	if err != nil {
+		formatter.PrintFatal("Couldn't create temporary directory", err)
+	}
+	defer os.RemoveAll(path)
+	runner, err := scriptRunnerArgs(c.String("runner"), c.String("image"), path, parameters)
+	if err != nil {
+		formatter.PrintFatal("Invalid runner", err)
+	}
+
+	log.Infof("Home Folder: %s", path)
+	attachmentDir := filepath.Join(path, "attachments")
+	if err = os.Mkdir(attachmentDir, 0777); err != nil {
+		formatter.PrintFatal("Couldn't create attachments directory", err)
+	}
+	env := utils.ScriptEnvironment(attachmentDir, parameters)
+	log.Infof("Attachment Folder: %s", attachmentDir)
+	log.Infof("Attachments")
+	if err = downloadScriptAttachments(scriptSvc, attachmentSvc, script.ID, attachmentDir); err != nil {
+		formatter.PrintFatal("Couldn't download script attachments", err)
+	}
+
+	output, exitCode, startedAt, finishedAt, err := utils.ExecCodeWithRunner(script.Code, path, script.ID, c.String("interpreter"), env, runner)
+	if err != nil {
+		formatter.PrintFatal("Couldn't run script", err)
+	}
+
+	conclusion := types.ScriptConclusion{
+		Output:     output,
+		ExitCode:   exitCode,
+		StartedAt:  startedAt.Format(utils.TimeStampLayout),
+		FinishedAt: finishedAt.Format(utils.TimeStampLayout),
+	}
+	if err = formatter.PrintItem(conclusion); err != nil {
+		formatter.PrintFatal("Couldn't print/format result", err)
+	}
+	if exitCode != 0 {
+		// exits as the script did, so that failures can be told apart in scripts and pipelines
+		os.RemoveAll(path)
+		os.Exit(exitCode)
+	}
+	return nil
+}
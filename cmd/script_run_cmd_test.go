@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/ingrammicro/concerto/api/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScriptRunParameters(t *testing.T) {
+	assert := assert.New(t)
+
+	script := &types.Script{ID: "script-1", Parameters: []string{"PORT", "USER"}}
+	tests := []struct {
+		params     []string
+		parameters map[string]string
+	}{
+		{[]string{}, map[string]string{}},
+		{[]string{"PORT=80", "USER=www"}, map[string]string{"PORT": "80", "USER": "www"}},
+		{[]string{"PORT=", "USER=a=b"}, map[string]string{"PORT": "", "USER": "a=b"}},
+		{[]string{"PORT=80", "PORT=8080"}, map[string]string{"PORT": "8080"}},
+	}
+	for _, test := range tests {
+		parameters, err := scriptRunParameters(script, test.params)
+		if assert.Nil(err, "Parameters %v should be accepted", test.params) {
+			assert.Equal(test.parameters, parameters, "Unexpected parameters for %v", test.params)
+		}
+	}
+
+	for _, params := range [][]string{{"PORT"}, {"=80"}, {"PORT=80", "USER"}, {"HOST=localhost"}, {"port=80"}} {
+		_, err := scriptRunParameters(script, params)
+		assert.NotNil(err, "Parameters %v should be rejected", params)
+	}
+}
+
+func TestScriptRunParametersMissing(t *testing.T) {
+	assert := assert.New(t)
+
+	var logged bytes.Buffer
+	log.SetOutput(&logged)
+	defer log.SetOutput(os.Stderr)
+
+	script := &types.Script{ID: "script-1", Parameters: []string{"PORT", "USER"}}
+	parameters, err := scriptRunParameters(script, []string{"PORT=80"})
+	assert.Nil(err, "Missing parameters should only be warned")
+	assert.Equal(map[string]string{"PORT": "80"}, parameters, "Missing parameters should not be given a value")
+	assert.Contains(logged.String(), "No value given for parameter USER", "Missing parameters should be warned")
+	assert.NotContains(logged.String(), "parameter PORT", "Given parameters should not be warned")
+}
+
+func TestScriptRunnerArgs(t *testing.T) {
+	assert := assert.New(t)
+
+	parameters := map[string]string{"USER": "www", "PORT": "80", "HOST": "localhost"}
+	for _, runner := range []string{"", ScriptRunnerLocal} {
+		args, err := scriptRunnerArgs(runner, "", "/tmp/cio", parameters)
+		assert.Nil(err, "Runner %q should be accepted", runner)
+		assert.Nil(args, "Scripts should be run locally by runner %q", runner)
+	}
+	for _, runner := range []string{ScriptRunnerDocker, ScriptRunnerPodman} {
+		args, err := scriptRunnerArgs(runner, "alpine:3", "/tmp/cio", parameters)
+		assert.Nil(err, "Runner %s should be accepted", runner)
+		assert.Equal([]string{
+			runner, "run", "--rm", "-v", "/tmp/cio:/tmp/cio", "-w", "/tmp/cio",
+			"-e", "ATTACHMENT_DIR", "-e", "HOST", "-e", "PORT", "-e", "USER",
+			"alpine:3",
+		}, args, "Attachment directory should be passed first, then parameters by name")
+
+		_, err = scriptRunnerArgs(runner, "", "/tmp/cio", parameters)
+		assert.NotNil(err, "Runner %s should require an image", runner)
+	}
+
+	args, err := scriptRunnerArgs(ScriptRunnerDocker, "alpine:3", "/tmp/cio", map[string]string{})
+	assert.Nil(err, "Scripts without parameters should be accepted")
+	assert.Equal([]string{"docker", "run", "--rm", "-v", "/tmp/cio:/tmp/cio", "-w", "/tmp/cio", "-e", "ATTACHMENT_DIR", "alpine:3"}, args, "Unexpected args")
+
+	_, err = scriptRunnerArgs("lxc", "alpine:3", "/tmp/cio", parameters)
+	assert.NotNil(err, "Unknown runners should be rejected")
+}
@@ -0,0 +1,484 @@
+package cmd
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/codegangsta/cli"
+	"github.com/ingrammicro/concerto/api/blueprint"
+	"github.com/ingrammicro/concerto/api/types"
+	"github.com/ingrammicro/concerto/utils/manifest"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	scriptMetadataFileName = "script.yml"
+	scriptAttachmentsDir   = "attachments"
+)
+
+// scriptSource is a script declared in a subdirectory of a scripts directory, such as:
+//
+//	install-nginx/
+//	  script.yml      name, description, parameters and labels
+//	  install.sh      the code, the only other file
+//	  attachments/    the files attached to the script
+//	    nginx.conf
+type scriptSource struct {
+	Name        string   `yaml:"name"`
+	Description string   `yaml:"description"`
+	Parameters  []string `yaml:"parameters"`
+	Labels      []string `yaml:"labels"`
+
+	code string
+	// attachments maps the attachment names to their files
+	attachments map[string]string
+}
+
+// readScriptSource reads the script declared in the directory. Its name defaults to the directory one.
+func readScriptSource(dir string) (*scriptSource, error) {
+	data, err := ioutil.ReadFile(filepath.Join(dir, scriptMetadataFileName))
+	if err != nil {
+		return nil, fmt.Errorf("cannot read script metadata: %v", err)
+	}
+	source := &scriptSource{Labels: []string{}, attachments: map[string]string{}}
+	if err = yaml.UnmarshalStrict(data, source); err != nil {
+		return nil, fmt.Errorf("invalid %s: %v", scriptMetadataFileName, err)
+	}
+	if source.Name == "" {
+		source.Name = filepath.Base(dir)
+	}
+	for i, label := range source.Labels {
+		source.Labels[i] = strings.TrimSpace(label)
+	}
+	sort.Strings(source.Labels)
+
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	codeFiles := []string{}
+	for _, entry := range entries {
+		if entry.Mode().IsRegular() && entry.Name() != scriptMetadataFileName && !strings.HasPrefix(entry.Name(), ".") {
+			codeFiles = append(codeFiles, entry.Name())
+		}
+	}
+	if len(codeFiles) != 1 {
+		return nil, fmt.Errorf("a single script file is expected besides %s, found %d", scriptMetadataFileName, len(codeFiles))
+	}
+	code, err := ioutil.ReadFile(filepath.Join(dir, codeFiles[0]))
+	if err != nil {
+		return nil, err
+	}
+	source.code = string(code)
+
+	entries, err = ioutil.ReadDir(filepath.Join(dir, scriptAttachmentsDir))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		if !entry.Mode().IsRegular() {
+			return nil, fmt.Errorf("attachment %s is not a regular file", entry.Name())
+		}
+		source.attachments[entry.Name()] = filepath.Join(dir, scriptAttachmentsDir, entry.Name())
+	}
+	return source, nil
+}
+
+// readScriptSources reads the scripts declared in every subdirectory of the directory, sorted by name
+func readScriptSources(dir string) ([]*scriptSource, error) {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	sources := []*scriptSource{}
+	names := map[string]string{}
+	for _, entry := range entries {
+		if !entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		source, err := readScriptSource(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", entry.Name(), err)
+		}
+		if other, found := names[source.Name]; found {
+			return nil, fmt.Errorf("script %s is declared both in %s and %s", source.Name, other, entry.Name())
+		}
+		names[source.Name] = entry.Name()
+		sources = append(sources, source)
+	}
+	sort.Slice(sources, func(i, j int) bool { return sources[i].Name < sources[j].Name })
+	return sources, nil
+}
+
+// fileSHA256 returns the hex encoded sha256 of the file contents
+func fileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	hash := sha256.New()
+	if _, err = io.Copy(hash, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// scriptSyncAction is a planned step over a script or an attachment
+type scriptSyncAction struct {
+	step   *manifest.Step
+	source *scriptSource
+	// payload are the script attributes to create or update
+	payload map[string]interface{}
+	// script is the current script, if any
+	script *types.Script
+	// attachment is the current attachment, if any, and file the local one
+	attachment *types.Attachment
+	file       string
+}
+
+// scriptSync plans and applies the changes making the scripts match a scripts directory
+type scriptSync struct {
+	engine        *manifestEngine
+	attachmentSvc *blueprint.AttachmentService
+	// scriptIDs are the IDs of the declared scripts by name, once they exist
+	scriptIDs map[string]string
+	actions   []*scriptSyncAction
+}
+
+// steps returns the steps of the planned actions
+func (s *scriptSync) steps() []*manifest.Step {
+	steps := make([]*manifest.Step, len(s.actions))
+	for i, action := range s.actions {
+		steps[i] = action.step
+	}
+	return steps
+}
+
+// changed tells whether there is any planned change
+func (s *scriptSync) changed() bool {
+	for _, action := range s.actions {
+		if action.step.Action != manifest.ActionNoop {
+			return true
+		}
+	}
+	return false
+}
+
+// plan computes the actions required to create or update the declared scripts and their attachments, deleting
+// the undeclared attachments and, when pruning, the undeclared scripts
+func (s *scriptSync) plan(sources []*scriptSource, prune bool) error {
+	if err := s.engine.loadKinds(map[string]bool{}); err != nil {
+		return err
+	}
+	scripts, err := s.engine.scriptSvc.GetScriptList()
+	if err != nil {
+		return fmt.Errorf("cannot receive scripts data: %v", err)
+	}
+	current := map[string]*types.Script{}
+	ambiguous := map[string]bool{}
+	for _, script := range scripts {
+		if _, found := current[script.Name]; found {
+			ambiguous[script.Name] = true
+		}
+		current[script.Name] = script
+	}
+
+	downloadDir, err := ioutil.TempDir("", "cio-sync")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(downloadDir)
+
+	s.scriptIDs = map[string]string{}
+	declared := map[string]bool{}
+	for _, source := range sources {
+		declared[source.Name] = true
+		if ambiguous[source.Name] {
+			return fmt.Errorf("there are several scripts named %s", source.Name)
+		}
+		script := current[source.Name]
+		if script != nil {
+			// the list may not have the code
+			if script, err = s.engine.scriptSvc.GetScript(script.ID); err != nil {
+				return fmt.Errorf("cannot receive script data: %v", err)
+			}
+			s.scriptIDs[source.Name] = script.ID
+		}
+		s.actions = append(s.actions, s.planScript(source, script))
+		if err = s.planAttachments(source, script, downloadDir); err != nil {
+			return err
+		}
+	}
+
+	if prune {
+		undeclared := []*types.Script{}
+		for _, script := range scripts {
+			if !declared[script.Name] {
+				undeclared = append(undeclared, script)
+			}
+		}
+		sort.SliceStable(undeclared, func(i, j int) bool { return undeclared[i].Name < undeclared[j].Name })
+		for _, script := range undeclared {
+			s.actions = append(s.actions, &scriptSyncAction{
+				step:   &manifest.Step{Action: manifest.ActionDelete, Kind: "scripts", Name: script.Name, ID: script.ID, Changes: []string{}},
+				script: script,
+			})
+		}
+	}
+	return nil
+}
+
+// planScript computes the action required by a declared script, given the current one, if any
+func (s *scriptSync) planScript(source *scriptSource, script *types.Script) *scriptSyncAction {
+	action := &scriptSyncAction{
+		step:    &manifest.Step{Kind: "scripts", Name: source.Name, Changes: []string{}},
+		source:  source,
+		payload: map[string]interface{}{},
+		script:  script,
+	}
+	desired := map[string]interface{}{
+		"description": source.Description,
+		"code":        source.code,
+		"parameters":  manifest.JSONValue(source.Parameters),
+	}
+
+	if script == nil {
+		action.step.Action = manifest.ActionCreate
+		action.payload = desired
+		action.payload["name"] = source.Name
+		if len(source.Parameters) == 0 {
+			delete(action.payload, "parameters")
+		}
+		for _, attribute := range manifest.SortedKeys(action.payload) {
+			action.step.Changes = append(action.step.Changes, manifest.Change(attribute, nil, action.payload[attribute]))
+		}
+		if len(source.Labels) > 0 {
+			action.step.Changes = append(action.step.Changes, manifest.Change("labels", nil, source.Labels))
+		}
+		return action
+	}
+
+	action.step.ID = script.ID
+	object := manifest.Object(script)
+	for _, attribute := range manifest.SortedKeys(desired) {
+		if !manifest.ValueEqual(attribute, desired[attribute], object[attribute]) {
+			action.payload[attribute] = desired[attribute]
+			action.step.Changes = append(action.step.Changes, manifest.Change(attribute, object[attribute], desired[attribute]))
+		}
+	}
+	if labels := s.engine.LabelNames(object); strings.Join(labels, ",") != strings.Join(source.Labels, ",") {
+		action.step.Changes = append(action.step.Changes, manifest.Change("labels", labels, source.Labels))
+	}
+	action.step.Action = manifest.ActionNoop
+	if len(action.step.Changes) > 0 {
+		action.step.Action = manifest.ActionUpdate
+	}
+	return action
+}
+
+// planAttachments computes the actions required to make the attachments of the script match the declared
+// ones. The current attachments are downloaded into downloadDir to compare their contents.
+func (s *scriptSync) planAttachments(source *scriptSource, script *types.Script, downloadDir string) error {
+	current := map[string]*types.Attachment{}
+	if script != nil {
+		attachments, err := s.engine.scriptSvc.ListScriptAttachments(script.ID)
+		if err != nil {
+			return fmt.Errorf("cannot receive script attachments data: %v", err)
+		}
+		for _, attachment := range attachments {
+			if _, found := current[attachment.Name]; found || source.attachments[attachment.Name] == "" {
+				// duplicated or undeclared
+				s.actions = append(s.actions, s.attachmentAction(manifest.ActionDelete, source, attachment, ""))
+				continue
+			}
+			current[attachment.Name] = attachment
+		}
+	}
+
+	names := []string{}
+	for name := range source.attachments {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		file := source.attachments[name]
+		desiredSum, err := fileSHA256(file)
+		if err != nil {
+			return err
+		}
+		attachment := current[name]
+		if attachment == nil {
+			action := s.attachmentAction(manifest.ActionCreate, source, nil, file)
+			action.step.Changes = append(action.step.Changes, manifest.Change("sha256", nil, desiredSum))
+			s.actions = append(s.actions, action)
+			continue
+		}
+
+		dir := filepath.Join(downloadDir, attachment.ID)
+		if err = os.Mkdir(dir, 0700); err != nil {
+			return err
+		}
+		downloaded, err := downloadScriptAttachment(s.attachmentSvc, attachment, dir)
+		if err != nil {
+			return err
+		}
+		currentSum, err := fileSHA256(downloaded)
+		if err != nil {
+			return err
+		}
+		if currentSum != desiredSum {
+			action := s.attachmentAction(manifest.ActionUpdate, source, attachment, file)
+			action.step.Changes = append(action.step.Changes, manifest.Change("sha256", currentSum, desiredSum))
+			s.actions = append(s.actions, action)
+		}
+	}
+	return nil
+}
+
+// attachmentAction returns an action over an attachment of the declared script
+func (s *scriptSync) attachmentAction(actionName string, source *scriptSource, attachment *types.Attachment, file string) *scriptSyncAction {
+	step := &manifest.Step{Action: actionName, Kind: "attachments", Changes: []string{}}
+	if attachment != nil {
+		step.Name = fmt.Sprintf("%s/%s", source.Name, attachment.Name)
+		step.ID = attachment.ID
+	} else {
+		step.Name = fmt.Sprintf("%s/%s", source.Name, filepath.Base(file))
+	}
+	return &scriptSyncAction{step: step, source: source, attachment: attachment, file: file}
+}
+
+// apply executes the planned actions in order, stopping at the first failure
+func (s *scriptSync) apply() error {
+	for _, action := range s.actions {
+		if action.step.Action == manifest.ActionNoop {
+			action.step.Status = "unchanged"
+			continue
+		}
+		if err := s.applyAction(action); err != nil {
+			action.step.Status = "failed"
+			return fmt.Errorf("cannot %s %s/%s: %v", action.step.Action, action.step.Kind, action.step.Name, err)
+		}
+	}
+	return nil
+}
+
+// applyAction executes a planned action over a script or an attachment
+func (s *scriptSync) applyAction(action *scriptSyncAction) error {
+	e := s.engine
+	step := action.step
+	if step.Kind == "scripts" {
+		switch step.Action {
+		case manifest.ActionCreate:
+			if len(action.source.Labels) > 0 {
+				action.payload["label_ids"] = LabelResolution(e.c, strings.Join(action.source.Labels, ","), &e.labelNamesByID, &e.labelIDsByName)
+			}
+			script, err := e.scriptSvc.CreateScript(&action.payload)
+			if err != nil {
+				return err
+			}
+			step.ID = script.ID
+			s.scriptIDs[action.source.Name] = script.ID
+			step.Status = "created"
+		case manifest.ActionUpdate:
+			script := action.script
+			if len(action.payload) > 0 {
+				var err error
+				if script, err = e.scriptSvc.UpdateScript(&action.payload, step.ID); err != nil {
+					return err
+				}
+			}
+			if err := e.syncLabels(manifest.Object(script), action.source.Labels); err != nil {
+				return err
+			}
+			step.Status = "updated"
+		case manifest.ActionDelete:
+			if err := e.scriptSvc.DeleteScript(step.ID); err != nil {
+				return err
+			}
+			step.Status = "deleted"
+		}
+		return nil
+	}
+
+	// attachments cannot be updated, they are replaced: the new one is uploaded before deleting the old one, so
+	// that the script is never left without it
+	if step.Action == manifest.ActionCreate || step.Action == manifest.ActionUpdate {
+		scriptID := s.scriptIDs[action.source.Name]
+		if scriptID == "" {
+			return fmt.Errorf("script %s does not exist", action.source.Name)
+		}
+		attachment, err := addScriptAttachment(e.scriptSvc, s.attachmentSvc, scriptID, filepath.Base(action.file), action.file)
+		if err != nil {
+			return err
+		}
+		step.ID = attachment.ID
+		step.Status = "created"
+	}
+	if step.Action == manifest.ActionDelete || step.Action == manifest.ActionUpdate {
+		if err := s.attachmentSvc.DeleteAttachment(action.attachment.ID); err != nil {
+			if step.Action == manifest.ActionUpdate {
+				return fmt.Errorf("new attachment %s uploaded, but cannot delete the previous one %s: %v", step.ID, action.attachment.ID, err)
+			}
+			return err
+		}
+		step.Status = "deleted"
+		if step.Action == manifest.ActionUpdate {
+			step.Status = "updated"
+		}
+	}
+	return nil
+}
+
+// ScriptSync subcommand function creates, updates and deletes scripts and their attachments to match a
+// scripts directory
+func ScriptSync(c *cli.Context) error {
+	debugCmdFuncInfo(c)
+	engine, formatter := wireUpManifest(c)
+	attachmentSvc, _ := WireUpAttachment(c)
+
+	checkRequiredFlags(c, []string{"dir"}, formatter)
+	sources, err := readScriptSources(c.String("dir"))
+	if err != nil {
+		formatter.PrintFatal("Couldn't read scripts directory", err)
+	}
+
+	sync := &scriptSync{engine: engine, attachmentSvc: attachmentSvc}
+	if err = sync.plan(sources, c.Bool("prune")); err != nil {
+		formatter.PrintFatal("Couldn't plan scripts sync", err)
+	}
+	if c.Bool("check") {
+		printManifestSteps(formatter, sync.steps())
+		if sync.changed() {
+			formatter.PrintFatal("Scripts drift detected", fmt.Errorf("scripts don't match directory %s", c.String("dir")))
+		}
+		return nil
+	}
+	if c.Bool("dry-run") || !sync.changed() {
+		printManifestSteps(formatter, sync.steps())
+		return nil
+	}
+	if !c.Bool("yes") {
+		printManifestSteps(formatter, sync.steps())
+		if !confirmBulk(c.Command.Name, "script", manifestStepTargets(sync.steps())) {
+			formatter.PrintFatal("Scripts sync cancelled", fmt.Errorf("changes have not been confirmed"))
+		}
+	}
+
+	if err = sync.apply(); err != nil {
+		printManifestSteps(formatter, sync.steps())
+		formatter.PrintFatal("Couldn't sync scripts", err)
+	}
+	printManifestSteps(formatter, sync.steps())
+	return nil
+}
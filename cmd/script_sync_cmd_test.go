@@ -0,0 +1,162 @@
+package cmd
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ingrammicro/concerto/api/blueprint"
+	"github.com/ingrammicro/concerto/api/types"
+	"github.com/ingrammicro/concerto/utils"
+	"github.com/ingrammicro/concerto/utils/manifest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// writeTestFiles writes the files, by path relative to dir, creating their directories
+func writeTestFiles(t *testing.T, dir string, files map[string]string) {
+	for path, content := range files {
+		path = filepath.Join(dir, path)
+		assert.Nil(t, os.MkdirAll(filepath.Dir(path), 0700), "Couldn't create directory")
+		assert.Nil(t, ioutil.WriteFile(path, []byte(content), 0600), "Couldn't write file")
+	}
+}
+
+func TestReadScriptSource(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "cio-sync-test")
+	assert.Nil(err, "Couldn't create directory")
+	defer os.RemoveAll(dir)
+
+	writeTestFiles(t, dir, map[string]string{
+		"install-nginx/script.yml":             "description: Installs nginx\nparameters: [port]\nlabels: [\" tier=web\", env=prod]\n",
+		"install-nginx/install.sh":             "apt-get install nginx",
+		"install-nginx/.install.sh.swp":        "",
+		"install-nginx/attachments/nginx.conf": "server {}",
+		"install-nginx/attachments/.hidden":    "",
+	})
+	source, err := readScriptSource(filepath.Join(dir, "install-nginx"))
+	assert.Nil(err, "Couldn't read script")
+	assert.Equal("install-nginx", source.Name, "Name should default to the directory one")
+	assert.Equal("Installs nginx", source.Description, "Unexpected description")
+	assert.Equal([]string{"port"}, source.Parameters, "Unexpected parameters")
+	assert.Equal([]string{"env=prod", "tier=web"}, source.Labels, "Labels should be trimmed and sorted")
+	assert.Equal("apt-get install nginx", source.code, "Unexpected code")
+	assert.Equal(map[string]string{"nginx.conf": filepath.Join(dir, "install-nginx", "attachments", "nginx.conf")}, source.attachments, "Unexpected attachments")
+
+	for name, files := range map[string]map[string]string{
+		"no metadata":      {"run.sh": ""},
+		"unknown metadata": {"script.yml": "title: a", "run.sh": ""},
+		"no code":          {"script.yml": "name: a"},
+		"several codes":    {"script.yml": "name: a", "run.sh": "", "run.ps1": ""},
+		"attachment dir":   {"script.yml": "name: a", "run.sh": "", "attachments/conf/a": ""},
+	} {
+		writeTestFiles(t, dir, map[string]string{filepath.Join(name, ".keep"): ""})
+		writeTestFiles(t, filepath.Join(dir, name), files)
+		_, err = readScriptSource(filepath.Join(dir, name))
+		assert.NotNil(err, "Script with %s should be invalid", name)
+	}
+}
+
+// onJSON mocks a GET of path answering with the value
+func onJSON(t *testing.T, cs *utils.MockConcertoService, path string, value interface{}) {
+	data, err := json.Marshal(value)
+	assert.Nil(t, err, "Test data corrupted")
+	cs.On("Get", path).Return(data, 200, nil)
+}
+
+// testScriptSync returns a scripts sync over a mocked API
+func testScriptSync(t *testing.T) (*scriptSync, *utils.MockConcertoService) {
+	cs := &utils.MockConcertoService{}
+	scriptSvc, err := blueprint.NewScriptService(cs)
+	assert.Nil(t, err, "Couldn't load script service")
+	attachmentSvc, err := blueprint.NewAttachmentService(cs)
+	assert.Nil(t, err, "Couldn't load attachment service")
+	engine := &manifestEngine{
+		scriptSvc:      scriptSvc,
+		state:          manifest.NewState(),
+		labelIDsByName: map[string]string{"env=prod": "label-1"},
+		labelNamesByID: map[string]string{"label-1": "env=prod"},
+	}
+	return &scriptSync{engine: engine, attachmentSvc: attachmentSvc}, cs
+}
+
+func TestScriptSyncPlan(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "cio-sync-test")
+	assert.Nil(err, "Couldn't create directory")
+	defer os.RemoveAll(dir)
+	writeTestFiles(t, dir, map[string]string{
+		"install/script.yml":             "labels: [env=prod]",
+		"install/run.sh":                 "echo installed",
+		"install/attachments/nginx.conf": "server { listen 80; }",
+		"install/attachments/site.conf":  "site",
+		"deploy/script.yml":              "description: Deploys",
+		"deploy/run.sh":                  "echo deployed",
+	})
+	sources, err := readScriptSources(dir)
+	assert.Nil(err, "Couldn't read scripts")
+
+	sync, cs := testScriptSync(t)
+	labelled := types.LabelableFields{LabelIDs: []string{"label-1"}}
+	onJSON(t, cs, "/blueprint/scripts", []*types.Script{
+		{ID: "script-1", Name: "install"},
+		{ID: "script-2", Name: "obsolete"},
+	})
+	onJSON(t, cs, "/blueprint/scripts/script-1", &types.Script{ID: "script-1", Name: "install", Code: "echo installed", LabelableFields: labelled})
+	onJSON(t, cs, "/blueprint/scripts/script-1/attachments", []*types.Attachment{
+		{ID: "attachment-1", Name: "nginx.conf", DownloadURL: "http://download/1"},
+		{ID: "attachment-2", Name: "stale.conf", DownloadURL: "http://download/2"},
+	})
+	download := cs.On("GetFile", "http://download/1", mock.Anything)
+	download.Run(func(args mock.Arguments) {
+		ioutil.WriteFile(args.String(1), []byte("server { listen 8080; }"), 0600)
+		download.Return(args.String(1), 200, nil)
+	})
+
+	assert.Nil(sync.plan(sources, true), "Couldn't plan scripts sync")
+	actions := []string{}
+	for _, step := range sync.steps() {
+		actions = append(actions, step.Action+" "+step.Kind+"/"+step.Name)
+	}
+	assert.Equal([]string{
+		"create scripts/deploy",
+		"no-op scripts/install",
+		"delete attachments/install/stale.conf",
+		"update attachments/install/nginx.conf",
+		"create attachments/install/site.conf",
+		"delete scripts/obsolete",
+	}, actions, "Unexpected plan")
+	assert.True(sync.changed(), "Plan should have changes")
+	assert.Equal(map[string]string{"install": "script-1"}, sync.scriptIDs, "Only existing scripts should have an ID")
+}
+
+func TestScriptSyncReplacesAttachments(t *testing.T) {
+	assert := assert.New(t)
+
+	sync, cs := testScriptSync(t)
+	sync.scriptIDs = map[string]string{"install": "script-1"}
+	action := &scriptSyncAction{
+		step:       &manifest.Step{Action: manifest.ActionUpdate, Kind: "attachments", Name: "install/nginx.conf"},
+		source:     &scriptSource{Name: "install"},
+		attachment: &types.Attachment{ID: "attachment-1", Name: "nginx.conf"},
+		file:       "/tmp/nginx.conf",
+	}
+	cs.On("Post", "/blueprint/scripts/script-1/attachments", mock.Anything).Return([]byte(`{"id":"attachment-3","upload_url":"http://upload/3"}`), 201, nil)
+	cs.On("PutFile", "/tmp/nginx.conf", "http://upload/3").Return([]byte(""), 200, nil)
+	cs.On("Put", "/blueprint/attachments/attachment-3/uploaded", mock.Anything).Return([]byte(`{"id":"attachment-3","uploaded":true}`), 200, nil)
+	cs.On("Delete", "/blueprint/attachments/attachment-1").Return([]byte(""), 204, nil)
+
+	assert.Nil(sync.applyAction(action), "Couldn't replace attachment")
+	methods := []string{}
+	for _, call := range cs.Calls {
+		methods = append(methods, call.Method)
+	}
+	assert.Equal([]string{"Post", "PutFile", "Put", "Delete"}, methods, "The new attachment should be uploaded before deleting the old one")
+	assert.Equal("attachment-3", action.step.ID, "Step should have the new attachment ID")
+	assert.Equal("updated", action.step.Status, "Unexpected status")
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strings"
 
+	log "github.com/Sirupsen/logrus"
 	"github.com/codegangsta/cli"
 	"github.com/ingrammicro/concerto/api/blueprint"
 	"github.com/ingrammicro/concerto/api/types"
@@ -177,42 +178,50 @@ func ScriptAttachmentAdd(c *cli.Context) error {
 		formatter.PrintFatal("Invalid file path", fmt.Errorf("no such file or directory: %s", sourceFilePath))
 	}
 
+	attachmentSvc, _ := WireUpAttachment(c)
+	attachment, err := addScriptAttachment(scriptSvc, attachmentSvc, c.String("id"), c.String("name"), sourceFilePath)
+	if err != nil {
+		formatter.PrintFatal("Couldn't add attachment to script", err)
+	}
+
+	if err = formatter.PrintItem(*attachment); err != nil {
+		formatter.PrintFatal("Couldn't print/format result", err)
+	}
+	return nil
+}
+
+// addScriptAttachment adds the file as an attachment of the script: the attachment is created, the file
+// uploaded and the attachment marked as uploaded. The attachment is deleted when the upload fails.
+func addScriptAttachment(scriptSvc *blueprint.ScriptService, attachmentSvc *blueprint.AttachmentService, scriptID string, name string, sourceFilePath string) (*types.Attachment, error) {
 	attachmentIn := map[string]interface{}{
-		"name": c.String("name"),
+		"name": name,
 	}
 
 	// adds new attachment
-	attachment, err := scriptSvc.AddScriptAttachment(&attachmentIn, c.String("id"))
+	attachment, err := scriptSvc.AddScriptAttachment(&attachmentIn, scriptID)
 	if err != nil {
-		formatter.PrintFatal("Couldn't add attachment to script", err)
+		return nil, err
 	}
 
 	// uploads new attachment file
-	err = scriptSvc.UploadScriptAttachment(sourceFilePath, attachment.UploadURL)
-	if err != nil {
-		cleanAttachment(c, attachment.ID)
-		formatter.PrintFatal("Couldn't upload attachment data", err)
+	if err = scriptSvc.UploadScriptAttachment(sourceFilePath, attachment.UploadURL); err != nil {
+		cleanScriptAttachment(attachmentSvc, attachment.ID)
+		return nil, fmt.Errorf("cannot upload attachment data: %v", err)
 	}
 
 	// marks the attachment as "uploaded"
 	attachmentID := attachment.ID
-	attachment, err = scriptSvc.UploadedScriptAttachment(&attachmentIn, attachment.ID)
-	if err != nil {
-		cleanAttachment(c, attachmentID)
-		formatter.PrintFatal("Couldn't set attachment as uploaded", err)
+	if attachment, err = scriptSvc.UploadedScriptAttachment(&attachmentIn, attachment.ID); err != nil {
+		cleanScriptAttachment(attachmentSvc, attachmentID)
+		return nil, fmt.Errorf("cannot set attachment as uploaded: %v", err)
 	}
-
-	if err = formatter.PrintItem(*attachment); err != nil {
-		formatter.PrintFatal("Couldn't print/format result", err)
-	}
-	return nil
+	return attachment, nil
 }
 
-// cleanAttachment deletes Attachment. Ideally for cleaning at uploading error cases
-func cleanAttachment(c *cli.Context, attachmentID string) {
-	attachmentSvc, formatter := WireUpAttachment(c)
+// cleanScriptAttachment deletes an attachment which couldn't be uploaded
+func cleanScriptAttachment(attachmentSvc *blueprint.AttachmentService, attachmentID string) {
 	if err := attachmentSvc.DeleteAttachment(attachmentID); err != nil {
-		formatter.PrintError("Couldn't clean failed attachment", err)
+		log.Errorf("Couldn't clean failed attachment: %v", err)
 	}
 }
 
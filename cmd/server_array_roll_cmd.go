@@ -0,0 +1,312 @@
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/codegangsta/cli"
+	"github.com/ingrammicro/concerto/api/cloud"
+	"github.com/ingrammicro/concerto/api/types"
+	"github.com/ingrammicro/concerto/utils"
+)
+
+const (
+	// DefaultRollBatchSize is the number of servers replaced at once
+	DefaultRollBatchSize = 1
+	// DefaultRollHealthTimeout is the time given to new servers to become operational, in seconds
+	DefaultRollHealthTimeout = 300
+
+	// maxEnlargeSize is the maximum number of servers a server array can be enlarged with at once
+	maxEnlargeSize = 5
+
+	rollRoleOld = "old"
+	rollRoleNew = "new"
+
+	rollStatusPending    = "pending"
+	rollStatusHealthy    = "healthy"
+	rollStatusRemoved    = "removed"
+	rollStatusRolledBack = "rolled-back"
+	rollStatusFailed     = "failed"
+)
+
+// RollResult is the outcome of a server array roll for one of its servers
+type RollResult struct {
+	Batch      int    `json:"batch" header:"BATCH"`
+	ServerID   string `json:"server_id" header:"SERVER_ID"`
+	ServerName string `json:"server_name" header:"SERVER_NAME"`
+	Role       string `json:"role" header:"ROLE"`
+	Status     string `json:"status" header:"STATUS"`
+	Error      string `json:"error,omitempty" header:"ERROR"`
+}
+
+// serverRoller replaces the servers of a server array, batch by batch
+type serverRoller struct {
+	serverSvc      *cloud.ServerService
+	serverArraySvc *cloud.ServerArrayService
+	serverArrayID  string
+	waitTimeout    time.Duration
+	healthTimeout  time.Duration
+	healthScript   *scriptExecutor
+	healthURL      string
+}
+
+// waitServer waits for the server to reach the state, failing if it stalls
+func (r *serverRoller) waitServer(ID string, state string) error {
+	_, err := utils.WaitForState(func() (string, error) {
+		server, err := r.serverSvc.GetServer(ID)
+		if err != nil {
+			return "", err
+		}
+		return server.State, nil
+	}, []string{state}, []string{"stalled", "commission_stalled"}, waitInterval, r.waitTimeout)
+	return err
+}
+
+// enlarge adds size servers to the array, returning them once they are operational
+func (r *serverRoller) enlarge(size int) ([]*types.Server, error) {
+	before, err := r.serverArraySvc.GetServerArrayServerList(r.serverArrayID)
+	if err != nil {
+		return nil, err
+	}
+	known := map[string]bool{}
+	for _, server := range before {
+		known[server.ID] = true
+	}
+
+	for added := 0; added < size; added += maxEnlargeSize {
+		count := size - added
+		if count > maxEnlargeSize {
+			count = maxEnlargeSize
+		}
+		if _, err = r.serverArraySvc.EnlargeServerArray(&map[string]interface{}{"size": count}, r.serverArrayID); err != nil {
+			return nil, err
+		}
+	}
+
+	after, err := r.serverArraySvc.GetServerArrayServerList(r.serverArrayID)
+	if err != nil {
+		return nil, err
+	}
+	servers := []*types.Server{}
+	for _, server := range after {
+		if !known[server.ID] {
+			servers = append(servers, server)
+		}
+	}
+	if len(servers) != size {
+		return servers, fmt.Errorf("server array has %d new servers, %d expected", len(servers), size)
+	}
+	return servers, nil
+}
+
+// probe returns the health URL for the server, replacing its {public_ip}, {private_ip} and {fqdn} placeholders
+func (r *serverRoller) probe(server *types.Server) string {
+	return strings.NewReplacer("{public_ip}", server.PublicIP, "{private_ip}", server.PrivateIP, "{fqdn}", server.Fqdn).Replace(r.healthURL)
+}
+
+// checkHealth gates a new server, running the health script on it or probing the health URL until it
+// answers with a 2xx status
+func (r *serverRoller) checkHealth(server *types.Server) error {
+	if r.healthScript != nil {
+		result := &ScriptExecutionResult{ServerID: server.ID, ServerName: server.Name}
+		r.healthScript.execute(result)
+		if result.Status != fleetStatusSucceeded {
+			return fmt.Errorf("health script %s: %s", result.Status, result.Output)
+		}
+	}
+	if r.healthURL == "" {
+		return nil
+	}
+
+	// the server might have been assigned its addresses once operational
+	if current, err := r.serverSvc.GetServer(server.ID); err == nil {
+		server = current
+	}
+	url := r.probe(server)
+	client := &http.Client{Timeout: waitInterval}
+	deadline := time.Now().Add(r.healthTimeout)
+	var err error
+	for {
+		var response *http.Response
+		if response, err = client.Get(url); err == nil {
+			response.Body.Close()
+			if response.StatusCode >= 200 && response.StatusCode < 300 {
+				return nil
+			}
+			err = fmt.Errorf("answered %s", response.Status)
+		}
+		if !time.Now().Add(waitInterval).Before(deadline) {
+			return fmt.Errorf("health probe %s failed: %v", url, err)
+		}
+		time.Sleep(waitInterval)
+	}
+}
+
+// decommission shuts the server down and deletes it
+func (r *serverRoller) decommission(server *types.Server) error {
+	current, err := r.serverSvc.GetServer(server.ID)
+	if err != nil {
+		return err
+	}
+	if current.State != "inactive" && current.State != "stalled" && current.State != "commission_stalled" {
+		if _, err = r.serverSvc.ShutdownServer(&map[string]interface{}{}, server.ID); err != nil {
+			return err
+		}
+		if err = r.waitServer(server.ID, "inactive"); err != nil {
+			return err
+		}
+	}
+	return r.serverSvc.DeleteServer(server.ID)
+}
+
+// roll replaces the old servers in batches: each batch enlarges the array, waits for the new servers to be
+// operational and healthy, and then decommissions as many old ones, so capacity is kept. When a batch fails the roll
+// stops and, unless told otherwise, the new servers of that batch are decommissioned while its old ones are kept.
+// Rollback is partial: batches already rolled stay replaced, as the array would create new servers from its current
+// template anyway, so the array keeps its capacity but may be left with servers of both templates.
+func (r *serverRoller) roll(old []*types.Server, batchSize int, rollback bool) ([]*RollResult, error) {
+	results := []*RollResult{}
+	var rollErr error
+	for i, batch := range fleetBatches(old, batchSize) {
+		batchResults := []*RollResult{}
+		for _, server := range batch {
+			batchResults = append(batchResults, &RollResult{Batch: i + 1, ServerID: server.ID, ServerName: server.Name, Role: rollRoleOld, Status: rollStatusPending})
+		}
+		results = append(results, batchResults...)
+		log.Infof("Rolling batch %d of server array %s: replacing %d servers", i+1, r.serverArrayID, len(batch))
+
+		added, err := r.enlarge(len(batch))
+		newResults := []*RollResult{}
+		for _, server := range added {
+			newResults = append(newResults, &RollResult{Batch: i + 1, ServerID: server.ID, ServerName: server.Name, Role: rollRoleNew, Status: rollStatusPending})
+		}
+		results = append(results, newResults...)
+		if err != nil {
+			rollErr = fmt.Errorf("couldn't enlarge server array: %v", err)
+		}
+
+		for j := 0; rollErr == nil && j < len(added); j++ {
+			if err = r.waitServer(added[j].ID, "operational"); err == nil {
+				err = r.checkHealth(added[j])
+			}
+			if err != nil {
+				newResults[j].Status, newResults[j].Error = rollStatusFailed, err.Error()
+				rollErr = fmt.Errorf("new server %s is not healthy: %v", added[j].ID, err)
+				break
+			}
+			newResults[j].Status = rollStatusHealthy
+		}
+
+		if rollErr != nil {
+			if !rollback {
+				break
+			}
+			log.Warnf("Rolling back batch %d of server array %s, previous batches stay replaced: %v", i+1, r.serverArrayID, rollErr)
+			for j, server := range added {
+				if err = r.decommission(server); err != nil {
+					newResults[j].Error = strings.TrimSpace(fmt.Sprintf("%s; rollback: %v", newResults[j].Error, err))
+					continue
+				}
+				newResults[j].Status = rollStatusRolledBack
+			}
+			break
+		}
+
+		for j, server := range batch {
+			if err = r.decommission(server); err != nil {
+				batchResults[j].Status, batchResults[j].Error = rollStatusFailed, err.Error()
+				rollErr = fmt.Errorf("couldn't decommission old server %s: %v", server.ID, err)
+				continue
+			}
+			batchResults[j].Status = rollStatusRemoved
+		}
+		if rollErr != nil {
+			break
+		}
+	}
+	return results, rollErr
+}
+
+// ServerArrayRoll subcommand function replaces the servers of a server array in batches, once confirmed. See roll
+// for how capacity is kept and how a failed batch is rolled back.
+func ServerArrayRoll(c *cli.Context) error {
+	debugCmdFuncInfo(c)
+	serverArraySvc, formatter := WireUpServerArray(c)
+	serverSvc, _ := WireUpServer(c)
+
+	checkRequiredFlags(c, []string{"id"}, formatter)
+	serverArray, err := serverArraySvc.GetServerArray(c.String("id"))
+	if err != nil {
+		formatter.PrintFatal("Couldn't receive server array data", err)
+	}
+	servers, err := serverArraySvc.GetServerArrayServerList(serverArray.ID)
+	if err != nil {
+		formatter.PrintFatal("Couldn't receive server data", err)
+	}
+	old := []*types.Server{}
+	for _, server := range servers {
+		if c.Bool("all") || server.TemplateID != serverArray.TemplateID {
+			old = append(old, server)
+		}
+	}
+
+	batchSize := c.Int("batch-size")
+	if batchSize <= 0 {
+		batchSize = DefaultRollBatchSize
+	}
+	if c.Bool("dry-run") || len(old) == 0 {
+		results := []*RollResult{}
+		for i, batch := range fleetBatches(old, batchSize) {
+			for _, server := range batch {
+				results = append(results, &RollResult{Batch: i + 1, ServerID: server.ID, ServerName: server.Name, Role: rollRoleOld, Status: rollStatusPending})
+			}
+		}
+		if err = formatter.PrintList(results); err != nil {
+			formatter.PrintFatal("Couldn't print/format result", err)
+		}
+		return nil
+	}
+	targets := []*bulkTarget{}
+	for _, server := range old {
+		targets = append(targets, &bulkTarget{ID: server.ID, Name: server.Name})
+	}
+	if !c.Bool("yes") && !confirmBulk("replace", "server", targets) {
+		formatter.PrintFatal("Roll cancelled", fmt.Errorf("server array %s roll has not been confirmed", serverArray.ID))
+	}
+
+	waitTimeout := c.Int("wait-timeout")
+	if waitTimeout <= 0 {
+		waitTimeout = DefaultWaitTimeout
+	}
+	healthTimeout := c.Int("health-timeout")
+	if healthTimeout <= 0 {
+		healthTimeout = DefaultRollHealthTimeout
+	}
+	roller := &serverRoller{
+		serverSvc:      serverSvc,
+		serverArraySvc: serverArraySvc,
+		serverArrayID:  serverArray.ID,
+		waitTimeout:    time.Duration(waitTimeout) * time.Second,
+		healthTimeout:  time.Duration(healthTimeout) * time.Second,
+		healthURL:      c.String("health-url"),
+	}
+	if c.String("health-script-id") != "" {
+		roller.healthScript = &scriptExecutor{
+			serverSvc: serverSvc,
+			scriptID:  c.String("health-script-id"),
+			timeout:   roller.healthTimeout,
+		}
+	}
+
+	results, rollErr := roller.roll(old, batchSize, !c.Bool("no-rollback"))
+	if err = formatter.PrintList(results); err != nil {
+		formatter.PrintFatal("Couldn't print/format result", err)
+	}
+	if rollErr != nil {
+		formatter.PrintFatal("Couldn't roll server array", rollErr)
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+package cmd
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/ingrammicro/concerto/api/cloud"
+	"github.com/ingrammicro/concerto/api/types"
+	"github.com/ingrammicro/concerto/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// testRoller returns a roller of server array array-1 over a mocked API
+func testRoller(t *testing.T) (*serverRoller, *utils.MockConcertoService) {
+	cs := &utils.MockConcertoService{}
+	serverSvc, err := cloud.NewServerService(cs)
+	assert.Nil(t, err, "Couldn't load server service")
+	serverArraySvc, err := cloud.NewServerArrayService(cs)
+	assert.Nil(t, err, "Couldn't load server array service")
+	return &serverRoller{
+		serverSvc:      serverSvc,
+		serverArraySvc: serverArraySvc,
+		serverArrayID:  "array-1",
+		waitTimeout:    time.Second,
+		healthTimeout:  time.Second,
+	}, cs
+}
+
+// onServers mocks the servers of the array, as listed by the next call
+func onServers(t *testing.T, cs *utils.MockConcertoService, IDs ...string) {
+	servers := []*types.Server{}
+	for _, ID := range IDs {
+		servers = append(servers, &types.Server{ID: ID, Name: ID, State: "operational"})
+	}
+	data, err := json.Marshal(servers)
+	assert.Nil(t, err, "Server test data corrupted")
+	cs.On("Get", "/cloud/server_arrays/array-1/servers").Return(data, 200, nil).Once()
+}
+
+// onServerStates mocks the states of the server, as retrieved by successive calls
+func onServerStates(t *testing.T, cs *utils.MockConcertoService, ID string, states ...string) {
+	for _, state := range states {
+		data, err := json.Marshal(&types.Server{ID: ID, Name: ID, State: state})
+		assert.Nil(t, err, "Server test data corrupted")
+		cs.On("Get", "/cloud/servers/"+ID).Return(data, 200, nil).Once()
+	}
+}
+
+// onDecommission mocks the shutdown, if the server is operational, and deletion of the server
+func onDecommission(t *testing.T, cs *utils.MockConcertoService, ID string, state string) {
+	if state == "operational" {
+		onServerStates(t, cs, ID, "operational", "inactive")
+		cs.On("Put", "/cloud/servers/"+ID+"/shutdown", mock.Anything).Return([]byte("{}"), 200, nil).Once()
+	} else {
+		onServerStates(t, cs, ID, state)
+	}
+	cs.On("Delete", "/cloud/servers/"+ID).Return([]byte(""), 204, nil).Once()
+}
+
+func TestRollEnlarge(t *testing.T) {
+	assert := assert.New(t)
+
+	roller, cs := testRoller(t)
+	onServers(t, cs, "old-1", "old-2")
+	onServers(t, cs, "old-1", "old-2", "new-1", "new-2")
+	cs.On("Post", "/cloud/server_arrays/array-1/servers", &map[string]interface{}{"size": 2}).Return([]byte("{}"), 200, nil).Once()
+
+	added, err := roller.enlarge(2)
+	assert.Nil(err, "Couldn't enlarge server array")
+	if assert.Len(added, 2, "Unexpected new servers") {
+		assert.Equal("new-1", added[0].ID, "Unexpected new server")
+		assert.Equal("new-2", added[1].ID, "Unexpected new server")
+	}
+
+	onServers(t, cs, "old-1")
+	onServers(t, cs, "old-1")
+	cs.On("Post", "/cloud/server_arrays/array-1/servers", &map[string]interface{}{"size": 1}).Return([]byte("{}"), 200, nil).Once()
+	_, err = roller.enlarge(1)
+	assert.NotNil(err, "Enlarging without new servers should fail")
+	cs.AssertExpectations(t)
+}
+
+func TestRoll(t *testing.T) {
+	assert := assert.New(t)
+
+	roller, cs := testRoller(t)
+	// first batch replaces old-1 with new-1
+	onServers(t, cs, "old-1", "old-2")
+	cs.On("Post", "/cloud/server_arrays/array-1/servers", mock.Anything).Return([]byte("{}"), 200, nil).Once()
+	onServers(t, cs, "old-1", "old-2", "new-1")
+	onServerStates(t, cs, "new-1", "operational")
+	onDecommission(t, cs, "old-1", "operational")
+	// second batch fails, as new-2 stalls, so it is rolled back
+	onServers(t, cs, "old-2", "new-1")
+	cs.On("Post", "/cloud/server_arrays/array-1/servers", mock.Anything).Return([]byte("{}"), 200, nil).Once()
+	onServers(t, cs, "old-2", "new-1", "new-2")
+	onServerStates(t, cs, "new-2", "stalled")
+	onDecommission(t, cs, "new-2", "stalled")
+
+	old := []*types.Server{{ID: "old-1", Name: "old-1"}, {ID: "old-2", Name: "old-2"}}
+	results, err := roller.roll(old, 1, true)
+	assert.NotNil(err, "Roll should fail")
+	statuses := map[string]string{}
+	for _, result := range results {
+		statuses[result.ServerID] = result.Status
+	}
+	assert.Equal(map[string]string{
+		"old-1": rollStatusRemoved,
+		"new-1": rollStatusHealthy,
+		"old-2": rollStatusPending,
+		"new-2": rollStatusRolledBack,
+	}, statuses, "Only the failed batch should be rolled back")
+	cs.AssertExpectations(t)
+}
+
+func TestRollWithoutRollback(t *testing.T) {
+	assert := assert.New(t)
+
+	roller, cs := testRoller(t)
+	onServers(t, cs, "old-1")
+	cs.On("Post", "/cloud/server_arrays/array-1/servers", mock.Anything).Return([]byte("{}"), 200, nil).Once()
+	onServers(t, cs, "old-1", "new-1")
+	onServerStates(t, cs, "new-1", "stalled")
+
+	results, err := roller.roll([]*types.Server{{ID: "old-1", Name: "old-1"}}, 1, false)
+	assert.NotNil(err, "Roll should fail")
+	if assert.Len(results, 2, "Unexpected results") {
+		assert.Equal(rollStatusPending, results[0].Status, "Old server should be kept")
+		assert.Equal(rollStatusFailed, results[1].Status, "New server should be kept as failed")
+	}
+	cs.AssertExpectations(t)
+}
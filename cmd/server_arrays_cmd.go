@@ -158,6 +158,9 @@ func ServerArrayBoot(c *cli.Context) error {
 	if err != nil {
 		formatter.PrintFatal("Couldn't boot server array", err)
 	}
+	if c.Bool("wait") {
+		serverArray = waitForFlaggedState(c, "server_array", serverArray.ID, formatter).(*types.ServerArray)
+	}
 
 	_, labelNamesByID := LabelLoadsMapping(c)
 	serverArray.FillInLabelNames(labelNamesByID)
@@ -16,7 +16,7 @@ func WireUpServerPlan(c *cli.Context) (ds *cloud.ServerPlanService, f format.For
 	if err != nil {
 		f.PrintFatal("Couldn't wire up config", err)
 	}
-	hcs, err := utils.NewHTTPConcertoService(config)
+	hcs, err := utils.NewHTTPConcertoServiceWithCache(config)
 	if err != nil {
 		f.PrintFatal("Couldn't wire up concerto service", err)
 	}
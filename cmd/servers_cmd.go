@@ -144,6 +144,9 @@ func ServerBoot(c *cli.Context) error {
 	if err != nil {
 		formatter.PrintFatal("Couldn't boot server", err)
 	}
+	if c.Bool("wait") {
+		server = waitForFlaggedState(c, "server", server.ID, formatter).(*types.Server)
+	}
 
 	_, labelNamesByID := LabelLoadsMapping(c)
 	server.FillInLabelNames(labelNamesByID)
@@ -182,6 +185,9 @@ func ServerShutdown(c *cli.Context) error {
 	if err != nil {
 		formatter.PrintFatal("Couldn't shutdown server", err)
 	}
+	if c.Bool("wait") {
+		server = waitForFlaggedState(c, "server", server.ID, formatter).(*types.Server)
+	}
 
 	_, labelNamesByID := LabelLoadsMapping(c)
 	server.FillInLabelNames(labelNamesByID)
@@ -299,13 +305,9 @@ func EventsList(c *cli.Context) error {
 	svc, formatter := WireUpServer(c)
 
 	checkRequiredFlags(c, []string{"id"}, formatter)
-	events, err := svc.GetEventsList(c.String("id"))
-	if err != nil {
-		formatter.PrintFatal("Couldn't receive event data", err)
-	}
-	if err = formatter.PrintList(events); err != nil {
-		formatter.PrintFatal("Couldn't print/format result", err)
-	}
+	listEvents(c, func(filter *types.EventFilter) ([]*types.Event, error) {
+		return svc.GetEventsListWithFilter(c.String("id"), filter)
+	}, formatter)
 	return nil
 }
 
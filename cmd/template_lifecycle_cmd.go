@@ -0,0 +1,340 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+
+	"github.com/codegangsta/cli"
+	"github.com/ingrammicro/concerto/utils"
+	"github.com/ingrammicro/concerto/utils/manifest"
+	"gopkg.in/yaml.v2"
+)
+
+// templateDocumentVersion is the version of the documents written by template export. Import rejects other ones.
+const templateDocumentVersion = 1
+
+// TemplateDifference is an attribute whose value differs between two templates
+type TemplateDifference struct {
+	Attribute string `json:"attribute" header:"ATTRIBUTE"`
+	First     string `json:"first" header:"FIRST"`
+	Second    string `json:"second" header:"SECOND"`
+}
+
+// exportTemplate returns the manifest entry declaring the template with the given ID, along with its scripts,
+// cookbook versions and labels
+func (e *manifestEngine) exportTemplate(ID string) (map[string]interface{}, error) {
+	if err := e.loadKinds(map[string]bool{"templates": true}); err != nil {
+		return nil, err
+	}
+	object := e.state.ObjectByID("templates", ID)
+	if object == nil {
+		return nil, fmt.Errorf("unknown template %s", ID)
+	}
+	exporter := &manifest.Exporter{State: e.state, Backend: e}
+	entry, err := exporter.ExportObject(manifest.KindByName("templates"), object)
+	if err != nil {
+		return nil, err
+	}
+	// as if it was read from a document
+	attributes, _ := manifest.JSONValue(entry).(map[string]interface{})
+	return attributes, nil
+}
+
+// planTemplate plans the creation or update of the template declared by the attributes, by name
+func (e *manifestEngine) planTemplate(attributes map[string]interface{}) (*manifest.Resource, *manifest.Step, error) {
+	resource, err := manifest.NewResource(manifest.KindByName("templates"), attributes)
+	if err != nil {
+		return nil, nil, err
+	}
+	steps, err := e.plan([]*manifest.Resource{resource})
+	if err != nil {
+		return nil, nil, err
+	}
+	return resource, steps[0], nil
+}
+
+// readTemplateDocument reads the template declared in a document written by template export, from a file or
+// stdin when fileName is "-". Both YAML and JSON are accepted.
+func readTemplateDocument(fileName string) (map[string]interface{}, error) {
+	var data []byte
+	var err error
+	if fileName == "-" {
+		data, err = ioutil.ReadAll(os.Stdin)
+	} else {
+		data, err = ioutil.ReadFile(fileName)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot read template document: %v", err)
+	}
+
+	var raw map[string]interface{}
+	if err = yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("invalid template document: %v", err)
+	}
+	document, _ := manifest.NormalizeValue(raw).(map[string]interface{})
+	for key := range document {
+		if key != "version" && key != "template" {
+			return nil, fmt.Errorf("invalid template document: unknown key %s", key)
+		}
+	}
+	if version, _ := document["version"].(int); version != templateDocumentVersion {
+		return nil, fmt.Errorf("unsupported template document version %v, %d expected", document["version"], templateDocumentVersion)
+	}
+	template, ok := document["template"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("invalid template document: template must be a map")
+	}
+	return template, nil
+}
+
+// templateDiffValue returns the printable representation of an attribute value, empty when it is missing
+func templateDiffValue(value interface{}) string {
+	if value == nil {
+		return ""
+	}
+	if s, ok := value.(string); ok {
+		return s
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	return string(data)
+}
+
+// flattenTemplateAttributes adds the leaves of a nested map to the attributes, with dotted names
+func flattenTemplateAttributes(attributes map[string]interface{}, prefix string, value interface{}) {
+	m, ok := value.(map[string]interface{})
+	if !ok || len(m) == 0 {
+		attributes[prefix] = value
+		return
+	}
+	for key, item := range m {
+		flattenTemplateAttributes(attributes, prefix+"."+key, item)
+	}
+}
+
+// comparableTemplate returns the attributes of an exported template, detailed so that differences point at the
+// changed item: configuration attributes by path, cookbook versions by cookbook and scripts by type and order
+func comparableTemplate(entry map[string]interface{}) map[string]interface{} {
+	attributes := map[string]interface{}{}
+	for key, value := range entry {
+		switch key {
+		case "configuration_attributes":
+			flattenTemplateAttributes(attributes, key, value)
+		case "cookbook_versions":
+			items, _ := value.([]interface{})
+			for _, item := range items {
+				cookbookVersion := fmt.Sprintf("%v", item)
+				values := templateCookbookVersionValueRegexp.FindStringSubmatch(cookbookVersion)
+				if len(values) == 0 {
+					attributes[key+"."+cookbookVersion] = cookbookVersion
+					continue
+				}
+				attributes[key+"."+values[1]] = values[2] + values[3]
+			}
+		case "scripts":
+			items, _ := value.([]interface{})
+			order := map[string]int{}
+			for _, item := range items {
+				script, _ := item.(map[string]interface{})
+				scriptType, _ := script["type"].(string)
+				details := map[string]interface{}{}
+				for key, detail := range script {
+					if key != "type" {
+						details[key] = detail
+					}
+				}
+				attributes[fmt.Sprintf("%s_scripts[%d]", scriptType, order[scriptType])] = details
+				order[scriptType]++
+			}
+		default:
+			attributes[key] = value
+		}
+	}
+	return attributes
+}
+
+// diffTemplates returns the attributes which differ between two exported templates, sorted by name
+func diffTemplates(first map[string]interface{}, second map[string]interface{}) []*TemplateDifference {
+	a, b := comparableTemplate(first), comparableTemplate(second)
+	names := map[string]bool{}
+	for name := range a {
+		names[name] = true
+	}
+	for name := range b {
+		names[name] = true
+	}
+	sorted := []string{}
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+
+	differences := []*TemplateDifference{}
+	for _, name := range sorted {
+		// unlike desired and current values, both templates must hold the same nested values to be equal
+		if (manifest.IsEmptyValue(a[name]) && manifest.IsEmptyValue(b[name])) || templateDiffValue(a[name]) == templateDiffValue(b[name]) {
+			continue
+		}
+		differences = append(differences, &TemplateDifference{Attribute: name, First: templateDiffValue(a[name]), Second: templateDiffValue(b[name])})
+	}
+	return differences
+}
+
+// cloneTemplateAttributes returns the attributes of an exported template for its clone, named as the name flag
+// and, when the labels flag is given, labelled as it says instead of as the original
+func cloneTemplateAttributes(c *cli.Context, attributes map[string]interface{}) map[string]interface{} {
+	attributes["name"] = c.String("name")
+	if c.IsSet("labels") {
+		attributes["labels"] = manifest.JSONValue(utils.SplitList(c.String("labels")))
+	}
+	return attributes
+}
+
+// TemplateClone subcommand function creates a copy of a template, with its scripts, cookbook versions and labels
+func TemplateClone(c *cli.Context) error {
+	debugCmdFuncInfo(c)
+	engine, formatter := wireUpManifest(c)
+
+	checkRequiredFlags(c, []string{"id", "name"}, formatter)
+	attributes, err := engine.exportTemplate(c.String("id"))
+	if err != nil {
+		formatter.PrintFatal("Couldn't export template", err)
+	}
+	resource, step, err := engine.planTemplate(cloneTemplateAttributes(c, attributes))
+	if err != nil {
+		formatter.PrintFatal("Couldn't plan template clone", err)
+	}
+	if step.Action != manifest.ActionCreate {
+		formatter.PrintFatal("Couldn't clone template", fmt.Errorf("there is already a template named %s", c.String("name")))
+	}
+	if err = engine.apply([]*manifest.Resource{resource}, []*manifest.Step{step}); err != nil {
+		formatter.PrintFatal("Couldn't clone template", err)
+	}
+
+	template, err := engine.templateSvc.GetTemplate(step.ID)
+	if err != nil {
+		formatter.PrintFatal("Couldn't receive template data", err)
+	}
+	if err = resolveCookbookVersions(c, template); err != nil {
+		formatter.PrintFatal("cannot resolve cookbook versions data", err)
+	}
+	template.FillInLabelNames(engine.labelNamesByID)
+	if err = formatter.PrintItem(*template); err != nil {
+		formatter.PrintFatal("Couldn't print/format result", err)
+	}
+	return nil
+}
+
+// TemplateDiff subcommand function compares two templates
+func TemplateDiff(c *cli.Context) error {
+	debugCmdFuncInfo(c)
+	engine, formatter := wireUpManifest(c)
+
+	IDs := c.StringSlice("id")
+	if len(IDs) != 2 {
+		formatter.PrintFatal("Incorrect usage.", fmt.Errorf("please use parameter --id twice, once per template"))
+	}
+	entries := []map[string]interface{}{}
+	for _, ID := range IDs {
+		if !utils.IsFullID(ID) {
+			candidates, err := resourceListers["template"](c)
+			if err != nil {
+				formatter.PrintFatal("Couldn't receive template data", err)
+			}
+			if ID, err = utils.ResolveID("template", ID, candidates); err != nil {
+				formatter.PrintFatal("Couldn't resolve --id", err)
+			}
+		}
+		entry, err := engine.exportTemplate(ID)
+		if err != nil {
+			formatter.PrintFatal("Couldn't export template", err)
+		}
+		entries = append(entries, entry)
+	}
+
+	if err := formatter.PrintList(diffTemplates(entries[0], entries[1])); err != nil {
+		formatter.PrintFatal("Couldn't print/format result", err)
+	}
+	return nil
+}
+
+// TemplateExport subcommand function writes a template, with its scripts, cookbook versions and labels, to a
+// versioned document
+func TemplateExport(c *cli.Context) error {
+	debugCmdFuncInfo(c)
+	engine, formatter := wireUpManifest(c)
+
+	checkRequiredFlags(c, []string{"id"}, formatter)
+	outputFormat := c.String("format")
+	if outputFormat != "yaml" && outputFormat != "json" {
+		formatter.PrintFatal("Invalid format", fmt.Errorf("format must be yaml or json"))
+	}
+	template, err := engine.exportTemplate(c.String("id"))
+	if err != nil {
+		formatter.PrintFatal("Couldn't export template", err)
+	}
+
+	document := map[string]interface{}{"version": templateDocumentVersion, "template": template}
+	var data []byte
+	if outputFormat == "json" {
+		data, err = json.MarshalIndent(document, "", "  ")
+		data = append(data, '\n')
+	} else {
+		data, err = yaml.Marshal(document)
+	}
+	if err != nil {
+		formatter.PrintFatal("Couldn't format template document", err)
+	}
+
+	if c.String("file") == "" || c.String("file") == "-" {
+		_, err = os.Stdout.Write(data)
+	} else {
+		err = ioutil.WriteFile(c.String("file"), data, 0600)
+	}
+	if err != nil {
+		formatter.PrintFatal("Couldn't write template document", err)
+	}
+	return nil
+}
+
+// TemplateImport subcommand function creates or updates, by name, the template of a document written by
+// template export
+func TemplateImport(c *cli.Context) error {
+	debugCmdFuncInfo(c)
+	engine, formatter := wireUpManifest(c)
+
+	checkRequiredFlags(c, []string{"file"}, formatter)
+	attributes, err := readTemplateDocument(c.String("file"))
+	if err != nil {
+		formatter.PrintFatal("Couldn't read template document", err)
+	}
+	if c.IsSet("name") {
+		attributes["name"] = c.String("name")
+	}
+
+	resource, step, err := engine.planTemplate(attributes)
+	if err != nil {
+		formatter.PrintFatal("Couldn't plan template import", err)
+	}
+	if c.Bool("dry-run") || step.Action == manifest.ActionNoop {
+		printManifestSteps(formatter, []*manifest.Step{step})
+		return nil
+	}
+	if c.String("file") == "-" && !c.Bool("yes") {
+		// STDIN has been taken by the document, so there is no way to confirm the changes
+		formatter.PrintFatal("Incorrect usage.", fmt.Errorf("please use parameter --yes when reading the document from STDIN"))
+	}
+	confirmManifestSteps(c, []*manifest.Step{step}, formatter)
+
+	if err = engine.apply([]*manifest.Resource{resource}, []*manifest.Step{step}); err != nil {
+		printManifestSteps(formatter, []*manifest.Step{step})
+		formatter.PrintFatal("Couldn't import template", err)
+	}
+	printManifestSteps(formatter, []*manifest.Step{step})
+	return nil
+}
@@ -0,0 +1,188 @@
+package cmd
+
+import (
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/codegangsta/cli"
+	"github.com/ingrammicro/concerto/api/types"
+	"github.com/ingrammicro/concerto/utils/manifest"
+	"github.com/stretchr/testify/assert"
+)
+
+// testExportedTemplate returns a template as exported, with scripts, cookbook versions and configuration
+func testExportedTemplate() map[string]interface{} {
+	return map[string]interface{}{
+		"name":              "web",
+		"generic_image_id":  "image",
+		"run_list":          []interface{}{"recipe[nginx]"},
+		"cookbook_versions": []interface{}{"apt~>2.0", "nginx:1.0"},
+		"configuration_attributes": map[string]interface{}{
+			"nginx": map[string]interface{}{"port": 80.0, "sites": map[string]interface{}{}},
+			"debug": true,
+		},
+		"scripts": []interface{}{
+			map[string]interface{}{"type": "boot", "script": "install"},
+			map[string]interface{}{"type": "boot", "script": "configure", "parameter_values": map[string]interface{}{"port": "80"}},
+			map[string]interface{}{"type": "shutdown", "script": "drain"},
+		},
+		"labels": []interface{}{"env=prod"},
+	}
+}
+
+func TestComparableTemplate(t *testing.T) {
+	assert := assert.New(t)
+
+	template := testExportedTemplate()
+	template["cookbook_versions"] = []interface{}{"apt~>2.0", "nginx:1.0", "unversioned"}
+	assert.Equal(map[string]interface{}{
+		"name":                                 "web",
+		"generic_image_id":                     "image",
+		"run_list":                             []interface{}{"recipe[nginx]"},
+		"cookbook_versions.apt":                "~>2.0",
+		"cookbook_versions.nginx":              ":1.0",
+		"cookbook_versions.unversioned":        "unversioned",
+		"configuration_attributes.nginx.port":  80.0,
+		"configuration_attributes.nginx.sites": map[string]interface{}{},
+		"configuration_attributes.debug":       true,
+		"boot_scripts[0]":                      map[string]interface{}{"script": "install"},
+		"boot_scripts[1]":                      map[string]interface{}{"script": "configure", "parameter_values": map[string]interface{}{"port": "80"}},
+		"shutdown_scripts[0]":                  map[string]interface{}{"script": "drain"},
+		"labels":                               []interface{}{"env=prod"},
+	}, comparableTemplate(template), "Unexpected comparable attributes")
+}
+
+func TestDiffTemplates(t *testing.T) {
+	assert := assert.New(t)
+
+	tests := []struct {
+		name        string
+		change      func(template map[string]interface{})
+		differences []*TemplateDifference
+	}{
+		{"identical", func(template map[string]interface{}) {}, []*TemplateDifference{}},
+		{"run list", func(template map[string]interface{}) {
+			template["run_list"] = []interface{}{"recipe[nginx]", "recipe[php]"}
+		}, []*TemplateDifference{
+			{Attribute: "run_list", First: `["recipe[nginx]"]`, Second: `["recipe[nginx]","recipe[php]"]`},
+		}},
+		{"cookbook versions are compared by name", func(template map[string]interface{}) {
+			template["cookbook_versions"] = []interface{}{"nginx:1.1", "apt~>2.0", "php:7.0"}
+		}, []*TemplateDifference{
+			{Attribute: "cookbook_versions.nginx", First: ":1.0", Second: ":1.1"},
+			{Attribute: "cookbook_versions.php", First: "", Second: ":7.0"},
+		}},
+		{"scripts are compared by type and order", func(template map[string]interface{}) {
+			template["scripts"] = []interface{}{
+				map[string]interface{}{"type": "boot", "script": "install"},
+				map[string]interface{}{"type": "shutdown", "script": "drain"},
+				map[string]interface{}{"type": "shutdown", "script": "deregister"},
+				map[string]interface{}{"type": "boot", "script": "configure", "parameter_values": map[string]interface{}{"port": "80", "user": "www"}},
+			}
+		}, []*TemplateDifference{
+			{Attribute: "boot_scripts[1]", First: `{"parameter_values":{"port":"80"},"script":"configure"}`, Second: `{"parameter_values":{"port":"80","user":"www"},"script":"configure"}`},
+			{Attribute: "shutdown_scripts[1]", First: "", Second: `{"script":"deregister"}`},
+		}},
+		{"nested configuration attributes", func(template map[string]interface{}) {
+			template["configuration_attributes"] = map[string]interface{}{
+				"nginx": map[string]interface{}{"port": 8080.0, "sites": map[string]interface{}{"default": "off"}},
+				"debug": true,
+			}
+		}, []*TemplateDifference{
+			{Attribute: "configuration_attributes.nginx.port", First: "80", Second: "8080"},
+			{Attribute: "configuration_attributes.nginx.sites.default", First: "", Second: "off"},
+		}},
+		{"empty values are not differences", func(template map[string]interface{}) {
+			template["labels"] = []interface{}{}
+			template["description"] = ""
+		}, []*TemplateDifference{
+			{Attribute: "labels", First: `["env=prod"]`, Second: "[]"},
+		}},
+	}
+	for _, test := range tests {
+		second := testExportedTemplate()
+		test.change(second)
+		assert.Equal(test.differences, diffTemplates(testExportedTemplate(), second), "Unexpected differences: %s", test.name)
+	}
+}
+
+func TestReadTemplateDocument(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "cio-template-test")
+	assert.Nil(err, "Couldn't create directory")
+	defer os.RemoveAll(dir)
+	writeTestFiles(t, dir, map[string]string{
+		"web.yml":         "version: 1\ntemplate:\n  name: web\n  run_list:\n  - recipe[nginx]\n  configuration_attributes:\n    nginx:\n      port: 80\n",
+		"web.json":        `{"version": 1, "template": {"name": "web", "run_list": ["recipe[nginx]"], "configuration_attributes": {"nginx": {"port": 80}}}}`,
+		"version.yml":     "version: 2\ntemplate:\n  name: web\n",
+		"unversioned.yml": "template:\n  name: web\n",
+		"unknown.yml":     "version: 1\ntemplates:\n  name: web\n",
+		"list.yml":        "version: 1\ntemplate:\n- name: web\n",
+		"invalid.yml":     "version: [",
+	})
+
+	expected := map[string]interface{}{
+		"name":                     "web",
+		"run_list":                 []interface{}{"recipe[nginx]"},
+		"configuration_attributes": map[string]interface{}{"nginx": map[string]interface{}{"port": 80}},
+	}
+	for _, name := range []string{"web.yml", "web.json"} {
+		template, err := readTemplateDocument(filepath.Join(dir, name))
+		if assert.Nil(err, "Couldn't read template document %s", name) {
+			assert.Equal(expected, template, "Unexpected template read from %s", name)
+		}
+	}
+	for _, name := range []string{"version.yml", "unversioned.yml", "unknown.yml", "list.yml", "invalid.yml", "missing.yml"} {
+		_, err := readTemplateDocument(filepath.Join(dir, name))
+		assert.NotNil(err, "Template document %s should be rejected", name)
+	}
+}
+
+// testCloneContext returns the context of template clone with the given flags set
+func testCloneContext(t *testing.T, flags map[string]string) *cli.Context {
+	set := flag.NewFlagSet("clone", flag.ContinueOnError)
+	for _, f := range []cli.Flag{cli.StringFlag{Name: "id"}, cli.StringFlag{Name: "name"}, cli.StringFlag{Name: "labels"}} {
+		f.Apply(set)
+	}
+	for name, value := range flags {
+		assert.Nil(t, set.Set(name, value), "Couldn't set flag %s", name)
+	}
+	return cli.NewContext(nil, set, nil)
+}
+
+func TestCloneTemplateAttributes(t *testing.T) {
+	assert := assert.New(t)
+
+	// scripts are exported as the API returns them
+	templateScript := &types.TemplateScript{ParameterValues: map[string]interface{}{"port": "80", "workers": 4}}
+	exported := testExportedTemplate()
+	exported["scripts"] = []interface{}{map[string]interface{}{
+		"type": "boot", "script": "configure", "parameter_values": manifest.JSONValue(templateScript.ParameterValues),
+	}}
+
+	tests := []struct {
+		flags  map[string]string
+		labels interface{}
+	}{
+		{map[string]string{"name": "web-copy"}, []interface{}{"env=prod"}},
+		{map[string]string{"name": "web-copy", "labels": "env=staging, tier=web"}, []interface{}{"env=staging", "tier=web"}},
+		{map[string]string{"name": "web-copy", "labels": ""}, []interface{}{}},
+	}
+	for _, test := range tests {
+		attributes := cloneTemplateAttributes(testCloneContext(t, test.flags), testExportedTemplate())
+		attributes["scripts"] = exported["scripts"]
+		assert.Equal("web-copy", attributes["name"], "Clone should be named as given")
+		assert.Equal(test.labels, attributes["labels"], "Unexpected labels for %v", test.flags)
+
+		resource, err := manifest.NewResource(manifest.KindByName("templates"), attributes)
+		if assert.Nil(err, "Clone should be a valid template") {
+			assert.Equal("web-copy", resource.Name, "Unexpected name")
+			script := resource.Attributes["scripts"].([]interface{})[0].(map[string]interface{})
+			assert.Equal(map[string]interface{}{"port": "80", "workers": 4.0}, script["parameter_values"], "Parameter values should be cloned")
+		}
+	}
+}
@@ -152,6 +152,10 @@ func VolumeAttach(c *cli.Context) error {
 	if err != nil {
 		formatter.PrintFatal("Couldn't attach volume", err)
 	}
+	if c.Bool("wait") {
+		// the attached server is shown once the attachment completes
+		waitForFlaggedState(c, "volume", c.String("id"), formatter)
+	}
 
 	_, labelNamesByID := LabelLoadsMapping(c)
 	server.FillInLabelNames(labelNamesByID)
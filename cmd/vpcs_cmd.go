@@ -105,6 +105,9 @@ func VPCCreate(c *cli.Context) error {
 	if err != nil {
 		formatter.PrintFatal("Couldn't create VPC", err)
 	}
+	if c.Bool("wait") {
+		vpc = waitForFlaggedState(c, "vpc", vpc.ID, formatter).(*types.Vpc)
+	}
 
 	vpc.FillInLabelNames(labelNamesByID)
 	if err = formatter.PrintItem(*vpc); err != nil {
@@ -0,0 +1,188 @@
+package cmd
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/codegangsta/cli"
+	"github.com/ingrammicro/concerto/utils"
+	"github.com/ingrammicro/concerto/utils/format"
+)
+
+const (
+	waitInterval       = 5 * time.Second
+	DefaultWaitTimeout = 600
+)
+
+// resourceWaiter retrieves the state of a kind of resource
+type resourceWaiter struct {
+	get         func(c *cli.Context, ID string) (item interface{}, state string, err error)
+	targetState string
+	errorStates []string
+}
+
+// resourceWaiters are the resources which can be waited for, by name
+var resourceWaiters = map[string]*resourceWaiter{
+	"server": {
+		get: func(c *cli.Context, ID string) (interface{}, string, error) {
+			svc, _ := WireUpServer(c)
+			server, err := svc.GetServer(ID)
+			if err != nil {
+				return nil, "", err
+			}
+			return server, server.State, nil
+		},
+		targetState: "operational",
+		errorStates: []string{"stalled", "commission_stalled"},
+	},
+	"server_array": {
+		get: func(c *cli.Context, ID string) (interface{}, string, error) {
+			svc, _ := WireUpServerArray(c)
+			serverArray, err := svc.GetServerArray(ID)
+			if err != nil {
+				return nil, "", err
+			}
+			return serverArray, serverArray.State, nil
+		},
+		targetState: "operational",
+		errorStates: []string{"stalled"},
+	},
+	"volume": {
+		get: func(c *cli.Context, ID string) (interface{}, string, error) {
+			svc, _ := WireUpVolume(c)
+			volume, err := svc.GetVolume(ID)
+			if err != nil {
+				return nil, "", err
+			}
+			return volume, volume.State, nil
+		},
+		targetState: "attached",
+		errorStates: []string{"error"},
+	},
+	"floating_ip": {
+		get: func(c *cli.Context, ID string) (interface{}, string, error) {
+			svc, _ := WireUpFloatingIP(c)
+			floatingIP, err := svc.GetFloatingIP(ID)
+			if err != nil {
+				return nil, "", err
+			}
+			return floatingIP, floatingIP.State, nil
+		},
+		targetState: "attached",
+		errorStates: []string{"error"},
+	},
+	"vpc": {
+		get: func(c *cli.Context, ID string) (interface{}, string, error) {
+			svc, _ := WireUpVPC(c)
+			vpc, err := svc.GetVPC(ID)
+			if err != nil {
+				return nil, "", err
+			}
+			return vpc, vpc.State, nil
+		},
+		targetState: "available",
+		errorStates: []string{"error"},
+	},
+	"cookbook_version": {
+		get: func(c *cli.Context, ID string) (interface{}, string, error) {
+			svc, _ := WireUpCookbookVersion(c)
+			cookbookVersion, err := svc.GetCookbookVersion(ID)
+			if err != nil {
+				return nil, "", err
+			}
+			return cookbookVersion, cookbookVersion.State, nil
+		},
+		targetState: "ready",
+		errorStates: []string{"error"},
+	},
+}
+
+// waitForFlaggedState waits for the resource as requested through the flags returned by WaitFlags
+func waitForFlaggedState(c *cli.Context, resource string, ID string, formatter format.Formatter) interface{} {
+	return waitForState(c, resource, ID, c.String("wait-state"), c.Int("wait-timeout"), formatter)
+}
+
+// WaitFlags returns the flags of a command able to wait for the resource to reach a state
+func WaitFlags(targetState string) []cli.Flag {
+	return []cli.Flag{
+		cli.BoolFlag{
+			Name:  "wait",
+			Usage: "Waits until the resource reaches the target state",
+		},
+		cli.IntFlag{
+			Name:  "wait-timeout",
+			Usage: "Maximum time to wait, in seconds",
+			Value: DefaultWaitTimeout,
+		},
+		cli.StringFlag{
+			Name:  "wait-state",
+			Usage: "A list of comma separated target states to wait for",
+			Value: targetState,
+		},
+	}
+}
+
+// waitForState polls the resource until it reaches one of the comma separated states, or the usual target
+// state of the resource when none is given, returning its last retrieved value. It is fatal to reach an
+// error state or the timeout, in seconds.
+func waitForState(c *cli.Context, resource string, ID string, states string, timeout int, formatter format.Formatter) interface{} {
+	waiter := resourceWaiters[resource]
+
+	targets := []string{}
+	for _, state := range strings.Split(states, ",") {
+		if state = strings.TrimSpace(state); state != "" {
+			targets = append(targets, state)
+		}
+	}
+	if len(targets) == 0 {
+		targets = []string{waiter.targetState}
+	}
+	if timeout <= 0 {
+		timeout = DefaultWaitTimeout
+	}
+
+	var item interface{}
+	_, err := utils.WaitForState(func() (string, error) {
+		var state string
+		var err error
+		item, state, err = waiter.get(c, ID)
+		return state, err
+	}, targets, waiter.errorStates, waitInterval, time.Duration(timeout)*time.Second)
+	if err != nil {
+		formatter.PrintFatal(fmt.Sprintf("Couldn't wait for %s %s", resource, ID), err)
+	}
+	return item
+}
+
+// Wait subcommand function
+func Wait(c *cli.Context) error {
+	debugCmdFuncInfo(c)
+	formatter := format.GetFormatter()
+
+	resources := []string{}
+	for resource := range resourceWaiters {
+		resources = append(resources, resource)
+	}
+	sort.Strings(resources)
+
+	resource := c.Args().First()
+	if _, found := resourceWaiters[resource]; !found {
+		formatter.PrintFatal("Invalid resource", fmt.Errorf("resource must be one of %s", strings.Join(resources, ", ")))
+	}
+	checkRequiredFlags(c, []string{"id"}, formatter)
+
+	item := waitForState(c, resource, c.String("id"), c.String("state"), c.Int("timeout"), formatter)
+	if labelable, ok := item.(interface {
+		FillInLabelNames(map[string]string)
+	}); ok {
+		_, labelNamesByID := LabelLoadsMapping(c)
+		labelable.FillInLabelNames(labelNamesByID)
+	}
+	if err := formatter.PrintItem(reflect.Indirect(reflect.ValueOf(item)).Interface()); err != nil {
+		formatter.PrintFatal("Couldn't print/format result", err)
+	}
+	return nil
+}
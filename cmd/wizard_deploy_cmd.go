@@ -0,0 +1,296 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/codegangsta/cli"
+	"github.com/ingrammicro/concerto/api/types"
+	"github.com/ingrammicro/concerto/utils"
+	"gopkg.in/yaml.v2"
+)
+
+// wizardAnswers are the choices of a deployment, given by ID, name or ID prefix
+type wizardAnswers struct {
+	App           string `yaml:"app"`
+	Location      string `yaml:"location"`
+	CloudProvider string `yaml:"cloud_provider"`
+	CloudAccount  string `yaml:"cloud_account"`
+	ServerPlan    string `yaml:"server_plan"`
+	Hostname      string `yaml:"hostname"`
+}
+
+// wizardOption is a choice of a deployment step
+type wizardOption struct {
+	utils.NamedResource
+	Description string
+}
+
+// wizardPrompter takes the choices of a deployment, either from the answers file or asking the user
+type wizardPrompter struct {
+	answers *wizardAnswers
+	// reader is nil when the deployment is not interactive
+	reader *bufio.Reader
+}
+
+// readWizardAnswers reads the answers file (YAML or JSON), or STDIN when fileName is "-"
+func readWizardAnswers(fileName string) (*wizardAnswers, error) {
+	var reader io.Reader = os.Stdin
+	if fileName != "-" {
+		f, err := os.Open(fileName)
+		if err != nil {
+			return nil, fmt.Errorf("cannot open answers file %s: %v", fileName, err)
+		}
+		defer f.Close()
+		reader = f
+	}
+	data, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read answers file %s: %v", fileName, err)
+	}
+
+	answers := &wizardAnswers{}
+	if err = yaml.UnmarshalStrict(data, answers); err != nil {
+		return nil, fmt.Errorf("invalid answers file %s: %v", fileName, err)
+	}
+	return answers, nil
+}
+
+// choose returns the option of the step given in the answer. When there is no answer, the only option is taken,
+// or the user is asked to choose one.
+func (p *wizardPrompter) choose(step string, answer string, options []*wizardOption) (*wizardOption, error) {
+	if len(options) == 0 {
+		return nil, fmt.Errorf("there is no %s available for the previous choices", step)
+	}
+	candidates := []*utils.NamedResource{}
+	for _, option := range options {
+		candidates = append(candidates, &option.NamedResource)
+	}
+	byID := func(ID string) *wizardOption {
+		for _, option := range options {
+			if option.ID == ID {
+				return option
+			}
+		}
+		return nil
+	}
+
+	if answer != "" {
+		ID, err := utils.ResolveID(step, answer, candidates)
+		if err != nil {
+			return nil, err
+		}
+		return byID(ID), nil
+	}
+	if len(options) == 1 {
+		fmt.Fprintf(os.Stderr, "Using %s %s (%s)\n", step, options[0].Name, options[0].ID)
+		return options[0], nil
+	}
+	if p.reader == nil {
+		return nil, fmt.Errorf("the answers file must choose the %s among %d options", step, len(options))
+	}
+
+	for {
+		fmt.Fprintf(os.Stderr, "Available %ss:\n", step)
+		for i, option := range options {
+			fmt.Fprintf(os.Stderr, "  %2d) %s (%s) %s\n", i+1, option.Name, option.ID, option.Description)
+		}
+		fmt.Fprintf(os.Stderr, "Choose a %s [1-%d, name or ID]: ", step, len(options))
+
+		line, err := p.reader.ReadString('\n')
+		line = strings.TrimSpace(line)
+		if err != nil && line == "" {
+			return nil, fmt.Errorf("no %s has been chosen", step)
+		}
+		if i, convErr := strconv.Atoi(line); convErr == nil && i >= 1 && i <= len(options) {
+			return options[i-1], nil
+		}
+		ID, resolveErr := utils.ResolveID(step, line, candidates)
+		if resolveErr == nil {
+			return byID(ID), nil
+		}
+		fmt.Fprintln(os.Stderr, resolveErr)
+	}
+}
+
+// ask returns the answer, or asks the user for a value
+func (p *wizardPrompter) ask(question string, answer string) (string, error) {
+	if answer != "" {
+		return answer, nil
+	}
+	if p.reader == nil {
+		return "", fmt.Errorf("the answers file must give the %s", question)
+	}
+	for {
+		fmt.Fprintf(os.Stderr, "Enter the %s: ", question)
+		line, err := p.reader.ReadString('\n')
+		if line = strings.TrimSpace(line); line != "" {
+			return line, nil
+		}
+		if err != nil {
+			return "", fmt.Errorf("no %s has been given", question)
+		}
+	}
+}
+
+// wizardNumber converts a numeric flavour requirement
+func wizardNumber(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case int:
+		return float64(v), true
+	case float64:
+		return v, true
+	case string:
+		f, err := strconv.ParseFloat(v, 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// meetsFlavourRequirements tells whether the server plan provides the memory the app requires. The API only
+// requires memory, in MB as the server plans give it, e.g. {"memory":1024}; other requirements are not enforced.
+func meetsFlavourRequirements(serverPlan *types.ServerPlan, requirements map[string]interface{}) bool {
+	for key, value := range requirements {
+		if key != "memory" {
+			log.Debugf("Flavour requirement %s is not enforced", key)
+			continue
+		}
+		if required, ok := wizardNumber(value); ok && float64(serverPlan.Memory) < required {
+			return false
+		}
+	}
+	return true
+}
+
+// WizardDeploy subcommand function walks through the choice of app, location, cloud provider, cloud account and
+// server plan, each of them restricted by the previous ones, and deploys the app
+func WizardDeploy(c *cli.Context) error {
+	debugCmdFuncInfo(c)
+	appSvc, formatter := WireUpApp(c)
+
+	prompter := &wizardPrompter{answers: &wizardAnswers{}}
+	if c.IsSet("answers-file") {
+		answers, err := readWizardAnswers(c.String("answers-file"))
+		if err != nil {
+			formatter.PrintFatal("Couldn't read answers", err)
+		}
+		prompter.answers = answers
+	} else {
+		prompter.reader = bufio.NewReader(os.Stdin)
+	}
+	if c.IsSet("hostname") {
+		prompter.answers.Hostname = c.String("hostname")
+	}
+
+	apps, err := appSvc.GetAppList()
+	if err != nil {
+		formatter.PrintFatal("Couldn't receive app data", err)
+	}
+	options := []*wizardOption{}
+	for _, app := range apps {
+		options = append(options, &wizardOption{utils.NamedResource{ID: app.ID, Name: app.Name}, ""})
+	}
+	choice, err := prompter.choose("app", prompter.answers.App, options)
+	if err != nil {
+		formatter.PrintFatal("Couldn't choose app", err)
+	}
+	var app *types.WizardApp
+	for _, candidate := range apps {
+		if candidate.ID == choice.ID {
+			app = candidate
+		}
+	}
+
+	locationSvc, _ := WireUpLocation(c)
+	locations, err := locationSvc.GetLocationList()
+	if err != nil {
+		formatter.PrintFatal("Couldn't receive location data", err)
+	}
+	options = []*wizardOption{}
+	for _, location := range locations {
+		options = append(options, &wizardOption{utils.NamedResource{ID: location.ID, Name: location.Name}, ""})
+	}
+	location, err := prompter.choose("location", prompter.answers.Location, options)
+	if err != nil {
+		formatter.PrintFatal("Couldn't choose location", err)
+	}
+
+	wizCloudProviderSvc, _ := WireUpWizCloudProvider(c)
+	cloudProviders, err := wizCloudProviderSvc.GetWizCloudProviderList(app.ID, location.ID)
+	if err != nil {
+		formatter.PrintFatal("Couldn't receive cloud provider data", err)
+	}
+	options = []*wizardOption{}
+	for _, cloudProvider := range cloudProviders {
+		options = append(options, &wizardOption{utils.NamedResource{ID: cloudProvider.ID, Name: cloudProvider.Name}, ""})
+	}
+	cloudProvider, err := prompter.choose("cloud provider", prompter.answers.CloudProvider, options)
+	if err != nil {
+		formatter.PrintFatal("Couldn't choose cloud provider", err)
+	}
+
+	cloudAccountSvc, _ := WireUpCloudAccount(c)
+	cloudAccounts, err := cloudAccountSvc.GetCloudAccountList()
+	if err != nil {
+		formatter.PrintFatal("Couldn't receive cloud account data", err)
+	}
+	options = []*wizardOption{}
+	for _, cloudAccount := range cloudAccounts {
+		if cloudAccount.CloudProviderID == cloudProvider.ID {
+			options = append(options, &wizardOption{utils.NamedResource{ID: cloudAccount.ID, Name: cloudAccount.Name}, ""})
+		}
+	}
+	cloudAccount, err := prompter.choose("cloud account", prompter.answers.CloudAccount, options)
+	if err != nil {
+		formatter.PrintFatal("Couldn't choose cloud account", err)
+	}
+
+	wizServerPlanSvc, _ := WireUpWizServerPlan(c)
+	serverPlans, err := wizServerPlanSvc.GetWizServerPlanList(app.ID, location.ID, cloudProvider.ID)
+	if err != nil {
+		formatter.PrintFatal("Couldn't receive server plan data", err)
+	}
+	options = []*wizardOption{}
+	for _, serverPlan := range serverPlans {
+		if meetsFlavourRequirements(serverPlan, app.FlavourRequirements) {
+			description := fmt.Sprintf("memory: %d, cpus: %v, storage: %d", serverPlan.Memory, serverPlan.CPUs, serverPlan.Storage)
+			options = append(options, &wizardOption{utils.NamedResource{ID: serverPlan.ID, Name: serverPlan.Name}, description})
+		}
+	}
+	serverPlan, err := prompter.choose("server plan", prompter.answers.ServerPlan, options)
+	if err != nil {
+		formatter.PrintFatal("Couldn't choose server plan", err)
+	}
+
+	hostname, err := prompter.ask("hostname", prompter.answers.Hostname)
+	if err != nil {
+		formatter.PrintFatal("Couldn't choose hostname", err)
+	}
+
+	appIn := map[string]interface{}{
+		"location_id":      location.ID,
+		"cloud_account_id": cloudAccount.ID,
+		"server_plan_id":   serverPlan.ID,
+		"hostname":         hostname,
+	}
+	server, err := appSvc.DeployApp(&appIn, app.ID)
+	if err != nil {
+		formatter.PrintFatal("Couldn't deploy app", err)
+	}
+	if c.Bool("wait") {
+		server = waitForFlaggedState(c, "server", server.ID, formatter).(*types.Server)
+	}
+
+	_, labelNamesByID := LabelLoadsMapping(c)
+	server.FillInLabelNames(labelNamesByID)
+	if err = formatter.PrintItem(*server); err != nil {
+		formatter.PrintFatal("Couldn't print/format result", err)
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+package cmd
+
+import (
+	"bufio"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ingrammicro/concerto/api/types"
+	"github.com/ingrammicro/concerto/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMeetsFlavourRequirements(t *testing.T) {
+	assert := assert.New(t)
+
+	serverPlan := &types.ServerPlan{ID: "plan-1", Memory: 2048, CPUs: 2, Storage: 40}
+	tests := []struct {
+		requirements map[string]interface{}
+		meets        bool
+	}{
+		{nil, true},
+		{map[string]interface{}{}, true},
+		{map[string]interface{}{"memory": 1024.0}, true},
+		{map[string]interface{}{"memory": 2048.0}, true},
+		{map[string]interface{}{"memory": 4096.0}, false},
+		{map[string]interface{}{"memory": 4096}, false},
+		{map[string]interface{}{"memory": "4096"}, false},
+		// requirements which cannot be understood are not enforced
+		{map[string]interface{}{"memory": "lots"}, true},
+		{map[string]interface{}{"gpus": 4.0}, true},
+	}
+	for _, test := range tests {
+		assert.Equal(test.meets, meetsFlavourRequirements(serverPlan, test.requirements), "Unexpected result for %v", test.requirements)
+	}
+}
+
+func testWizardOptions() []*wizardOption {
+	return []*wizardOption{
+		{utils.NamedResource{ID: "5aabb7551de0240abb000060", Name: "North America"}, ""},
+		{utils.NamedResource{ID: "5aabb7551de0240abb000061", Name: "Europe"}, ""},
+		{utils.NamedResource{ID: "5aabb7551de0240abb000062", Name: "Asia Pacific"}, ""},
+	}
+}
+
+func TestWizardChooseAnswered(t *testing.T) {
+	assert := assert.New(t)
+
+	prompter := &wizardPrompter{answers: &wizardAnswers{}}
+	for answer, ID := range map[string]string{
+		"5aabb7551de0240abb000061": "5aabb7551de0240abb000061",
+		"Europe":                   "5aabb7551de0240abb000061",
+		"5aabb7551de0240abb000062": "5aabb7551de0240abb000062",
+	} {
+		option, err := prompter.choose("location", answer, testWizardOptions())
+		if assert.Nil(err, "Answer %s should be resolved", answer) {
+			assert.Equal(ID, option.ID, "Unexpected option for %s", answer)
+		}
+	}
+
+	_, err := prompter.choose("location", "Antarctica", testWizardOptions())
+	assert.NotNil(err, "Unknown answers should fail")
+	_, err = prompter.choose("location", "5aabb", testWizardOptions())
+	assert.NotNil(err, "Ambiguous answers should fail")
+	_, err = prompter.choose("location", "", []*wizardOption{})
+	assert.NotNil(err, "Choosing without options should fail")
+}
+
+func TestWizardChooseUnanswered(t *testing.T) {
+	assert := assert.New(t)
+
+	prompter := &wizardPrompter{answers: &wizardAnswers{}}
+	option, err := prompter.choose("location", "", testWizardOptions()[:1])
+	if assert.Nil(err, "The only option should be taken") {
+		assert.Equal("North America", option.Name, "Unexpected option")
+	}
+	_, err = prompter.choose("location", "", testWizardOptions())
+	assert.NotNil(err, "Choosing among several options should fail when not interactive")
+
+	tests := map[string]string{
+		"2\n":                        "Europe",
+		"Asia Pacific\n":             "Asia Pacific",
+		"7\nAntarctica\n3\n":         "Asia Pacific",
+		"\n5aabb7551de0240abb000060": "North America",
+	}
+	for input, name := range tests {
+		prompter.reader = bufio.NewReader(strings.NewReader(input))
+		option, err := prompter.choose("location", "", testWizardOptions())
+		if assert.Nil(err, "Input %q should choose an option", input) {
+			assert.Equal(name, option.Name, "Unexpected option for input %q", input)
+		}
+	}
+
+	prompter.reader = bufio.NewReader(strings.NewReader("Antarctica\n"))
+	_, err = prompter.choose("location", "", testWizardOptions())
+	assert.NotNil(err, "Running out of input should fail")
+}
+
+func TestReadWizardAnswers(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "cio-wizard-test")
+	assert.Nil(err, "Couldn't create directory")
+	defer os.RemoveAll(dir)
+	writeTestFiles(t, dir, map[string]string{
+		"answers.yml":  "app: Wordpress\nlocation: Europe\ncloud_provider: AWS\ncloud_account: aws-prod\nserver_plan: m5.large\nhostname: wpnode1\n",
+		"answers.json": `{"app": "Wordpress", "location": "Europe"}`,
+		"unknown.yml":  "app: Wordpress\nregion: Europe\n",
+		"invalid.yml":  "app: [",
+	})
+
+	answers, err := readWizardAnswers(filepath.Join(dir, "answers.yml"))
+	assert.Nil(err, "Couldn't read YAML answers")
+	assert.Equal(&wizardAnswers{
+		App:           "Wordpress",
+		Location:      "Europe",
+		CloudProvider: "AWS",
+		CloudAccount:  "aws-prod",
+		ServerPlan:    "m5.large",
+		Hostname:      "wpnode1",
+	}, answers, "Unexpected answers")
+
+	answers, err = readWizardAnswers(filepath.Join(dir, "answers.json"))
+	assert.Nil(err, "Couldn't read JSON answers")
+	assert.Equal(&wizardAnswers{App: "Wordpress", Location: "Europe"}, answers, "Unexpected answers")
+
+	for _, name := range []string{"unknown.yml", "invalid.yml", "missing.yml"} {
+		_, err = readWizardAnswers(filepath.Join(dir, name))
+		assert.NotNil(err, "Answers file %s should be rejected", name)
+	}
+}
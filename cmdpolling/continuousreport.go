@@ -8,6 +8,7 @@ import (
 
 	log "github.com/Sirupsen/logrus"
 	"github.com/codegangsta/cli"
+	"github.com/ingrammicro/concerto/api/polling"
 	"github.com/ingrammicro/concerto/cmd"
 	"github.com/ingrammicro/concerto/utils"
 	"github.com/ingrammicro/concerto/utils/format"
@@ -23,6 +24,7 @@ func cmdContinuousReportRun(c *cli.Context) error {
 
 	formatter := format.GetFormatter()
 	pollingSvc := cmd.WireUpPolling(c)
+	outbox := cmd.WireUpOutbox(c)
 
 	// cli command argument
 	var cmdArg string
@@ -42,25 +44,31 @@ func cmdContinuousReportRun(c *cli.Context) error {
 	// Custom method for chunks processing
 	fn := func(chunk string) error {
 		log.Debug("sendChunks")
-		err := utils.Retry(RetriesNumber, time.Second, func() error {
-			log.Debug("Sending: ", chunk)
+		commandIn := map[string]interface{}{
+			"stdout": chunk,
+		}
 
-			commandIn := map[string]interface{}{
-				"stdout": chunk,
-			}
+		// chunks which cannot be sent are queued, so the buffer doesn't keep growing
+		_, err := outbox.Deliver("", "POST", polling.BootstrapLogsPath, &commandIn, func() (int, error) {
+			var statusCode int
+			err := utils.Retry(RetriesNumber, time.Second, func() error {
+				log.Debug("Sending: ", chunk)
 
-			_, statusCode, err := pollingSvc.ReportBootstrapLog(&commandIn)
-			switch {
-			// 0<100 error cases??
-			case statusCode == 0:
-				return fmt.Errorf("communication error %v %v", statusCode, err)
-			case statusCode >= 500:
-				return fmt.Errorf("server error %v %v", statusCode, err)
-			case statusCode >= 400:
-				return fmt.Errorf("client error %v %v", statusCode, err)
-			default:
-				return nil
-			}
+				var err error
+				_, statusCode, err = pollingSvc.ReportBootstrapLog(&commandIn)
+				switch {
+				// 0<100 error cases??
+				case statusCode == 0:
+					return fmt.Errorf("communication error %v %v", statusCode, err)
+				case statusCode >= 500:
+					return fmt.Errorf("server error %v %v", statusCode, err)
+				case statusCode >= 400:
+					return fmt.Errorf("client error %v %v", statusCode, err)
+				default:
+					return nil
+				}
+			})
+			return statusCode, err
 		})
 
 		if err != nil {
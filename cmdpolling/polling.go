@@ -2,6 +2,7 @@ package cmdpolling
 
 import (
 	"context"
+	"fmt"
 	"os"
 	"os/signal"
 	"strings"
@@ -58,16 +59,42 @@ func cmdStart(c *cli.Context) error {
 	}
 	log.Debug("Ping short time interval:", pollingPingTimingIntervalShort)
 
+	config, err := utils.GetConcertoConfig()
+	if err != nil {
+		formatter.PrintFatal("Couldn't wire up config", err)
+	}
+	sandbox := utils.NewSandbox(evaluatePollingConfig(c, config.PollingConfig))
+	if sandbox != nil {
+		log.Debugf("Commands will run sandboxed: %+v", *sandbox)
+	}
+
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
 	go handleSysSignals(cancel)
 
-	pingRoutine(ctx, c, pollingPingTimingIntervalLong, pollingPingTimingIntervalShort)
+	pingRoutine(ctx, c, pollingPingTimingIntervalLong, pollingPingTimingIntervalShort, sandbox)
 
 	return nil
 }
 
+// Overrides the configured polling sandbox with the cli flags
+func evaluatePollingConfig(c *cli.Context, pollingConfig utils.PollingConfig) utils.PollingConfig {
+	if c.IsSet("sandbox") {
+		pollingConfig.Sandbox = c.Bool("sandbox")
+	}
+	if c.IsSet("user") {
+		pollingConfig.RunAsUser = c.String("user")
+	}
+	if c.IsSet("group") {
+		pollingConfig.RunAsGroup = c.String("group")
+	}
+	if c.IsSet("env-allowlist") {
+		pollingConfig.EnvAllowlist = c.String("env-allowlist")
+	}
+	return pollingConfig
+}
+
 // Stop the polling process
 func cmdStop(c *cli.Context) error {
 	log.Debug("cmdStop")
@@ -82,11 +109,12 @@ func cmdStop(c *cli.Context) error {
 }
 
 // Main polling background routine
-func pingRoutine(ctx context.Context, c *cli.Context, longTimePeriod int64, shortTimePeriod int64) {
+func pingRoutine(ctx context.Context, c *cli.Context, longTimePeriod int64, shortTimePeriod int64, sandbox *utils.Sandbox) {
 	log.Debug("pingRoutine")
 
 	formatter := format.GetFormatter()
 	pollingSvc := cmd.WireUpPolling(c)
+	outbox := cmd.WireUpOutbox(c)
 	commandProcessed := make(chan bool, 1)
 
 	// initialization
@@ -99,11 +127,18 @@ func pingRoutine(ctx context.Context, c *cli.Context, longTimePeriod int64, shor
 		if err != nil {
 			formatter.PrintError("Couldn't receive polling ping data", err)
 		} else {
+			// deliver the reports queued while the platform was unreachable
+			if !isRunningCommandRoutine {
+				if _, err := outbox.Flush(); err != nil {
+					formatter.PrintError("Couldn't flush outbox", err)
+				}
+			}
+
 			// One command is available, and no process running
 			if status == 201 && ping.PendingCommands && !isRunningCommandRoutine {
 				log.Debug("Detected a candidate command")
 				isRunningCommandRoutine = true
-				go processingCommandRoutine(pollingSvc, formatter, commandProcessed)
+				go processingCommandRoutine(pollingSvc, outbox, formatter, commandProcessed, sandbox)
 			}
 		}
 
@@ -132,7 +167,7 @@ func pingRoutine(ctx context.Context, c *cli.Context, longTimePeriod int64, shor
 }
 
 // Subsidiary routine for commands processing
-func processingCommandRoutine(pollingSvc *polling.PollingService, formatter format.Formatter, commandProcessed chan bool) {
+func processingCommandRoutine(pollingSvc *polling.PollingService, outbox *utils.Outbox, formatter format.Formatter, commandProcessed chan bool, sandbox *utils.Sandbox) {
 	log.Debug("processingCommandRoutine")
 
 	// 1. Request for the new command available
@@ -145,7 +180,7 @@ func processingCommandRoutine(pollingSvc *polling.PollingService, formatter form
 	// 2. Execute the retrieved command
 	if status == 200 {
 		log.Debug("Running the retrieved command")
-		command.ExitCode, command.Stdout, command.Stderr, _, _ = utils.RunTracedCmd(command.Script)
+		command.ExitCode, command.Stdout, command.Stderr, _, _ = utils.RunTracedCmd(command.Script, command.Interpreter, sandbox)
 
 		// 3. then status is propagated to IMCO
 		log.Debug("Reporting command execution status")
@@ -158,15 +193,25 @@ func processingCommandRoutine(pollingSvc *polling.PollingService, formatter form
 			"exit_code": command.ExitCode,
 		}
 
-		_, status, err := pollingSvc.UpdateCommand(&commandIn, command.ID)
-		if err != nil {
+		queued, err := outbox.Deliver(fmt.Sprintf("command:%s", command.ID), "PUT", polling.CommandPath(command.ID), &commandIn, func() (int, error) {
+			var status int
+			err := utils.Retry(RetriesNumber, time.Second, func() error {
+				var err error
+				_, status, err = pollingSvc.UpdateCommand(&commandIn, command.ID)
+				if err == nil && status != 200 {
+					err = fmt.Errorf("received non-ok %d response", status)
+				}
+				return err
+			})
+			return status, err
+		})
+		switch {
+		case err != nil:
 			formatter.PrintError("Couldn't send polling command report data", err)
-		}
-
-		if status == 200 {
+		case queued:
+			log.Warn("Command execution results queued to be reported later")
+		default:
 			log.Debug("Command execution results successfully reported")
-		} else {
-			log.Error("Cannot report the command execution results")
 		}
 	} else {
 		log.Error("Cannot retrieve the next command")
@@ -27,6 +27,22 @@ func SubCommands() []cli.Command {
 					Usage: "Polling ping short time interval (seconds)",
 					Value: DefaultPollingPingTimingIntervalShort,
 				},
+				cli.BoolFlag{
+					Name:  "sandbox",
+					Usage: "Runs every command in a private working directory with a scrubbed environment",
+				},
+				cli.StringFlag{
+					Name:  "user",
+					Usage: "User the commands are run as (implies sandbox)",
+				},
+				cli.StringFlag{
+					Name:  "group",
+					Usage: "Group the commands are run as (implies sandbox)",
+				},
+				cli.StringFlag{
+					Name:  "env-allowlist",
+					Usage: "Comma separated list of environment variables inherited by sandboxed commands",
+				},
 			},
 		},
 		{
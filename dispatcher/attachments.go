@@ -0,0 +1,366 @@
+package dispatcher
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/codegangsta/cli"
+	"github.com/ingrammicro/concerto/api/dispatcher"
+	"github.com/ingrammicro/concerto/utils"
+	"github.com/ingrammicro/concerto/utils/format"
+)
+
+const (
+	// DefaultDownloadConcurrency is the default maximum number of attachments downloaded at the same time
+	DefaultDownloadConcurrency = 4
+	cacheIndexFileName         = "index.json"
+	cacheBlobsDirName          = "blobs"
+)
+
+// cacheEntry stores the validators and integrity data of a cached attachment
+type cacheEntry struct {
+	FileName     string `json:"file_name"`
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+	Size         int64  `json:"size"`
+	SHA256       string `json:"sha256"`
+}
+
+// attachmentCache is a local content-addressed store of downloaded attachments, indexed by url
+type attachmentCache struct {
+	dir   string
+	mutex sync.Mutex
+	index map[string]*cacheEntry
+}
+
+// attachmentDownloader downloads script attachments concurrently through the local cache
+type attachmentDownloader struct {
+	dispatcherSvc *dispatcher.DispatcherService
+	apiEndpoint   string
+	cache         *attachmentCache
+	concurrency   int
+}
+
+// attachmentsCacheDir returns the directory where attachments are cached
+func attachmentsCacheDir(config *utils.Config) string {
+	return filepath.Join(config.ConfLocation, "cache", "attachments")
+}
+
+// openAttachmentCache loads the cache index stored in dir, creating the cache if it doesn't exist
+func openAttachmentCache(dir string) (*attachmentCache, error) {
+	if err := os.MkdirAll(filepath.Join(dir, cacheBlobsDirName), 0700); err != nil {
+		return nil, err
+	}
+
+	ac := &attachmentCache{dir: dir, index: map[string]*cacheEntry{}}
+	data, err := ioutil.ReadFile(filepath.Join(dir, cacheIndexFileName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return ac, nil
+		}
+		return nil, err
+	}
+	if err = json.Unmarshal(data, &ac.index); err != nil {
+		log.Warn("Attachments cache index is corrupted, starting an empty one: ", err)
+		ac.index = map[string]*cacheEntry{}
+	}
+	ac.evictUnreferenced()
+	return ac, nil
+}
+
+func (ac *attachmentCache) blobPath(sum string) string {
+	return filepath.Join(ac.dir, cacheBlobsDirName, sum)
+}
+
+// validEntry returns the entry cached for url, if its blob is still present and intact
+func (ac *attachmentCache) validEntry(url string) *cacheEntry {
+	ac.mutex.Lock()
+	entry := ac.index[url]
+	ac.mutex.Unlock()
+	if entry == nil {
+		return nil
+	}
+
+	size, sums, err := fileChecksums(ac.blobPath(entry.SHA256))
+	if err != nil || size != entry.Size || sums["sha-256"] != entry.SHA256 {
+		log.Warnf("Cached attachment %s is missing or corrupted, it will be downloaded again", url)
+		ac.remove(url)
+		return nil
+	}
+	return entry
+}
+
+// store moves the downloaded file into the blobs and stores the entry for url, persisting the index. The blob
+// of the previous entry is evicted when no other entry references it.
+func (ac *attachmentCache) store(url string, fileName string, entry *cacheEntry) error {
+	ac.mutex.Lock()
+	defer ac.mutex.Unlock()
+
+	if err := os.Rename(fileName, ac.blobPath(entry.SHA256)); err != nil {
+		return err
+	}
+	previous := ac.index[url]
+	ac.index[url] = entry
+	if previous != nil {
+		ac.evict(previous.SHA256)
+	}
+	return ac.save()
+}
+
+// remove drops the entry for url, evicting its blob when no other entry references it, and persists the index
+func (ac *attachmentCache) remove(url string) {
+	ac.mutex.Lock()
+	defer ac.mutex.Unlock()
+
+	if entry := ac.index[url]; entry != nil {
+		delete(ac.index, url)
+		ac.evict(entry.SHA256)
+	}
+	if err := ac.save(); err != nil {
+		log.Warn("Cannot save attachments cache index: ", err)
+	}
+}
+
+// copyBlob copies the blob of the entry into target. The mutex is held so that the blob cannot be evicted meanwhile
+func (ac *attachmentCache) copyBlob(entry *cacheEntry, target string) error {
+	ac.mutex.Lock()
+	defer ac.mutex.Unlock()
+
+	return copyFile(ac.blobPath(entry.SHA256), target)
+}
+
+// evict removes the blob unless an entry references it. Caller must hold the mutex
+func (ac *attachmentCache) evict(sum string) {
+	for _, entry := range ac.index {
+		if entry.SHA256 == sum {
+			return
+		}
+	}
+	if err := os.Remove(ac.blobPath(sum)); err != nil && !os.IsNotExist(err) {
+		log.Warn("Cannot evict cached attachment: ", err)
+	}
+}
+
+// evictUnreferenced removes the blobs no entry references, such as the ones left by an interrupted run
+func (ac *attachmentCache) evictUnreferenced() {
+	files, err := ioutil.ReadDir(filepath.Join(ac.dir, cacheBlobsDirName))
+	if err != nil {
+		log.Warn("Cannot read cached attachments: ", err)
+		return
+	}
+	ac.mutex.Lock()
+	defer ac.mutex.Unlock()
+	for _, file := range files {
+		ac.evict(file.Name())
+	}
+}
+
+// save writes the index atomically. Caller must hold the mutex
+func (ac *attachmentCache) save() error {
+	data, err := json.Marshal(ac.index)
+	if err != nil {
+		return err
+	}
+	tmp := filepath.Join(ac.dir, fmt.Sprintf("%s.%s", cacheIndexFileName, utils.RandomString(6)))
+	if err = ioutil.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(ac.dir, cacheIndexFileName))
+}
+
+// fetch places the attachment at url into targetDir, sending a conditional request when it is already cached
+func (ac *attachmentCache) fetch(dispatcherSvc *dispatcher.DispatcherService, url string, targetDir string) (string, error) {
+	var etag, lastModified string
+	entry := ac.validEntry(url)
+	if entry != nil {
+		etag, lastModified = entry.ETag, entry.LastModified
+	}
+
+	downloadDir, err := ioutil.TempDir(ac.dir, "download")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(downloadDir)
+
+	realFileName, header, status, err := dispatcherSvc.DownloadAttachmentIfModified(url, downloadDir, etag, lastModified)
+	if err != nil {
+		return "", err
+	}
+
+	if status == http.StatusNotModified {
+		if entry == nil {
+			return "", fmt.Errorf("received not modified response for an attachment which is not cached")
+		}
+		log.Debugf("Attachment %s not modified, taken from cache", url)
+		target := filepath.Join(targetDir, entry.FileName)
+		return target, ac.copyBlob(entry, target)
+	}
+
+	size, sum, err := verifyDownload(realFileName, header)
+	if err != nil {
+		return "", err
+	}
+	target := filepath.Join(targetDir, filepath.Base(realFileName))
+	if err = copyFile(realFileName, target); err != nil {
+		return "", err
+	}
+
+	entry = &cacheEntry{
+		FileName:     filepath.Base(realFileName),
+		ETag:         header.Get("ETag"),
+		LastModified: header.Get("Last-Modified"),
+		Size:         size,
+		SHA256:       sum,
+	}
+	if err = ac.store(url, realFileName, entry); err != nil {
+		log.Warn("Cannot cache attachment: ", err)
+	}
+	return target, nil
+}
+
+// download gets every attachment into attachmentDir, with a limited number of concurrent downloads
+func (ad *attachmentDownloader) download(endpoints []string, attachmentDir string) error {
+	concurrency := ad.concurrency
+	if !(concurrency > 0) {
+		concurrency = DefaultDownloadConcurrency
+	}
+
+	semaphore := make(chan struct{}, concurrency)
+	errs := make([]error, len(endpoints))
+	var wg sync.WaitGroup
+	for i, endpoint := range endpoints {
+		wg.Add(1)
+		go func(i int, endpoint string) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			url := fmt.Sprintf("%s%s", ad.apiEndpoint, endpoint)
+			var realFileName string
+			var err error
+			if ad.cache != nil {
+				realFileName, err = ad.cache.fetch(ad.dispatcherSvc, url, attachmentDir)
+			} else {
+				var status int
+				realFileName, status, err = ad.dispatcherSvc.DownloadAttachment(url, attachmentDir)
+				if err == nil && status != 200 {
+					err = fmt.Errorf("obtained non-ok response %d", status)
+				}
+			}
+			if err != nil {
+				errs[i] = fmt.Errorf("couldn't download attachment %s: %v", endpoint, err)
+				return
+			}
+			log.Infof("\t - %s --> %s", endpoint, realFileName)
+		}(i, endpoint)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// verifyDownload checks the downloaded file against the checksums announced by the server Digest header, if any,
+// and returns its size and sha256. Its size and Content-MD5 have already been verified by the transfer.
+func verifyDownload(fileName string, header http.Header) (int64, string, error) {
+	size, sums, err := fileChecksums(fileName)
+	if err != nil {
+		return 0, "", err
+	}
+
+	expected := map[string]string{}
+	// Digest: SHA-256=<base64>,MD5=<base64>
+	for _, digest := range strings.Split(header.Get("Digest"), ",") {
+		if kv := strings.SplitN(strings.TrimSpace(digest), "=", 2); len(kv) == 2 {
+			expected[strings.ToLower(kv[0])] = kv[1]
+		}
+	}
+	for algorithm, b64 := range expected {
+		sum, ok := sums[algorithm]
+		if !ok {
+			continue
+		}
+		raw, err := base64.StdEncoding.DecodeString(b64)
+		if err != nil || hex.EncodeToString(raw) != sum {
+			return 0, "", fmt.Errorf("downloaded file %s checksum does not match", algorithm)
+		}
+	}
+
+	return size, sums["sha-256"], nil
+}
+
+// fileChecksums returns the size of the file and its hex encoded checksums
+func fileChecksums(fileName string) (int64, map[string]string, error) {
+	f, err := os.Open(fileName)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer f.Close()
+
+	hashes := map[string]hash.Hash{"sha-256": sha256.New(), "md5": md5.New()}
+	writers := []io.Writer{}
+	for _, h := range hashes {
+		writers = append(writers, h)
+	}
+	size, err := io.Copy(io.MultiWriter(writers...), f)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	sums := map[string]string{}
+	for algorithm, h := range hashes {
+		sums[algorithm] = hex.EncodeToString(h.Sum(nil))
+	}
+	return size, sums, nil
+}
+
+// copyFile copies source file contents into target file
+func copyFile(source string, target string) error {
+	in, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(target)
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+func cmdCacheClean(c *cli.Context) error {
+	formatter := format.GetFormatter()
+	config, err := utils.GetConcertoConfig()
+	if err != nil {
+		formatter.PrintFatal("Couldn't wire up config", err)
+	}
+
+	dir := attachmentsCacheDir(config)
+	if err := os.RemoveAll(dir); err != nil {
+		formatter.PrintFatal("Couldn't clean attachments cache", err)
+	}
+	log.Infof("Attachments cache %s successfully cleaned", dir)
+	return nil
+}
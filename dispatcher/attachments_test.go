@@ -0,0 +1,217 @@
+package dispatcher
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ingrammicro/concerto/api/dispatcher"
+	"github.com/ingrammicro/concerto/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// newTestAttachmentCache returns a cache in a temporary directory, along with a dispatcher service over a mocked API
+func newTestAttachmentCache(t *testing.T) (*attachmentCache, *dispatcher.DispatcherService, *utils.MockConcertoService, string) {
+	dir, err := ioutil.TempDir("", "cio-attachments")
+	assert.Nil(t, err, "Couldn't create temp dir")
+	ac, err := openAttachmentCache(filepath.Join(dir, "cache"))
+	assert.Nil(t, err, "Couldn't open cache")
+	cs := &utils.MockConcertoService{}
+	ds, err := dispatcher.NewDispatcherService(cs)
+	assert.Nil(t, err, "Couldn't load dispatcher service")
+	return ac, ds, cs, dir
+}
+
+// onDownload mocks the next download of url with the given validators, which receives the file with the contents
+// and response headers. A nil content answers as not modified.
+func onDownload(cs *utils.MockConcertoService, url string, validators map[string]string, name string, content []byte, header http.Header) *mock.Call {
+	if header == nil {
+		header = http.Header{}
+	}
+	call := cs.On("GetFileConditional", url, mock.Anything, validators).Once()
+	call.Run(func(args mock.Arguments) {
+		if content == nil {
+			call.Return("", header, http.StatusNotModified, nil)
+			return
+		}
+		fileName := filepath.Join(args.String(1), name)
+		ioutil.WriteFile(fileName, content, 0600)
+		call.Return(fileName, header, http.StatusOK, nil)
+	})
+	return call
+}
+
+func blobSum(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+func TestAttachmentCacheIndex(t *testing.T) {
+	assert := assert.New(t)
+	ac, ds, cs, dir := newTestAttachmentCache(t)
+	defer os.RemoveAll(dir)
+
+	content := []byte("server {}")
+	onDownload(cs, "http://api/a/1", map[string]string{}, "nginx.conf", content, http.Header{"Etag": []string{`"v1"`}})
+	target, err := ac.fetch(ds, "http://api/a/1", dir)
+	assert.Nil(err, "Couldn't fetch attachment")
+	assert.Equal(filepath.Join(dir, "nginx.conf"), target, "Attachment should be named as downloaded")
+	received, _ := ioutil.ReadFile(target)
+	assert.Equal(content, received, "Unexpected attachment contents")
+
+	reopened, err := openAttachmentCache(ac.dir)
+	assert.Nil(err, "Couldn't reopen cache")
+	if entry := reopened.index["http://api/a/1"]; assert.NotNil(entry, "Index should be persisted") {
+		assert.Equal(&cacheEntry{FileName: "nginx.conf", ETag: `"v1"`, Size: int64(len(content)), SHA256: blobSum(content)}, entry, "Unexpected entry")
+	}
+	files, _ := ioutil.ReadDir(ac.dir)
+	for _, file := range files {
+		assert.Contains([]string{cacheIndexFileName, cacheBlobsDirName}, file.Name(), "Temporary files should be removed")
+	}
+	cs.AssertExpectations(t)
+}
+
+func TestAttachmentCacheNotModified(t *testing.T) {
+	assert := assert.New(t)
+	ac, ds, cs, dir := newTestAttachmentCache(t)
+	defer os.RemoveAll(dir)
+
+	content := []byte("server {}")
+	onDownload(cs, "http://api/a/1", map[string]string{}, "nginx.conf", content, http.Header{"Etag": []string{`"v1"`}, "Last-Modified": []string{"yesterday"}})
+	onDownload(cs, "http://api/a/1", map[string]string{"If-None-Match": `"v1"`, "If-Modified-Since": "yesterday"}, "", nil, nil)
+	_, err := ac.fetch(ds, "http://api/a/1", dir)
+	assert.Nil(err, "Couldn't fetch attachment")
+
+	targetDir := filepath.Join(dir, "second")
+	assert.Nil(os.Mkdir(targetDir, 0700), "Couldn't create dir")
+	target, err := ac.fetch(ds, "http://api/a/1", targetDir)
+	assert.Nil(err, "Couldn't fetch cached attachment")
+	received, _ := ioutil.ReadFile(target)
+	assert.Equal(content, received, "Not modified attachments should be taken from the cache")
+	cs.AssertExpectations(t)
+}
+
+func TestAttachmentCacheCorruptedBlob(t *testing.T) {
+	assert := assert.New(t)
+	ac, ds, cs, dir := newTestAttachmentCache(t)
+	defer os.RemoveAll(dir)
+
+	content := []byte("server {}")
+	onDownload(cs, "http://api/a/1", map[string]string{}, "nginx.conf", content, http.Header{"Etag": []string{`"v1"`}})
+	// no validators are sent for corrupted blobs, so that the attachment is received again
+	onDownload(cs, "http://api/a/1", map[string]string{}, "nginx.conf", content, http.Header{"Etag": []string{`"v1"`}})
+	_, err := ac.fetch(ds, "http://api/a/1", dir)
+	assert.Nil(err, "Couldn't fetch attachment")
+
+	assert.Nil(ioutil.WriteFile(ac.blobPath(blobSum(content)), []byte("server { corrupted }"), 0600), "Couldn't corrupt blob")
+	target, err := ac.fetch(ds, "http://api/a/1", dir)
+	assert.Nil(err, "Couldn't fetch attachment again")
+	received, _ := ioutil.ReadFile(target)
+	assert.Equal(content, received, "Corrupted blobs should be downloaded again")
+	received, _ = ioutil.ReadFile(ac.blobPath(blobSum(content)))
+	assert.Equal(content, received, "Blob should be repaired")
+	cs.AssertExpectations(t)
+}
+
+func TestAttachmentCacheChecksumMismatch(t *testing.T) {
+	assert := assert.New(t)
+	ac, ds, cs, dir := newTestAttachmentCache(t)
+	defer os.RemoveAll(dir)
+
+	other := sha256.Sum256([]byte("other"))
+	digest := "SHA-256=" + base64.StdEncoding.EncodeToString(other[:])
+	onDownload(cs, "http://api/a/1", map[string]string{}, "nginx.conf", []byte("server {}"), http.Header{"Digest": []string{digest}})
+	_, err := ac.fetch(ds, "http://api/a/1", dir)
+	assert.NotNil(err, "Checksum mismatch should fail")
+	assert.Empty(ac.index, "Mismatching attachments should not be cached")
+	blobs, _ := ioutil.ReadDir(filepath.Join(ac.dir, cacheBlobsDirName))
+	assert.Empty(blobs, "Mismatching attachments should not be stored")
+	_, err = os.Stat(filepath.Join(dir, "nginx.conf"))
+	assert.True(os.IsNotExist(err), "Mismatching attachments should not be placed")
+}
+
+func TestAttachmentCacheEvictsUnreferencedBlobs(t *testing.T) {
+	assert := assert.New(t)
+	ac, ds, cs, dir := newTestAttachmentCache(t)
+	defer os.RemoveAll(dir)
+
+	shared, updated := []byte("shared"), []byte("updated")
+	onDownload(cs, "http://api/a/1", map[string]string{}, "a.conf", shared, http.Header{"Etag": []string{`"v1"`}})
+	onDownload(cs, "http://api/a/2", map[string]string{}, "b.conf", shared, http.Header{"Etag": []string{`"v1"`}})
+	onDownload(cs, "http://api/a/1", map[string]string{"If-None-Match": `"v1"`}, "a.conf", updated, http.Header{"Etag": []string{`"v2"`}})
+	onDownload(cs, "http://api/a/2", map[string]string{"If-None-Match": `"v1"`}, "b.conf", updated, http.Header{"Etag": []string{`"v2"`}})
+	blobExists := func(content []byte) bool {
+		_, err := os.Stat(ac.blobPath(blobSum(content)))
+		return err == nil
+	}
+
+	for _, url := range []string{"http://api/a/1", "http://api/a/2"} {
+		_, err := ac.fetch(ds, url, dir)
+		assert.Nil(err, "Couldn't fetch attachment")
+	}
+	_, err := ac.fetch(ds, "http://api/a/1", dir)
+	assert.Nil(err, "Couldn't fetch updated attachment")
+	assert.True(blobExists(shared), "Blobs still referenced should be kept")
+	_, err = ac.fetch(ds, "http://api/a/2", dir)
+	assert.Nil(err, "Couldn't fetch updated attachment")
+	assert.False(blobExists(shared), "Blobs no longer referenced should be evicted")
+	assert.True(blobExists(updated), "Referenced blobs should be kept")
+
+	stray := ac.blobPath(blobSum([]byte("stray")))
+	assert.Nil(ioutil.WriteFile(stray, []byte("stray"), 0600), "Couldn't write blob")
+	ac, err = openAttachmentCache(ac.dir)
+	assert.Nil(err, "Couldn't reopen cache")
+	_, err = os.Stat(stray)
+	assert.True(os.IsNotExist(err), "Blobs left unreferenced should be evicted when the cache is opened")
+	assert.True(blobExists(updated), "Referenced blobs should be kept")
+	cs.AssertExpectations(t)
+}
+
+func TestAttachmentDownloaderConcurrency(t *testing.T) {
+	assert := assert.New(t)
+	ac, ds, cs, dir := newTestAttachmentCache(t)
+	defer os.RemoveAll(dir)
+
+	var mutex sync.Mutex
+	active, maxActive := 0, 0
+	endpoints := []string{}
+	for i := 0; i < 6; i++ {
+		endpoint := fmt.Sprintf("/attachments/%d", i)
+		endpoints = append(endpoints, endpoint)
+		call := onDownload(cs, "http://api"+endpoint, map[string]string{}, fmt.Sprintf("file-%d", i), []byte(endpoint), nil)
+		write := call.RunFn
+		call.Run(func(args mock.Arguments) {
+			mutex.Lock()
+			active++
+			if active > maxActive {
+				maxActive = active
+			}
+			mutex.Unlock()
+			time.Sleep(20 * time.Millisecond)
+			write(args)
+			mutex.Lock()
+			active--
+			mutex.Unlock()
+		})
+	}
+
+	downloader := &attachmentDownloader{dispatcherSvc: ds, apiEndpoint: "http://api", cache: ac, concurrency: 2}
+	assert.Nil(downloader.download(endpoints, dir), "Couldn't download attachments")
+	assert.True(maxActive <= 2, "There should be at most 2 concurrent downloads, there were %d", maxActive)
+	assert.Equal(2, maxActive, "Downloads should be concurrent")
+	for i, endpoint := range endpoints {
+		received, _ := ioutil.ReadFile(filepath.Join(dir, fmt.Sprintf("file-%d", i)))
+		assert.Equal(endpoint, string(received), "Unexpected attachment contents")
+	}
+	assert.Len(ac.index, len(endpoints), "Every attachment should be cached")
+	cs.AssertExpectations(t)
+}
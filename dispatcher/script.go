@@ -2,15 +2,21 @@ package dispatcher
 
 import (
 	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
 	log "github.com/Sirupsen/logrus"
 	"github.com/codegangsta/cli"
+	"github.com/ingrammicro/concerto/api/dispatcher"
 	"github.com/ingrammicro/concerto/api/types"
 	"github.com/ingrammicro/concerto/cmd"
 	"github.com/ingrammicro/concerto/utils"
-	"io/ioutil"
-	"os"
 )
 
+const retriesNumber = 5
+
 func cmdBoot(c *cli.Context) error {
 	execute(c, "boot", "")
 	return nil
@@ -29,6 +35,7 @@ func cmdShutdown(c *cli.Context) error {
 func execute(c *cli.Context, phase string, scriptCharacterizationUUID string) {
 	var scriptChars []*types.ScriptCharacterization
 	dispatcherSvc, config, formatter := cmd.WireUpDispatcher(c)
+	outbox := cmd.WireUpOutbox(c)
 
 	var err error
 	log.Debugf("Current Script Characterization %s (UUID=%s)", phase, scriptCharacterizationUUID)
@@ -41,62 +48,104 @@ func execute(c *cli.Context, phase string, scriptCharacterizationUUID string) {
 		formatter.PrintFatal("Couldn't receive Script Characterization data", err)
 	}
 
+	downloader := &attachmentDownloader{
+		dispatcherSvc: dispatcherSvc,
+		apiEndpoint:   config.APIEndpoint,
+		concurrency:   c.Int("download-concurrency"),
+	}
+	if downloader.cache, err = openAttachmentCache(attachmentsCacheDir(config)); err != nil {
+		formatter.PrintError("Couldn't open attachments cache, downloading without cache", err)
+	}
+
+	continueOnError := c.Bool("continue-on-error")
+	failed := 0
 	for _, sc := range scriptChars {
 		log.Infof("------------------------------------------------------------------------------------------------")
-		path, err := ioutil.TempDir("", "cio")
-		if err != nil {
-			formatter.PrintFatal("Couldn't create temporary directory", err)
+		if err := executeScriptCharacterization(dispatcherSvc, outbox, downloader, sc); err != nil {
+			if !continueOnError {
+				formatter.PrintFatal(fmt.Sprintf("Couldn't execute script characterization %s", sc.UUID), err)
+			}
+			formatter.PrintError(fmt.Sprintf("Couldn't execute script characterization %s", sc.UUID), err)
+			failed++
 		}
-		defer os.RemoveAll(path)
+		log.Infof("------------------------------------------------------------------------------------------------")
+	}
 
-		if err = os.Setenv("ATTACHMENT_DIR", fmt.Sprintf("%s/%s", path, "attachments")); err != nil {
-			formatter.PrintFatal("Couldn't set attachments directory as environment variable", err)
-		}
+	if failed > 0 {
+		formatter.PrintFatal("Script characterizations execution failed", fmt.Errorf("%d of %d script characterizations failed", failed, len(scriptChars)))
+	}
+}
 
-		log.Infof("UUID: %s", sc.UUID)
-		log.Infof("Home Folder: %s", path)
+// executeScriptCharacterization runs a script characterization in its own working directory and environment,
+// and reports its conclusion. The working directory is removed as soon as the script is done.
+func executeScriptCharacterization(dispatcherSvc *dispatcher.DispatcherService, outbox *utils.Outbox, downloader *attachmentDownloader, sc *types.ScriptCharacterization) error {
+	path, err := ioutil.TempDir("", "cio")
+	if err != nil {
+		return fmt.Errorf("couldn't create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(path)
 
-		attachmentDir := os.Getenv("ATTACHMENT_DIR")
-		if err = os.Mkdir(attachmentDir, 0777); err != nil {
-			formatter.PrintFatal("Couldn't create attachments directory", err)
-		}
+	log.Infof("UUID: %s", sc.UUID)
+	log.Infof("Home Folder: %s", path)
 
-		// Setting up environment Variables
-		log.Infof("Environment Variables")
-		for index, value := range sc.Parameters {
-			if err = os.Setenv(index, value); err != nil {
-				formatter.PrintFatal(fmt.Sprintf("Couldn't set environment variable %s:%s", index, value), err)
-			}
-			log.Infof("\t - %s=%s", index, value)
-		}
+	attachmentDir := filepath.Join(path, "attachments")
+	if err = os.Mkdir(attachmentDir, 0777); err != nil {
+		return reportFailedScriptConclusion(dispatcherSvc, outbox, sc, fmt.Errorf("couldn't create attachments directory: %v", err))
+	}
 
-		if len(sc.Script.AttachmentPaths) > 0 {
-			log.Infof("Attachment Folder: %s", attachmentDir)
-			log.Infof("Attachments")
-			for _, endpoint := range sc.Script.AttachmentPaths {
-				realFileName, _, err := dispatcherSvc.DownloadAttachment(fmt.Sprintf("%s%s", config.APIEndpoint, endpoint), attachmentDir)
-				if err != nil {
-					formatter.PrintFatal("Couldn't download attachment", err)
-				}
-				log.Infof("\t - %s --> %s", endpoint, realFileName)
-			}
-		}
+	// Setting up environment Variables
+	env := utils.ScriptEnvironment(attachmentDir, sc.Parameters)
 
-		output, exitCode, startedAt, finishedAt := utils.ExecCode(sc.Script.Code, path, sc.Script.UUID)
-		scriptConclusionIn := map[string]interface{}{
-			"script_characterization_id": sc.UUID,
-			"output":                     output,
-			"exit_code":                  exitCode,
-			"started_at":                 startedAt.Format(utils.TimeStampLayout),
-			"finished_at":                finishedAt.Format(utils.TimeStampLayout),
-		}
-		scriptConclusionRootIn := map[string]interface{}{
-			"script_conclusion": scriptConclusionIn,
-		}
-		_, _, err = dispatcherSvc.ReportScriptConclusions(&scriptConclusionRootIn)
-		if err != nil {
-			formatter.PrintFatal("Couldn't send script_conclusions report data", err)
+	if len(sc.Script.AttachmentPaths) > 0 {
+		log.Infof("Attachment Folder: %s", attachmentDir)
+		log.Infof("Attachments")
+		if err = downloader.download(sc.Script.AttachmentPaths, attachmentDir); err != nil {
+			return reportFailedScriptConclusion(dispatcherSvc, outbox, sc, err)
 		}
-		log.Infof("------------------------------------------------------------------------------------------------")
 	}
+
+	output, exitCode, startedAt, finishedAt, err := utils.ExecCode(sc.Script.Code, path, sc.Script.UUID, sc.Script.Interpreter, env)
+	if err != nil {
+		return reportFailedScriptConclusion(dispatcherSvc, outbox, sc, err)
+	}
+	return reportScriptConclusion(dispatcherSvc, outbox, sc, output, exitCode, startedAt, finishedAt)
+}
+
+// reportScriptConclusion sends the script execution results to IMCO, queuing them in the outbox when IMCO is unreachable
+func reportScriptConclusion(dispatcherSvc *dispatcher.DispatcherService, outbox *utils.Outbox, sc *types.ScriptCharacterization, output string, exitCode int, startedAt time.Time, finishedAt time.Time) error {
+	scriptConclusionIn := map[string]interface{}{
+		"script_characterization_id": sc.UUID,
+		"output":                     output,
+		"exit_code":                  exitCode,
+		"started_at":                 startedAt.Format(utils.TimeStampLayout),
+		"finished_at":                finishedAt.Format(utils.TimeStampLayout),
+	}
+	scriptConclusionRootIn := map[string]interface{}{
+		"script_conclusion": scriptConclusionIn,
+	}
+	queued, err := outbox.Deliver(fmt.Sprintf("script_conclusion:%s", sc.UUID), "POST", dispatcher.ScriptConclusionsPath, &scriptConclusionRootIn, func() (int, error) {
+		var status int
+		err := utils.Retry(retriesNumber, time.Second, func() error {
+			var err error
+			_, status, err = dispatcherSvc.ReportScriptConclusions(&scriptConclusionRootIn)
+			return err
+		})
+		return status, err
+	})
+	if err != nil {
+		return fmt.Errorf("couldn't send script_conclusions report data: %v", err)
+	}
+	if queued {
+		log.Warnf("Script characterization %s conclusion queued to be reported later", sc.UUID)
+	}
+	return nil
+}
+
+// reportFailedScriptConclusion reports a script that couldn't be run, returning the cause
+func reportFailedScriptConclusion(dispatcherSvc *dispatcher.DispatcherService, outbox *utils.Outbox, sc *types.ScriptCharacterization, cause error) error {
+	now := time.Now()
+	if err := reportScriptConclusion(dispatcherSvc, outbox, sc, cause.Error(), 1, now, now); err != nil {
+		log.Error(err)
+	}
+	return cause
 }
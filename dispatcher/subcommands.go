@@ -4,6 +4,19 @@ import (
 	"github.com/codegangsta/cli"
 )
 
+// scriptExecutionFlags are the flags of the commands executing script characterizations
+var scriptExecutionFlags = []cli.Flag{
+	cli.BoolFlag{
+		Name:  "continue-on-error",
+		Usage: "Keeps executing the remaining script characterizations when one of them fails",
+	},
+	cli.IntFlag{
+		Name:  "download-concurrency",
+		Usage: "Maximum number of attachments downloaded at the same time",
+		Value: DefaultDownloadConcurrency,
+	},
+}
+
 // SubCommands returns dispatcher commands
 func SubCommands() []cli.Command {
 	return []cli.Command{
@@ -11,16 +24,30 @@ func SubCommands() []cli.Command {
 			Name:   "boot",
 			Usage:  "Executes script characterizations associated to booting state of host",
 			Action: cmdBoot,
+			Flags:  scriptExecutionFlags,
 		},
 		{
 			Name:   "operational",
 			Usage:  "Executes all script characterizations associated to operational state of host or the one with the given id",
 			Action: cmdOperational,
+			Flags:  scriptExecutionFlags,
 		},
 		{
 			Name:   "shutdown",
 			Usage:  "Executes script characterizations associated to shutdown state of host",
 			Action: cmdShutdown,
+			Flags:  scriptExecutionFlags,
+		},
+		{
+			Name:  "cache",
+			Usage: "Manages the local cache of script attachments",
+			Subcommands: []cli.Command{
+				{
+					Name:   "clean",
+					Usage:  "Removes every cached attachment",
+					Action: cmdCacheClean,
+				},
+			},
 		},
 	}
 }
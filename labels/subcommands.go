@@ -12,6 +12,96 @@ func SubCommands() []cli.Command {
 			Name:   "list",
 			Usage:  "Lists the current labels existing in the platform for the user",
 			Action: cmd.LabelList,
+			Flags: []cli.Flag{
+				cli.BoolFlag{
+					Name:  "all",
+					Usage: "Includes the labels with a namespace",
+				},
+			},
+		},
+		{
+			Name:   "show",
+			Usage:  "Shows information about the label given by its id or name",
+			Action: cmd.LabelShow,
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "id",
+					Usage: "Label Id",
+				},
+				cli.StringFlag{
+					Name:  "label",
+					Usage: "Label name, as [namespace:]name[=value]",
+				},
+			},
+		},
+		{
+			Name:   "update",
+			Usage:  "Renames the label given by its id or name",
+			Action: cmd.LabelUpdate,
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "id",
+					Usage: "Label Id",
+				},
+				cli.StringFlag{
+					Name:  "label",
+					Usage: "Label name, as [namespace:]name[=value]",
+				},
+				cli.StringFlag{
+					Name:  "name",
+					Usage: "New label name, as [namespace:]name[=value]",
+				},
+			},
+		},
+		{
+			Name:   "delete",
+			Usage:  "Deletes the label given by its id or name",
+			Action: cmd.LabelDelete,
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "id",
+					Usage: "Label Id",
+				},
+				cli.StringFlag{
+					Name:  "label",
+					Usage: "Label name, as [namespace:]name[=value]",
+				},
+			},
+		},
+		{
+			Name:   "resources",
+			Usage:  "Lists the resources of any type carrying the label, grouped by resource type",
+			Action: cmd.LabelResourceList,
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "id",
+					Usage: "Label Id",
+				},
+				cli.StringFlag{
+					Name:  "label",
+					Usage: "Label name, as [namespace:]name[=value]",
+				},
+			},
+		},
+		{
+			Name:   "prune",
+			Usage:  "Deletes the labels which are not assigned to any resource",
+			Action: cmd.LabelPrune,
+			Flags: []cli.Flag{
+				cli.BoolFlag{
+					Name:  "dry-run",
+					Usage: "Shows the labels which would be deleted, without deleting them",
+				},
+				cli.BoolFlag{
+					Name:  "yes, y",
+					Usage: "Deletes the labels without asking for confirmation",
+				},
+				cli.IntFlag{
+					Name:  "concurrency",
+					Usage: "Maximum number of labels looked up or deleted at the same time",
+					Value: cmd.DefaultBulkConcurrency,
+				},
+			},
 		},
 	}
 }
@@ -4,27 +4,37 @@ import (
 	"fmt"
 	log "github.com/Sirupsen/logrus"
 	"github.com/codegangsta/cli"
+	"github.com/ingrammicro/concerto/agent"
 	"github.com/ingrammicro/concerto/audit"
 	"github.com/ingrammicro/concerto/blueprint"
 	"github.com/ingrammicro/concerto/bootstrapping"
 	"github.com/ingrammicro/concerto/brownfield"
 	"github.com/ingrammicro/concerto/cloud"
+	"github.com/ingrammicro/concerto/cmd"
 	"github.com/ingrammicro/concerto/cmdpolling"
 	"github.com/ingrammicro/concerto/converge"
 	"github.com/ingrammicro/concerto/dispatcher"
 	"github.com/ingrammicro/concerto/firewall"
 	"github.com/ingrammicro/concerto/labels"
+	"github.com/ingrammicro/concerto/manifest"
 	"github.com/ingrammicro/concerto/network"
+	"github.com/ingrammicro/concerto/scaler"
 	"github.com/ingrammicro/concerto/settings"
 	"github.com/ingrammicro/concerto/storage"
 	"github.com/ingrammicro/concerto/utils"
 	"github.com/ingrammicro/concerto/utils/format"
+	"github.com/ingrammicro/concerto/wait"
 	"github.com/ingrammicro/concerto/wizard"
 	"os"
 	"sort"
 )
 
 var serverCommands = []cli.Command{
+	{
+		Name:        "agent",
+		Usage:       "Manages agent state within a Host",
+		Subcommands: append(agent.SubCommands()),
+	},
 	{
 		Name:        "bootstrap",
 		Usage:       "Manages bootstrapping commands",
@@ -57,7 +67,7 @@ var serverCommands = []cli.Command{
 	},
 }
 
-var clientCommands = []cli.Command{
+var clientCommands = append(append(manifest.SubCommands(), wait.SubCommands()...), []cli.Command{
 	{
 		Name:        "blueprint",
 		ShortName:   "bl",
@@ -70,6 +80,19 @@ var clientCommands = []cli.Command{
 		Usage:       "Manages cloud related commands for server arrays, servers, generic images, ssh profiles, cloud providers and server plans",
 		Subcommands: append(cloud.SubCommands()),
 	},
+	{
+		Name:      "completion",
+		Usage:     "Prints the shell completion script, which completes commands, flags and resource IDs",
+		ArgsUsage: "bash|zsh|fish|powershell",
+		Action:    cmd.Completion,
+	},
+	{
+		Name:            cmd.CompleteCommandName,
+		Usage:           "Prints the candidates completing a command line",
+		Hidden:          true,
+		SkipFlagParsing: true,
+		Action:          cmd.Complete,
+	},
 	{
 		Name:        "events",
 		ShortName:   "ev",
@@ -88,6 +111,11 @@ var clientCommands = []cli.Command{
 		Usage:       "Manages network related commands for firewall profiles",
 		Subcommands: append(network.SubCommands()),
 	},
+	{
+		Name:        "scaler",
+		Usage:       "Scales server arrays following scheduled and metric based policies",
+		Subcommands: append(scaler.SubCommands()),
+	},
 	{
 		Name:        "storage",
 		ShortName:   "st",
@@ -106,7 +134,7 @@ var clientCommands = []cli.Command{
 		Usage:       "Manages wizard related commands for apps, locations, cloud providers, server plans",
 		Subcommands: append(wizard.SubCommands()),
 	},
-}
+}...)
 
 var appFlags = []cli.Flag{
 	cli.BoolFlag{
@@ -158,6 +186,11 @@ var appFlags = []cli.Flag{
 		Name:   "concerto-server-id",
 		Usage:  "Concerto Server ID",
 	},
+	cli.BoolFlag{
+		EnvVar: "CONCERTO_NO_CACHE",
+		Name:   "no-cache",
+		Usage:  "Retrieve reference data (labels, cloud providers, locations, server plans, generic images) from Concerto instead of the local cache",
+	},
 	cli.StringFlag{
 		EnvVar: "CONCERTO_FORMATTER",
 		Name:   "formatter",
@@ -221,7 +254,7 @@ func prepareFlags(c *cli.Context) error {
 		c.App.Commands = serverCommands
 	} else {
 		log.Debug("Setting client commands to concerto")
-		c.App.Commands = clientCommands
+		c.App.Commands = cmd.WithIDResolution(clientCommands)
 
 		// Excluding Server/Agent contextual flags
 		c.App.Flags = excludeFlags(c.App.VisibleFlags(), []string{"concerto-brownfield-token", "concerto-command-polling-token", "concerto-server-id"})
@@ -0,0 +1,86 @@
+package manifest
+
+import (
+	"github.com/codegangsta/cli"
+	"github.com/ingrammicro/concerto/cmd"
+)
+
+// SubCommands returns manifest commands
+func SubCommands() []cli.Command {
+	return []cli.Command{
+		{
+			Name:   "apply",
+			Usage:  "Creates or updates the resources declared in a manifest, in dependency order",
+			Action: cmd.ManifestApply,
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "file, f",
+					Usage: "Manifest file (YAML or JSON) declaring the resources, or \"-\" to read it from STDIN",
+				},
+				cli.BoolFlag{
+					Name:  "dry-run",
+					Usage: "Shows the changes which would be applied, without applying them",
+				},
+				cli.BoolFlag{
+					Name:  "yes, y",
+					Usage: "Applies the changes without asking for confirmation",
+				},
+			},
+		},
+		{
+			Name:   "delete",
+			Usage:  "Deletes the resources declared in a manifest, in reverse dependency order",
+			Action: cmd.ManifestDelete,
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "file, f",
+					Usage: "Manifest file (YAML or JSON) declaring the resources, or \"-\" to read it from STDIN",
+				},
+				cli.BoolFlag{
+					Name:  "dry-run",
+					Usage: "Shows the resources which would be deleted, without deleting them",
+				},
+				cli.BoolFlag{
+					Name:  "yes, y",
+					Usage: "Deletes the resources without asking for confirmation",
+				},
+			},
+		},
+		{
+			Name:   "export",
+			Usage:  "Exports the existing resources to a manifest, referencing them by name where possible",
+			Action: cmd.ManifestExport,
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "file, f",
+					Usage: "Manifest file to write, or \"-\" to write it to STDOUT",
+					Value: "-",
+				},
+				cli.StringFlag{
+					Name:  "format",
+					Usage: "Manifest format: yaml or json",
+					Value: "yaml",
+				},
+				cli.StringFlag{
+					Name:  "labels",
+					Usage: "A label selector, such as env=prod,tier!=db. Only the resources matching it are exported",
+				},
+				cli.StringFlag{
+					Name:  "resource-types",
+					Usage: "A list of comma separated resource types to export, as manifest sections (e.g. servers,templates). All of them by default",
+				},
+			},
+		},
+		{
+			Name:   "plan",
+			Usage:  "Shows the changes required to converge the current resources to the ones declared in a manifest",
+			Action: cmd.ManifestPlan,
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "file, f",
+					Usage: "Manifest file (YAML or JSON) declaring the resources, or \"-\" to read it from STDIN",
+				},
+			},
+		},
+	}
+}
@@ -19,7 +19,7 @@ func SubCommands() []cli.Command {
 				},
 				cli.StringFlag{
 					Name:  "labels",
-					Usage: "A list of comma separated label as a query filter",
+					Usage: "A label selector as a query filter, such as env=prod,tier!=db,team in (a,b),!deprecated",
 				},
 			},
 		},
@@ -53,7 +53,7 @@ func SubCommands() []cli.Command {
 				},
 				cli.StringFlag{
 					Name:  "labels",
-					Usage: "A list of comma separated label names to be associated with floating IP",
+					Usage: "A list of comma separated labels, as [namespace:]name[=value], to be associated with floating IP",
 				},
 			},
 		},
@@ -76,7 +76,7 @@ func SubCommands() []cli.Command {
 			Name:   "attach",
 			Usage:  "Attaches the floating IP to server",
 			Action: cmd.FloatingIPAttach,
-			Flags: []cli.Flag{
+			Flags: append([]cli.Flag{
 				cli.StringFlag{
 					Name:  "id",
 					Usage: "Floating IP Id",
@@ -85,7 +85,7 @@ func SubCommands() []cli.Command {
 					Name:  "server-id",
 					Usage: "Identifier of the server to attach the floating IP",
 				},
-			},
+			}, cmd.WaitFlags("attached")...),
 		},
 		{
 			Name:   "detach",
@@ -162,5 +162,45 @@ func SubCommands() []cli.Command {
 				},
 			},
 		},
+		{
+			Name:  "bulk",
+			Usage: "Applies an action to every one of the floating IPs matching the given labels",
+			Subcommands: []cli.Command{
+				{
+					Name:   "detach",
+					Usage:  "Detaches every floating IP matching the given labels",
+					Action: cmd.FloatingIPBulk,
+					Flags:  cmd.BulkFlags(),
+				},
+				{
+					Name:   "delete",
+					Usage:  "Deletes every floating IP matching the given labels",
+					Action: cmd.FloatingIPBulk,
+					Flags:  cmd.BulkFlags(),
+				},
+				{
+					Name:   "add-label",
+					Usage:  "Assigns a single label to every floating IP matching the given labels",
+					Action: cmd.FloatingIPBulk,
+					Flags: append(cmd.BulkFlags(),
+						cli.StringFlag{
+							Name:  "label",
+							Usage: "Label name",
+						},
+					),
+				},
+				{
+					Name:   "remove-label",
+					Usage:  "Unassigns a single label from every floating IP matching the given labels",
+					Action: cmd.FloatingIPBulk,
+					Flags: append(cmd.BulkFlags(),
+						cli.StringFlag{
+							Name:  "label",
+							Usage: "Label name",
+						},
+					),
+				},
+			},
+		},
 	}
 }
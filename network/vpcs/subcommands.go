@@ -15,7 +15,7 @@ func SubCommands() []cli.Command {
 			Flags: []cli.Flag{
 				cli.StringFlag{
 					Name:  "labels",
-					Usage: "A list of comma separated label as a query filter",
+					Usage: "A label selector as a query filter, such as env=prod,tier!=db,team in (a,b),!deprecated",
 				},
 			},
 		},
@@ -34,7 +34,7 @@ func SubCommands() []cli.Command {
 			Name:   "create",
 			Usage:  "Creates a new VPC",
 			Action: cmd.VPCCreate,
-			Flags: []cli.Flag{
+			Flags: append([]cli.Flag{
 				cli.StringFlag{
 					Name:  "name",
 					Usage: "Name of the VPC",
@@ -53,9 +53,9 @@ func SubCommands() []cli.Command {
 				},
 				cli.StringFlag{
 					Name:  "labels",
-					Usage: "A list of comma separated label names to be associated with VPC",
+					Usage: "A list of comma separated labels, as [namespace:]name[=value], to be associated with VPC",
 				},
-			},
+			}, cmd.WaitFlags("available")...),
 		},
 		{
 			Name:   "update",
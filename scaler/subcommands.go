@@ -0,0 +1,39 @@
+package scaler
+
+import (
+	"github.com/codegangsta/cli"
+	"github.com/ingrammicro/concerto/cmd"
+)
+
+// SubCommands returns scaler commands
+func SubCommands() []cli.Command {
+	return []cli.Command{
+		{
+			Name:   "run",
+			Usage:  "Keeps reconciling the size of the server arrays of a scaling policy, with scheduled bounds and metric thresholds",
+			Action: cmd.ScalerRun,
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "policy-file, f",
+					Usage: "Scaling policy file (YAML), with the size bounds, schedules, metric hooks and cooldown of every server array",
+				},
+				cli.BoolFlag{
+					Name:  "dry-run",
+					Usage: "Shows the decisions without resizing the server arrays",
+				},
+				cli.BoolFlag{
+					Name:  "once",
+					Usage: "Reconciles the server arrays once and exits",
+				},
+				cli.StringFlag{
+					Name:  "decision-log",
+					Usage: "File the decisions are appended to, as JSON lines",
+				},
+				cli.StringFlag{
+					Name:  "state-file",
+					Usage: "File keeping the time of the last change of every server array, for the cooldowns. By default, it is kept in the configuration directory",
+				},
+			},
+		},
+	}
+}
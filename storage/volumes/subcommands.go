@@ -19,7 +19,7 @@ func SubCommands() []cli.Command {
 				},
 				cli.StringFlag{
 					Name:  "labels",
-					Usage: "A list of comma separated label as a query filter",
+					Usage: "A label selector as a query filter, such as env=prod,tier!=db,team in (a,b),!deprecated",
 				},
 			},
 		},
@@ -57,7 +57,7 @@ func SubCommands() []cli.Command {
 				},
 				cli.StringFlag{
 					Name:  "labels",
-					Usage: "A list of comma separated label names to be associated with volume",
+					Usage: "A list of comma separated labels, as [namespace:]name[=value], to be associated with volume",
 				},
 			},
 		},
@@ -80,7 +80,7 @@ func SubCommands() []cli.Command {
 			Name:   "attach",
 			Usage:  "Attaches the volume to server",
 			Action: cmd.VolumeAttach,
-			Flags: []cli.Flag{
+			Flags: append([]cli.Flag{
 				cli.StringFlag{
 					Name:  "id",
 					Usage: "Volume Id",
@@ -89,7 +89,7 @@ func SubCommands() []cli.Command {
 					Name:  "server-id",
 					Usage: "Identifier of the server to attach the volume",
 				},
-			},
+			}, cmd.WaitFlags("attached")...),
 		},
 		{
 			Name:   "detach",
@@ -166,5 +166,45 @@ func SubCommands() []cli.Command {
 				},
 			},
 		},
+		{
+			Name:  "bulk",
+			Usage: "Applies an action to every one of the volumes matching the given labels",
+			Subcommands: []cli.Command{
+				{
+					Name:   "detach",
+					Usage:  "Detaches every volume matching the given labels",
+					Action: cmd.VolumeBulk,
+					Flags:  cmd.BulkFlags(),
+				},
+				{
+					Name:   "delete",
+					Usage:  "Deletes every volume matching the given labels",
+					Action: cmd.VolumeBulk,
+					Flags:  cmd.BulkFlags(),
+				},
+				{
+					Name:   "add-label",
+					Usage:  "Assigns a single label to every volume matching the given labels",
+					Action: cmd.VolumeBulk,
+					Flags: append(cmd.BulkFlags(),
+						cli.StringFlag{
+							Name:  "label",
+							Usage: "Label name",
+						},
+					),
+				},
+				{
+					Name:   "remove-label",
+					Usage:  "Unassigns a single label from every volume matching the given labels",
+					Action: cmd.VolumeBulk,
+					Flags: append(cmd.BulkFlags(),
+						cli.StringFlag{
+							Name:  "label",
+							Usage: "Label name",
+						},
+					),
+				},
+			},
+		},
 	}
 }
@@ -10,13 +10,13 @@ func GetAppData() []*types.WizardApp {
 		{
 			ID:                  "fakeID0",
 			Name:                "fakeName0",
-			FlavourRequirements: map[string]interface{}{"fakeFlavour01": "x", "fakeFlavour02": "y"},
+			FlavourRequirements: map[string]interface{}{"memory": 1024.0},
 			GenericImageID:      "fakeGenericImageID0",
 		},
 		{
 			ID:                  "fakeID1",
 			Name:                "fakeName1",
-			FlavourRequirements: map[string]interface{}{"fakeFlavour11": "a", "fakeFlavour12": "b"},
+			FlavourRequirements: map[string]interface{}{},
 			GenericImageID:      "fakeGenericImageID1",
 		},
 	}
@@ -13,6 +13,7 @@ func GetScriptCharacterizationsData() []*types.ScriptCharacterization {
 				Code:            "fakeCode1",
 				UUID:            "fakeUUID1",
 				AttachmentPaths: []string{"fakeAttachmentPath1"},
+				Interpreter:     "bash",
 			},
 			UUID:       "fakeUUID1",
 			Order:      0,
@@ -37,5 +38,8 @@ func GetDownloadAttachmentData() map[string]string {
 	return map[string]string{
 		"fakeEndpoint":      "/blueprint/attachments/fakeID1",
 		"fakeAttachmentDir": "/tmp/fakeFolderID1/attachments",
+		"fakeFileName":      "/tmp/fakeFolderID1/attachments/fakeFileName1",
+		"fakeETag":          "\"fakeETag1\"",
+		"fakeLastModified":  "Mon, 02 Jan 2006 15:04:05 GMT",
 	}
 }
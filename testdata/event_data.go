@@ -26,3 +26,32 @@ func GetEventData() []*types.Event {
 		},
 	}
 }
+
+// GetScriptConclusionEventData loads test data: the events of an operational script execution on a server, from
+// its request to its conclusion
+func GetScriptConclusionEventData() []*types.Event {
+
+	return []*types.Event{
+		{
+			ID:          "5aabb7551de0240abb000080",
+			Timestamp:   time.Date(2018, 3, 16, 10, 0, 0, 0, time.UTC),
+			Level:       "info",
+			Header:      "Execution of script characterization 5aabb7551de0240abb000070 requested",
+			Description: "",
+		},
+		{
+			ID:          "5aabb7551de0240abb000081",
+			Timestamp:   time.Date(2018, 3, 16, 10, 0, 5, 0, time.UTC),
+			Level:       "info",
+			Header:      "Execution of script characterization 5aabb7551de0240abb000071 requested",
+			Description: "",
+		},
+		{
+			ID:          "5aabb7551de0240abb000082",
+			Timestamp:   time.Date(2018, 3, 16, 10, 0, 30, 0, time.UTC),
+			Level:       "error",
+			Header:      "Script characterization 5aabb7551de0240abb000070 concluded",
+			Description: "Exit code: 1\nInstalling nginx\nE: Unable to locate package nginx",
+		},
+	}
+}
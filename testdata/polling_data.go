@@ -16,11 +16,12 @@ func GetPollingPingData() *types.PollingPing {
 func GetPollingCommandData() *types.PollingCommand {
 
 	return &types.PollingCommand{
-		ID:       "fakeID0",
-		Script:   "fakeScript0",
-		Stdout:   "fakeStdout0",
-		Stderr:   "fakeStderr0",
-		ExitCode: 0,
+		ID:          "fakeID0",
+		Script:      "fakeScript0",
+		Interpreter: "sh",
+		Stdout:      "fakeStdout0",
+		Stderr:      "fakeStderr0",
+		ExitCode:    0,
 	}
 }
 
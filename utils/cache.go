@@ -0,0 +1,228 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+// DefaultCacheTTL is the time reference data is served from the cache without revalidating it
+const DefaultCacheTTL = 5 * time.Minute
+
+// referenceDataPaths are the API paths whose responses are cached, by the path prefix of the
+// resources which invalidate them when created, updated or deleted
+var referenceDataPaths = map[string]*regexp.Regexp{
+	"/labels":                regexp.MustCompile(`^/labels$`),
+	"/cloud/cloud_providers": regexp.MustCompile(`^/cloud/cloud_providers(/[^/?]+/server_plans)?$`),
+	"/cloud/server_plans":    regexp.MustCompile(`^/cloud/server_plans/[^/?]+$`),
+	"/cloud/generic_images":  regexp.MustCompile(`^/cloud/generic_images$`),
+	"/wizard/locations":      regexp.MustCompile(`^/wizard/locations$`),
+}
+
+// conditionalConcertoService is a web service manager able to send conditional requests
+type conditionalConcertoService interface {
+	ConcertoService
+	GetConditional(path string, headers map[string]string) ([]byte, http.Header, int, error)
+}
+
+// cacheEntry is a cached response of the API
+type cacheEntry struct {
+	Path     string    `json:"path"`
+	ETag     string    `json:"etag,omitempty"`
+	StoredAt time.Time `json:"stored_at"`
+	Body     []byte    `json:"body"`
+}
+
+// CachedConcertoService is a web service manager which keeps the responses for reference data (labels, cloud
+// providers, locations, server plans and generic images) in a local cache. Cached responses are served while
+// they are fresher than the TTL, and revalidated with their ETag afterwards. Writes to a kind of reference data
+// invalidate its cached responses.
+type CachedConcertoService struct {
+	conditionalConcertoService
+	dir string
+	ttl time.Duration
+}
+
+// NewCachedConcertoService returns a web service manager caching reference data in dir
+func NewCachedConcertoService(concertoService conditionalConcertoService, dir string, ttl time.Duration) (*CachedConcertoService, error) {
+	if concertoService == nil {
+		return nil, fmt.Errorf("must initialize ConcertoService before using it")
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("cannot create cache directory: %v", err)
+	}
+	return &CachedConcertoService{conditionalConcertoService: concertoService, dir: dir, ttl: ttl}, nil
+}
+
+// NewHTTPConcertoServiceWithCache creates new http Concerto client based on config, caching reference data
+// per configuration profile and endpoint unless the cache is disabled
+func NewHTTPConcertoServiceWithCache(config *Config) (ConcertoService, error) {
+	hcs, err := NewHTTPConcertoService(config)
+	if err != nil {
+		return nil, err
+	}
+	if config.NoCache {
+		return hcs, nil
+	}
+
+	cs, err := NewCachedConcertoService(hcs, ReferenceCacheDir(config), DefaultCacheTTL)
+	if err != nil {
+		log.Warnf("Reference data won't be cached: %v", err)
+		return hcs, nil
+	}
+	return cs, nil
+}
+
+// ReferenceCacheDir returns the directory caching the reference data of the configuration profile and endpoint
+func ReferenceCacheDir(config *Config) string {
+	return ProfileCacheDir(config, "reference")
+}
+
+// ProfileCacheDir returns the directory of the named cache for the configuration profile and endpoint
+func ProfileCacheDir(config *Config, name string) string {
+	return filepath.Join(config.ConfLocation, "cache", name, cacheKey(config.ConfFile, config.Certificate.Cert, config.APIEndpoint))
+}
+
+// ProfileStateDir returns the directory of the named state for the configuration profile and endpoint. Unlike
+// caches, state is not expected to be discarded.
+func ProfileStateDir(config *Config, name string) string {
+	return filepath.Join(config.ConfLocation, "state", name, cacheKey(config.ConfFile, config.Certificate.Cert, config.APIEndpoint))
+}
+
+// cacheKey returns a file name identifying the given values
+func cacheKey(values ...string) string {
+	hash := sha256.Sum256([]byte(strings.Join(values, "\n")))
+	return hex.EncodeToString(hash[:16])
+}
+
+// isReferenceData tells whether the response of the path is cached, returning the prefix which invalidates it
+func isReferenceData(path string) (string, bool) {
+	for prefix, re := range referenceDataPaths {
+		if re.MatchString(path) {
+			return prefix, true
+		}
+	}
+	return "", false
+}
+
+func (cs *CachedConcertoService) entryPath(path string) string {
+	prefix, _ := isReferenceData(path)
+	return filepath.Join(cs.dir, fmt.Sprintf("%s-%s.json", cacheKey(prefix), cacheKey(path)))
+}
+
+func (cs *CachedConcertoService) load(path string) *cacheEntry {
+	data, err := ioutil.ReadFile(cs.entryPath(path))
+	if err != nil {
+		return nil
+	}
+	entry := &cacheEntry{}
+	if err = json.Unmarshal(data, entry); err != nil || entry.Path != path {
+		log.Debugf("Ignoring invalid cache entry for %s", path)
+		return nil
+	}
+	return entry
+}
+
+func (cs *CachedConcertoService) store(entry *cacheEntry) {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		log.Debugf("Couldn't cache %s: %v", entry.Path, err)
+		return
+	}
+	if err = WriteFileAtomic(cs.entryPath(entry.Path), data); err != nil {
+		log.Debugf("Couldn't cache %s: %v", entry.Path, err)
+	}
+}
+
+// WriteFileAtomic writes the file aside and renames it, so that concurrent readers never get partial contents
+func WriteFileAtomic(path string, data []byte) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path))
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+	return err
+}
+
+// Invalidate removes the cached responses invalidated by writes to the given path
+func (cs *CachedConcertoService) Invalidate(path string) {
+	for prefix := range referenceDataPaths {
+		if path != prefix && !strings.HasPrefix(path, prefix+"/") {
+			continue
+		}
+		log.Debugf("Invalidating cached %s", prefix)
+		entries, _ := filepath.Glob(filepath.Join(cs.dir, cacheKey(prefix)+"-*.json"))
+		for _, entry := range entries {
+			os.Remove(entry)
+		}
+	}
+}
+
+// Get sends GET request to Concerto API, serving reference data from the cache when possible
+func (cs *CachedConcertoService) Get(path string) ([]byte, int, error) {
+	if _, cached := isReferenceData(path); !cached {
+		return cs.conditionalConcertoService.Get(path)
+	}
+
+	entry := cs.load(path)
+	if entry != nil && time.Since(entry.StoredAt) < cs.ttl {
+		log.Debugf("Serving %s from cache", path)
+		return entry.Body, http.StatusOK, nil
+	}
+
+	headers := map[string]string{}
+	if entry != nil && entry.ETag != "" {
+		headers["If-None-Match"] = entry.ETag
+	}
+	body, header, status, err := cs.GetConditional(path, headers)
+	if err != nil {
+		return body, status, err
+	}
+	if status == http.StatusNotModified && entry != nil {
+		log.Debugf("Cached %s is still valid", path)
+		entry.StoredAt = time.Now()
+		cs.store(entry)
+		return entry.Body, http.StatusOK, nil
+	}
+	if status == http.StatusOK {
+		cs.store(&cacheEntry{Path: path, ETag: header.Get("ETag"), StoredAt: time.Now(), Body: body})
+	}
+	return body, status, nil
+}
+
+// Post sends POST request to Concerto API, invalidating the cached data it modifies
+func (cs *CachedConcertoService) Post(path string, payload *map[string]interface{}) ([]byte, int, error) {
+	defer cs.Invalidate(path)
+	return cs.conditionalConcertoService.Post(path, payload)
+}
+
+// Put sends PUT request to Concerto API, invalidating the cached data it modifies
+func (cs *CachedConcertoService) Put(path string, payload *map[string]interface{}) ([]byte, int, error) {
+	defer cs.Invalidate(path)
+	return cs.conditionalConcertoService.Put(path, payload)
+}
+
+// Delete sends DELETE request to Concerto API, invalidating the cached data it modifies
+func (cs *CachedConcertoService) Delete(path string) ([]byte, int, error) {
+	defer cs.Invalidate(path)
+	return cs.conditionalConcertoService.Delete(path)
+}
@@ -0,0 +1,83 @@
+package utils
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestCache(t *testing.T, ttl time.Duration) (*CachedConcertoService, *MockConcertoService, string) {
+	dir, err := ioutil.TempDir("", "cio-cache")
+	assert.Nil(t, err, "Couldn't create temp dir")
+
+	ms := &MockConcertoService{}
+	cs, err := NewCachedConcertoService(ms, dir, ttl)
+	assert.Nil(t, err, "Couldn't create cached service")
+	return cs, ms, dir
+}
+
+func TestCachedConcertoServiceServesFreshEntries(t *testing.T) {
+	assert := assert.New(t)
+	cs, ms, dir := newTestCache(t, time.Hour)
+	defer os.RemoveAll(dir)
+
+	ms.On("GetConditional", "/labels", map[string]string{}).Return([]byte(`[{"id":"1"}]`), http.Header{}, 200, nil).Once()
+	for i := 0; i < 2; i++ {
+		body, status, err := cs.Get("/labels")
+		assert.Nil(err, "Error getting labels")
+		assert.Equal(200, status, "Unexpected status")
+		assert.Equal(`[{"id":"1"}]`, string(body), "Unexpected body")
+	}
+	ms.AssertExpectations(t)
+}
+
+func TestCachedConcertoServiceRevalidatesWithETag(t *testing.T) {
+	assert := assert.New(t)
+	cs, ms, dir := newTestCache(t, 0)
+	defer os.RemoveAll(dir)
+
+	ms.On("GetConditional", "/cloud/generic_images", map[string]string{}).Return([]byte(`[]`), http.Header{"Etag": {`"v1"`}}, 200, nil).Once()
+	ms.On("GetConditional", "/cloud/generic_images", map[string]string{"If-None-Match": `"v1"`}).Return([]byte{}, http.Header{}, 304, nil).Once()
+
+	cs.Get("/cloud/generic_images")
+	body, status, err := cs.Get("/cloud/generic_images")
+	assert.Nil(err, "Error getting generic images")
+	assert.Equal(200, status, "Not modified data should be served from cache")
+	assert.Equal(`[]`, string(body), "Unexpected body")
+	ms.AssertExpectations(t)
+}
+
+func TestCachedConcertoServiceInvalidatesOnWrite(t *testing.T) {
+	assert := assert.New(t)
+	cs, ms, dir := newTestCache(t, time.Hour)
+	defer os.RemoveAll(dir)
+
+	ms.On("GetConditional", "/labels", map[string]string{}).Return([]byte(`[]`), http.Header{}, 200, nil).Twice()
+	ms.On("Delete", "/labels/1").Return([]byte{}, 204, nil).Once()
+
+	cs.Get("/labels")
+	_, _, err := cs.Delete("/labels/1")
+	assert.Nil(err, "Error deleting label")
+	cs.Get("/labels")
+	ms.AssertExpectations(t)
+}
+
+func TestCachedConcertoServiceSkipsOtherData(t *testing.T) {
+	assert := assert.New(t)
+	cs, ms, dir := newTestCache(t, time.Hour)
+	defer os.RemoveAll(dir)
+
+	ms.On("Get", "/cloud/servers").Return([]byte(`[]`), 200, nil).Twice()
+	ms.On("GetConditional", "/labels", map[string]string{}).Return([]byte(`error`), http.Header{}, 500, nil).Twice()
+
+	cs.Get("/cloud/servers")
+	cs.Get("/cloud/servers")
+	_, status, _ := cs.Get("/labels")
+	assert.Equal(500, status, "Unexpected status")
+	cs.Get("/labels")
+	ms.AssertExpectations(t)
+}
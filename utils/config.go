@@ -41,6 +41,7 @@ type Config struct {
 	LogLevel            string          `xml:"log_level,attr"`
 	Certificate         Cert            `xml:"ssl"`
 	BootstrapConfig     BootstrapConfig `xml:"bootstrap"`
+	PollingConfig       PollingConfig   `xml:"polling"`
 	ConfLocation        string
 	ConfFile            string
 	IsHost              bool
@@ -50,6 +51,7 @@ type Config struct {
 	ServerID            string
 	CurrentUserName     string
 	CurrentUserIsAdmin  bool
+	NoCache             bool
 }
 
 // Cert stores cert files location
@@ -67,6 +69,26 @@ type BootstrapConfig struct {
 	RunOnce              bool `xml:"run_once,attr"`
 }
 
+// PollingConfig stores configuration specific to the polling command
+type PollingConfig struct {
+	Sandbox      bool           `xml:"sandbox,attr"`
+	RunAsUser    string         `xml:"run_as_user,attr"`
+	RunAsGroup   string         `xml:"run_as_group,attr"`
+	EnvAllowlist string         `xml:"env_allowlist,attr"`
+	Limits       ResourceLimits `xml:"limits"`
+}
+
+// ResourceLimits stores the resource-limit profile applied to sandboxed commands.
+// Zero values mean no limit.
+type ResourceLimits struct {
+	CPUSeconds  int    `xml:"cpu_seconds,attr"`
+	CPUPercent  int    `xml:"cpu_percent,attr"`
+	MemoryMB    int    `xml:"memory_mb,attr"`
+	OpenFiles   int    `xml:"open_files,attr"`
+	Processes   int    `xml:"processes,attr"`
+	CgroupSlice string `xml:"cgroup_slice,attr"`
+}
+
 var cachedConfig *Config
 
 // GetConcertoConfig returns concerto configuration
@@ -251,6 +273,11 @@ func (config *Config) readConcertoConfig(c *cli.Context) error {
 		config.Certificate.Ca = overwCa
 	}
 
+	if c.Bool("no-cache") {
+		log.Debug("Reference data cache disabled from env/args")
+		config.NoCache = true
+	}
+
 	// if endpoint empty set default
 	// we can't set the default from flags, because it would overwrite config file
 	if config.APIEndpoint == "" {
@@ -0,0 +1,253 @@
+// Package cookbook validates the metadata of Chef cookbooks and packages them in deterministic archives
+package cookbook
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultConstraint is the version constraint of the dependencies declared without one
+const DefaultConstraint = ">= 0.0.0"
+
+var (
+	nameRegexp       = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+	versionRegexp    = regexp.MustCompile(`^\d+\.\d+(\.\d+)?$`)
+	constraintRegexp = regexp.MustCompile(`^(=|!=|>|<|>=|<=|~>)?\s*\d+(\.\d+){0,2}$`)
+
+	// metadata.rb statements, with single or double quoted arguments
+	rbNameRegexp    = regexp.MustCompile(`^\s*name\s*\(?\s*['"]([^'"]*)['"]`)
+	rbVersionRegexp = regexp.MustCompile(`^\s*version\s*\(?\s*['"]([^'"]*)['"]`)
+	rbDependsRegexp = regexp.MustCompile(`^\s*depends\s*\(?\s*['"]([^'"]*)['"](\s*,\s*['"]([^'"]*)['"])?`)
+
+	// excludedNames are never packaged, wherever they are
+	excludedNames = map[string]bool{".git": true, ".svn": true, ".hg": true, ".bzr": true, "CVS": true, ".DS_Store": true}
+	// excludedTopNames are not packaged when they are at the top of the cookbook
+	excludedTopNames = map[string]bool{
+		"test": true, "tests": true, "spec": true, ".kitchen": true, ".kitchen.yml": true, "kitchen.yml": true,
+		".rspec": true, ".gitignore": true, ".gitattributes": true, ".travis.yml": true,
+	}
+)
+
+// Metadata is the identity and dependencies of a cookbook
+type Metadata struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	// Dependencies maps the cookbooks the cookbook depends on to their version constraints
+	Dependencies map[string]string `json:"dependencies"`
+}
+
+// ReadMetadata reads the metadata of the cookbook in the directory, from metadata.json or, when it is missing,
+// metadata.rb, and validates it
+func ReadMetadata(dir string) (*Metadata, error) {
+	metadata := &Metadata{}
+	data, err := ioutil.ReadFile(filepath.Join(dir, "metadata.json"))
+	if err == nil {
+		if err = json.Unmarshal(data, metadata); err != nil {
+			return nil, fmt.Errorf("invalid metadata.json: %v", err)
+		}
+	} else if os.IsNotExist(err) {
+		data, err = ioutil.ReadFile(filepath.Join(dir, "metadata.rb"))
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("no metadata.json nor metadata.rb found in %s", dir)
+		}
+		if err != nil {
+			return nil, err
+		}
+		metadata = parseMetadataRb(string(data))
+	} else {
+		return nil, err
+	}
+
+	if err = metadata.Validate(); err != nil {
+		return nil, err
+	}
+	return metadata, nil
+}
+
+// parseMetadataRb extracts the name, version and dependencies statements of a metadata.rb
+func parseMetadataRb(source string) *Metadata {
+	metadata := &Metadata{Dependencies: map[string]string{}}
+	for _, line := range strings.Split(source, "\n") {
+		if values := rbNameRegexp.FindStringSubmatch(line); values != nil {
+			metadata.Name = values[1]
+		} else if values = rbVersionRegexp.FindStringSubmatch(line); values != nil {
+			metadata.Version = values[1]
+		} else if values = rbDependsRegexp.FindStringSubmatch(line); values != nil {
+			metadata.Dependencies[values[1]] = values[3]
+		}
+	}
+	return metadata
+}
+
+// Validate checks the name, version and dependencies of the cookbook. Dependencies without constraint get the
+// default one.
+func (m *Metadata) Validate() error {
+	if m.Name == "" {
+		return fmt.Errorf("invalid metadata: name is required")
+	}
+	if !nameRegexp.MatchString(m.Name) {
+		return fmt.Errorf("invalid metadata: name %q can only have letters, digits, underscores and dashes", m.Name)
+	}
+	if m.Version == "" {
+		return fmt.Errorf("invalid metadata: version is required")
+	}
+	if !versionRegexp.MatchString(m.Version) {
+		return fmt.Errorf("invalid metadata: version %q is not as x.y.z", m.Version)
+	}
+	if m.Dependencies == nil {
+		m.Dependencies = map[string]string{}
+	}
+	for name, constraint := range m.Dependencies {
+		if !nameRegexp.MatchString(name) {
+			return fmt.Errorf("invalid metadata: dependency name %q can only have letters, digits, underscores and dashes", name)
+		}
+		if name == m.Name {
+			return fmt.Errorf("invalid metadata: cookbook %s depends on itself", name)
+		}
+		if constraint == "" {
+			m.Dependencies[name] = DefaultConstraint
+			continue
+		}
+		if !constraintRegexp.MatchString(strings.TrimSpace(constraint)) {
+			return fmt.Errorf("invalid metadata: version constraint %q of dependency %s", constraint, name)
+		}
+	}
+	return nil
+}
+
+// excluded tells whether the file, with its path relative to the cookbook, is left out of the archive
+func excluded(relPath string) bool {
+	parts := strings.Split(filepath.ToSlash(relPath), "/")
+	if excludedTopNames[parts[0]] {
+		return true
+	}
+	for _, part := range parts {
+		if excludedNames[part] {
+			return true
+		}
+	}
+	base := parts[len(parts)-1]
+	return strings.HasSuffix(base, "~") || strings.HasSuffix(base, ".swp")
+}
+
+// Files returns the paths, relative to the cookbook directory and sorted, of the files to package
+func Files(dir string) ([]string, error) {
+	files := []string{}
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		relPath, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		if relPath == "." {
+			return nil
+		}
+		if excluded(relPath) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info.IsDir() {
+			return nil
+		}
+		if !info.Mode().IsRegular() {
+			return fmt.Errorf("cannot package %s: only regular files are supported", relPath)
+		}
+		files = append(files, filepath.ToSlash(relPath))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// Package writes the cookbook in the directory as a tar.gz archive, with its files under a directory named as
+// the cookbook. The archive only depends on the paths, contents and executable bit of the files, so packaging
+// the same cookbook twice gives the same bytes. It returns the paths of the packaged files.
+func Package(dir string, name string, w io.Writer) ([]string, error) {
+	files, err := Files(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	gz, err := gzip.NewWriterLevel(w, gzip.BestCompression)
+	if err != nil {
+		return nil, err
+	}
+	gz.ModTime = time.Unix(0, 0)
+	tw := tar.NewWriter(gz)
+
+	dirs := map[string]bool{}
+	for _, file := range files {
+		// parent directories first, once
+		parts := strings.Split(file, "/")
+		for i := 0; i < len(parts); i++ {
+			parent := strings.Join(append([]string{name}, parts[:i]...), "/") + "/"
+			if dirs[parent] {
+				continue
+			}
+			dirs[parent] = true
+			if err = tw.WriteHeader(archiveHeader(parent, tar.TypeDir, 0755, 0)); err != nil {
+				return nil, err
+			}
+		}
+		if err = addFile(tw, filepath.Join(dir, filepath.FromSlash(file)), name+"/"+file); err != nil {
+			return nil, err
+		}
+	}
+
+	if err = tw.Close(); err != nil {
+		return nil, err
+	}
+	if err = gz.Close(); err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// archiveHeader returns a header without owner nor times
+func archiveHeader(name string, typeflag byte, mode int64, size int64) *tar.Header {
+	return &tar.Header{
+		Name:     name,
+		Typeflag: typeflag,
+		Mode:     mode,
+		Size:     size,
+		ModTime:  time.Unix(0, 0),
+	}
+}
+
+func addFile(tw *tar.Writer, path string, name string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	mode := int64(0644)
+	if info.Mode()&0111 != 0 {
+		mode = 0755
+	}
+	if err = tw.WriteHeader(archiveHeader(name, tar.TypeReg, mode, info.Size())); err != nil {
+		return err
+	}
+	_, err = io.CopyN(tw, f, info.Size())
+	return err
+}
@@ -0,0 +1,119 @@
+package cookbook
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeCookbook(t *testing.T, files map[string]string) string {
+	dir, err := ioutil.TempDir("", "cookbook")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err = os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err = ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestReadMetadata(t *testing.T) {
+	assert := assert.New(t)
+
+	dir := writeCookbook(t, map[string]string{"metadata.rb": `
+name             'webserver'
+maintainer       'Ops'
+version          "1.2.3"
+depends 'apt'
+depends "nginx", '~> 2.7'
+`})
+	defer os.RemoveAll(dir)
+	metadata, err := ReadMetadata(dir)
+	assert.Nil(err, "Couldn't read metadata.rb")
+	assert.Equal("webserver", metadata.Name, "Unexpected name")
+	assert.Equal("1.2.3", metadata.Version, "Unexpected version")
+	assert.Equal(map[string]string{"apt": DefaultConstraint, "nginx": "~> 2.7"}, metadata.Dependencies, "Unexpected dependencies")
+
+	dir = writeCookbook(t, map[string]string{"metadata.json": `{"name": "db", "version": "0.1.0", "dependencies": {"mysql": ">= 8.0"}}`})
+	defer os.RemoveAll(dir)
+	metadata, err = ReadMetadata(dir)
+	assert.Nil(err, "Couldn't read metadata.json")
+	assert.Equal("db", metadata.Name, "Unexpected name")
+	assert.Equal(">= 8.0", metadata.Dependencies["mysql"], "Unexpected dependencies")
+
+	for _, invalid := range []*Metadata{
+		{Version: "1.0.0"},
+		{Name: "web server", Version: "1.0.0"},
+		{Name: "web"},
+		{Name: "web", Version: "1.0.0.1"},
+		{Name: "web", Version: "1.0.0", Dependencies: map[string]string{"apt": "latest"}},
+		{Name: "web", Version: "1.0.0", Dependencies: map[string]string{"web": ""}},
+	} {
+		assert.NotNil(invalid.Validate(), "%v should be invalid", invalid)
+	}
+
+	dir = writeCookbook(t, map[string]string{"README.md": ""})
+	defer os.RemoveAll(dir)
+	_, err = ReadMetadata(dir)
+	assert.NotNil(err, "Missing metadata should be invalid")
+}
+
+func TestPackage(t *testing.T) {
+	assert := assert.New(t)
+
+	dir := writeCookbook(t, map[string]string{
+		"metadata.rb":                 "name 'web'\nversion '1.0.0'\n",
+		"recipes/default.rb":          "package 'nginx'",
+		"templates/default/site.erb":  "<%= @name %>",
+		".git/config":                 "",
+		"recipes/.svn/entries":        "",
+		"test/integration/default.rb": "",
+		"spec/default_spec.rb":        "",
+		".kitchen.yml":                "",
+		"recipes/default.rb~":         "",
+	})
+	defer os.RemoveAll(dir)
+
+	var first bytes.Buffer
+	files, err := Package(dir, "web", &first)
+	assert.Nil(err, "Couldn't package cookbook")
+	assert.Equal([]string{"metadata.rb", "recipes/default.rb", "templates/default/site.erb"}, files, "Unexpected packaged files")
+
+	// newer times must not change the archive
+	later := time.Now().Add(time.Hour)
+	assert.Nil(os.Chtimes(filepath.Join(dir, "metadata.rb"), later, later))
+	var second bytes.Buffer
+	_, err = Package(dir, "web", &second)
+	assert.Nil(err, "Couldn't package cookbook")
+	assert.Equal(first.Bytes(), second.Bytes(), "Archives should be identical")
+
+	gz, err := gzip.NewReader(&first)
+	assert.Nil(err, "Invalid gzip")
+	tr := tar.NewReader(gz)
+	names := []string{}
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		assert.Nil(err, "Invalid tar")
+		assert.Equal(0, header.Uid, "Owner should be zeroed")
+		assert.Equal(int64(0), header.ModTime.Unix(), "Time should be zeroed")
+		names = append(names, header.Name)
+	}
+	assert.Equal([]string{"web/", "web/metadata.rb", "web/recipes/", "web/recipes/default.rb", "web/templates/", "web/templates/default/", "web/templates/default/site.erb"}, names, "Unexpected entries")
+}
@@ -9,6 +9,7 @@ import (
 	"os"
 	"os/exec"
 	"runtime"
+	"sort"
 	"strings"
 	"syscall"
 	"time"
@@ -34,49 +35,75 @@ func extractExitCode(err error) int {
 	return 0
 }
 
-func ExecCode(code string, path string, filename string) (output string, exitCode int, startedAt time.Time, finishedAt time.Time) {
-	var err error
-	var tmp *os.File
+// ExecCode saves the code in a file within path and runs it with the given interpreter and environment.
+// A nil environment means the process environment is inherited.
+func ExecCode(code string, path string, filename string, interpreter string, env []string) (output string, exitCode int, startedAt time.Time, finishedAt time.Time, err error) {
+	return ExecCodeWithRunner(code, path, filename, interpreter, env, nil)
+}
 
-	if runtime.GOOS == "windows" {
-		tmp, err = os.Create(fmt.Sprintf("%s/%s.bat", path, filename))
-	} else {
-		tmp, err = os.Create(fmt.Sprintf("%s/%s", path, filename))
+// ExecCodeWithRunner is ExecCode with the command line running the script prefixed by the runner one, such as a
+// container runtime invocation mounting path
+func ExecCodeWithRunner(code string, path string, filename string, interpreter string, env []string, runner []string) (output string, exitCode int, startedAt time.Time, finishedAt time.Time, err error) {
+	si, err := resolveInterpreter(interpreter, code)
+	if err != nil {
+		return "", 0, startedAt, finishedAt, fmt.Errorf("error resolving script interpreter: %v", err)
 	}
 
+	tmp, err := os.Create(fmt.Sprintf("%s/%s", path, si.FileName(filename)))
 	if err != nil {
-		log.Fatalf("Error creating temp file: %v", err)
+		return "", 0, startedAt, finishedAt, fmt.Errorf("error creating temp file: %v", err)
 	}
 
 	defer tmp.Close()
 
 	_, err = tmp.WriteString(code)
 	if err != nil {
-		log.Fatalf("Error writing to file: %v", err)
+		return "", 0, startedAt, finishedAt, fmt.Errorf("error writing to file: %v", err)
 	}
 
-	os.Chmod(tmp.Name(), 0777)
+	err = os.Chmod(tmp.Name(), 0777)
 	if err != nil {
-		log.Fatalf("Error changing permission to file: %v", err)
+		return "", 0, startedAt, finishedAt, fmt.Errorf("error changing permission to file: %v", err)
 	}
 
-	return RunFile(tmp.Name())
+	args := append(append([]string{}, runner...), si.CommandArgs(tmp.Name())...)
+	output, exitCode, startedAt, finishedAt = runFileArgs(args, env)
+	return output, exitCode, startedAt, finishedAt, nil
+}
+
+// ScriptEnvironment returns the process environment extended with the attachments directory and the script parameters
+func ScriptEnvironment(attachmentDir string, parameters map[string]string) []string {
+	env := append(os.Environ(), fmt.Sprintf("ATTACHMENT_DIR=%s", attachmentDir))
+
+	log.Infof("Environment Variables")
+	names := make([]string, 0, len(parameters))
+	for name := range parameters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		env = append(env, fmt.Sprintf("%s=%s", name, parameters[name]))
+		log.Infof("\t - %s=%s", name, parameters[name])
+	}
+	return env
 }
 
+// RunFile runs the given file with the platform default shell
 func RunFile(command string) (output string, exitCode int, startedAt time.Time, finishedAt time.Time) {
+	if runtime.GOOS == "windows" {
+		return runFileArgs([]string{"cmd", "/C", command}, nil)
+	}
+	return runFileArgs([]string{"/bin/sh", command}, nil)
+}
 
-	var cmd *exec.Cmd
+func runFileArgs(args []string, env []string) (output string, exitCode int, startedAt time.Time, finishedAt time.Time) {
 
 	var b bytes.Buffer
 	buffer := bufio.NewWriter(&b)
 
-	if runtime.GOOS == "windows" {
-		log.Infof("Command: %s", command)
-		cmd = exec.Command("cmd", "/C", command)
-	} else {
-		log.Infof("Command: %s %s", "/bin/sh", command)
-		cmd = exec.Command("/bin/sh", command)
-	}
+	log.Infof("Command: %s", strings.Join(args, " "))
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Env = env
 
 	stdout, err := cmd.StdoutPipe()
 	if err != nil {
@@ -148,12 +175,13 @@ func RunCmd(command string) (output string, exitCode int, startedAt time.Time, f
 }
 
 // Save script/command in a temp file
-func createCommandWithFilename(command string) (cmd *exec.Cmd, cmdFileName string) {
+func createCommandWithFilename(command string, interpreter string) (cmd *exec.Cmd, cmdFileName string, err error) {
 
-	cmdFileName = strings.Join([]string{time.Now().Format(TimeLayoutYYYYMMDDHHMMSS), "_", RandomString(10)}, "")
-	if runtime.GOOS == "windows" {
-		cmdFileName = strings.Join([]string{cmdFileName, ".bat"}, "")
+	si, err := resolveInterpreter(interpreter, command)
+	if err != nil {
+		return nil, "", err
 	}
+	cmdFileName = si.FileName(strings.Join([]string{time.Now().Format(TimeLayoutYYYYMMDDHHMMSS), "_", RandomString(10)}, ""))
 
 	// Writes content to file
 	if err := ioutil.WriteFile(cmdFileName, []byte(command), 0600); err != nil {
@@ -161,12 +189,9 @@ func createCommandWithFilename(command string) (cmd *exec.Cmd, cmdFileName strin
 	}
 
 	// Creates command
-	if runtime.GOOS == "windows" {
-		cmd = exec.Command("cmd", "/C", cmdFileName)
-	} else {
-		cmd = exec.Command("/bin/sh", cmdFileName)
-	}
-	return
+	args := si.CommandArgs(cmdFileName)
+	cmd = exec.Command(args[0], args[1:]...)
+	return cmd, cmdFileName, nil
 }
 
 // Remove temp file
@@ -177,16 +202,45 @@ func deleteTmpCommandFilename(cmdFileName string) {
 	}
 }
 
-// RunTracedCmd executes the received command and manages two output pipes (output and error)
+// Remove temp working directory
+func deleteTmpWorkDir(workDir string) {
+	if err := os.RemoveAll(workDir); err != nil {
+		log.Warn("Temp working directory cannot be removed", err.Error())
+	}
+}
+
+// RunTracedCmd executes the received command with the given interpreter and manages two output pipes (output and error)
+// When a sandbox is given, the command runs isolated in a private working directory.
 // It shouldn't throw any exception/error or stop the process.
-func RunTracedCmd(command string) (exitCode int, stdOut string, stdErr string, startedAt time.Time, finishedAt time.Time) {
+func RunTracedCmd(command string, interpreter string, sandbox *Sandbox) (exitCode int, stdOut string, stdErr string, startedAt time.Time, finishedAt time.Time) {
 	log.Debug("RunTracedCmd")
 
-	// Saves script/command in a temp file
-	var cmd, cmdFileName = createCommandWithFilename(command)
+	var cmd *exec.Cmd
+	var sc *sandboxedCommand
+	if sandbox == nil {
+		// Saves script/command in a temp file
+		var cmdFileName string
+		var err error
+		cmd, cmdFileName, err = createCommandWithFilename(command, interpreter)
+		if err != nil {
+			log.Error("cannot prepare command: ", err)
+			return 1, "", err.Error(), time.Now(), time.Now()
+		}
 
-	// Removes temp file
-	defer deleteTmpCommandFilename(cmdFileName)
+		// Removes temp file
+		defer deleteTmpCommandFilename(cmdFileName)
+	} else {
+		var err error
+		sc, err = createSandboxedCommand(command, interpreter, sandbox)
+		if err != nil {
+			log.Error("cannot prepare sandboxed command: ", err)
+			return 1, "", err.Error(), time.Now(), time.Now()
+		}
+		cmd = sc.Cmd
+
+		// Removes working directory and cgroup
+		defer sc.cleanup()
+	}
 
 	stdoutIn, err := cmd.StdoutPipe()
 	if err != nil {
@@ -205,6 +259,9 @@ func RunTracedCmd(command string) (exitCode int, stdOut string, stdErr string, s
 
 	if err = cmd.Start(); err != nil {
 		log.Error("cmd.Start() failed: ", err)
+	} else if sc != nil {
+		// the command is held until attached, so no process escapes its cgroup
+		sc.release()
 	}
 
 	go func() {
@@ -247,7 +304,10 @@ func RunContinuousCmd(fn func(chunk string) error, command string, thresholdTime
 	log.Debug("RunContinuousCmd")
 
 	// Saves script/command in a temp file
-	var cmd, cmdFileName = createCommandWithFilename(command)
+	cmd, cmdFileName, err := createCommandWithFilename(command, "")
+	if err != nil {
+		return 1, fmt.Errorf("cannot prepare the specified command %v", err)
+	}
 
 	// Removes temp file
 	defer deleteTmpCommandFilename(cmdFileName)
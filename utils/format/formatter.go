@@ -16,6 +16,9 @@ var osExit = os.Exit
 type Formatter interface {
 	PrintItem(item interface{}) error
 	PrintList(items interface{}) error
+	// PrintListRows prints the items of a list, along with its headers only when asked to, so that a list printed
+	// in several batches shows them once
+	PrintListRows(items interface{}, headers bool) error
 	PrintError(context string, err error)
 	PrintFatal(context string, err error)
 }
@@ -57,6 +57,11 @@ func (f *JSONFormatter) PrintList(items interface{}) error {
 	return nil
 }
 
+// PrintListRows prints item list, as PrintList does, since JSON lists have no headers
+func (f *JSONFormatter) PrintListRows(items interface{}, headers bool) error {
+	return f.PrintList(items)
+}
+
 // PrintError prints an error
 func (f *JSONFormatter) PrintError(context string, err error) {
 	log.Debug("PrintError")
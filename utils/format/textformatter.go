@@ -186,6 +186,30 @@ func (f *TextFormatter) PrintList(items interface{}) error {
 	return nil
 }
 
+// PrintListRows prints item list, with its headers only when asked to, and without a trailing blank line
+func (f *TextFormatter) PrintListRows(items interface{}, headers bool) error {
+	log.Debug("PrintListRows")
+
+	// should be an array
+	its := reflect.ValueOf(items)
+	t := its.Type().Kind()
+	if t != reflect.Slice {
+		return fmt.Errorf("couldn't print list. Expected slice, but received %s", t.String())
+	}
+
+	w := tabwriter.NewWriter(f.output, 15, 1, 3, ' ', 0)
+	if headers {
+		f.printListHeadersAux(w, reflect.TypeOf(items).Elem())
+		fmt.Fprintln(w)
+	}
+
+	f.printListBodyAux(w, reflect.ValueOf(items), 0)
+
+	w.Flush()
+
+	return nil
+}
+
 // PrintError prints an error
 func (f *TextFormatter) PrintError(context string, err error) {
 	log.Debug("PrintError")
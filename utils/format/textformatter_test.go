@@ -242,3 +242,24 @@ func TestPrintFatalTXT(t *testing.T) {
 		t.Errorf("Expected exit code: %d, got: %d", exp, got)
 	}
 }
+
+func TestPrintListRowsTXT(t *testing.T) {
+
+	assert := assert.New(t)
+	serversIn := testdata.GetServerData()
+	serversOut := cloud.GetServerListMocked(t, serversIn)
+
+	var b bytes.Buffer
+	mockOut := bufio.NewWriter(&b)
+	InitializeFormatter("text", mockOut)
+	f := GetFormatter()
+	assert.NotNil(f, "Formatter")
+
+	err := f.PrintListRows(serversOut[:1], true)
+	assert.Nil(err, "Text formatter PrintListRows error")
+	err = f.PrintListRows(serversOut[1:], false)
+	assert.Nil(err, "Text formatter PrintListRows error")
+	mockOut.Flush()
+
+	assert.Regexp(fmt.Sprintf("^ID.*\n%s.*\n%s.*\n$", serversOut[0].ID, serversOut[1].ID), b.String(), "Headers should be printed once")
+}
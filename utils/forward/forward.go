@@ -0,0 +1,236 @@
+// Package forward delivers IMCO events to external sinks, such as syslog servers, JSON Lines files and webhooks
+package forward
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/ingrammicro/concerto/api/types"
+	"github.com/ingrammicro/concerto/utils"
+)
+
+const (
+	// DefaultRetries is the number of times events are sent again to a sink which failed to receive them
+	DefaultRetries = 5
+	// DefaultRetryInterval is the time waited before the first retry, which grows linearly with each of them
+	DefaultRetryInterval = 2 * time.Second
+)
+
+// Sink receives the events forwarded
+type Sink interface {
+	// Send delivers the events, in order. An error means that they may not have been delivered, so they
+	// will be sent again.
+	Send(events []*types.Event) error
+	Close() error
+}
+
+// SinkOptions configures the sinks created by NewSink
+type SinkOptions struct {
+	// WebhookSecret is the key signing the webhook requests
+	WebhookSecret string
+	// SyslogFacility is the facility of the syslog messages, local0 by default
+	SyslogFacility string
+}
+
+// NewSink returns the sink for an URL:
+//
+//	syslog+udp://host:port, syslog+tcp://host:port, syslog+tls://host:port[?ca-cert=file]
+//	file:///path/events.jsonl[?max-size=bytes&max-files=n]
+//	http://host/path, https://host/path
+func NewSink(rawURL string, options *SinkOptions) (Sink, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sink %s: %v", rawURL, err)
+	}
+	if options == nil {
+		options = &SinkOptions{}
+	}
+
+	switch u.Scheme {
+	case "syslog+udp", "syslog+tcp", "syslog+tls":
+		return NewSyslogSink(u.Scheme[len("syslog+"):], u.Host, options.SyslogFacility, u.Query().Get("ca-cert"))
+	case "file":
+		maxSize, maxFiles := int64(DefaultMaxFileSize), DefaultMaxFiles
+		if value := u.Query().Get("max-size"); value != "" {
+			if _, err := fmt.Sscanf(value, "%d", &maxSize); err != nil {
+				return nil, fmt.Errorf("invalid sink %s: invalid max-size %s", rawURL, value)
+			}
+		}
+		if value := u.Query().Get("max-files"); value != "" {
+			if _, err := fmt.Sscanf(value, "%d", &maxFiles); err != nil {
+				return nil, fmt.Errorf("invalid sink %s: invalid max-files %s", rawURL, value)
+			}
+		}
+		return NewJSONLSink(u.Path, maxSize, maxFiles)
+	case "http", "https":
+		return NewWebhookSink(rawURL, options.WebhookSecret), nil
+	}
+	return nil, fmt.Errorf("invalid sink %s: unsupported scheme %q", rawURL, u.Scheme)
+}
+
+// State is the high-water mark of the forwarded events: the timestamp of the latest ones and their IDs, as
+// several events may share it
+type State struct {
+	Timestamp time.Time `json:"timestamp"`
+	IDs       []string  `json:"ids"`
+}
+
+// delivered tells whether the event is covered by the high-water mark
+func (s *State) delivered(event *types.Event) bool {
+	if event.Timestamp.Before(s.Timestamp) {
+		return true
+	}
+	return event.Timestamp.Equal(s.Timestamp) && utils.Contains(s.IDs, event.ID)
+}
+
+// advance moves the high-water mark past the events
+func (s *State) advance(events []*types.Event) {
+	for _, event := range events {
+		if event.Timestamp.After(s.Timestamp) {
+			s.Timestamp, s.IDs = event.Timestamp, []string{}
+		}
+		if event.Timestamp.Equal(s.Timestamp) && !utils.Contains(s.IDs, event.ID) {
+			s.IDs = append(s.IDs, event.ID)
+		}
+	}
+}
+
+// Forwarder polls for events and delivers the new ones to every sink, at least once. The high-water mark is
+// persisted once the events have been delivered to all of the sinks, so they are sent again after a failure.
+type Forwarder struct {
+	get           func(filter *types.EventFilter) ([]*types.Event, error)
+	sinks         []Sink
+	statePath     string
+	state         *State
+	Retries       int
+	RetryInterval time.Duration
+}
+
+// NewForwarder returns a forwarder of the events retrieved by get, resuming from the state stored in statePath.
+// When there is no state yet, it forwards the events from start.
+func NewForwarder(get func(filter *types.EventFilter) ([]*types.Event, error), sinks []Sink, statePath string, start time.Time) (*Forwarder, error) {
+	if len(sinks) == 0 {
+		return nil, fmt.Errorf("at least a sink is required")
+	}
+	f := &Forwarder{
+		get:           get,
+		sinks:         sinks,
+		statePath:     statePath,
+		state:         &State{Timestamp: start, IDs: []string{}},
+		Retries:       DefaultRetries,
+		RetryInterval: DefaultRetryInterval,
+	}
+
+	data, err := ioutil.ReadFile(statePath)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("cannot read forwarding state: %v", err)
+	}
+	if err == nil {
+		if err = json.Unmarshal(data, f.state); err != nil {
+			return nil, fmt.Errorf("invalid forwarding state %s: %v", statePath, err)
+		}
+	}
+	return f, nil
+}
+
+// State returns the current high-water mark
+func (f *Forwarder) State() State {
+	return *f.state
+}
+
+func (f *Forwarder) saveState() error {
+	data, err := json.Marshal(f.state)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(f.statePath), 0700); err != nil {
+		return err
+	}
+	return utils.WriteFileAtomic(f.statePath, data)
+}
+
+// deliver sends the events to every sink, retrying the failing ones
+func (f *Forwarder) deliver(events []*types.Event) error {
+	pending := f.sinks
+	var err error
+	for attempt := 0; attempt <= f.Retries && len(pending) > 0; attempt++ {
+		if attempt > 0 {
+			log.Warnf("Retrying delivery of %d events to %d sinks: %v", len(events), len(pending), err)
+			time.Sleep(time.Duration(attempt) * f.RetryInterval)
+		}
+		failed := []Sink{}
+		for _, sink := range pending {
+			if sendErr := sink.Send(events); sendErr != nil {
+				err = sendErr
+				failed = append(failed, sink)
+			}
+		}
+		pending = failed
+	}
+	if len(pending) > 0 {
+		return fmt.Errorf("cannot deliver %d events to %d sinks: %v", len(events), len(pending), err)
+	}
+	return nil
+}
+
+// Poll retrieves the events after the high-water mark and delivers them, returning how many there were
+func (f *Forwarder) Poll() (int, error) {
+	events, err := f.get(&types.EventFilter{Since: f.state.Timestamp})
+	if err != nil {
+		return 0, fmt.Errorf("cannot receive events: %v", err)
+	}
+	pending := []*types.Event{}
+	for _, event := range events {
+		if !f.state.delivered(event) {
+			pending = append(pending, event)
+		}
+	}
+	if len(pending) == 0 {
+		return 0, nil
+	}
+	sort.SliceStable(pending, func(i, j int) bool { return pending[i].Timestamp.Before(pending[j].Timestamp) })
+
+	if err = f.deliver(pending); err != nil {
+		return 0, err
+	}
+	f.state.advance(pending)
+	if err = f.saveState(); err != nil {
+		return len(pending), fmt.Errorf("cannot save forwarding state: %v", err)
+	}
+	return len(pending), nil
+}
+
+// Run polls every interval until stop is closed. Failed polls are logged and retried on the next one.
+func (f *Forwarder) Run(interval time.Duration, stop <-chan struct{}) {
+	for {
+		if n, err := f.Poll(); err != nil {
+			log.Errorf("Couldn't forward events: %v", err)
+		} else if n > 0 {
+			log.Infof("Forwarded %d events", n)
+		}
+
+		select {
+		case <-stop:
+			return
+		case <-time.After(interval):
+		}
+	}
+}
+
+// Close closes every sink
+func (f *Forwarder) Close() error {
+	var err error
+	for _, sink := range f.sinks {
+		if closeErr := sink.Close(); closeErr != nil {
+			err = closeErr
+		}
+	}
+	return err
+}
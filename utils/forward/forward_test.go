@@ -0,0 +1,121 @@
+package forward
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ingrammicro/concerto/api/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeSink records the events sent, failing the given number of times first
+type fakeSink struct {
+	failures int
+	events   []*types.Event
+}
+
+func (s *fakeSink) Send(events []*types.Event) error {
+	if s.failures > 0 {
+		s.failures--
+		return fmt.Errorf("fake failure")
+	}
+	s.events = append(s.events, events...)
+	return nil
+}
+
+func (s *fakeSink) Close() error {
+	return nil
+}
+
+func testEvents() []*types.Event {
+	return []*types.Event{
+		{ID: "2", Timestamp: time.Date(2018, 6, 1, 10, 0, 0, 0, time.UTC), Level: "info", Header: "second"},
+		{ID: "1", Timestamp: time.Date(2018, 6, 1, 9, 0, 0, 0, time.UTC), Level: "info", Header: "first"},
+		{ID: "3", Timestamp: time.Date(2018, 6, 1, 10, 0, 0, 0, time.UTC), Level: "error", Header: "third"},
+	}
+}
+
+func TestForwarderDeliversOnce(t *testing.T) {
+	assert := assert.New(t)
+	dir, err := ioutil.TempDir("", "cio-forward")
+	assert.Nil(err, "Couldn't create temp dir")
+	defer os.RemoveAll(dir)
+
+	events := testEvents()
+	get := func(filter *types.EventFilter) ([]*types.Event, error) { return filter.Filter(events), nil }
+	sink := &fakeSink{}
+	statePath := filepath.Join(dir, "state.json")
+
+	f, err := NewForwarder(get, []Sink{sink}, statePath, time.Time{})
+	assert.Nil(err, "Couldn't create forwarder")
+	n, err := f.Poll()
+	assert.Nil(err, "Couldn't poll")
+	assert.Equal(3, n, "Every event should be delivered")
+	assert.Equal("1", sink.events[0].ID, "Events should be delivered in order")
+
+	n, err = f.Poll()
+	assert.Nil(err, "Couldn't poll")
+	assert.Equal(0, n, "Events should not be delivered again")
+
+	// resumes from the persisted high-water mark
+	events = append(events, &types.Event{ID: "4", Timestamp: time.Date(2018, 6, 1, 10, 0, 0, 0, time.UTC)})
+	f, err = NewForwarder(get, []Sink{sink}, statePath, time.Time{})
+	assert.Nil(err, "Couldn't create forwarder")
+	n, err = f.Poll()
+	assert.Nil(err, "Couldn't poll")
+	assert.Equal(1, n, "Only the new event should be delivered")
+	assert.Len(sink.events, 4, "Unexpected delivered events")
+	assert.Equal([]string{"2", "3", "4"}, f.State().IDs, "Unexpected high-water mark")
+}
+
+func TestForwarderRetries(t *testing.T) {
+	assert := assert.New(t)
+	dir, err := ioutil.TempDir("", "cio-forward")
+	assert.Nil(err, "Couldn't create temp dir")
+	defer os.RemoveAll(dir)
+
+	get := func(filter *types.EventFilter) ([]*types.Event, error) { return testEvents(), nil }
+	healthy, flaky := &fakeSink{}, &fakeSink{failures: 2}
+
+	f, err := NewForwarder(get, []Sink{healthy, flaky}, filepath.Join(dir, "state.json"), time.Time{})
+	assert.Nil(err, "Couldn't create forwarder")
+	f.RetryInterval = time.Millisecond
+
+	n, err := f.Poll()
+	assert.Nil(err, "Delivery should be retried")
+	assert.Equal(3, n, "Every event should be delivered")
+	assert.Len(healthy.events, 3, "Healthy sink should receive the events once")
+	assert.Len(flaky.events, 3, "Flaky sink should receive the events after retrying")
+
+	// the high-water mark doesn't move when delivery fails
+	events := append(testEvents(), &types.Event{ID: "4", Timestamp: time.Date(2018, 6, 2, 0, 0, 0, 0, time.UTC)})
+	get = func(filter *types.EventFilter) ([]*types.Event, error) { return filter.Filter(events), nil }
+	broken := &fakeSink{failures: 10}
+	f, err = NewForwarder(get, []Sink{broken}, filepath.Join(dir, "state.json"), time.Time{})
+	assert.Nil(err, "Couldn't create forwarder")
+	f.Retries, f.RetryInterval = 1, time.Millisecond
+	_, err = f.Poll()
+	assert.NotNil(err, "Delivery should fail")
+	assert.Equal(time.Date(2018, 6, 1, 10, 0, 0, 0, time.UTC), f.State().Timestamp, "High-water mark should not move")
+}
+
+func TestNewSink(t *testing.T) {
+	assert := assert.New(t)
+	dir, err := ioutil.TempDir("", "cio-forward")
+	assert.Nil(err, "Couldn't create temp dir")
+	defer os.RemoveAll(dir)
+
+	for _, rawURL := range []string{"syslog+udp://localhost:514", "syslog+tcp://localhost:601", "file://" + dir + "/events.jsonl?max-size=1024&max-files=2", "https://localhost/hook"} {
+		sink, err := NewSink(rawURL, nil)
+		assert.Nil(err, "%s should be a valid sink", rawURL)
+		assert.NotNil(sink, "%s should be a valid sink", rawURL)
+	}
+	for _, rawURL := range []string{"ftp://localhost", "syslog+sctp://localhost:514", "file://" + dir + "/events.jsonl?max-size=big"} {
+		_, err := NewSink(rawURL, nil)
+		assert.NotNil(err, "%s should be an invalid sink", rawURL)
+	}
+}
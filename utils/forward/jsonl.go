@@ -0,0 +1,114 @@
+package forward
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/ingrammicro/concerto/api/types"
+)
+
+const (
+	// DefaultMaxFileSize is the size a JSONL file may reach before it is rotated, in bytes
+	DefaultMaxFileSize = 100 * 1024 * 1024
+	// DefaultMaxFiles is the number of rotated JSONL files kept besides the current one
+	DefaultMaxFiles = 5
+)
+
+// JSONLSink appends the events to a file, one JSON object per line. Once the file would exceed its maximum size,
+// it is rotated as file.1, file.2... keeping up to the maximum number of rotated files.
+type JSONLSink struct {
+	path     string
+	maxSize  int64
+	maxFiles int
+	file     *os.File
+	size     int64
+}
+
+// NewJSONLSink returns a sink appending the events to the file at path
+func NewJSONLSink(path string, maxSize int64, maxFiles int) (*JSONLSink, error) {
+	if path == "" {
+		return nil, fmt.Errorf("a file path is required")
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, fmt.Errorf("cannot create events directory: %v", err)
+	}
+	return &JSONLSink{path: path, maxSize: maxSize, maxFiles: maxFiles}, nil
+}
+
+func (s *JSONLSink) open() error {
+	if s.file != nil {
+		return nil
+	}
+	f, err := os.OpenFile(s.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	s.file, s.size = f, info.Size()
+	return nil
+}
+
+// rotate shifts the rotated files, dropping the oldest one, and moves the current file to file.1
+func (s *JSONLSink) rotate() error {
+	if err := s.Close(); err != nil {
+		return err
+	}
+	if s.maxFiles <= 0 {
+		return os.Remove(s.path)
+	}
+	os.Remove(fmt.Sprintf("%s.%d", s.path, s.maxFiles))
+	for i := s.maxFiles - 1; i >= 1; i-- {
+		if err := os.Rename(fmt.Sprintf("%s.%d", s.path, i), fmt.Sprintf("%s.%d", s.path, i+1)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return os.Rename(s.path, s.path+".1")
+}
+
+// Send appends the events to the file, and syncs it so that they are durable once delivered
+func (s *JSONLSink) Send(events []*types.Event) error {
+	for _, event := range events {
+		line, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		line = append(line, '\n')
+
+		if err = s.open(); err != nil {
+			return fmt.Errorf("cannot open events file: %v", err)
+		}
+		if s.maxSize > 0 && s.size > 0 && s.size+int64(len(line)) > s.maxSize {
+			if err = s.rotate(); err != nil {
+				return fmt.Errorf("cannot rotate events file: %v", err)
+			}
+			if err = s.open(); err != nil {
+				return fmt.Errorf("cannot open events file: %v", err)
+			}
+		}
+		n, err := s.file.Write(line)
+		s.size += int64(n)
+		if err != nil {
+			return fmt.Errorf("cannot write events file: %v", err)
+		}
+	}
+	if s.file == nil {
+		return nil
+	}
+	return s.file.Sync()
+}
+
+// Close closes the file
+func (s *JSONLSink) Close() error {
+	if s.file == nil {
+		return nil
+	}
+	err := s.file.Close()
+	s.file = nil
+	return err
+}
@@ -0,0 +1,191 @@
+package forward
+
+import (
+	"bufio"
+	"crypto/tls"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ingrammicro/concerto/api/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSyslogSinkFormat(t *testing.T) {
+	assert := assert.New(t)
+
+	s, err := NewSyslogSink("udp", "localhost:514", "local0", "")
+	assert.Nil(err, "Couldn't create syslog sink")
+	s.hostname = "host"
+	msg := s.Format(&types.Event{ID: `a"1]`, Timestamp: time.Date(2018, 6, 1, 10, 0, 0, 0, time.UTC), Level: "error", Header: "Server stalled", Description: "Timeout"})
+	assert.Equal(`<131>1 2018-06-01T10:00:00Z host concerto - event [imco@32473 id="a\"1\]" level="error"] Server stalled: Timeout`, msg, "Unexpected message")
+
+	_, err = NewSyslogSink("udp", "localhost:514", "unknown", "")
+	assert.NotNil(err, "Unknown facility should fail")
+}
+
+func TestSyslogSinkUDP(t *testing.T) {
+	assert := assert.New(t)
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	assert.Nil(err, "Couldn't listen")
+	defer conn.Close()
+
+	s, err := NewSyslogSink("udp", conn.LocalAddr().String(), "", "")
+	assert.Nil(err, "Couldn't create syslog sink")
+	defer s.Close()
+	assert.Nil(s.Send(testEvents()[:2]), "Couldn't send events")
+
+	buf := make([]byte, 2048)
+	for _, header := range []string{"second", "first"} {
+		conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		n, _, err := conn.ReadFrom(buf)
+		assert.Nil(err, "Couldn't receive message")
+		assert.True(strings.HasSuffix(string(buf[:n]), header), "Unexpected message %s", buf[:n])
+	}
+}
+
+// receiveSyslogStream reads octet counted messages from the first connection accepted by the listener
+func receiveSyslogStream(t *testing.T, listener net.Listener, count int) []string {
+	conn, err := listener.Accept()
+	assert.Nil(t, err, "Couldn't accept connection")
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	reader := bufio.NewReader(conn)
+	messages := []string{}
+	for i := 0; i < count; i++ {
+		var length int
+		_, err := fmt.Fscan(reader, &length)
+		assert.Nil(t, err, "Couldn't read message length")
+		msg := make([]byte, length+1)
+		_, err = io.ReadFull(reader, msg)
+		assert.Nil(t, err, "Couldn't read message")
+		messages = append(messages, string(msg[1:]))
+	}
+	return messages
+}
+
+func TestSyslogSinkTCP(t *testing.T) {
+	assert := assert.New(t)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(err, "Couldn't listen")
+	defer listener.Close()
+
+	s, err := NewSyslogSink("tcp", listener.Addr().String(), "", "")
+	assert.Nil(err, "Couldn't create syslog sink")
+	defer s.Close()
+	assert.Nil(s.Send(testEvents()), "Couldn't send events")
+
+	messages := receiveSyslogStream(t, listener, 3)
+	assert.True(strings.HasSuffix(messages[2], "third"), "Unexpected message %s", messages[2])
+}
+
+func TestSyslogSinkTLS(t *testing.T) {
+	assert := assert.New(t)
+
+	// borrows the certificate of a test HTTPS server, which is valid for 127.0.0.1
+	server := httptest.NewTLSServer(http.NotFoundHandler())
+	defer server.Close()
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", server.TLS)
+	assert.Nil(err, "Couldn't listen")
+	defer listener.Close()
+
+	dir, err := ioutil.TempDir("", "cio-forward")
+	assert.Nil(err, "Couldn't create temp dir")
+	defer os.RemoveAll(dir)
+	caCert := filepath.Join(dir, "ca.pem")
+	err = ioutil.WriteFile(caCert, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}), 0600)
+	assert.Nil(err, "Couldn't write CA cert")
+
+	s, err := NewSyslogSink("tls", listener.Addr().String(), "", caCert)
+	assert.Nil(err, "Couldn't create syslog sink")
+	defer s.Close()
+
+	done := make(chan []string)
+	go func() { done <- receiveSyslogStream(t, listener, 1) }()
+	assert.Nil(s.Send(testEvents()[:1]), "Couldn't send events")
+	messages := <-done
+	assert.True(strings.HasSuffix(messages[0], "second"), "Unexpected message %s", messages[0])
+}
+
+func TestSyslogSinkRetriesRefusedConnections(t *testing.T) {
+	assert := assert.New(t)
+
+	// a port nobody listens on
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(err, "Couldn't listen")
+	address := listener.Addr().String()
+	listener.Close()
+
+	for _, network := range []string{"tcp", "tls"} {
+		s, err := NewSyslogSink(network, address, "", "")
+		assert.Nil(err, "Couldn't create syslog sink")
+		for i := 0; i < 2; i++ {
+			assert.NotNil(s.Send(testEvents()[:1]), "Sending over %s to a closed port should fail", network)
+		}
+		assert.Nil(s.Close(), "Closing a sink which never connected should not fail")
+	}
+}
+
+func TestJSONLSinkRotates(t *testing.T) {
+	assert := assert.New(t)
+	dir, err := ioutil.TempDir("", "cio-forward")
+	assert.Nil(err, "Couldn't create temp dir")
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "events.jsonl")
+	s, err := NewJSONLSink(path, 200, 1)
+	assert.Nil(err, "Couldn't create JSONL sink")
+	for i := 0; i < 3; i++ {
+		assert.Nil(s.Send(testEvents()), "Couldn't send events")
+	}
+	assert.Nil(s.Close(), "Couldn't close JSONL sink")
+
+	data, err := ioutil.ReadFile(path)
+	assert.Nil(err, "Couldn't read events file")
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	event := &types.Event{}
+	assert.Nil(json.Unmarshal([]byte(lines[len(lines)-1]), event), "Lines should be JSON events")
+	assert.Equal("3", event.ID, "Unexpected last event")
+
+	_, err = os.Stat(path + ".1")
+	assert.Nil(err, "Events file should be rotated")
+	_, err = os.Stat(path + ".2")
+	assert.True(os.IsNotExist(err), "Only one rotated file should be kept")
+}
+
+func TestWebhookSink(t *testing.T) {
+	assert := assert.New(t)
+
+	received := make(chan []*types.Event, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		if r.Header.Get(SignatureHeader) != Sign("secret", body) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		payload := map[string][]*types.Event{}
+		json.Unmarshal(body, &payload)
+		received <- payload["events"]
+	}))
+	defer server.Close()
+
+	s := NewWebhookSink(server.URL, "secret")
+	assert.Nil(s.Send(testEvents()), "Couldn't send events")
+	assert.Len(<-received, 3, "Unexpected events received")
+
+	s = NewWebhookSink(server.URL, "wrong")
+	assert.NotNil(s.Send(testEvents()), "Rejected delivery should fail")
+}
@@ -0,0 +1,163 @@
+package forward
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ingrammicro/concerto/api/types"
+)
+
+const (
+	syslogAppName = "concerto"
+	// syslogSDID identifies the structured data of the events, under the private enterprise number of the examples
+	// in RFC 5424
+	syslogSDID     = "imco@32473"
+	syslogTimeout  = 30 * time.Second
+	syslogFacility = "local0"
+)
+
+var syslogFacilities = map[string]int{
+	"kern": 0, "user": 1, "mail": 2, "daemon": 3, "auth": 4, "syslog": 5, "lpr": 6, "news": 7,
+	"uucp": 8, "cron": 9, "authpriv": 10, "ftp": 11, "local0": 16, "local1": 17, "local2": 18,
+	"local3": 19, "local4": 20, "local5": 21, "local6": 22, "local7": 23,
+}
+
+// syslogSeverities maps event levels to syslog severities. Other levels are notices.
+var syslogSeverities = map[string]int{
+	"emergency": 0, "alert": 1, "critical": 2, "error": 3, "warning": 4, "notice": 5, "info": 6, "debug": 7,
+}
+
+// SyslogSink sends the events as RFC 5424 messages, over UDP, TCP or TLS. Stream transports use octet counting
+// framing (RFC 6587), and the connection is opened again after a failure.
+type SyslogSink struct {
+	network  string
+	address  string
+	facility int
+	tls      *tls.Config
+	hostname string
+	conn     net.Conn
+}
+
+// NewSyslogSink returns a sink sending the events to the syslog server at address, through the network (udp,
+// tcp or tls). The server certificate is checked against caCert, when given, or the system CAs.
+func NewSyslogSink(network string, address string, facility string, caCert string) (*SyslogSink, error) {
+	if facility == "" {
+		facility = syslogFacility
+	}
+	code, found := syslogFacilities[facility]
+	if !found {
+		return nil, fmt.Errorf("unknown syslog facility %s", facility)
+	}
+	if network != "udp" && network != "tcp" && network != "tls" {
+		return nil, fmt.Errorf("unsupported syslog transport %s", network)
+	}
+
+	s := &SyslogSink{network: network, address: address, facility: code, hostname: "-"}
+	if hostname, err := os.Hostname(); err == nil && hostname != "" {
+		s.hostname = hostname
+	}
+	if network == "tls" {
+		s.tls = &tls.Config{}
+		if host, _, err := net.SplitHostPort(address); err == nil {
+			s.tls.ServerName = host
+		}
+		if caCert != "" {
+			pem, err := ioutil.ReadFile(caCert)
+			if err != nil {
+				return nil, fmt.Errorf("cannot read syslog CA cert: %v", err)
+			}
+			s.tls.RootCAs = x509.NewCertPool()
+			if !s.tls.RootCAs.AppendCertsFromPEM(pem) {
+				return nil, fmt.Errorf("invalid syslog CA cert %s", caCert)
+			}
+		}
+	}
+	return s, nil
+}
+
+// escapeSDParam escapes a structured data parameter value, as required by RFC 5424
+func escapeSDParam(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`).Replace(value)
+}
+
+// Format returns the RFC 5424 message of the event
+func (s *SyslogSink) Format(event *types.Event) string {
+	severity, found := syslogSeverities[strings.ToLower(event.Level)]
+	if !found {
+		severity = syslogSeverities["notice"]
+	}
+	msg := event.Header
+	if event.Description != "" {
+		msg = fmt.Sprintf("%s: %s", event.Header, event.Description)
+	}
+	return fmt.Sprintf("<%d>1 %s %s %s - event [%s id=\"%s\" level=\"%s\"] %s",
+		s.facility*8+severity,
+		event.Timestamp.UTC().Format(time.RFC3339Nano),
+		s.hostname,
+		syslogAppName,
+		syslogSDID,
+		escapeSDParam(event.ID),
+		escapeSDParam(event.Level),
+		msg,
+	)
+}
+
+func (s *SyslogSink) connect() error {
+	if s.conn != nil {
+		return nil
+	}
+	var (
+		conn net.Conn
+		err  error
+	)
+	dialer := &net.Dialer{Timeout: syslogTimeout}
+	if s.network == "tls" {
+		// a failed dial returns a nil *tls.Conn, which must not be kept as a non nil net.Conn
+		var tlsConn *tls.Conn
+		if tlsConn, err = tls.DialWithDialer(dialer, "tcp", s.address, s.tls); err == nil {
+			conn = tlsConn
+		}
+	} else {
+		conn, err = dialer.Dial(s.network, s.address)
+	}
+	if err != nil {
+		return err
+	}
+	s.conn = conn
+	return nil
+}
+
+// Send delivers every event as a syslog message
+func (s *SyslogSink) Send(events []*types.Event) error {
+	if err := s.connect(); err != nil {
+		return fmt.Errorf("cannot connect to syslog %s: %v", s.address, err)
+	}
+	for _, event := range events {
+		msg := s.Format(event)
+		if s.network != "udp" {
+			msg = fmt.Sprintf("%d %s", len(msg), msg)
+		}
+		s.conn.SetWriteDeadline(time.Now().Add(syslogTimeout))
+		if _, err := s.conn.Write([]byte(msg)); err != nil {
+			s.Close()
+			return fmt.Errorf("cannot send to syslog %s: %v", s.address, err)
+		}
+	}
+	return nil
+}
+
+// Close closes the connection to the syslog server
+func (s *SyslogSink) Close() error {
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn = nil
+	return err
+}
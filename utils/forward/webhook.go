@@ -0,0 +1,79 @@
+package forward
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/ingrammicro/concerto/api/types"
+)
+
+const (
+	// SignatureHeader carries the HMAC-SHA256 of the webhook request body, as sha256=<hex>
+	SignatureHeader = "X-Concerto-Signature"
+
+	webhookTimeout = 30 * time.Second
+)
+
+// WebhookSink posts the events as a JSON object, {"events": [...]}, to an URL. Any response other than 2xx
+// is a failed delivery.
+type WebhookSink struct {
+	url    string
+	secret string
+	client *http.Client
+}
+
+// NewWebhookSink returns a sink posting the events to the URL, signing the requests with the secret when given
+func NewWebhookSink(url string, secret string) *WebhookSink {
+	return &WebhookSink{url: url, secret: secret, client: &http.Client{Timeout: webhookTimeout}}
+}
+
+// Sign returns the signature of a body with the secret, as sent in the SignatureHeader
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Send posts the events
+func (s *WebhookSink) Send(events []*types.Event) error {
+	body, err := json.Marshal(map[string]interface{}{"events": events})
+	if err != nil {
+		return err
+	}
+	request, err := http.NewRequest("POST", s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	if s.secret != "" {
+		request.Header.Set(SignatureHeader, Sign(s.secret, body))
+	}
+
+	response, err := s.client.Do(request)
+	if err != nil {
+		return fmt.Errorf("cannot post to webhook: %v", err)
+	}
+	defer response.Body.Close()
+	io.Copy(ioutil.Discard, response.Body)
+
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return fmt.Errorf("webhook answered %s", response.Status)
+	}
+	return nil
+}
+
+// Close releases the idle connections to the webhook
+func (s *WebhookSink) Close() error {
+	if transport, ok := s.client.Transport.(*http.Transport); ok {
+		transport.CloseIdleConnections()
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package utils
+
+import (
+	"bufio"
+	"fmt"
+	"runtime"
+	"strings"
+)
+
+// Supported script interpreters
+const (
+	InterpreterSh     = "sh"
+	InterpreterBash   = "bash"
+	InterpreterPython = "python"
+	InterpreterPwsh   = "pwsh"
+)
+
+// scriptInterpreter defines how a script file is named and invoked
+type scriptInterpreter struct {
+	extension string
+	args      []string
+}
+
+// FileName returns the script file name for the given base name
+func (si *scriptInterpreter) FileName(base string) string {
+	return strings.Join([]string{base, si.extension}, "")
+}
+
+// CommandArgs returns the full command line running the script file
+func (si *scriptInterpreter) CommandArgs(filePath string) []string {
+	return append(append([]string{}, si.args...), filePath)
+}
+
+// resolveInterpreter returns the interpreter for the given name.
+// When no name is given, the shebang line of the code is honoured on unix, falling back to the platform default shell.
+func resolveInterpreter(name string, code string) (*scriptInterpreter, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "":
+		if runtime.GOOS == "windows" {
+			return &scriptInterpreter{".bat", []string{"cmd", "/C"}}, nil
+		}
+		if args := shebangArgs(code); args != nil {
+			return &scriptInterpreter{"", args}, nil
+		}
+		return &scriptInterpreter{"", []string{"/bin/sh"}}, nil
+	case InterpreterSh:
+		if runtime.GOOS == "windows" {
+			return &scriptInterpreter{".sh", []string{"sh"}}, nil
+		}
+		return &scriptInterpreter{"", []string{"/bin/sh"}}, nil
+	case InterpreterBash:
+		return &scriptInterpreter{".sh", []string{"bash"}}, nil
+	case InterpreterPython:
+		if runtime.GOOS == "windows" {
+			return &scriptInterpreter{".py", []string{"python"}}, nil
+		}
+		return &scriptInterpreter{".py", []string{"python3"}}, nil
+	case InterpreterPwsh:
+		return &scriptInterpreter{".ps1", []string{"pwsh", "-NoProfile", "-NonInteractive", "-ExecutionPolicy", "Bypass", "-File"}}, nil
+	}
+	return nil, fmt.Errorf("unsupported interpreter %q, use one of [ %s | %s | %s | %s ]", name, InterpreterSh, InterpreterBash, InterpreterPython, InterpreterPwsh)
+}
+
+// shebangArgs returns the interpreter and its optional argument declared in the shebang line, if any.
+// As the kernel does, everything after the interpreter path is taken as a single argument.
+func shebangArgs(code string) []string {
+	line, _ := bufio.NewReader(strings.NewReader(code)).ReadString('\n')
+	if !strings.HasPrefix(line, "#!") {
+		return nil
+	}
+	line = strings.TrimSpace(strings.TrimPrefix(line, "#!"))
+	if line == "" {
+		return nil
+	}
+
+	i := strings.IndexAny(line, " \t")
+	if i < 0 {
+		return []string{line}
+	}
+	if arg := strings.TrimSpace(line[i:]); arg != "" {
+		return []string{line[:i], arg}
+	}
+	return []string{line[:i]}
+}
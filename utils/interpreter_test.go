@@ -0,0 +1,126 @@
+package utils
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolveInterpreterDefault(t *testing.T) {
+	assert := assert.New(t)
+
+	si, err := resolveInterpreter("", "echo fake")
+	assert.Nil(err, "Default interpreter should be resolved")
+	if runtime.GOOS == "windows" {
+		assert.Equal("fake.bat", si.FileName("fake"), "Unexpected file name")
+		assert.Equal([]string{"cmd", "/C", "fake.bat"}, si.CommandArgs("fake.bat"), "Unexpected command")
+	} else {
+		assert.Equal("fake", si.FileName("fake"), "Unexpected file name")
+		assert.Equal([]string{"/bin/sh", "fake"}, si.CommandArgs("fake"), "Unexpected command")
+	}
+}
+
+func TestResolveInterpreterShebang(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shebang is not honoured on windows")
+	}
+	assert := assert.New(t)
+
+	si, err := resolveInterpreter("", "#!/usr/bin/env python3\nprint('fake')\n")
+	assert.Nil(err, "Shebang interpreter should be resolved")
+	assert.Equal("fake", si.FileName("fake"), "Unexpected file name")
+	assert.Equal([]string{"/usr/bin/env", "python3", "fake"}, si.CommandArgs("fake"), "Unexpected command")
+
+	si, err = resolveInterpreter("", "#! /bin/bash  -e \necho fake\n")
+	assert.Nil(err, "Shebang interpreter should be resolved")
+	assert.Equal([]string{"/bin/bash", "-e", "fake"}, si.CommandArgs("fake"), "Unexpected command")
+
+	si, err = resolveInterpreter("", "#!\necho fake\n")
+	assert.Nil(err, "Empty shebang should fall back to default")
+	assert.Equal([]string{"/bin/sh", "fake"}, si.CommandArgs("fake"), "Unexpected command")
+}
+
+func TestResolveInterpreterExplicit(t *testing.T) {
+	assert := assert.New(t)
+
+	si, err := resolveInterpreter(InterpreterSh, "#!/bin/bash\necho fake")
+	assert.Nil(err, "sh interpreter should be resolved")
+	if runtime.GOOS != "windows" {
+		assert.Equal([]string{"/bin/sh", "fake"}, si.CommandArgs("fake"), "Explicit interpreter should override shebang")
+	}
+
+	si, err = resolveInterpreter(InterpreterBash, "")
+	assert.Nil(err, "bash interpreter should be resolved")
+	assert.Equal("fake.sh", si.FileName("fake"), "Unexpected file name")
+	assert.Equal([]string{"bash", "fake.sh"}, si.CommandArgs("fake.sh"), "Unexpected command")
+
+	si, err = resolveInterpreter("Python", "")
+	assert.Nil(err, "python interpreter should be resolved")
+	assert.Equal("fake.py", si.FileName("fake"), "Unexpected file name")
+	assert.Contains([]string{"python", "python3"}, si.CommandArgs("fake.py")[0], "Unexpected command")
+
+	si, err = resolveInterpreter(InterpreterPwsh, "")
+	assert.Nil(err, "pwsh interpreter should be resolved")
+	assert.Equal("fake.ps1", si.FileName("fake"), "Unexpected file name")
+	args := si.CommandArgs("fake.ps1")
+	assert.Equal("pwsh", args[0], "Unexpected command")
+	assert.Equal([]string{"-File", "fake.ps1"}, args[len(args)-2:], "Script should be run as file")
+}
+
+func TestResolveInterpreterUnsupported(t *testing.T) {
+	assert := assert.New(t)
+
+	si, err := resolveInterpreter("ruby", "")
+	assert.Nil(si, "Unsupported interpreter should not be resolved")
+	assert.NotNil(err, "Unsupported interpreter should return error")
+	assert.Contains(err.Error(), "unsupported interpreter", "Unexpected error message")
+}
+
+func TestExecCodeInterpreters(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("unix interpreters only")
+	}
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "cio-test")
+	assert.Nil(err, "Couldn't create temp dir")
+	defer os.RemoveAll(dir)
+
+	output, exitCode, _, _, err := ExecCode("echo fake", dir, "default", "", nil)
+	assert.Nil(err, "Couldn't execute code")
+	assert.Equal(0, exitCode, "Unexpected exit code")
+	assert.Equal("fake\n", output, "Unexpected output")
+
+	output, exitCode, _, _, _ = ExecCode("#!/bin/sh -e\nfalse\necho fake", dir, "shebang", "", nil)
+	assert.Equal(1, exitCode, "Shebang arguments should be honoured")
+	assert.Equal("", output, "Unexpected output")
+
+	if _, err := exec.LookPath("bash"); err == nil {
+		output, exitCode, _, _, _ = ExecCode("echo ${BASH_VERSION:+bash}", dir, "bash", InterpreterBash, nil)
+		assert.Equal(0, exitCode, "Unexpected exit code")
+		assert.Equal("bash\n", output, "Script should run with bash")
+		assert.True(FileExists(dir+"/bash.sh"), "Script file should have bash extension")
+	}
+
+	if _, err := exec.LookPath("python3"); err == nil {
+		output, exitCode, _, _, _ = ExecCode("import sys\nprint('fake')\nsys.exit(3)", dir, "python", InterpreterPython, nil)
+		assert.Equal(3, exitCode, "Unexpected exit code")
+		assert.Equal("fake\n", output, "Script should run with python")
+	}
+
+	_, _, _, _, err = ExecCode("echo fake", dir, "unsupported", "ruby", nil)
+	assert.NotNil(err, "Unsupported interpreter should return error")
+
+	output, _, _, _, err = ExecCode("echo $FAKE_PARAM", dir, "env", "", []string{"FAKE_PARAM=fakeValue"})
+	assert.Nil(err, "Couldn't execute code")
+	assert.Equal("fakeValue\n", output, "Script should receive the given environment")
+
+	env := ScriptEnvironment(dir, map[string]string{"FAKE_PARAM": "fakeValue"})
+	output, _, _, _, err = ExecCodeWithRunner("echo $ATTACHMENT_DIR $FAKE_PARAM $RUNNER_PARAM", dir, "runner", "", env, []string{"env", "RUNNER_PARAM=runner"})
+	assert.Nil(err, "Couldn't execute code")
+	assert.Equal(dir+" fakeValue runner\n", output, "Script should run through the runner, with attachments directory and parameters")
+}
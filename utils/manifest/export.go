@@ -0,0 +1,224 @@
+package manifest
+
+import (
+	"fmt"
+	"strings"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/ingrammicro/concerto/api/types"
+	"github.com/ingrammicro/concerto/utils"
+)
+
+// Exporter declares the current objects of a state as manifest entries, the way Parse reads them back
+type Exporter struct {
+	State   *State
+	Backend Backend
+}
+
+// Export returns the manifest sections declaring the current objects of the given kinds. When a label selector
+// is given, only the objects matching it are exported, along with the labels assigned to them.
+func (x *Exporter) Export(kinds []*Kind, selector *types.LabelSelector) (map[string]interface{}, error) {
+	sections := map[string]interface{}{}
+	assigned := map[string]bool{}
+	exportLabels := false
+	for _, kind := range kinds {
+		if kind.Name == "labels" {
+			exportLabels = true
+			continue
+		}
+		entries := []interface{}{}
+		for _, current := range x.State.Entries(kind.Name) {
+			if current.Ambiguous {
+				log.Warnf("Skipping %s: there are several with that name", current.Address())
+				continue
+			}
+			object := current.Object
+			// servers belonging to an array are managed through it
+			if ID, _ := object["server_array_id"].(string); kind.Name == "servers" && ID != "" {
+				continue
+			}
+			if !x.matchesLabels(kind, object, selector) {
+				continue
+			}
+			entry, err := x.ExportObject(kind, object)
+			if err != nil {
+				return nil, fmt.Errorf("cannot export %s: %v", current.Address(), err)
+			}
+			if labels, found := entry["labels"].([]string); found {
+				for _, name := range labels {
+					assigned[name] = true
+				}
+			}
+			entries = append(entries, entry)
+		}
+		if len(entries) > 0 {
+			sections[kind.Name] = entries
+		}
+	}
+
+	if exportLabels {
+		entries := []interface{}{}
+		for _, current := range x.State.Entries("labels") {
+			// namespaced labels are internal to the platform
+			if namespace, _ := current.Object["namespace"].(string); namespace != "" {
+				continue
+			}
+			if selector != nil && !assigned[current.Name] {
+				continue
+			}
+			entries = append(entries, map[string]interface{}{"name": current.Name})
+		}
+		if len(entries) > 0 {
+			sections["labels"] = entries
+		}
+	}
+	return sections, nil
+}
+
+// matchesLabels tells whether the object labels match the selector. Objects which cannot be labelled follow
+// their parent.
+func (x *Exporter) matchesLabels(kind *Kind, object map[string]interface{}, selector *types.LabelSelector) bool {
+	if selector == nil {
+		return true
+	}
+	if !kind.Labelable {
+		if kind.Parent == "" {
+			return false
+		}
+		parentID, _ := object[kind.Parent+"_id"].(string)
+		parent := x.State.ObjectByID(kind.ParentKind(), parentID)
+		return parent != nil && x.matchesLabels(KindByName(kind.ParentKind()), parent, selector)
+	}
+	labels := []*types.Label{}
+	for _, name := range x.Backend.LabelNames(object) {
+		if label, err := types.ParseLabel(name); err == nil {
+			labels = append(labels, label)
+		}
+	}
+	return selector.Matches(labels)
+}
+
+// ExportObject returns the manifest entry declaring the current object
+func (x *Exporter) ExportObject(kind *Kind, object map[string]interface{}) (map[string]interface{}, error) {
+	entry := map[string]interface{}{}
+	for _, field := range kind.Fields {
+		value := object[field]
+		if utils.Contains(kind.Secrets, field) || IsEmptyValue(value) {
+			continue
+		}
+		switch field {
+		case "rules":
+			value = ExportFirewallRules(value)
+		case "cookbook_versions":
+			cookbookVersions, err := x.exportCookbookVersions(value)
+			if err != nil {
+				return nil, err
+			}
+			value = cookbookVersions
+		}
+		entry[field] = value
+	}
+
+	for attribute, refKind := range kind.Refs {
+		key := attribute + "_id"
+		if attribute == kind.Attach {
+			key = "attached_server_id"
+		}
+		ID, _ := object[key].(string)
+		if ID == "" {
+			continue
+		}
+		if name, ok := x.State.RefName(refKind, ID); ok {
+			entry[attribute] = name
+		} else {
+			entry[attribute+"_id"] = ID
+		}
+	}
+
+	if kind.Labelable {
+		if labels := x.Backend.LabelNames(object); len(labels) > 0 {
+			entry["labels"] = labels
+		}
+	}
+
+	if kind.Name == "templates" {
+		ID, _ := object["id"].(string)
+		scripts, err := x.exportScripts(ID)
+		if err != nil {
+			return nil, err
+		}
+		if len(scripts) > 0 {
+			entry["scripts"] = scripts
+		}
+	}
+	return entry, nil
+}
+
+// ExportFirewallRules converts rules into strings, as in --rules flag
+func ExportFirewallRules(value interface{}) []interface{} {
+	rules := []interface{}{}
+	items, _ := value.([]interface{})
+	for _, item := range items {
+		rule, ok := item.(map[string]interface{})
+		if !ok {
+			rules = append(rules, item)
+			continue
+		}
+		ports := fmt.Sprintf("%v", rule["min_port"])
+		if maxPort := fmt.Sprintf("%v", rule["max_port"]); maxPort != ports {
+			ports = fmt.Sprintf("%s-%s", ports, maxPort)
+		}
+		rules = append(rules, fmt.Sprintf("%v/%s:%v", rule["ip_protocol"], ports, rule["source"]))
+	}
+	return rules
+}
+
+// exportCookbookVersions converts cookbook versions into strings, as in --cookbook-versions flag
+func (x *Exporter) exportCookbookVersions(value interface{}) ([]interface{}, error) {
+	items, _ := value.(map[string]interface{})
+	cookbookVersions := []interface{}{}
+	for _, name := range SortedKeys(items) {
+		fields, _ := items[name].(map[string]interface{})
+		versionID, _ := fields["version_id"].(string)
+		if versionID == "" {
+			version, _ := fields["version"].(string)
+			cookbookVersions = append(cookbookVersions, name+strings.Replace(version, " ", "", -1))
+			continue
+		}
+		version, err := x.Backend.CookbookVersion(versionID)
+		if err != nil {
+			return nil, fmt.Errorf("cookbook %s: %v", name, err)
+		}
+		cookbookVersions = append(cookbookVersions, fmt.Sprintf("%s:%s", name, version))
+	}
+	return cookbookVersions, nil
+}
+
+// exportScripts returns the scripts of a template, as declared in manifests
+func (x *Exporter) exportScripts(templateID string) ([]interface{}, error) {
+	current, err := x.Backend.TemplateScripts(templateID)
+	if err != nil {
+		return nil, err
+	}
+
+	scripts := []interface{}{}
+	for _, scriptType := range TemplateScriptTypes {
+		for _, templateScript := range current[scriptType] {
+			script := map[string]interface{}{"type": scriptType}
+			name, err := x.Backend.ScriptName(templateScript.ScriptID)
+			if err != nil {
+				return nil, err
+			}
+			if name != "" {
+				script["script"] = name
+			} else {
+				script["script_id"] = templateScript.ScriptID
+			}
+			if len(templateScript.ParameterValues) > 0 {
+				script["parameter_values"] = templateScript.ParameterValues
+			}
+			scripts = append(scripts, script)
+		}
+	}
+	return scripts, nil
+}
@@ -0,0 +1,100 @@
+package manifest
+
+import (
+	"testing"
+
+	"github.com/ingrammicro/concerto/api/types"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v2"
+)
+
+// testExportObjects returns current objects of every kind, as received from the API
+func testExportObjects() map[string][]map[string]interface{} {
+	return map[string][]map[string]interface{}{
+		"labels": {
+			{"id": "label-1", "name": "env=prod", "namespace": ""},
+			{"id": "label-2", "name": "imco:managed", "namespace": "imco"},
+		},
+		"firewall_profiles": {
+			{"id": "fp-1", "name": "web", "description": "Web", "rules": []interface{}{
+				map[string]interface{}{"ip_protocol": "tcp", "min_port": 80.0, "max_port": 80.0, "source": "0.0.0.0/0"},
+				map[string]interface{}{"ip_protocol": "udp", "min_port": 1000.0, "max_port": 2000.0, "source": "10.0.0.0/8"},
+			}},
+		},
+		"vpcs": {
+			{"id": "vpc-1", "name": "staging", "cidr": "10.0.0.0/16", "cloud_account_id": "account", "realm_provider_name": "eu-west-1"},
+			{"id": "vpc-2", "name": "prod", "cidr": "10.1.0.0/16", "cloud_account_id": "account", "realm_provider_name": "eu-west-1"},
+		},
+		"subnets": {
+			{"parent": "staging", "id": "subnet-1", "name": "public", "vpc_id": "vpc-1", "cidr": "10.0.1.0/24", "type": "public"},
+			{"parent": "prod", "id": "subnet-2", "name": "public", "vpc_id": "vpc-2", "cidr": "10.1.1.0/24", "type": "public"},
+		},
+		"templates": {
+			{"id": "template-1", "name": "web", "generic_image_id": "image", "run_list": []interface{}{"recipe[nginx]"},
+				"label_ids": []interface{}{"label-1", "label-2"},
+				"cookbook_versions": map[string]interface{}{
+					"nginx": map[string]interface{}{"version_id": "cbv-1", "version": "1.0"},
+					"apt":   map[string]interface{}{"version": "~> 2.0"},
+				}},
+		},
+		"server_arrays": {
+			{"id": "array-1", "name": "workers", "size": 2.0, "cloud_account_id": "account", "server_plan_id": "plan",
+				"template_id": "template-1", "subnet_id": "subnet-1"},
+		},
+		"servers": {
+			{"id": "server-1", "name": "workers-1", "cloud_account_id": "account", "server_plan_id": "plan", "server_array_id": "array-1"},
+			{"id": "server-2", "name": "web", "cloud_account_id": "account", "server_plan_id": "plan",
+				"template_id": "template-1", "subnet_id": "subnet-2", "firewall_profile_id": "fp-1", "ssh_profile_id": "unknown"},
+		},
+	}
+}
+
+func TestExportRoundTrip(t *testing.T) {
+	assert := assert.New(t)
+
+	planner := testPlanner(testExportObjects())
+	exporter := &Exporter{State: planner.State, Backend: planner.Backend}
+	sections, err := exporter.Export(Kinds, nil)
+	assert.Nil(err, "Couldn't export resources")
+
+	servers, _ := sections["servers"].([]interface{})
+	if assert.Len(servers, 1, "Servers belonging to an array should not be exported") {
+		server := servers[0].(map[string]interface{})
+		assert.Equal("prod/public", server["subnet"], "Subnets named as others should be referenced along with their VPC")
+		assert.Equal("unknown", server["ssh_profile_id"], "Unknown references should be kept as IDs")
+	}
+	fp := sections["firewall_profiles"].([]interface{})[0].(map[string]interface{})
+	assert.Equal([]interface{}{"tcp/80:0.0.0.0/0", "udp/1000-2000:10.0.0.0/8"}, fp["rules"], "Unexpected rules")
+	template := sections["templates"].([]interface{})[0].(map[string]interface{})
+	assert.Equal([]interface{}{"apt~>2.0", "nginx:1.0"}, template["cookbook_versions"], "Unexpected cookbook versions")
+	assert.Equal([]interface{}{map[string]interface{}{"type": "boot", "script": "install"}}, template["scripts"], "Unexpected scripts")
+	assert.Equal([]interface{}{map[string]interface{}{"name": "env=prod"}}, sections["labels"], "Namespaced labels should not be exported")
+
+	data, err := yaml.Marshal(sections)
+	assert.Nil(err, "Couldn't marshal manifest")
+	resources, err := Parse(data)
+	if !assert.Nil(err, "Couldn't parse exported manifest:\n%s", data) {
+		return
+	}
+	steps, err := testPlanner(testExportObjects()).Plan(resources)
+	assert.Nil(err, "Couldn't plan exported manifest")
+	assert.Len(steps, 9, "Every exported resource should be planned")
+	for _, step := range steps {
+		assert.Equal(ActionNoop, step.Action, "%s/%s should be unchanged: %v", step.Kind, step.Name, step.Changes)
+	}
+}
+
+func TestExportSelector(t *testing.T) {
+	assert := assert.New(t)
+
+	planner := testPlanner(testExportObjects())
+	exporter := &Exporter{State: planner.State, Backend: planner.Backend}
+	selector, err := types.ParseLabelSelector("env=prod")
+	assert.Nil(err, "Couldn't parse selector")
+	sections, err := exporter.Export([]*Kind{KindByName("labels"), KindByName("vpcs"), KindByName("templates"), KindByName("servers")}, selector)
+	assert.Nil(err, "Couldn't export resources")
+
+	assert.Len(sections, 2, "Only the matching templates should be exported, along with their labels: %v", sections)
+	assert.Len(sections["templates"], 1, "Template should be exported")
+	assert.Equal([]interface{}{map[string]interface{}{"name": "env=prod"}}, sections["labels"], "Only the assigned labels should be exported")
+}
@@ -0,0 +1,357 @@
+// Package manifest parses manifests declaring IMCO resources, and plans the steps converging the current
+// resources to the declared ones
+package manifest
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/ingrammicro/concerto/api/types"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	ActionCreate   = "create"
+	ActionUpdate   = "update"
+	ActionNoop     = "no-op"
+	ActionConflict = "conflict"
+	ActionDelete   = "delete"
+
+	// KnownAfterApply is the value of the attributes which depend on resources which don't exist yet
+	KnownAfterApply = "(known after apply)"
+)
+
+// Kind describes a kind of resource which can be declared in a manifest
+type Kind struct {
+	// Name is both the manifest section and the kind used in references
+	Name string
+	// Fields are the attributes sent as they are to the API
+	Fields   []string
+	Required []string
+	// Refs are the attributes referencing another resource by name, mapped to the referenced kind.
+	// Their value is sent as <attribute>_id, which can also be given directly.
+	Refs map[string]string
+	// Parent is the reference sent in the request path instead of the payload
+	Parent string
+	// ParentFlag is the parent attribute telling whether the parent has a resource of this kind
+	ParentFlag string
+	// NamedByParent resources have no name of their own, they are named after their parent
+	NamedByParent bool
+	// Attach is the reference to the server the resource is attached to, once created
+	Attach string
+	// Immutable are the payload attributes which cannot be updated
+	Immutable []string
+	// Secrets are the attributes which are only sent on creation, and never exported
+	Secrets   []string
+	Labelable bool
+}
+
+// Kinds are ordered by dependency: a resource only references resources of previous kinds
+var Kinds = []*Kind{
+	{
+		// labels are named as [namespace:]name[=value]
+		Name:     "labels",
+		Fields:   []string{"name"},
+		Required: []string{"name"},
+	},
+	{
+		Name:      "ssh_profiles",
+		Fields:    []string{"name", "public_key", "private_key"},
+		Required:  []string{"name", "public_key"},
+		Secrets:   []string{"private_key"},
+		Labelable: true,
+	},
+	{
+		Name:      "firewall_profiles",
+		Fields:    []string{"name", "description", "rules"},
+		Required:  []string{"name", "description"},
+		Labelable: true,
+	},
+	{
+		Name:      "vpcs",
+		Fields:    []string{"name", "cidr", "cloud_account_id", "realm_provider_name"},
+		Required:  []string{"name", "cidr", "cloud_account_id", "realm_provider_name"},
+		Immutable: []string{"cidr", "cloud_account_id", "realm_provider_name"},
+		Labelable: true,
+	},
+	{
+		Name:      "subnets",
+		Fields:    []string{"name", "cidr", "type"},
+		Required:  []string{"name", "cidr", "type"},
+		Refs:      map[string]string{"vpc": "vpcs"},
+		Parent:    "vpc",
+		Immutable: []string{"cidr", "type"},
+	},
+	{
+		Name:          "vpns",
+		Fields:        []string{"public_ip", "psk", "exposed_cidrs", "vpn_plan_id"},
+		Required:      []string{"public_ip", "exposed_cidrs", "vpn_plan_id"},
+		Refs:          map[string]string{"vpc": "vpcs"},
+		Parent:        "vpc",
+		ParentFlag:    "has_vpn",
+		NamedByParent: true,
+		Immutable:     []string{"public_ip", "exposed_cidrs", "vpn_plan_id"},
+		Secrets:       []string{"psk"},
+	},
+	{
+		Name:      "templates",
+		Fields:    []string{"name", "generic_image_id", "run_list", "configuration_attributes", "cookbook_versions"},
+		Required:  []string{"name", "generic_image_id"},
+		Immutable: []string{"generic_image_id"},
+		Labelable: true,
+	},
+	{
+		Name:     "server_arrays",
+		Fields:   []string{"name", "size", "cloud_account_id", "server_plan_id", "privateness"},
+		Required: []string{"name", "cloud_account_id", "server_plan_id"},
+		Refs: map[string]string{
+			"template":         "templates",
+			"firewall_profile": "firewall_profiles",
+			"ssh_profile":      "ssh_profiles",
+			"subnet":           "subnets",
+		},
+		Immutable: []string{"cloud_account_id", "template_id", "subnet_id", "privateness"},
+		Labelable: true,
+	},
+	{
+		Name:     "servers",
+		Fields:   []string{"name", "cloud_account_id", "server_plan_id", "privateness"},
+		Required: []string{"name", "cloud_account_id", "server_plan_id"},
+		Refs: map[string]string{
+			"template":         "templates",
+			"firewall_profile": "firewall_profiles",
+			"ssh_profile":      "ssh_profiles",
+			"subnet":           "subnets",
+		},
+		Immutable: []string{"cloud_account_id", "template_id", "subnet_id", "privateness"},
+		Labelable: true,
+	},
+	{
+		Name:      "volumes",
+		Fields:    []string{"name", "size", "cloud_account_id", "storage_plan_id"},
+		Required:  []string{"name", "size", "cloud_account_id", "storage_plan_id"},
+		Refs:      map[string]string{"server": "servers"},
+		Attach:    "server",
+		Immutable: []string{"size", "cloud_account_id", "storage_plan_id"},
+		Labelable: true,
+	},
+	{
+		Name:      "floating_ips",
+		Fields:    []string{"name", "cloud_account_id", "realm_id"},
+		Required:  []string{"name", "cloud_account_id", "realm_id"},
+		Refs:      map[string]string{"server": "servers"},
+		Attach:    "server",
+		Immutable: []string{"cloud_account_id", "realm_id"},
+		Labelable: true,
+	},
+}
+
+// KindByName returns the manifest kind with the given name
+func KindByName(name string) *Kind {
+	for _, kind := range Kinds {
+		if kind.Name == name {
+			return kind
+		}
+	}
+	return nil
+}
+
+// ParentKind returns the kind of the parent, if any
+func (k *Kind) ParentKind() string {
+	return k.Refs[k.Parent]
+}
+
+// Resource is a resource declared in a manifest
+type Resource struct {
+	Kind *Kind
+	Name string
+	// Parent is the name or ID of the parent, for kinds with a parent
+	Parent     string
+	Attributes map[string]interface{}
+}
+
+// String returns the resource address, as kind/name, or parent_kind/parent/kind/name for kinds with a parent
+func (r *Resource) String() string {
+	return address(r.Kind, r.Parent, r.Name)
+}
+
+// QualifiedName returns the resource name, prefixed by its parent for kinds with a parent
+func (r *Resource) QualifiedName() string {
+	return qualifiedName(r.Kind, r.Parent, r.Name)
+}
+
+// qualifiedName returns the name, prefixed by the parent for kinds with a parent which are not named after it
+func qualifiedName(kind *Kind, parent string, name string) string {
+	if kind.Parent == "" || kind.NamedByParent {
+		return name
+	}
+	return fmt.Sprintf("%s/%s", parent, name)
+}
+
+// address returns the address of a resource, which is unique for every kind
+func address(kind *Kind, parent string, name string) string {
+	if kind.Parent == "" || kind.NamedByParent {
+		return fmt.Sprintf("%s/%s", kind.Name, name)
+	}
+	return fmt.Sprintf("%s/%s/%s/%s", kind.ParentKind(), parent, kind.Name, name)
+}
+
+// Ref returns the name or ID the resource references through the given attribute, telling whether it is an ID
+func (r *Resource) Ref(attribute string) (value string, isID bool) {
+	if id, ok := r.Attributes[attribute+"_id"].(string); ok && id != "" {
+		return id, true
+	}
+	name, _ := r.Attributes[attribute].(string)
+	return name, false
+}
+
+// ManagesAttachment tells whether the resource declares the server it is attached to, even if it is none
+func (r *Resource) ManagesAttachment() bool {
+	if r.Kind.Attach == "" {
+		return false
+	}
+	_, declared := r.Attributes[r.Kind.Attach]
+	return declared || r.Attributes[r.Kind.Attach+"_id"] != nil
+}
+
+// Labels returns the label names declared for the resource, sorted, telling whether they are declared at all
+func (r *Resource) Labels() ([]string, bool) {
+	value, found := r.Attributes["labels"]
+	if !found {
+		return nil, false
+	}
+	labels := []string{}
+	items, _ := value.([]interface{})
+	for _, item := range items {
+		labels = append(labels, strings.TrimSpace(fmt.Sprintf("%v", item)))
+	}
+	sort.Strings(labels)
+	return labels, true
+}
+
+// Step is an action planned over a manifest resource
+type Step struct {
+	Action  string   `json:"action" header:"ACTION"`
+	Kind    string   `json:"kind" header:"KIND"`
+	Name    string   `json:"name" header:"NAME"`
+	ID      string   `json:"id,omitempty" header:"ID"`
+	Changes []string `json:"changes,omitempty" header:"CHANGES"`
+	Status  string   `json:"status,omitempty" header:"STATUS"`
+}
+
+// Read reads the manifest file, or stdin when fileName is "-". Both YAML and JSON are accepted.
+func Read(fileName string) ([]*Resource, error) {
+	var reader io.Reader
+	if fileName == "-" {
+		reader = os.Stdin
+		fileName = "STDIN"
+	} else {
+		f, err := os.Open(fileName)
+		if err != nil {
+			return nil, fmt.Errorf("cannot open manifest %s: %v", fileName, err)
+		}
+		defer f.Close()
+		reader = f
+	}
+	data, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read manifest %s: %v", fileName, err)
+	}
+
+	resources, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid manifest %s: %v", fileName, err)
+	}
+	return resources, nil
+}
+
+// Parse decodes and validates a manifest, returning its resources in dependency order
+func Parse(data []byte) ([]*Resource, error) {
+	var raw map[string]interface{}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+
+	for section := range raw {
+		if KindByName(section) == nil {
+			return nil, fmt.Errorf("unknown section %s", section)
+		}
+	}
+
+	resources := []*Resource{}
+	declared := map[string]bool{}
+	for _, kind := range Kinds {
+		section, found := raw[kind.Name]
+		if !found || section == nil {
+			continue
+		}
+		entries, ok := NormalizeValue(section).([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("section %s must be a list", kind.Name)
+		}
+		for i, entry := range entries {
+			attributes, ok := entry.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("%s[%d] must be a map", kind.Name, i)
+			}
+			resource, err := NewResource(kind, attributes)
+			if err != nil {
+				return nil, fmt.Errorf("%s[%d]: %v", kind.Name, i, err)
+			}
+			if declared[resource.String()] {
+				return nil, fmt.Errorf("%s is declared more than once", resource)
+			}
+			declared[resource.String()] = true
+			resources = append(resources, resource)
+		}
+	}
+	return resources, nil
+}
+
+// NewResource validates the attributes of a resource of the given kind
+func NewResource(kind *Kind, attributes map[string]interface{}) (*Resource, error) {
+	known := map[string]bool{"labels": kind.Labelable, "scripts": kind.Name == "templates"}
+	for _, field := range kind.Fields {
+		known[field] = true
+	}
+	for ref := range kind.Refs {
+		known[ref], known[ref+"_id"] = true, true
+	}
+	for attribute := range attributes {
+		if !known[attribute] {
+			return nil, fmt.Errorf("unknown attribute %s", attribute)
+		}
+	}
+	for _, attribute := range kind.Required {
+		if v, found := attributes[attribute]; !found || v == nil || v == "" {
+			return nil, fmt.Errorf("missing required attribute %s", attribute)
+		}
+	}
+
+	resource := &Resource{Kind: kind, Attributes: attributes}
+	if kind.Parent != "" {
+		resource.Parent, _ = resource.Ref(kind.Parent)
+		if resource.Parent == "" {
+			return nil, fmt.Errorf("missing required attribute %s", kind.Parent)
+		}
+		if kind.NamedByParent {
+			resource.Name = resource.Parent
+			return resource, nil
+		}
+	}
+	name, ok := attributes["name"].(string)
+	if !ok {
+		return nil, fmt.Errorf("name must be a string")
+	}
+	if kind.Name == "labels" {
+		if _, err := types.ParseLabel(name); err != nil {
+			return nil, err
+		}
+	}
+	resource.Name = name
+	return resource, nil
+}
@@ -0,0 +1,131 @@
+package manifest
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testManifest = `
+servers:
+  - name: web
+    cloud_account_id: account
+    server_plan_id: plan
+    subnet: public
+    labels: [ "env=prod ", tier ]
+subnets:
+  - name: public
+    vpc: main
+    cidr: 10.0.1.0/24
+    type: public
+vpns:
+  - vpc: main
+    public_ip: 1.2.3.4
+    exposed_cidrs: [ 10.0.0.0/16 ]
+    vpn_plan_id: plan
+vpcs:
+  - name: main
+    cidr: 10.0.0.0/16
+    cloud_account_id: account
+    realm_provider_name: eu-west-1
+`
+
+func TestParse(t *testing.T) {
+	assert := assert.New(t)
+
+	resources, err := Parse([]byte(testManifest))
+	assert.Nil(err, "Couldn't parse manifest")
+	addresses := []string{}
+	for _, resource := range resources {
+		addresses = append(addresses, resource.String())
+	}
+	assert.Equal([]string{"vpcs/main", "vpcs/main/subnets/public", "vpns/main", "servers/web"}, addresses, "Resources should be in dependency order")
+
+	labels, declared := resources[3].Labels()
+	assert.True(declared, "Labels should be declared")
+	assert.Equal([]string{"env=prod", "tier"}, labels, "Unexpected labels")
+	_, declared = resources[0].Labels()
+	assert.False(declared, "Labels should not be declared")
+
+	ref, isID := resources[3].Ref("subnet")
+	assert.Equal("public", ref, "Unexpected reference")
+	assert.False(isID, "Reference should be a name")
+
+	for manifest, message := range map[string]string{
+		"volumes: []\nunknown: []": "unknown section unknown",
+		"vpcs: {name: main}":       "section vpcs must be a list",
+		"vpcs: [main]":             "vpcs[0] must be a map",
+		"ssh_profiles: [{name: a, public_key: k, key: v}]":                                    "ssh_profiles[0]: unknown attribute key",
+		"ssh_profiles: [{name: a}]":                                                           "ssh_profiles[0]: missing required attribute public_key",
+		"ssh_profiles: [{name: 1, public_key: k}]":                                            "ssh_profiles[0]: name must be a string",
+		"ssh_profiles: [{name: a, public_key: k}, {name: a, public_key: k}]":                  "ssh_profiles/a is declared more than once",
+		"subnets: [{name: a, cidr: c, type: t}]":                                              "subnets[0]: missing required attribute vpc",
+		"subnets: [{name: a, cidr: c, type: t, vpc: v}, {name: a, cidr: d, type: t, vpc: v}]": "vpcs/v/subnets/a is declared more than once",
+	} {
+		_, err = Parse([]byte(manifest))
+		if assert.NotNil(err, "%s should be invalid", manifest) {
+			assert.Equal(message, err.Error(), "Unexpected error for %s", manifest)
+		}
+	}
+}
+
+func TestNewResource(t *testing.T) {
+	assert := assert.New(t)
+
+	resource, err := NewResource(KindByName("vpns"), map[string]interface{}{
+		"vpc_id": "vpc-id", "public_ip": "1.2.3.4", "exposed_cidrs": []interface{}{}, "vpn_plan_id": "plan",
+	})
+	assert.Nil(err, "Couldn't create resource")
+	assert.Equal("vpc-id", resource.Name, "Resources without name should be named after their parent")
+	ref, isID := resource.Ref("vpc")
+	assert.Equal("vpc-id", ref, "Unexpected reference")
+	assert.True(isID, "Reference should be an ID")
+
+	resource, err = NewResource(KindByName("volumes"), map[string]interface{}{
+		"name": "data", "size": 10, "cloud_account_id": "account", "storage_plan_id": "plan", "server": nil,
+	})
+	assert.Nil(err, "Couldn't create resource")
+	assert.True(resource.ManagesAttachment(), "A null server should detach the volume")
+	delete(resource.Attributes, "server")
+	assert.False(resource.ManagesAttachment(), "Attachment should not be managed when not declared")
+
+	_, err = NewResource(KindByName("vpcs"), map[string]interface{}{"name": "main", "labels": []interface{}{}, "scripts": []interface{}{}})
+	assert.NotNil(err, "Only templates have scripts")
+	_, err = NewResource(KindByName("subnets"), map[string]interface{}{"name": "a", "cidr": "c", "type": "t", "vpc": "main", "labels": []interface{}{}})
+	assert.NotNil(err, "Subnets cannot be labelled")
+}
+
+func TestValueEqual(t *testing.T) {
+	assert := assert.New(t)
+
+	tests := []struct {
+		attribute string
+		desired   interface{}
+		current   interface{}
+		equal     bool
+	}{
+		{"name", "a", "a", true},
+		{"name", "a", "b", false},
+		{"size", 10, 10.0, true},
+		{"run_list", []interface{}{"a", "b"}, []interface{}{"b", "a"}, false},
+		{"rules", []interface{}{map[string]interface{}{"cidr": "a"}, map[string]interface{}{"cidr": "b"}}, []interface{}{map[string]interface{}{"cidr": "b"}, map[string]interface{}{"cidr": "a"}}, true},
+		{"description", "", nil, true},
+		{"exposed_cidrs", []interface{}{}, nil, true},
+		{"configuration_attributes", map[string]interface{}{}, nil, true},
+		{"configuration_attributes", map[string]interface{}{"a": 1}, map[string]interface{}{"a": 1, "b": 2}, false},
+		// nested maps only need to contain the desired keys
+		{"cookbook_versions", map[string]interface{}{"nginx": map[string]interface{}{"version": "1.0"}}, map[string]interface{}{"nginx": map[string]interface{}{"version": "1.0", "version_id": "id"}}, true},
+		{"cookbook_versions", map[string]interface{}{"nginx": map[string]interface{}{"version": "1.0"}}, map[string]interface{}{"nginx": map[string]interface{}{"version": "2.0"}}, false},
+	}
+	for _, test := range tests {
+		assert.Equal(test.equal, ValueEqual(test.attribute, test.desired, test.current), "Unexpected comparison of %s: %v and %v", test.attribute, test.desired, test.current)
+	}
+}
+
+func TestChange(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal(`+ name: "a"`, Change("name", nil, "a"), "Unexpected addition")
+	assert.Equal(`~ size: 1 -> 2`, Change("size", 1, 2), "Unexpected change")
+	assert.Equal(`~ subnet_id: "id" -> (known after apply)`, Change("subnet_id", "id", KnownAfterApply), "Unexpected pending change")
+}
@@ -0,0 +1,304 @@
+package manifest
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ingrammicro/concerto/api/types"
+	"github.com/ingrammicro/concerto/utils"
+)
+
+// TemplateScriptTypes are the types of template scripts, in execution order
+var TemplateScriptTypes = []string{"boot", "operational", "shutdown"}
+
+// Backend provides the details of the current resources a plan needs besides their objects
+type Backend interface {
+	// CookbookVersions converts cookbook versions given as in --cookbook-versions flag
+	CookbookVersions(cookbookVersions []string) (map[string]interface{}, error)
+	// CookbookVersion returns the version of the uploaded cookbook version with the given ID
+	CookbookVersion(versionID string) (string, error)
+	// ScriptID resolves a script by its name
+	ScriptID(name string) (string, error)
+	// ScriptName returns the name of the script with the given ID, or none when it doesn't identify it
+	ScriptName(ID string) (string, error)
+	// TemplateScripts returns the scripts of a template, by type and in execution order
+	TemplateScripts(templateID string) (map[string][]*types.TemplateScript, error)
+	// LabelNames returns the names of the labels assigned to an object, sorted
+	LabelNames(object map[string]interface{}) []string
+}
+
+// Script is a template script declared in a manifest
+type Script struct {
+	Type            string                 `json:"type"`
+	ScriptID        string                 `json:"script_id"`
+	ParameterValues map[string]interface{} `json:"parameter_values,omitempty"`
+}
+
+// Planner computes the steps converging the current state to the declared resources
+type Planner struct {
+	State   *State
+	Backend Backend
+}
+
+// Plan computes the step required by every declared resource, in order. The state must hold the current
+// objects of every kind the resources declare or reference.
+func (p *Planner) Plan(resources []*Resource) ([]*Step, error) {
+	p.State.Declare(resources)
+	steps := []*Step{}
+	for _, resource := range resources {
+		step, err := p.PlanResource(resource)
+		if err != nil {
+			return nil, err
+		}
+		steps = append(steps, step)
+	}
+	return steps, nil
+}
+
+// DesiredPayload returns the payload for the resource, with its references resolved
+func (p *Planner) DesiredPayload(resource *Resource) (map[string]interface{}, error) {
+	payload := map[string]interface{}{}
+	for _, field := range resource.Kind.Fields {
+		value, found := resource.Attributes[field]
+		if !found {
+			continue
+		}
+		switch field {
+		case "rules":
+			rules, err := FirewallRules(value)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %v", resource, err)
+			}
+			value = rules
+		case "cookbook_versions":
+			items, ok := value.([]interface{})
+			if !ok {
+				return nil, fmt.Errorf("%s: cookbook_versions must be a list", resource)
+			}
+			cbvs := []string{}
+			for _, item := range items {
+				cbvs = append(cbvs, fmt.Sprintf("%v", item))
+			}
+			cookbookVersions, err := p.Backend.CookbookVersions(cbvs)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %v", resource, err)
+			}
+			value = cookbookVersions
+		}
+		payload[field] = value
+	}
+
+	for attribute := range resource.Kind.Refs {
+		if attribute == resource.Kind.Parent || attribute == resource.Kind.Attach {
+			continue
+		}
+		ID, pending, err := p.State.ResolveRef(resource, attribute)
+		if err != nil {
+			return nil, err
+		}
+		if pending {
+			payload[attribute+"_id"] = KnownAfterApply
+		} else if ID != "" {
+			payload[attribute+"_id"] = ID
+		}
+	}
+	return payload, nil
+}
+
+// FirewallRules converts rules given as strings, as in --rules flag, or as maps into firewall rules
+func FirewallRules(value interface{}) ([]interface{}, error) {
+	items, ok := value.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("rules must be a list")
+	}
+	rules := []interface{}{}
+	for _, item := range items {
+		if s, ok := item.(string); ok {
+			fp := new(types.FirewallProfile)
+			if err := fp.ConvertFlagParamsToRules(s); err != nil {
+				return nil, err
+			}
+			for _, rule := range fp.Rules {
+				rules = append(rules, rule)
+			}
+			continue
+		}
+		rules = append(rules, item)
+	}
+	return rules, nil
+}
+
+// DesiredScripts returns the template scripts declared for the resource, by type, telling whether they are
+// declared at all
+func (p *Planner) DesiredScripts(resource *Resource) (map[string][]*Script, bool, error) {
+	value, found := resource.Attributes["scripts"]
+	if !found {
+		return nil, false, nil
+	}
+	items, ok := value.([]interface{})
+	if !ok {
+		return nil, false, fmt.Errorf("%s: scripts must be a list", resource)
+	}
+
+	scripts := map[string][]*Script{}
+	for i, item := range items {
+		attributes, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, false, fmt.Errorf("%s: scripts[%d] must be a map", resource, i)
+		}
+		script := &Script{}
+		script.Type, _ = attributes["type"].(string)
+		valid := false
+		for _, t := range TemplateScriptTypes {
+			valid = valid || t == script.Type
+		}
+		if !valid {
+			return nil, false, fmt.Errorf("%s: scripts[%d] type must be one of %s", resource, i, strings.Join(TemplateScriptTypes, ", "))
+		}
+		script.ScriptID, _ = attributes["script_id"].(string)
+		if script.ScriptID == "" {
+			name, _ := attributes["script"].(string)
+			ID, err := p.Backend.ScriptID(name)
+			if err != nil {
+				return nil, false, fmt.Errorf("%s: scripts[%d]: %v", resource, i, err)
+			}
+			script.ScriptID = ID
+		}
+		script.ParameterValues, _ = attributes["parameter_values"].(map[string]interface{})
+		scripts[script.Type] = append(scripts[script.Type], script)
+	}
+	return scripts, true, nil
+}
+
+// ChangedScriptTypes returns the script types whose scripts differ from the declared ones
+func ChangedScriptTypes(desired map[string][]*Script, current map[string][]*types.TemplateScript) []string {
+	changed := []string{}
+	for _, scriptType := range TemplateScriptTypes {
+		d, c := desired[scriptType], current[scriptType]
+		equal := len(d) == len(c)
+		for i := 0; equal && i < len(d); i++ {
+			equal = d[i].ScriptID == c[i].ScriptID && ValueEqual("parameter_values", d[i].ParameterValues, c[i].ParameterValues)
+		}
+		if !equal {
+			changed = append(changed, scriptType)
+		}
+	}
+	return changed
+}
+
+// PlanResource computes the step required by a declared resource
+func (p *Planner) PlanResource(resource *Resource) (*Step, error) {
+	kind := resource.Kind
+	step := &Step{Kind: kind.Name, Name: resource.QualifiedName(), Changes: []string{}}
+
+	object, err := p.State.Lookup(resource)
+	if err != nil {
+		return nil, err
+	}
+	payload, err := p.DesiredPayload(resource)
+	if err != nil {
+		return nil, err
+	}
+	labels, manageLabels := resource.Labels()
+	scripts, manageScripts, err := p.DesiredScripts(resource)
+	if err != nil {
+		return nil, err
+	}
+
+	references := map[string]string{}
+	for _, attribute := range []string{kind.Parent, kind.Attach} {
+		if attribute == "" {
+			continue
+		}
+		ID, pending, err := p.State.ResolveRef(resource, attribute)
+		if err != nil {
+			return nil, err
+		}
+		if pending {
+			ID = KnownAfterApply
+		}
+		references[attribute] = ID
+	}
+
+	if object == nil {
+		step.Action = ActionCreate
+		for _, attribute := range SortedKeys(payload) {
+			step.Changes = append(step.Changes, Change(attribute, nil, payload[attribute]))
+		}
+		for _, attribute := range []string{kind.Parent, kind.Attach} {
+			if value, _ := resource.Ref(attribute); value != "" {
+				step.Changes = append(step.Changes, Change(attribute, nil, value))
+			}
+		}
+		if manageLabels {
+			step.Changes = append(step.Changes, Change("labels", nil, labels))
+		}
+		for _, scriptType := range TemplateScriptTypes {
+			if len(scripts[scriptType]) > 0 {
+				step.Changes = append(step.Changes, Change(scriptType+"_scripts", nil, len(scripts[scriptType])))
+			}
+		}
+		return step, nil
+	}
+
+	if step.ID, _ = object["id"].(string); step.ID == "" {
+		return nil, fmt.Errorf("%s has no ID", resource)
+	}
+	conflict := false
+	for _, attribute := range SortedKeys(payload) {
+		if utils.Contains(kind.Secrets, attribute) {
+			continue
+		}
+		if payload[attribute] != KnownAfterApply && ValueEqual(attribute, payload[attribute], object[attribute]) {
+			continue
+		}
+		step.Changes = append(step.Changes, Change(attribute, object[attribute], payload[attribute]))
+		conflict = conflict || utils.Contains(kind.Immutable, attribute)
+	}
+	if kind.Parent != "" {
+		attribute := kind.Parent + "_id"
+		if ID := references[kind.Parent]; ID != object[attribute] {
+			step.Changes = append(step.Changes, Change(attribute, object[attribute], ID))
+			conflict = true
+		}
+	}
+	if kind.Attach != "" && resource.ManagesAttachment() {
+		if ID, _ := object["attached_server_id"].(string); ID != references[kind.Attach] {
+			step.Changes = append(step.Changes, Change("attached_server_id", ID, references[kind.Attach]))
+		}
+	}
+	if manageLabels {
+		if current := p.Backend.LabelNames(object); strings.Join(current, ",") != strings.Join(labels, ",") {
+			step.Changes = append(step.Changes, Change("labels", current, labels))
+		}
+	}
+	if manageScripts {
+		current, err := p.Backend.TemplateScripts(step.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, scriptType := range ChangedScriptTypes(scripts, current) {
+			step.Changes = append(step.Changes, Change(scriptType+"_scripts", len(current[scriptType]), len(scripts[scriptType])))
+		}
+	}
+
+	switch {
+	case conflict:
+		step.Action = ActionConflict
+	case len(step.Changes) > 0:
+		step.Action = ActionUpdate
+	default:
+		step.Action = ActionNoop
+	}
+	return step, nil
+}
+
+// Conflict returns an error for the first step which cannot be applied, if any
+func Conflict(steps []*Step) error {
+	for _, step := range steps {
+		if step.Action == ActionConflict {
+			return fmt.Errorf("%s/%s has changes on attributes which cannot be updated, it must be deleted first", step.Kind, step.Name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,316 @@
+package manifest
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/ingrammicro/concerto/api/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// testBackend resolves the details of the current resources from fixed data
+type testBackend struct {
+	scripts          map[string]string
+	templateScripts  map[string][]*types.TemplateScript
+	labels           map[string]string
+	cookbookVersions map[string]string
+}
+
+func (b *testBackend) CookbookVersions(cookbookVersions []string) (map[string]interface{}, error) {
+	versions := map[string]interface{}{}
+	for _, cbv := range cookbookVersions {
+		if i := strings.Index(cbv, ":"); i > 0 {
+			for ID, version := range b.cookbookVersions {
+				if version == cbv {
+					versions[cbv[:i]] = map[string]interface{}{"version_id": ID}
+				}
+			}
+			continue
+		}
+		i := strings.IndexAny(cbv, "~<>=")
+		versions[cbv[:i]] = map[string]interface{}{"version": cbv[i:i+2] + " " + cbv[i+2:]}
+	}
+	return versions, nil
+}
+
+func (b *testBackend) CookbookVersion(versionID string) (string, error) {
+	if version, found := b.cookbookVersions[versionID]; found {
+		return version[strings.Index(version, ":")+1:], nil
+	}
+	return "", fmt.Errorf("unknown cookbook version %s", versionID)
+}
+
+func (b *testBackend) ScriptID(name string) (string, error) {
+	if ID, found := b.scripts[name]; found {
+		return ID, nil
+	}
+	return "", fmt.Errorf("unknown script %s", name)
+}
+
+func (b *testBackend) ScriptName(ID string) (string, error) {
+	for name, scriptID := range b.scripts {
+		if scriptID == ID {
+			return name, nil
+		}
+	}
+	return "", nil
+}
+
+func (b *testBackend) TemplateScripts(templateID string) (map[string][]*types.TemplateScript, error) {
+	return map[string][]*types.TemplateScript{"boot": b.templateScripts[templateID]}, nil
+}
+
+func (b *testBackend) LabelNames(object map[string]interface{}) []string {
+	names := []string{}
+	IDs, _ := object["label_ids"].([]interface{})
+	for _, ID := range IDs {
+		if name, found := b.labels[fmt.Sprintf("%v", ID)]; found {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// testPlanner returns a planner whose state has the given objects, by kind
+func testPlanner(objects map[string][]map[string]interface{}) *Planner {
+	state := NewState()
+	for _, kind := range Kinds {
+		for _, object := range objects[kind.Name] {
+			parent := ""
+			if kind.Parent != "" {
+				parent, _ = object["parent"].(string)
+				delete(object, "parent")
+			}
+			state.Add(kind, parent, object)
+		}
+	}
+	return &Planner{
+		State: state,
+		Backend: &testBackend{
+			scripts:          map[string]string{"install": "script-1"},
+			templateScripts:  map[string][]*types.TemplateScript{"template-1": {{ID: "ts-1", ScriptID: "script-1"}}},
+			labels:           map[string]string{"label-1": "env=prod", "label-2": "imco:managed"},
+			cookbookVersions: map[string]string{"cbv-1": "nginx:1.0"},
+		},
+	}
+}
+
+func TestPlan(t *testing.T) {
+	assert := assert.New(t)
+
+	planner := testPlanner(map[string][]map[string]interface{}{
+		"vpcs":      {{"id": "vpc-1", "name": "main", "cidr": "10.0.0.0/16", "cloud_account_id": "account", "realm_provider_name": "eu-west-1"}},
+		"subnets":   {{"parent": "main", "id": "subnet-1", "name": "public", "vpc_id": "vpc-1", "cidr": "10.0.1.0/24", "type": "public"}},
+		"templates": {{"id": "template-1", "name": "web", "generic_image_id": "image", "label_ids": []interface{}{"label-1"}}},
+		"firewall_profiles": {
+			{"id": "fp-1", "name": "web", "description": "Web", "rules": []interface{}{
+				map[string]interface{}{"ip_protocol": "tcp", "min_port": 443, "max_port": 443, "source": "0.0.0.0/0"},
+				map[string]interface{}{"ip_protocol": "tcp", "min_port": 80, "max_port": 80, "source": "0.0.0.0/0"},
+			}},
+		},
+	})
+	resources, err := Parse([]byte(`
+vpcs:
+  - name: main
+    cidr: 10.0.0.0/8
+    cloud_account_id: account
+    realm_provider_name: eu-west-1
+subnets:
+  - name: public
+    vpc: main
+    cidr: 10.0.1.0/24
+    type: public
+firewall_profiles:
+  - name: web
+    description: Web
+    rules: [ "tcp/80:0.0.0.0/0", "tcp/443:0.0.0.0/0" ]
+templates:
+  - name: web
+    generic_image_id: image
+    labels: [ env=prod, tier=web ]
+    scripts:
+      - type: boot
+        script: install
+ssh_profiles:
+  - name: admin
+    public_key: key
+servers:
+  - name: web
+    cloud_account_id: account
+    server_plan_id: plan
+    subnet: public
+    template: web
+    ssh_profile: admin
+`))
+	assert.Nil(err, "Couldn't parse manifest")
+
+	steps, err := planner.Plan(resources)
+	assert.Nil(err, "Couldn't plan manifest")
+	plan := map[string]*Step{}
+	for _, step := range steps {
+		plan[fmt.Sprintf("%s/%s", step.Kind, step.Name)] = step
+	}
+
+	assert.Equal(ActionConflict, plan["vpcs/main"].Action, "Changing the CIDR of a VPC should conflict")
+	assert.Equal([]string{`~ cidr: "10.0.0.0/16" -> "10.0.0.0/8"`}, plan["vpcs/main"].Changes, "Unexpected VPC changes")
+	assert.Equal(ActionNoop, plan["subnets/main/public"].Action, "Subnet should be unchanged: %v", plan["subnets/main/public"].Changes)
+	assert.Equal(ActionNoop, plan["firewall_profiles/web"].Action, "Rules should be compared regardless of order: %v", plan["firewall_profiles/web"].Changes)
+	assert.Equal(ActionUpdate, plan["templates/web"].Action, "Template labels should be updated")
+	assert.Equal("template-1", plan["templates/web"].ID, "Unexpected template ID")
+	assert.Equal([]string{`~ labels: ["env=prod"] -> ["env=prod","tier=web"]`}, plan["templates/web"].Changes, "Scripts should be unchanged")
+	assert.Equal(ActionCreate, plan["ssh_profiles/admin"].Action, "SSH profile should be created")
+	assert.Equal(ActionCreate, plan["servers/web"].Action, "Server should be created")
+	assert.Contains(plan["servers/web"].Changes, `+ ssh_profile_id: (known after apply)`, "SSH profile should be pending")
+	assert.Contains(plan["servers/web"].Changes, `+ subnet_id: "subnet-1"`, "Subnet should be resolved")
+	assert.Contains(plan["servers/web"].Changes, `+ template_id: "template-1"`, "Template should be resolved")
+
+	assert.NotNil(Conflict(steps), "Plan should conflict")
+	plan["vpcs/main"].Action = ActionUpdate
+	assert.Nil(Conflict(steps), "Only the VPC should conflict")
+}
+
+func TestPlanTemplateScripts(t *testing.T) {
+	assert := assert.New(t)
+
+	planner := testPlanner(map[string][]map[string]interface{}{
+		"templates": {{"id": "template-1", "name": "web", "generic_image_id": "image"}},
+	})
+	resource, err := NewResource(KindByName("templates"), map[string]interface{}{
+		"name":             "web",
+		"generic_image_id": "image",
+		"scripts": []interface{}{
+			map[string]interface{}{"type": "boot", "script": "install", "parameter_values": map[string]interface{}{"port": "80"}},
+			map[string]interface{}{"type": "shutdown", "script_id": "script-2"},
+		},
+	})
+	assert.Nil(err, "Couldn't create resource")
+
+	step, err := planner.PlanResource(resource)
+	assert.Nil(err, "Couldn't plan resource")
+	assert.Equal(ActionUpdate, step.Action, "Template should be updated")
+	assert.Equal([]string{"~ boot_scripts: 1 -> 1", "~ shutdown_scripts: 0 -> 1"}, step.Changes, "Unexpected changes")
+
+	resource.Attributes["scripts"] = []interface{}{map[string]interface{}{"type": "boot", "script": "unknown"}}
+	_, err = planner.PlanResource(resource)
+	assert.NotNil(err, "Unknown scripts should fail")
+	resource.Attributes["scripts"] = []interface{}{map[string]interface{}{"type": "reboot", "script": "install"}}
+	_, err = planner.PlanResource(resource)
+	assert.NotNil(err, "Unknown script types should fail")
+}
+
+func TestResolveRef(t *testing.T) {
+	assert := assert.New(t)
+
+	planner := testPlanner(map[string][]map[string]interface{}{
+		"templates": {
+			{"id": "template-1", "name": "web"},
+			{"id": "template-2", "name": "db"},
+			{"id": "template-3", "name": "db"},
+			{"name": "broken"},
+		},
+	})
+	state := planner.State
+	state.Declare([]*Resource{{Kind: KindByName("templates"), Name: "cache"}})
+	server := func(attributes map[string]interface{}) *Resource {
+		return &Resource{Kind: KindByName("servers"), Name: "server", Attributes: attributes}
+	}
+
+	ID, pending, err := state.ResolveRef(server(map[string]interface{}{"template": "web"}), "template")
+	assert.Nil(err, "Couldn't resolve reference")
+	assert.Equal("template-1", ID, "Unexpected ID")
+	assert.False(pending, "Existing references should not be pending")
+
+	ID, pending, err = state.ResolveRef(server(map[string]interface{}{"template": "web", "template_id": "template-9"}), "template")
+	assert.Nil(err, "Couldn't resolve reference")
+	assert.Equal("template-9", ID, "IDs should be taken as given")
+
+	_, pending, err = state.ResolveRef(server(map[string]interface{}{"template": "cache"}), "template")
+	assert.Nil(err, "Couldn't resolve reference")
+	assert.True(pending, "Declared references should be pending")
+
+	ID, _, err = state.ResolveRef(server(map[string]interface{}{}), "template")
+	assert.Nil(err, "Missing references should not fail")
+	assert.Equal("", ID, "Missing references should have no ID")
+
+	for _, name := range []string{"db", "broken", "unknown"} {
+		_, _, err = state.ResolveRef(server(map[string]interface{}{"template": name}), "template")
+		assert.NotNil(err, "Reference to %s should fail", name)
+	}
+
+	_, err = state.Lookup(&Resource{Kind: KindByName("templates"), Name: "db"})
+	assert.NotNil(err, "Ambiguous resources cannot be managed")
+	name, found := state.RefName("templates", "template-2")
+	assert.False(found, "Ambiguous resources cannot be referenced by name: %s", name)
+	name, found = state.RefName("templates", "template-1")
+	assert.True(found, "Unambiguous resources should be referenced by name")
+	assert.Equal("web", name, "Unexpected reference name")
+}
+
+func TestChildrenByParent(t *testing.T) {
+	assert := assert.New(t)
+
+	planner := testPlanner(map[string][]map[string]interface{}{
+		"vpcs": {{"id": "vpc-1", "name": "staging"}, {"id": "vpc-2", "name": "prod"}},
+		"subnets": {
+			{"parent": "staging", "id": "subnet-1", "name": "public", "vpc_id": "vpc-1", "cidr": "10.0.1.0/24", "type": "public"},
+			{"parent": "prod", "id": "subnet-2", "name": "public", "vpc_id": "vpc-2", "cidr": "10.1.1.0/24", "type": "public"},
+			{"parent": "prod", "id": "subnet-3", "name": "10.1.2.0/24", "vpc_id": "vpc-2", "cidr": "10.1.2.0/24", "type": "private"},
+		},
+	})
+	resources, err := Parse([]byte(`
+vpcs:
+  - name: test
+    cidr: 10.2.0.0/16
+    cloud_account_id: account
+    realm_provider_name: eu-west-1
+subnets:
+  - name: public
+    vpc: staging
+    cidr: 10.0.1.0/24
+    type: public
+  - name: public
+    vpc_id: vpc-2
+    cidr: 10.1.1.0/24
+    type: public
+  - name: public
+    vpc: test
+    cidr: 10.2.1.0/24
+    type: public
+`))
+	assert.Nil(err, "Subnets with the same name in different VPCs should not be duplicates")
+
+	steps, err := planner.Plan(resources)
+	assert.Nil(err, "Couldn't plan manifest")
+	steps = steps[1:]
+	assert.Equal("staging/public", steps[0].Name, "Subnets should be named after their VPC")
+	assert.Equal(ActionNoop, steps[0].Action, "Subnet of staging should be unchanged: %v", steps[0].Changes)
+	assert.Equal("subnet-1", steps[0].ID, "Unexpected subnet of staging")
+	assert.Equal(ActionNoop, steps[1].Action, "Subnet of the VPC given by ID should be unchanged: %v", steps[1].Changes)
+	assert.Equal("subnet-2", steps[1].ID, "Unexpected subnet of prod")
+	assert.Equal(ActionCreate, steps[2].Action, "Subnet of test should be created")
+
+	server := func(subnet string) *Resource {
+		return &Resource{Kind: KindByName("servers"), Name: "server", Attributes: map[string]interface{}{"subnet": subnet}}
+	}
+	for subnet, expected := range map[string]string{"staging/public": "subnet-1", "prod/public": "subnet-2", "10.1.2.0/24": "subnet-3"} {
+		ID, _, err := planner.State.ResolveRef(server(subnet), "subnet")
+		assert.Nil(err, "Couldn't resolve subnet %s", subnet)
+		assert.Equal(expected, ID, "Unexpected subnet %s", subnet)
+	}
+	_, pending, err := planner.State.ResolveRef(server("test/public"), "subnet")
+	assert.Nil(err, "Couldn't resolve declared subnet")
+	assert.True(pending, "Declared subnet should be pending")
+	_, _, err = planner.State.ResolveRef(server("public"), "subnet")
+	assert.NotNil(err, "Subnets named as several others should be ambiguous")
+	_, _, err = planner.State.ResolveRef(server("dev/public"), "subnet")
+	assert.NotNil(err, "Subnets of unknown VPCs should fail")
+
+	name, found := planner.State.RefName("subnets", "subnet-2")
+	assert.True(found, "Subnet should be referenced by name")
+	assert.Equal("prod/public", name, "Subnets named as several others should be referenced along with their VPC")
+	name, _ = planner.State.RefName("subnets", "subnet-3")
+	assert.Equal("10.1.2.0/24", name, "Subnets with a unique name should be referenced by it")
+}
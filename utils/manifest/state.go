@@ -0,0 +1,229 @@
+package manifest
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Entry is a current object, as known by its name and the name of its parent
+type Entry struct {
+	Kind   *Kind
+	Name   string
+	Parent string
+	Object map[string]interface{}
+	// Ambiguous entries share their address with other objects, so they cannot be managed by name
+	Ambiguous bool
+}
+
+// Address returns the entry address, as kind/name, or parent_kind/parent/kind/name for kinds with a parent
+func (e *Entry) Address() string {
+	return address(e.Kind, e.Parent, e.Name)
+}
+
+// State holds the current objects of the kinds involved in a plan, along with the declared resources which
+// will exist once applied. Both are kept by kind and address.
+type State struct {
+	entries  map[string]map[string]*Entry
+	declared map[string]map[string]*Entry
+}
+
+// NewState returns an empty state
+func NewState() *State {
+	return &State{entries: map[string]map[string]*Entry{}, declared: map[string]map[string]*Entry{}}
+}
+
+// Loaded tells whether the objects of the kind have been added
+func (s *State) Loaded(kind string) bool {
+	_, found := s.entries[kind]
+	return found
+}
+
+// Clear removes the objects of the kind, so that they can be added again
+func (s *State) Clear(kind string) {
+	s.entries[kind] = map[string]*Entry{}
+}
+
+// Add adds a current object of the kind, belonging to the parent with the given name for kinds with a parent.
+// Objects of kinds named after their parent take its name.
+func (s *State) Add(kind *Kind, parent string, object map[string]interface{}) {
+	if s.entries[kind.Name] == nil {
+		s.Clear(kind.Name)
+	}
+	if kind.NamedByParent {
+		object["name"] = parent
+	}
+	name, _ := object["name"].(string)
+	entry := &Entry{Kind: kind, Name: name, Parent: parent, Object: object}
+	if other, found := s.entries[kind.Name][entry.Address()]; found {
+		entry.Ambiguous, other.Ambiguous = true, true
+	}
+	s.entries[kind.Name][entry.Address()] = entry
+}
+
+// Entries returns the current objects of the kind, sorted by address
+func (s *State) Entries(kind string) []*Entry {
+	entries := make([]*Entry, 0, len(s.entries[kind]))
+	for _, entry := range s.entries[kind] {
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Address() < entries[j].Address() })
+	return entries
+}
+
+// entry returns the current or declared entry for the resource, the latter with no object. Parents given by ID
+// are replaced by their name, when they exist.
+func (s *State) entry(resource *Resource) *Entry {
+	kind := resource.Kind
+	entry := &Entry{Kind: kind, Name: resource.Name, Parent: resource.Parent}
+	if kind.Parent != "" {
+		if ID, isID := resource.Ref(kind.Parent); isID {
+			if parent := s.entryByID(kind.ParentKind(), ID); parent != nil {
+				entry.Parent = parent.Name
+			}
+		}
+		if kind.NamedByParent {
+			entry.Name = entry.Parent
+		}
+	}
+	if current := s.entries[kind.Name][entry.Address()]; current != nil {
+		return current
+	}
+	return entry
+}
+
+// Declare adds the resources which will exist once the manifest is applied, so that they can be referenced
+func (s *State) Declare(resources []*Resource) {
+	for _, resource := range resources {
+		entry := s.entry(resource)
+		if s.declared[entry.Kind.Name] == nil {
+			s.declared[entry.Kind.Name] = map[string]*Entry{}
+		}
+		s.declared[entry.Kind.Name][entry.Address()] = &Entry{Kind: entry.Kind, Name: entry.Name, Parent: entry.Parent}
+	}
+}
+
+// Lookup returns the current object for the resource, if it exists
+func (s *State) Lookup(resource *Resource) (map[string]interface{}, error) {
+	entry := s.entry(resource)
+	if entry.Ambiguous {
+		return nil, fmt.Errorf("there are several %s named %s, it cannot be managed by name", entry.Kind.Name, qualifiedName(entry.Kind, entry.Parent, entry.Name))
+	}
+	return entry.Object, nil
+}
+
+// Set sets the current object for the resource, once it has been created or updated
+func (s *State) Set(resource *Resource, object map[string]interface{}) {
+	entry := s.entry(resource)
+	if s.entries[entry.Kind.Name] == nil {
+		s.Clear(entry.Kind.Name)
+	}
+	s.entries[entry.Kind.Name][entry.Address()] = &Entry{Kind: entry.Kind, Name: entry.Name, Parent: entry.Parent, Object: object}
+}
+
+// ObjectByID returns the current object of the kind with the given ID
+func (s *State) ObjectByID(kind string, ID string) map[string]interface{} {
+	if entry := s.entryByID(kind, ID); entry != nil {
+		return entry.Object
+	}
+	return nil
+}
+
+func (s *State) entryByID(kind string, ID string) *Entry {
+	if ID == "" {
+		return nil
+	}
+	for _, entry := range s.entries[kind] {
+		if entry.Object["id"] == ID {
+			return entry
+		}
+	}
+	return nil
+}
+
+// RefName returns the name referencing the object of the kind with the given ID, when it identifies it. Objects
+// of kinds with a parent are referenced by their name alone when it is unique, or by parent/name otherwise.
+func (s *State) RefName(kind string, ID string) (string, bool) {
+	entry := s.entryByID(kind, ID)
+	if entry == nil || entry.Name == "" || entry.Ambiguous {
+		return "", false
+	}
+	if len(s.entriesNamed(kind, entry.Name)) > 1 {
+		return qualifiedName(entry.Kind, entry.Parent, entry.Name), true
+	}
+	return entry.Name, true
+}
+
+// entriesNamed returns the current and declared entries of the kind, by address, which have the given name
+func (s *State) entriesNamed(kind string, name string) map[string]*Entry {
+	named := map[string]*Entry{}
+	for _, entries := range []map[string]*Entry{s.declared[kind], s.entries[kind]} {
+		for address, entry := range entries {
+			if entry.Name == name {
+				named[address] = entry
+			}
+		}
+	}
+	return named
+}
+
+// refEntry returns the current or declared entry referenced by value, which is a name or, for kinds with a
+// parent, parent/name
+func (s *State) refEntry(resource *Resource, kind *Kind, value string) (*Entry, error) {
+	if kind.Parent == "" || kind.NamedByParent {
+		key := address(kind, "", value)
+		if entry := s.entries[kind.Name][key]; entry != nil {
+			return entry, nil
+		}
+		if entry := s.declared[kind.Name][key]; entry != nil {
+			return entry, nil
+		}
+		return nil, fmt.Errorf("%s references unknown %s", resource, key)
+	}
+
+	// names can have slashes themselves, so parent/name is only taken as such when it exists
+	if i := strings.Index(value, "/"); i > 0 {
+		key := address(kind, value[:i], value[i+1:])
+		if entry := s.entries[kind.Name][key]; entry != nil {
+			return entry, nil
+		}
+		if entry := s.declared[kind.Name][key]; entry != nil {
+			return entry, nil
+		}
+	}
+	named := s.entriesNamed(kind.Name, value)
+	switch len(named) {
+	case 0:
+		return nil, fmt.Errorf("%s references unknown %s/%s", resource, kind.Name, value)
+	case 1:
+		for _, entry := range named {
+			return entry, nil
+		}
+	}
+	return nil, fmt.Errorf("%s references %s/%s, but there are several %s with that name, reference it as <%s>/%s instead",
+		resource, kind.Name, value, kind.Name, kind.Parent, value)
+}
+
+// ResolveRef returns the ID of the resource referenced through the attribute. When the referenced resource
+// is declared in the manifest but doesn't exist yet, it is pending.
+func (s *State) ResolveRef(resource *Resource, attribute string) (ID string, pending bool, err error) {
+	value, isID := resource.Ref(attribute)
+	if value == "" || isID {
+		return value, false, nil
+	}
+	kind := KindByName(resource.Kind.Refs[attribute])
+	entry, err := s.refEntry(resource, kind, value)
+	if err != nil {
+		return "", false, err
+	}
+	if entry.Object == nil {
+		return "", true, nil
+	}
+	if entry.Ambiguous {
+		return "", false, fmt.Errorf("%s references %s, but there are several %s with that name", resource, entry.Address(), kind.Name)
+	}
+	if ID, _ = entry.Object["id"].(string); ID == "" {
+		return "", false, fmt.Errorf("%s references %s, which has no ID", resource, entry.Address())
+	}
+	return ID, false, nil
+}
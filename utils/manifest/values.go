@@ -0,0 +1,167 @@
+package manifest
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// NormalizeValue converts the maps decoded from YAML into JSON compatible ones
+func NormalizeValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[interface{}]interface{}:
+		m := map[string]interface{}{}
+		for key, item := range v {
+			m[fmt.Sprintf("%v", key)] = NormalizeValue(item)
+		}
+		return m
+	case map[string]interface{}:
+		m := map[string]interface{}{}
+		for key, item := range v {
+			m[key] = NormalizeValue(item)
+		}
+		return m
+	case []interface{}:
+		l := make([]interface{}, len(v))
+		for i, item := range v {
+			l[i] = NormalizeValue(item)
+		}
+		return l
+	default:
+		return v
+	}
+}
+
+// JSONValue returns the value as it would be decoded from a JSON document
+func JSONValue(value interface{}) interface{} {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return value
+	}
+	var v interface{}
+	if err = json.Unmarshal(data, &v); err != nil {
+		return value
+	}
+	return v
+}
+
+// Object converts an API item into a generic object
+func Object(item interface{}) map[string]interface{} {
+	object, _ := JSONValue(item).(map[string]interface{})
+	return object
+}
+
+// ValueEqual compares a desired attribute value against the current one. Top level maps must have the same
+// keys, while nested maps only need to contain the desired keys, as the API adds its own details.
+func ValueEqual(attribute string, desired interface{}, current interface{}) bool {
+	desired, current = JSONValue(desired), JSONValue(current)
+
+	if dm, ok := desired.(map[string]interface{}); ok {
+		cm, ok := current.(map[string]interface{})
+		if !ok {
+			return len(dm) == 0 && IsEmptyValue(current)
+		}
+		if len(dm) != len(cm) {
+			return false
+		}
+		for key, value := range dm {
+			if cv, found := cm[key]; !found || !valueContained(value, cv) {
+				return false
+			}
+		}
+		return true
+	}
+
+	// firewall rules are not ordered
+	if attribute == "rules" {
+		return reflect.DeepEqual(sortedJSONList(desired), sortedJSONList(current))
+	}
+	if desired == nil || current == nil {
+		return IsEmptyValue(desired) && IsEmptyValue(current)
+	}
+	return reflect.DeepEqual(desired, current)
+}
+
+// valueContained tells whether the desired value is contained in the current one
+func valueContained(desired interface{}, current interface{}) bool {
+	dm, ok := desired.(map[string]interface{})
+	if !ok {
+		return reflect.DeepEqual(desired, current)
+	}
+	cm, ok := current.(map[string]interface{})
+	if !ok {
+		return false
+	}
+	for key, value := range dm {
+		if !valueContained(value, cm[key]) {
+			return false
+		}
+	}
+	return true
+}
+
+// IsEmptyValue tells whether a JSON value is the zero value of its type
+func IsEmptyValue(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case string:
+		return v == ""
+	case []interface{}:
+		return len(v) == 0
+	case map[string]interface{}:
+		return len(v) == 0
+	case bool:
+		return !v
+	case float64:
+		return v == 0
+	}
+	return false
+}
+
+// sortedJSONList returns the JSON representation of every item of a list, sorted
+func sortedJSONList(value interface{}) []string {
+	items, _ := value.([]interface{})
+	sorted := make([]string, 0, len(items))
+	for _, item := range items {
+		data, _ := json.Marshal(item)
+		sorted = append(sorted, string(data))
+	}
+	sort.Strings(sorted)
+	return sorted
+}
+
+// formatValue returns a short printable representation of a value
+func formatValue(value interface{}) string {
+	if s, ok := value.(string); ok && s == KnownAfterApply {
+		return s
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	s := string(data)
+	if len(s) > 60 {
+		s = s[:57] + "..."
+	}
+	return s
+}
+
+// Change describes the change of an attribute
+func Change(attribute string, current interface{}, desired interface{}) string {
+	if current == nil {
+		return fmt.Sprintf("+ %s: %s", attribute, formatValue(desired))
+	}
+	return fmt.Sprintf("~ %s: %s -> %s", attribute, formatValue(current), formatValue(desired))
+}
+
+// SortedKeys returns the map keys sorted
+func SortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
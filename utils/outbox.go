@@ -0,0 +1,352 @@
+package utils
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+const (
+	outboxJournalFileName     = "journal"
+	outboxJournalLockFileName = "journal.lock"
+	outboxJournalLockTimeout  = time.Minute
+	outboxLockFileName        = "flush.lock"
+	outboxLockTimeout         = 10 * time.Minute
+)
+
+// OutboxEntry is an agent report pending to be delivered to IMCO
+type OutboxEntry struct {
+	Seq       int64                  `json:"seq" header:"SEQ"`
+	Key       string                 `json:"key,omitempty" header:"KEY"`
+	Method    string                 `json:"method" header:"METHOD"`
+	Path      string                 `json:"path" header:"PATH"`
+	Payload   map[string]interface{} `json:"payload" header:"PAYLOAD" show:"nolist"`
+	CreatedAt time.Time              `json:"created_at" header:"CREATED_AT"`
+}
+
+// outboxRecord is a line of the journal: either a queued entry or the acknowledgement of a delivered one
+type outboxRecord struct {
+	Entry *OutboxEntry `json:"entry,omitempty"`
+	Ack   int64        `json:"ack,omitempty"`
+}
+
+// Outbox is a durable, append-only journal of agent reports which couldn't be delivered to IMCO.
+// Entries sharing a key are deduplicated, so only the latest report for a command or characterization is sent.
+type Outbox struct {
+	dir             string
+	concertoService ConcertoService
+	mutex           sync.Mutex
+	lastSeq         int64
+}
+
+// NewOutbox returns the outbox stored in dir, delivering through the given service
+func NewOutbox(dir string, concertoService ConcertoService) (*Outbox, error) {
+	if concertoService == nil {
+		return nil, fmt.Errorf("must initialize ConcertoService before using it")
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("cannot create outbox directory: %v", err)
+	}
+	return &Outbox{dir: dir, concertoService: concertoService}, nil
+}
+
+func (o *Outbox) journalPath() string {
+	return filepath.Join(o.dir, outboxJournalFileName)
+}
+
+// Entries returns the pending entries, in delivery order
+func (o *Outbox) Entries() ([]*OutboxEntry, error) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	return o.load()
+}
+
+// load replays the journal. Caller must hold the mutex
+func (o *Outbox) load() ([]*OutboxEntry, error) {
+	f, err := os.Open(o.journalPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []*OutboxEntry{}, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	entries := []*OutboxEntry{}
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	for scanner.Scan() {
+		var record outboxRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			// a partially written last line is ignored
+			log.Warn("Skipping corrupted outbox record: ", err)
+			continue
+		}
+		if record.Entry != nil {
+			entries = append(removeOutboxEntries(entries, record.Entry.Key, 0), record.Entry)
+		} else if record.Ack > 0 {
+			entries = removeOutboxEntries(entries, "", record.Ack)
+		}
+	}
+	return entries, scanner.Err()
+}
+
+// removeOutboxEntries drops the entries superseded by key, or the one matching seq
+func removeOutboxEntries(entries []*OutboxEntry, key string, seq int64) []*OutboxEntry {
+	kept := entries[:0]
+	for _, entry := range entries {
+		if (key != "" && entry.Key == key) || (seq > 0 && entry.Seq == seq) {
+			continue
+		}
+		kept = append(kept, entry)
+	}
+	return kept
+}
+
+// lockJournal waits for the lock guarding the journal against writers in other agent processes, and returns the
+// function releasing it. The lock is held only while writing, never while delivering.
+func (o *Outbox) lockJournal() (func(), error) {
+	lockPath := filepath.Join(o.dir, outboxJournalLockFileName)
+	deadline := time.Now().Add(2 * outboxJournalLockTimeout)
+	for {
+		lock, err := os.OpenFile(lockPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+		if err == nil {
+			lock.Close()
+			return func() { os.Remove(lockPath) }, nil
+		}
+		if !os.IsExist(err) {
+			return nil, err
+		}
+		// a lock left behind by a process which died while writing
+		if info, err := os.Stat(lockPath); err == nil && time.Since(info.ModTime()) > outboxJournalLockTimeout {
+			os.Remove(lockPath)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("timed out waiting for outbox journal lock")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// append writes records at the end of the journal. Caller must hold the mutex
+func (o *Outbox) append(records ...*outboxRecord) error {
+	unlock, err := o.lockJournal()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	f, err := os.OpenFile(o.journalPath(), os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	for _, record := range records {
+		data, err := json.Marshal(record)
+		if err != nil {
+			return err
+		}
+		if _, err = f.Write(append(data, '\n')); err != nil {
+			return err
+		}
+	}
+	return f.Sync()
+}
+
+// compact rewrites the journal with its pending entries only, returning how many there are. The journal is
+// replayed under the journal lock, so entries appended meanwhile by other processes are kept. Caller must hold
+// the mutex
+func (o *Outbox) compact() (int, error) {
+	unlock, err := o.lockJournal()
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	entries, err := o.load()
+	if err != nil {
+		return len(entries), err
+	}
+	return len(entries), o.rewrite(entries)
+}
+
+// rewrite replaces the journal with the given entries. Caller must hold the mutex and the journal lock
+func (o *Outbox) rewrite(entries []*OutboxEntry) error {
+	if len(entries) == 0 {
+		err := os.Remove(o.journalPath())
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	tmp := fmt.Sprintf("%s.%s", o.journalPath(), RandomString(6))
+	f, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		data, err := json.Marshal(&outboxRecord{Entry: entry})
+		if err == nil {
+			_, err = f.Write(append(data, '\n'))
+		}
+		if err != nil {
+			f.Close()
+			os.Remove(tmp)
+			return err
+		}
+	}
+	if err = f.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, o.journalPath())
+}
+
+// Enqueue stores a report to be delivered later. A pending report with the same key is superseded.
+func (o *Outbox) Enqueue(key string, method string, path string, payload *map[string]interface{}) error {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	// sequence numbers must be unique even on platforms with a coarse clock
+	seq := time.Now().UnixNano()
+	if seq <= o.lastSeq {
+		seq = o.lastSeq + 1
+	}
+	o.lastSeq = seq
+
+	entry := &OutboxEntry{
+		Seq:       seq,
+		Key:       key,
+		Method:    method,
+		Path:      path,
+		Payload:   *payload,
+		CreatedAt: time.Now().UTC(),
+	}
+	log.Infof("Queuing %s %s report in outbox", method, path)
+	return o.append(&outboxRecord{Entry: entry})
+}
+
+// Flush sends the pending entries in order, stopping at the first one which cannot be delivered.
+// Entries rejected by IMCO (4xx) are discarded, as retrying them would never succeed.
+// It returns the number of entries still pending, including those queued meanwhile by other processes.
+func (o *Outbox) Flush() (int, error) {
+	if o == nil {
+		return 0, nil
+	}
+
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	entries, err := o.load()
+	if err != nil || len(entries) == 0 {
+		return len(entries), err
+	}
+
+	// prevents several agent processes from delivering the same entries
+	lockPath := filepath.Join(o.dir, outboxLockFileName)
+	if info, err := os.Stat(lockPath); err == nil && time.Since(info.ModTime()) > outboxLockTimeout {
+		os.Remove(lockPath)
+	}
+	lock, err := os.OpenFile(lockPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		log.Debug("Outbox is being flushed by another process")
+		return len(entries), nil
+	}
+	lock.Close()
+	defer os.Remove(lockPath)
+
+	log.Infof("Flushing %d outbox entries", len(entries))
+	for len(entries) > 0 {
+		entry := entries[0]
+		status, err := o.send(entry)
+		if err != nil && !(status >= 400 && status < 500) {
+			log.Warnf("Cannot deliver outbox entry %d: %v", entry.Seq, err)
+			break
+		}
+		if err != nil {
+			log.Errorf("Discarding outbox entry %d rejected by IMCO: %v", entry.Seq, err)
+		}
+		if err = o.append(&outboxRecord{Ack: entry.Seq}); err != nil {
+			return len(entries), err
+		}
+		entries = entries[1:]
+	}
+
+	return o.compact()
+}
+
+// send delivers an entry through the concerto service
+func (o *Outbox) send(entry *OutboxEntry) (int, error) {
+	var data []byte
+	var status int
+	var err error
+	switch entry.Method {
+	case "POST":
+		data, status, err = o.concertoService.Post(entry.Path, &entry.Payload)
+	case "PUT":
+		data, status, err = o.concertoService.Put(entry.Path, &entry.Payload)
+	default:
+		return 400, fmt.Errorf("unsupported method %s", entry.Method)
+	}
+	if err != nil {
+		return status, err
+	}
+	return status, CheckStandardStatus(status, data)
+}
+
+// Deliver sends a report through the given function, unless older reports are still pending, in which case
+// it is queued behind them to preserve the order. Reports which cannot be sent due to communication or server
+// errors are queued to be delivered later. It returns whether the report has been queued.
+// A nil outbox just sends the report.
+func (o *Outbox) Deliver(key string, method string, path string, payload *map[string]interface{}, send func() (int, error)) (bool, error) {
+	if o == nil {
+		_, err := send()
+		return false, err
+	}
+
+	// when the outbox cannot be flushed, reports may be pending, so the report is queued behind them
+	pending, err := o.Flush()
+	if err != nil {
+		log.Warn("Cannot flush outbox: ", err)
+	}
+
+	if err == nil && pending == 0 {
+		status, err := send()
+		if err == nil {
+			return false, nil
+		}
+		if status >= 400 && status < 500 {
+			return false, err
+		}
+		log.Warn("Cannot deliver report: ", err)
+	}
+
+	if err := o.Enqueue(key, method, path, payload); err != nil {
+		return false, fmt.Errorf("cannot queue report in outbox: %v", err)
+	}
+	return true, nil
+}
+
+// Clear removes every pending entry
+func (o *Outbox) Clear() error {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	unlock, err := o.lockJournal()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	return o.rewrite(nil)
+}
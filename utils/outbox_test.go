@@ -0,0 +1,164 @@
+package utils
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newTestOutbox(t *testing.T) (*Outbox, *MockConcertoService, string) {
+	dir, err := ioutil.TempDir("", "cio-outbox")
+	assert.Nil(t, err, "Couldn't create temp dir")
+
+	cs := &MockConcertoService{}
+	outbox, err := NewOutbox(dir, cs)
+	assert.Nil(t, err, "Couldn't create outbox")
+	return outbox, cs, dir
+}
+
+func TestOutboxEnqueueDeduplicates(t *testing.T) {
+	assert := assert.New(t)
+	outbox, _, dir := newTestOutbox(t)
+	defer os.RemoveAll(dir)
+
+	assert.Nil(outbox.Enqueue("command:1", "PUT", "/command_polling/commands/1", &map[string]interface{}{"exit_code": 1}))
+	assert.Nil(outbox.Enqueue("", "POST", "/command_polling/bootstrap_logs", &map[string]interface{}{"stdout": "fake"}))
+	assert.Nil(outbox.Enqueue("", "POST", "/command_polling/bootstrap_logs", &map[string]interface{}{"stdout": "fake"}))
+	assert.Nil(outbox.Enqueue("command:1", "PUT", "/command_polling/commands/1", &map[string]interface{}{"exit_code": 0}))
+
+	entries, err := outbox.Entries()
+	assert.Nil(err, "Couldn't read outbox entries")
+	assert.Len(entries, 3, "Entries with the same key should be deduplicated")
+	assert.Equal("command:1", entries[2].Key, "Latest entry should be the last one delivered")
+	assert.EqualValues(0, entries[2].Payload["exit_code"], "Latest entry should supersede the previous one")
+	assert.True(entries[0].Seq < entries[1].Seq && entries[1].Seq < entries[2].Seq, "Sequence should be increasing")
+}
+
+func TestOutboxFlushInOrder(t *testing.T) {
+	assert := assert.New(t)
+	outbox, cs, dir := newTestOutbox(t)
+	defer os.RemoveAll(dir)
+
+	first := map[string]interface{}{"stdout": "first"}
+	second := map[string]interface{}{"stdout": "second"}
+	assert.Nil(outbox.Enqueue("", "POST", "/blueprint/bootstrap_logs", &first))
+	assert.Nil(outbox.Enqueue("script_conclusion:1", "POST", "/blueprint/script_conclusions", &second))
+
+	// first delivery fails, nothing after it should be sent
+	cs.On("Post", "/blueprint/bootstrap_logs", &first).Return([]byte{}, 0, fmt.Errorf("connection refused")).Once()
+	pending, err := outbox.Flush()
+	assert.Nil(err, "Failed delivery shouldn't return error")
+	assert.Equal(2, pending, "Entries should remain pending")
+
+	cs.On("Post", "/blueprint/bootstrap_logs", &first).Return([]byte("{}"), 201, nil).Once()
+	cs.On("Post", "/blueprint/script_conclusions", &second).Return([]byte("{}"), 201, nil).Once()
+	pending, err = outbox.Flush()
+	assert.Nil(err, "Couldn't flush outbox")
+	assert.Equal(0, pending, "Every entry should have been delivered")
+	cs.AssertExpectations(t)
+
+	assert.False(FileExists(outbox.journalPath()), "Journal should be compacted once delivered")
+}
+
+func TestOutboxFlushDiscardsRejected(t *testing.T) {
+	assert := assert.New(t)
+	outbox, cs, dir := newTestOutbox(t)
+	defer os.RemoveAll(dir)
+
+	payload := map[string]interface{}{"stdout": "fake"}
+	assert.Nil(outbox.Enqueue("command:1", "PUT", "/command_polling/commands/1", &payload))
+
+	cs.On("Put", "/command_polling/commands/1", &payload).Return([]byte("{}"), 404, nil).Once()
+	pending, err := outbox.Flush()
+	assert.Nil(err, "Couldn't flush outbox")
+	assert.Equal(0, pending, "Rejected entries should be discarded")
+}
+
+func TestOutboxDeliver(t *testing.T) {
+	assert := assert.New(t)
+	outbox, cs, dir := newTestOutbox(t)
+	defer os.RemoveAll(dir)
+
+	payload := map[string]interface{}{"stdout": "fake"}
+	queued, err := outbox.Deliver("", "POST", "/blueprint/bootstrap_logs", &payload, func() (int, error) {
+		return 201, nil
+	})
+	assert.Nil(err, "Couldn't deliver report")
+	assert.False(queued, "Delivered report shouldn't be queued")
+
+	queued, err = outbox.Deliver("", "POST", "/blueprint/bootstrap_logs", &payload, func() (int, error) {
+		return 422, fmt.Errorf("client error")
+	})
+	assert.NotNil(err, "Rejected report should return error")
+	assert.False(queued, "Rejected report shouldn't be queued")
+
+	queued, err = outbox.Deliver("", "POST", "/blueprint/bootstrap_logs", &payload, func() (int, error) {
+		return 503, fmt.Errorf("server error")
+	})
+	assert.Nil(err, "Undelivered report should be queued")
+	assert.True(queued, "Undelivered report should be queued")
+
+	// while older reports are pending, new ones are queued behind them
+	cs.On("Post", "/blueprint/bootstrap_logs", &payload).Return([]byte{}, 0, fmt.Errorf("connection refused")).Once()
+	queued, err = outbox.Deliver("", "POST", "/blueprint/bootstrap_logs", &payload, func() (int, error) {
+		t.Error("Report shouldn't be sent while older reports are pending")
+		return 201, nil
+	})
+	assert.Nil(err, "Report should be queued")
+	assert.True(queued, "Report should be queued behind the pending ones")
+	cs.AssertExpectations(t)
+
+	var nilOutbox *Outbox
+	queued, err = nilOutbox.Deliver("", "POST", "/blueprint/bootstrap_logs", &payload, func() (int, error) {
+		return 503, fmt.Errorf("server error")
+	})
+	assert.NotNil(err, "Nil outbox should return the delivery error")
+	assert.False(queued, "Nil outbox shouldn't queue reports")
+}
+
+func TestOutboxFlushKeepsEntriesQueuedByOtherProcesses(t *testing.T) {
+	assert := assert.New(t)
+	outbox, cs, dir := newTestOutbox(t)
+	defer os.RemoveAll(dir)
+	other, err := NewOutbox(dir, &MockConcertoService{})
+	assert.Nil(err, "Couldn't open outbox from another process")
+
+	first := map[string]interface{}{"stdout": "first"}
+	queuedMeanwhile := map[string]interface{}{"stdout": "queued meanwhile"}
+	assert.Nil(outbox.Enqueue("", "POST", "/blueprint/bootstrap_logs", &first))
+
+	cs.On("Post", "/blueprint/bootstrap_logs", &first).Return([]byte("{}"), 201, nil).Once().Run(func(args mock.Arguments) {
+		assert.Nil(other.Enqueue("", "POST", "/blueprint/bootstrap_logs", &queuedMeanwhile), "Couldn't queue entry while flushing")
+	})
+	pending, err := outbox.Flush()
+	assert.Nil(err, "Couldn't flush outbox")
+	assert.Equal(1, pending, "Entries queued while flushing should remain pending")
+	cs.AssertExpectations(t)
+
+	entries, err := outbox.Entries()
+	assert.Nil(err, "Couldn't read outbox entries")
+	if assert.Len(entries, 1, "Entries queued while flushing should be kept") {
+		assert.Equal("queued meanwhile", entries[0].Payload["stdout"], "Unexpected entry")
+	}
+	assert.False(FileExists(filepath.Join(dir, outboxJournalLockFileName)), "Journal lock should be released")
+}
+
+func TestOutboxDeliverUnreadableJournal(t *testing.T) {
+	assert := assert.New(t)
+	outbox, _, dir := newTestOutbox(t)
+	defer os.RemoveAll(dir)
+
+	// a directory in place of the journal can be neither read nor written
+	assert.Nil(os.Mkdir(outbox.journalPath(), 0700), "Couldn't break journal")
+	payload := map[string]interface{}{"stdout": "fake"}
+	_, err := outbox.Deliver("", "POST", "/blueprint/bootstrap_logs", &payload, func() (int, error) {
+		t.Error("Report shouldn't be sent ahead of reports which may be pending")
+		return 201, nil
+	})
+	assert.NotNil(err, "Report which cannot be queued should return error")
+}
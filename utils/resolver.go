@@ -0,0 +1,79 @@
+package utils
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+var fullIDRegexp = regexp.MustCompile(`^[0-9a-fA-F]{24}$`)
+
+// NamedResource is a resource which can be referred to by its ID, its name or a prefix of its ID
+type NamedResource struct {
+	ID   string
+	Name string
+}
+
+// IsFullID tells whether the value is a complete resource ID, so that it needs no resolution
+func IsFullID(value string) bool {
+	return fullIDRegexp.MatchString(value)
+}
+
+// NamedResources returns the ID and Name fields of every item of a slice of structs, or pointers to them
+func NamedResources(items interface{}) []*NamedResource {
+	resources := []*NamedResource{}
+	v := reflect.Indirect(reflect.ValueOf(items))
+	if v.Kind() != reflect.Slice {
+		return resources
+	}
+	for i := 0; i < v.Len(); i++ {
+		item := reflect.Indirect(v.Index(i))
+		if item.Kind() != reflect.Struct {
+			continue
+		}
+		id, name := item.FieldByName("ID"), item.FieldByName("Name")
+		if !id.IsValid() || id.Kind() != reflect.String {
+			continue
+		}
+		resource := &NamedResource{ID: id.String()}
+		if name.IsValid() && name.Kind() == reflect.String {
+			resource.Name = name.String()
+		}
+		resources = append(resources, resource)
+	}
+	return resources
+}
+
+// ResolveID returns the ID of the only candidate whose ID or name is the value, or whose ID starts with it.
+// Exact IDs take precedence over names, and names over ID prefixes.
+func ResolveID(kind string, value string, candidates []*NamedResource) (string, error) {
+	byName, byPrefix := []*NamedResource{}, []*NamedResource{}
+	for _, candidate := range candidates {
+		if candidate.ID == value {
+			return candidate.ID, nil
+		}
+		if candidate.Name == value {
+			byName = append(byName, candidate)
+		}
+		if strings.HasPrefix(candidate.ID, value) {
+			byPrefix = append(byPrefix, candidate)
+		}
+	}
+
+	for _, matches := range [][]*NamedResource{byName, byPrefix} {
+		switch len(matches) {
+		case 0:
+			continue
+		case 1:
+			return matches[0].ID, nil
+		default:
+			ambiguous := []string{}
+			for _, match := range matches {
+				ambiguous = append(ambiguous, fmt.Sprintf("%s (%s)", match.ID, match.Name))
+			}
+			return "", fmt.Errorf("%s %q is ambiguous, it matches: %s", kind, value, strings.Join(ambiguous, ", "))
+		}
+	}
+	return "", fmt.Errorf("no %s matches %q by ID, name or ID prefix", kind, value)
+}
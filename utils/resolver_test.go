@@ -0,0 +1,54 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNamedResources(t *testing.T) {
+	assert := assert.New(t)
+
+	type item struct {
+		ID   string
+		Name string
+	}
+	resources := NamedResources([]*item{{ID: "1", Name: "one"}, {ID: "2", Name: "two"}})
+	assert.Equal([]*NamedResource{{ID: "1", Name: "one"}, {ID: "2", Name: "two"}}, resources, "Unexpected resources")
+	assert.Empty(NamedResources("not a slice"), "Only slices should be converted")
+}
+
+func TestResolveID(t *testing.T) {
+	assert := assert.New(t)
+
+	candidates := []*NamedResource{
+		{ID: "5b0e7e2a1b3c4d0001a1b2c3", Name: "web"},
+		{ID: "5b0e7e2a1b3c4d0001a1b2c4", Name: "db"},
+		{ID: "5c11aa2a1b3c4d0001a1b2c5", Name: "db"},
+		{ID: "6d22bb2a1b3c4d0001a1b2c6", Name: "5b0e"},
+	}
+	tests := map[string]string{
+		"5b0e7e2a1b3c4d0001a1b2c4": "5b0e7e2a1b3c4d0001a1b2c4",
+		"web":                      "5b0e7e2a1b3c4d0001a1b2c3",
+		"5c11":                     "5c11aa2a1b3c4d0001a1b2c5",
+		"5b0e":                     "6d22bb2a1b3c4d0001a1b2c6",
+	}
+	for value, expected := range tests {
+		ID, err := ResolveID("server", value, candidates)
+		assert.Nil(err, "%s should be resolved", value)
+		assert.Equal(expected, ID, "Unexpected resolution of %s", value)
+	}
+
+	for _, value := range []string{"db", "5b0e7e", "unknown"} {
+		_, err := ResolveID("server", value, candidates)
+		assert.NotNil(err, "%s should not be resolved", value)
+	}
+}
+
+func TestIsFullID(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.True(IsFullID("5b0e7e2a1b3c4d0001a1b2c3"), "Object ID should be a full ID")
+	assert.False(IsFullID("5b0e7e"), "Prefix should not be a full ID")
+	assert.False(IsFullID("my-server"), "Name should not be a full ID")
+}
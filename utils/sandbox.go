@@ -0,0 +1,160 @@
+package utils
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+// DefaultEnvAllowlist holds the environment variables inherited by sandboxed commands when no allowlist is configured
+var DefaultEnvAllowlist = []string{"PATH", "LANG", "LC_ALL", "TZ", "SystemRoot", "ComSpec", "PATHEXT"}
+
+// Sandbox describes how a command is isolated from the agent process
+type Sandbox struct {
+	User         string
+	Group        string
+	EnvAllowlist []string
+	Limits       ResourceLimits
+}
+
+// NewSandbox creates a sandbox from the polling configuration, returning nil when sandboxing is disabled
+func NewSandbox(pc PollingConfig) *Sandbox {
+	if !pc.Sandbox && pc.RunAsUser == "" && pc.RunAsGroup == "" {
+		return nil
+	}
+
+	sandbox := &Sandbox{
+		User:         pc.RunAsUser,
+		Group:        pc.RunAsGroup,
+		EnvAllowlist: DefaultEnvAllowlist,
+		Limits:       pc.Limits,
+	}
+	if pc.EnvAllowlist != "" {
+		sandbox.EnvAllowlist = SplitList(pc.EnvAllowlist)
+	}
+	return sandbox
+}
+
+// SplitList splits a comma separated list, discarding empty items
+func SplitList(list string) []string {
+	items := []string{}
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// environment returns the scrubbed environment for a command running in workDir
+func (s *Sandbox) environment(workDir string, home string, userName string) []string {
+	env := []string{}
+	defined := map[string]bool{}
+	for _, name := range s.EnvAllowlist {
+		if value, ok := os.LookupEnv(name); ok && !defined[name] {
+			env = append(env, fmt.Sprintf("%s=%s", name, value))
+			defined[name] = true
+		}
+	}
+
+	defaults := [][]string{{"HOME", home}, {"TMPDIR", workDir}}
+	if userName != "" {
+		defaults = append(defaults, []string{"USER", userName}, []string{"LOGNAME", userName})
+	}
+	for _, kv := range defaults {
+		if !defined[kv[0]] {
+			env = append(env, fmt.Sprintf("%s=%s", kv[0], kv[1]))
+		}
+	}
+	return env
+}
+
+// sandboxedCommand is a command prepared to run isolated. While it has a cgroup, it is held by a gate before running
+// anything, so that it can be attached to the cgroup once started and every process it spawns is accounted there.
+type sandboxedCommand struct {
+	*exec.Cmd
+	workDir string
+	cgroup  string
+	gate    *os.File
+}
+
+// createSandboxedCommand saves the script/command in a private temp directory and prepares its isolated execution
+func createSandboxedCommand(command string, interpreter string, sandbox *Sandbox) (*sandboxedCommand, error) {
+
+	si, err := resolveInterpreter(interpreter, command)
+	if err != nil {
+		return nil, err
+	}
+
+	workDir, err := ioutil.TempDir("", "cio-polling")
+	if err != nil {
+		return nil, fmt.Errorf("cannot create working directory: %v", err)
+	}
+	sc := &sandboxedCommand{workDir: workDir}
+	if err = os.Chmod(workDir, 0700); err != nil {
+		sc.cleanup()
+		return nil, fmt.Errorf("cannot set working directory permissions: %v", err)
+	}
+
+	cmdFileName := si.FileName(strings.Join([]string{time.Now().Format(TimeLayoutYYYYMMDDHHMMSS), "_", RandomString(10)}, ""))
+	cmdFilePath := filepath.Join(workDir, cmdFileName)
+
+	if err = ioutil.WriteFile(cmdFilePath, []byte(command), 0600); err != nil {
+		sc.cleanup()
+		return nil, fmt.Errorf("cannot create temp file: %v", err)
+	}
+
+	sc.cgroup = sandbox.createCgroup(filepath.Base(workDir))
+	args := sandbox.limitArgs(si.CommandArgs(cmdFilePath), sc.cgroup != "")
+	sc.Cmd = exec.Command(args[0], args[1:]...)
+	sc.Dir = workDir
+	if sc.cgroup != "" {
+		held, gate, err := os.Pipe()
+		if err != nil {
+			sc.cleanup()
+			return nil, fmt.Errorf("cannot create cgroup gate: %v", err)
+		}
+		sc.ExtraFiles = []*os.File{held}
+		sc.gate = gate
+	}
+
+	home, userName, err := sandbox.apply(sc.Cmd, workDir, cmdFilePath)
+	if err != nil {
+		sc.cleanup()
+		return nil, err
+	}
+	sc.Env = sandbox.environment(workDir, home, userName)
+
+	log.Debugf("Sandboxed command prepared in %s", workDir)
+	return sc, nil
+}
+
+// release attaches the started command to its cgroup and lets it run. Commands not attached still run, limited
+// only by their rlimits.
+func (sc *sandboxedCommand) release() {
+	if sc.gate == nil {
+		return
+	}
+	sc.ExtraFiles[0].Close()
+	if err := attachCgroup(sc.cgroup, sc.Process.Pid); err != nil {
+		log.Warn("Cannot attach command to cgroup: ", err)
+	}
+	sc.gate.Close()
+	sc.gate = nil
+}
+
+// cleanup removes the cgroup and the working directory of the command, once finished
+func (sc *sandboxedCommand) cleanup() {
+	if sc.gate != nil {
+		sc.ExtraFiles[0].Close()
+		sc.gate.Close()
+	}
+	releaseCgroup(sc.cgroup)
+	deleteTmpWorkDir(sc.workDir)
+}
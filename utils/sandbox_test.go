@@ -0,0 +1,131 @@
+package utils
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewSandbox(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Nil(NewSandbox(PollingConfig{}), "Sandbox should be disabled by default")
+
+	limits := ResourceLimits{CPUSeconds: 60, MemoryMB: 512}
+	sandbox := NewSandbox(PollingConfig{Sandbox: true, Limits: limits})
+	if assert.NotNil(sandbox, "Sandbox should be enabled") {
+		assert.Equal(DefaultEnvAllowlist, sandbox.EnvAllowlist, "Default allowlist should be used")
+		assert.Equal(limits, sandbox.Limits, "Unexpected limits")
+		assert.Empty(sandbox.User, "Command should run as the agent user")
+	}
+
+	sandbox = NewSandbox(PollingConfig{RunAsUser: "nobody", EnvAllowlist: "PATH, HOME,,"})
+	if assert.NotNil(sandbox, "Running as a different user should enable the sandbox") {
+		assert.Equal("nobody", sandbox.User, "Unexpected user")
+		assert.Equal([]string{"PATH", "HOME"}, sandbox.EnvAllowlist, "Unexpected allowlist")
+	}
+	assert.NotNil(NewSandbox(PollingConfig{RunAsGroup: "nogroup"}), "Running as a different group should enable the sandbox")
+}
+
+func TestSplitList(t *testing.T) {
+	assert := assert.New(t)
+
+	tests := map[string][]string{
+		"":                {},
+		" , ,":            {},
+		"PATH":            {"PATH"},
+		"PATH,LANG":       {"PATH", "LANG"},
+		" PATH ,, LANG, ": {"PATH", "LANG"},
+	}
+	for list, items := range tests {
+		assert.Equal(items, SplitList(list), "Unexpected items of %q", list)
+	}
+}
+
+func TestSandboxEnvironment(t *testing.T) {
+	assert := assert.New(t)
+
+	os.Setenv("CIO_SANDBOX_ALLOWED", "allowed")
+	os.Setenv("CIO_SANDBOX_DENIED", "denied")
+	defer os.Setenv("HOME", os.Getenv("HOME"))
+	os.Setenv("HOME", "/root")
+	os.Unsetenv("CIO_SANDBOX_UNSET")
+	defer os.Unsetenv("CIO_SANDBOX_ALLOWED")
+	defer os.Unsetenv("CIO_SANDBOX_DENIED")
+
+	sandbox := &Sandbox{EnvAllowlist: []string{"CIO_SANDBOX_ALLOWED", "CIO_SANDBOX_UNSET", "CIO_SANDBOX_ALLOWED"}}
+	assert.Equal([]string{
+		"CIO_SANDBOX_ALLOWED=allowed",
+		"HOME=/home/nobody",
+		"TMPDIR=/tmp/work",
+	}, sandbox.environment("/tmp/work", "/home/nobody", ""), "Only allowed variables should be inherited, once")
+
+	assert.Equal([]string{
+		"CIO_SANDBOX_ALLOWED=allowed",
+		"HOME=/home/nobody",
+		"TMPDIR=/tmp/work",
+		"USER=nobody",
+		"LOGNAME=nobody",
+	}, sandbox.environment("/tmp/work", "/home/nobody", "nobody"), "User variables should be defined")
+
+	sandbox = &Sandbox{EnvAllowlist: []string{"HOME"}}
+	assert.Equal([]string{
+		"HOME=/root",
+		"TMPDIR=/tmp/work",
+	}, sandbox.environment("/tmp/work", "/home/nobody", ""), "Allowed variables should not be overridden")
+}
+
+func TestSandboxLimitArgs(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("rlimits are not supported on windows")
+	}
+	assert := assert.New(t)
+
+	args := []string{"/bin/sh", "script"}
+	sandbox := &Sandbox{}
+	assert.Equal(args, sandbox.limitArgs(args, false), "Command should not be wrapped without limits")
+	assert.Equal([]string{"/bin/sh", "-c", `read -r gate <&3; exec "$0" "$@" 3<&-`, "/bin/sh", "script"},
+		sandbox.limitArgs(args, true), "Gated command should be wrapped")
+
+	sandbox = &Sandbox{Limits: ResourceLimits{CPUSeconds: 60, MemoryMB: 512, OpenFiles: 64, Processes: 10}}
+	assert.Equal([]string{
+		"/bin/sh", "-c", `ulimit -t 60 && ulimit -v 524288 && ulimit -n 64 && exec "$0" "$@" 3<&-`, "/bin/sh", "script",
+	}, sandbox.limitArgs(args, false), "Unexpected limited command")
+	gated := sandbox.limitArgs(args, true)
+	assert.True(strings.HasPrefix(gated[2], "read -r gate <&3; ulimit -t 60"), "Gate should be waited before any limit")
+}
+
+func TestSandboxLimitArgsGate(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("rlimits are not supported on windows")
+	}
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "cio-sandbox-test")
+	assert.Nil(err, "Couldn't create directory")
+	defer os.RemoveAll(dir)
+	marker := filepath.Join(dir, "ran")
+
+	sandbox := &Sandbox{Limits: ResourceLimits{OpenFiles: 64}}
+	args := sandbox.limitArgs([]string{"/bin/sh", "-c", `ulimit -n > "$1"`, "sh", marker}, true)
+	cmd := exec.Command(args[0], args[1:]...)
+	held, gate, err := os.Pipe()
+	assert.Nil(err, "Couldn't create gate")
+	cmd.ExtraFiles = []*os.File{held}
+	assert.Nil(cmd.Start(), "Couldn't start command")
+	held.Close()
+
+	time.Sleep(100 * time.Millisecond)
+	assert.False(FileExists(marker), "Command should be held until the gate is closed")
+	gate.Close()
+	assert.Nil(cmd.Wait(), "Command should run once the gate is closed")
+	limit, _ := ioutil.ReadFile(marker)
+	assert.Equal("64", strings.TrimSpace(string(limit)), "Limits should be applied")
+}
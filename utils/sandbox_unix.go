@@ -0,0 +1,155 @@
+// +build !windows
+
+package utils
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+const cgroupV2Root = "/sys/fs/cgroup"
+
+// apply sets the credentials of the sandbox to cmd, granting ownership of its working files
+func (s *Sandbox) apply(cmd *exec.Cmd, workDir string, cmdFilePath string) (home string, userName string, err error) {
+	home = workDir
+	uid, gid := os.Getuid(), os.Getgid()
+	switchCredentials := false
+
+	if s.User != "" {
+		u, err := user.Lookup(s.User)
+		if err != nil {
+			return "", "", fmt.Errorf("cannot find user %s: %v", s.User, err)
+		}
+		if uid, err = strconv.Atoi(u.Uid); err != nil {
+			return "", "", fmt.Errorf("invalid uid for user %s: %v", s.User, err)
+		}
+		if gid, err = strconv.Atoi(u.Gid); err != nil {
+			return "", "", fmt.Errorf("invalid gid for user %s: %v", s.User, err)
+		}
+		if u.HomeDir != "" {
+			home = u.HomeDir
+		}
+		userName = u.Username
+		switchCredentials = true
+	}
+
+	if s.Group != "" {
+		g, err := user.LookupGroup(s.Group)
+		if err != nil {
+			return "", "", fmt.Errorf("cannot find group %s: %v", s.Group, err)
+		}
+		if gid, err = strconv.Atoi(g.Gid); err != nil {
+			return "", "", fmt.Errorf("invalid gid for group %s: %v", s.Group, err)
+		}
+		switchCredentials = true
+	}
+
+	if switchCredentials {
+		for _, path := range []string{workDir, cmdFilePath} {
+			if err := os.Chown(path, uid, gid); err != nil {
+				return "", "", fmt.Errorf("cannot change owner of %s: %v", path, err)
+			}
+		}
+		cmd.SysProcAttr = &syscall.SysProcAttr{
+			Credential: &syscall.Credential{Uid: uint32(uid), Gid: uint32(gid), Groups: []uint32{}},
+		}
+		log.Debugf("Command will run as uid=%d gid=%d", uid, gid)
+	}
+
+	return home, userName, nil
+}
+
+// limitArgs wraps the command line so the rlimits of the sandbox are lowered by a shell before running it,
+// and hence inherited by every child. Only the options shared by every POSIX shell are used, process count
+// is bounded through the cgroup instead. When gated, the shell first waits until file descriptor 3 is closed,
+// so that nothing is run before the process has been attached to its cgroup.
+func (s *Sandbox) limitArgs(args []string, gated bool) []string {
+	ulimits := []string{}
+	if s.Limits.CPUSeconds > 0 {
+		ulimits = append(ulimits, fmt.Sprintf("ulimit -t %d", s.Limits.CPUSeconds))
+	}
+	if s.Limits.MemoryMB > 0 {
+		ulimits = append(ulimits, fmt.Sprintf("ulimit -v %d", s.Limits.MemoryMB*1024))
+	}
+	if s.Limits.OpenFiles > 0 {
+		ulimits = append(ulimits, fmt.Sprintf("ulimit -n %d", s.Limits.OpenFiles))
+	}
+	if len(ulimits) == 0 && !gated {
+		return args
+	}
+	script := strings.Join(append(ulimits, "exec \"$0\" \"$@\" 3<&-"), " && ")
+	if gated {
+		// read fails once the gate is closed without data, which must not prevent the command from running
+		script = "read -r gate <&3; " + script
+	}
+	return append([]string{"/bin/sh", "-c", script}, args...)
+}
+
+// createCgroup creates a dedicated cgroup v2 under the configured slice, when available, set to the limits of the
+// sandbox. It returns the cgroup directory, or an empty string if no cgroup is used.
+func (s *Sandbox) createCgroup(name string) string {
+	if s.Limits.CgroupSlice == "" {
+		return ""
+	}
+	if !FileExists(filepath.Join(cgroupV2Root, "cgroup.controllers")) {
+		log.Warn("cgroup v2 is not available, resource limits are applied only through rlimits")
+		return ""
+	}
+
+	slice := filepath.Join(cgroupV2Root, s.Limits.CgroupSlice)
+	dir := filepath.Join(slice, name)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		log.Warn("Cannot create cgroup: ", err)
+		return ""
+	}
+
+	// controllers must be enabled in the parent to be usable by the command cgroup
+	if err := ioutil.WriteFile(filepath.Join(slice, "cgroup.subtree_control"), []byte("+cpu +memory +pids"), 0644); err != nil {
+		log.Debug("Cannot enable cgroup controllers: ", err)
+	}
+
+	settings := map[string]string{}
+	if s.Limits.MemoryMB > 0 {
+		settings["memory.max"] = strconv.Itoa(s.Limits.MemoryMB * 1024 * 1024)
+	}
+	if s.Limits.Processes > 0 {
+		settings["pids.max"] = strconv.Itoa(s.Limits.Processes)
+	}
+	if s.Limits.CPUPercent > 0 {
+		settings["cpu.max"] = fmt.Sprintf("%d 100000", s.Limits.CPUPercent*1000)
+	}
+	for file, value := range settings {
+		if err := ioutil.WriteFile(filepath.Join(dir, file), []byte(value), 0644); err != nil {
+			log.Warnf("Cannot set cgroup %s to %s: %v", file, value, err)
+		}
+	}
+	return dir
+}
+
+// attachCgroup moves the process into the cgroup directory
+func attachCgroup(dir string, pid int) error {
+	if err := ioutil.WriteFile(filepath.Join(dir, "cgroup.procs"), []byte(strconv.Itoa(pid)), 0644); err != nil {
+		return err
+	}
+	log.Debugf("Command attached to cgroup %s", dir)
+	return nil
+}
+
+// releaseCgroup removes the cgroup once every process in it has finished
+func releaseCgroup(dir string) {
+	if dir == "" {
+		return
+	}
+	if err := os.Remove(dir); err != nil {
+		log.Warn("Cgroup cannot be removed: ", err)
+	}
+}
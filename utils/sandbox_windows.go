@@ -0,0 +1,40 @@
+// +build windows
+
+package utils
+
+import (
+	"fmt"
+	"os/exec"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+// apply checks the sandbox can be honoured, as credentials switching and rlimits are not supported on windows
+func (s *Sandbox) apply(cmd *exec.Cmd, workDir string, cmdFilePath string) (home string, userName string, err error) {
+	if s.User != "" || s.Group != "" {
+		return "", "", fmt.Errorf("running commands as a different user or group is not supported on windows")
+	}
+	if s.Limits != (ResourceLimits{}) {
+		log.Warn("Resource limits are not supported on windows and will be ignored")
+	}
+	return workDir, "", nil
+}
+
+// limitArgs returns the command line unchanged, as rlimits are not supported on windows
+func (s *Sandbox) limitArgs(args []string, gated bool) []string {
+	return args
+}
+
+// createCgroup returns no cgroup, as they are not available on windows
+func (s *Sandbox) createCgroup(name string) string {
+	return ""
+}
+
+// attachCgroup is a no-op on windows
+func attachCgroup(dir string, pid int) error {
+	return nil
+}
+
+// releaseCgroup is a no-op on windows
+func releaseCgroup(dir string) {
+}
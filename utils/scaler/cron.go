@@ -0,0 +1,117 @@
+package scaler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cronFields are the bounds of the fields of a cron expression: minute, hour, day of month, month and day of week
+var cronFields = []struct {
+	name     string
+	min, max int
+}{
+	{"minute", 0, 59},
+	{"hour", 0, 23},
+	{"day of month", 1, 31},
+	{"month", 1, 12},
+	{"day of week", 0, 7},
+}
+
+// Cron is a parsed cron expression, with the standard five fields. Each field is either *, a value, a range
+// (1-5) or a list of them (1,3,5), optionally with a step (*/15, 0-30/10). Sunday is either 0 or 7.
+type Cron struct {
+	expr   string
+	fields [5]map[int]bool
+	// restricted day of month and day of week fields match any of them, as in the standard cron
+	anyDay bool
+}
+
+// ParseCron parses a cron expression
+func ParseCron(expr string) (*Cron, error) {
+	parts := strings.Fields(expr)
+	if len(parts) != len(cronFields) {
+		return nil, fmt.Errorf("invalid cron expression %q: %d fields expected", expr, len(cronFields))
+	}
+
+	cron := &Cron{expr: expr}
+	for i, part := range parts {
+		values, err := parseCronField(part, cronFields[i].min, cronFields[i].max)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cron expression %q: %s %v", expr, cronFields[i].name, err)
+		}
+		cron.fields[i] = values
+	}
+	if cron.fields[4][7] {
+		cron.fields[4][0] = true
+	}
+	cron.anyDay = parts[2] != "*" && parts[4] != "*"
+	return cron, nil
+}
+
+// parseCronField returns the values matched by a field within its bounds
+func parseCronField(field string, min int, max int) (map[int]bool, error) {
+	values := map[int]bool{}
+	for _, item := range strings.Split(field, ",") {
+		step := 1
+		if i := strings.Index(item, "/"); i >= 0 {
+			var err error
+			if step, err = strconv.Atoi(item[i+1:]); err != nil || step <= 0 {
+				return nil, fmt.Errorf("has an invalid step in %q", item)
+			}
+			item = item[:i]
+		}
+
+		from, to := min, max
+		if item != "*" {
+			bounds := strings.SplitN(item, "-", 2)
+			var err error
+			if from, err = strconv.Atoi(bounds[0]); err != nil {
+				return nil, fmt.Errorf("has an invalid value %q", bounds[0])
+			}
+			to = from
+			if len(bounds) == 2 {
+				if to, err = strconv.Atoi(bounds[1]); err != nil {
+					return nil, fmt.Errorf("has an invalid value %q", bounds[1])
+				}
+			}
+			if from < min || to > max || from > to {
+				return nil, fmt.Errorf("is out of range %d-%d in %q", min, max, item)
+			}
+		}
+		for value := from; value <= to; value += step {
+			values[value] = true
+		}
+	}
+	return values, nil
+}
+
+// Matches tells whether the minute of the time matches the expression
+func (c *Cron) Matches(t time.Time) bool {
+	if !c.fields[0][t.Minute()] || !c.fields[1][t.Hour()] || !c.fields[3][int(t.Month())] {
+		return false
+	}
+	dayOfMonth, dayOfWeek := c.fields[2][t.Day()], c.fields[4][int(t.Weekday())]
+	if c.anyDay {
+		return dayOfMonth || dayOfWeek
+	}
+	return dayOfMonth && dayOfWeek
+}
+
+// LastMatch returns the latest minute matching the expression at or before the time, looking back up to the
+// given duration. It returns false when there is none.
+func (c *Cron) LastMatch(t time.Time, within time.Duration) (time.Time, bool) {
+	t = t.Truncate(time.Minute)
+	for earliest := t.Add(-within); !t.Before(earliest); t = t.Add(-time.Minute) {
+		if c.Matches(t) {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// String returns the expression
+func (c *Cron) String() string {
+	return c.expr
+}
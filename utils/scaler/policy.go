@@ -0,0 +1,294 @@
+// Package scaler decides the size of server arrays from scaling policies, with scheduled size windows and
+// metric thresholds
+package scaler
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	// DefaultInterval is the time between reconciliations when the policy doesn't set it, in seconds
+	DefaultInterval = 60
+	// DefaultMetricTimeout is the time a metric hook is given to print the metric value, in seconds
+	DefaultMetricTimeout = 30
+)
+
+// Actions of scaling decisions
+const (
+	// ActionNone keeps the size of the server array, as it is within the bounds and the metric thresholds
+	ActionNone = "none"
+	// ActionEnlarge adds servers to the server array
+	ActionEnlarge = "enlarge"
+	// ActionShrink decommissions servers of the server array
+	ActionShrink = "shrink"
+	// ActionCooldown keeps the size of the server array, which should change, until its cooldown is over
+	ActionCooldown = "cooldown"
+)
+
+// Policy is a scaling policy file, such as:
+//
+//	interval: 60
+//	server_arrays:
+//	  - id: 5b5ec2f9bf2b6500066d3e01
+//	    min: 1
+//	    max: 8
+//	    cooldown: 300
+//	    schedules:
+//	      - cron: "0 8 * * 1-5"
+//	        duration: 10h
+//	        min: 3
+//	    metric:
+//	      command: ./queue-depth.sh
+//	      scale_up_above: 100
+//	      scale_down_below: 10
+type Policy struct {
+	// Interval is the time between reconciliations, in seconds
+	Interval     int            `yaml:"interval"`
+	ServerArrays []*ArrayPolicy `yaml:"server_arrays"`
+}
+
+// ArrayPolicy bounds the size of a server array, and optionally scales it within the bounds following a metric
+type ArrayPolicy struct {
+	ID  string `yaml:"id"`
+	Min int    `yaml:"min"`
+	Max int    `yaml:"max"`
+	// Cooldown is the minimum time between size changes, in seconds
+	Cooldown  int         `yaml:"cooldown"`
+	Schedules []*Window   `yaml:"schedules"`
+	Metric    *MetricHook `yaml:"metric"`
+}
+
+// Window overrides the bounds of the server array from every time matching its cron expression, during its
+// duration. When several windows are active, the first one applies. Bounds not given in the window are the
+// ones of the server array policy.
+type Window struct {
+	Cron     string `yaml:"cron"`
+	Duration string `yaml:"duration"`
+	Min      *int   `yaml:"min"`
+	Max      *int   `yaml:"max"`
+
+	cron     *Cron
+	duration time.Duration
+}
+
+// MetricHook is an external command printing a number. The server array grows by the step when the number
+// is above ScaleUpAbove, and shrinks by it when the number is below ScaleDownBelow.
+type MetricHook struct {
+	Command        string   `yaml:"command"`
+	ScaleUpAbove   *float64 `yaml:"scale_up_above"`
+	ScaleDownBelow *float64 `yaml:"scale_down_below"`
+	Step           int      `yaml:"step"`
+	// Timeout is the maximum time the command can take, in seconds
+	Timeout int `yaml:"timeout"`
+}
+
+// LoadPolicy reads and validates the policy file
+func LoadPolicy(path string) (*Policy, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read policy file: %v", err)
+	}
+	return ParsePolicy(data)
+}
+
+// ParsePolicy parses and validates a policy
+func ParsePolicy(data []byte) (*Policy, error) {
+	policy := &Policy{}
+	if err := yaml.UnmarshalStrict(data, policy); err != nil {
+		return nil, fmt.Errorf("invalid policy: %v", err)
+	}
+	if policy.Interval <= 0 {
+		policy.Interval = DefaultInterval
+	}
+	if len(policy.ServerArrays) == 0 {
+		return nil, fmt.Errorf("invalid policy: no server arrays")
+	}
+	seen := map[string]bool{}
+	for i, array := range policy.ServerArrays {
+		if err := array.validate(); err != nil {
+			return nil, fmt.Errorf("invalid policy for server array #%d: %v", i+1, err)
+		}
+		if seen[array.ID] {
+			return nil, fmt.Errorf("invalid policy: server array %s is given more than once", array.ID)
+		}
+		seen[array.ID] = true
+	}
+	return policy, nil
+}
+
+func validateBounds(min int, max int) error {
+	if min < 0 || max < min {
+		return fmt.Errorf("min %d and max %d are not valid bounds", min, max)
+	}
+	return nil
+}
+
+func (p *ArrayPolicy) validate() error {
+	if p.ID == "" {
+		return fmt.Errorf("id is required")
+	}
+	if err := validateBounds(p.Min, p.Max); err != nil {
+		return err
+	}
+	if p.Cooldown < 0 {
+		return fmt.Errorf("cooldown cannot be negative")
+	}
+
+	for _, window := range p.Schedules {
+		var err error
+		if window.cron, err = ParseCron(window.Cron); err != nil {
+			return err
+		}
+		if window.duration, err = time.ParseDuration(window.Duration); err != nil || window.duration <= 0 {
+			return fmt.Errorf("invalid duration %q of schedule %q", window.Duration, window.Cron)
+		}
+		if err = validateBounds(window.bounds(p.Min, p.Max)); err != nil {
+			return fmt.Errorf("schedule %q: %v", window.Cron, err)
+		}
+	}
+
+	if p.Metric != nil {
+		if p.Metric.Command == "" {
+			return fmt.Errorf("metric command is required")
+		}
+		if p.Metric.ScaleUpAbove == nil && p.Metric.ScaleDownBelow == nil {
+			return fmt.Errorf("metric requires scale_up_above, scale_down_below or both")
+		}
+		if p.Metric.ScaleUpAbove != nil && p.Metric.ScaleDownBelow != nil && *p.Metric.ScaleDownBelow >= *p.Metric.ScaleUpAbove {
+			return fmt.Errorf("metric scale_down_below must be lower than scale_up_above")
+		}
+		if p.Metric.Step < 0 || p.Metric.Timeout < 0 {
+			return fmt.Errorf("metric step and timeout cannot be negative")
+		}
+		if p.Metric.Step == 0 {
+			p.Metric.Step = 1
+		}
+		if p.Metric.Timeout == 0 {
+			p.Metric.Timeout = DefaultMetricTimeout
+		}
+	}
+	return nil
+}
+
+// bounds returns the bounds of the window, defaulting to the given ones
+func (w *Window) bounds(min int, max int) (int, int) {
+	if w.Min != nil {
+		min = *w.Min
+	}
+	if w.Max != nil {
+		max = *w.Max
+	}
+	return min, max
+}
+
+// active tells whether the window is open at the time
+func (w *Window) active(now time.Time) bool {
+	_, found := w.cron.LastMatch(now, w.duration)
+	return found
+}
+
+// Bounds returns the size bounds of the server array at the time, and the cron expression of the window
+// setting them, if any
+func (p *ArrayPolicy) Bounds(now time.Time) (int, int, string) {
+	for _, window := range p.Schedules {
+		if window.active(now) {
+			min, max := window.bounds(p.Min, p.Max)
+			return min, max, window.Cron
+		}
+	}
+	return p.Min, p.Max, ""
+}
+
+// Read runs the command and returns the number it prints
+func (m *MetricHook) Read() (float64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(m.Timeout)*time.Second)
+	defer cancel()
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", m.Command)
+	} else {
+		cmd = exec.CommandContext(ctx, "/bin/sh", "-c", m.Command)
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return 0, fmt.Errorf("metric command failed: %v %s", err, strings.TrimSpace(stderr.String()))
+	}
+	value, err := strconv.ParseFloat(strings.TrimSpace(string(output)), 64)
+	if err != nil {
+		return 0, fmt.Errorf("metric command printed %q instead of a number", strings.TrimSpace(string(output)))
+	}
+	return value, nil
+}
+
+// Decision is the outcome of reconciling the size of a server array
+type Decision struct {
+	Timestamp     time.Time `json:"timestamp" header:"TIMESTAMP"`
+	ServerArrayID string    `json:"server_array_id" header:"SERVER_ARRAY_ID"`
+	Current       int       `json:"current" header:"CURRENT"`
+	Desired       int       `json:"desired" header:"DESIRED"`
+	Min           int       `json:"min" header:"MIN"`
+	Max           int       `json:"max" header:"MAX"`
+	Metric        string    `json:"metric,omitempty" header:"METRIC"`
+	Action        string    `json:"action" header:"ACTION"`
+	Reason        string    `json:"reason" header:"REASON"`
+	DryRun        bool      `json:"dry_run,omitempty" header:"DRY_RUN"`
+	Error         string    `json:"error,omitempty" header:"ERROR"`
+}
+
+// Decide returns the size the server array should have, given its current size, the metric value (when the
+// policy has a metric hook) and the time of its last size change
+func (p *ArrayPolicy) Decide(current int, metric *float64, lastChange time.Time, now time.Time) *Decision {
+	min, max, window := p.Bounds(now)
+	d := &Decision{Timestamp: now, ServerArrayID: p.ID, Current: current, Desired: current, Min: min, Max: max, Action: ActionNone}
+	reasons := []string{}
+	if window != "" {
+		reasons = append(reasons, fmt.Sprintf("schedule %q is active", window))
+	}
+
+	if metric != nil && p.Metric != nil {
+		d.Metric = strconv.FormatFloat(*metric, 'f', -1, 64)
+		switch {
+		case p.Metric.ScaleUpAbove != nil && *metric > *p.Metric.ScaleUpAbove:
+			d.Desired = current + p.Metric.Step
+			reasons = append(reasons, fmt.Sprintf("metric %s is above %v", d.Metric, *p.Metric.ScaleUpAbove))
+		case p.Metric.ScaleDownBelow != nil && *metric < *p.Metric.ScaleDownBelow:
+			d.Desired = current - p.Metric.Step
+			reasons = append(reasons, fmt.Sprintf("metric %s is below %v", d.Metric, *p.Metric.ScaleDownBelow))
+		}
+	}
+	if d.Desired < min {
+		d.Desired = min
+		reasons = append(reasons, fmt.Sprintf("minimum size is %d", min))
+	}
+	if d.Desired > max {
+		d.Desired = max
+		reasons = append(reasons, fmt.Sprintf("maximum size is %d", max))
+	}
+
+	switch {
+	case d.Desired == current:
+		reasons = append(reasons, "size is within bounds")
+	case !lastChange.IsZero() && now.Before(lastChange.Add(time.Duration(p.Cooldown)*time.Second)):
+		d.Action = ActionCooldown
+		reasons = append(reasons, fmt.Sprintf("cooling down until %s", lastChange.Add(time.Duration(p.Cooldown)*time.Second).Format(time.RFC3339)))
+	case d.Desired > current:
+		d.Action = ActionEnlarge
+	default:
+		d.Action = ActionShrink
+	}
+	d.Reason = strings.Join(reasons, ", ")
+	return d
+}
@@ -0,0 +1,111 @@
+package scaler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testPolicy = `
+interval: 30
+server_arrays:
+  - id: array
+    min: 1
+    max: 8
+    cooldown: 300
+    schedules:
+      - cron: "0 8 * * 1-5"
+        duration: 10h
+        min: 3
+    metric:
+      command: echo 42
+      scale_up_above: 100
+      scale_down_below: 10
+      step: 2
+`
+
+func TestParseCron(t *testing.T) {
+	assert := assert.New(t)
+
+	cron, err := ParseCron("*/15 8-18 * * 1-5")
+	assert.Nil(err, "Couldn't parse cron expression")
+	assert.True(cron.Matches(time.Date(2018, 6, 1, 8, 30, 0, 0, time.UTC)), "Friday at 8:30 should match")
+	assert.False(cron.Matches(time.Date(2018, 6, 1, 8, 31, 0, 0, time.UTC)), "Minute 31 should not match")
+	assert.False(cron.Matches(time.Date(2018, 6, 2, 8, 30, 0, 0, time.UTC)), "Saturday should not match")
+
+	cron, err = ParseCron("0 0 1 * 0")
+	assert.Nil(err, "Couldn't parse cron expression")
+	assert.True(cron.Matches(time.Date(2018, 6, 3, 0, 0, 0, 0, time.UTC)), "Sundays should match when day of week is restricted")
+	assert.True(cron.Matches(time.Date(2018, 6, 1, 0, 0, 0, 0, time.UTC)), "First day of month should match")
+
+	last, found := cron.LastMatch(time.Date(2018, 6, 1, 5, 20, 10, 0, time.UTC), 6*time.Hour)
+	assert.True(found, "Last match should be found")
+	assert.Equal(time.Date(2018, 6, 1, 0, 0, 0, 0, time.UTC), last, "Unexpected last match")
+
+	for _, expr := range []string{"* * * *", "60 * * * *", "* * * * 8", "5-1 * * * *", "*/0 * * * *", "a * * * *"} {
+		_, err = ParseCron(expr)
+		assert.NotNil(err, "%s should be invalid", expr)
+	}
+}
+
+func TestParsePolicy(t *testing.T) {
+	assert := assert.New(t)
+
+	policy, err := ParsePolicy([]byte(testPolicy))
+	assert.Nil(err, "Couldn't parse policy")
+	assert.Equal(30, policy.Interval, "Unexpected interval")
+	assert.Equal(DefaultMetricTimeout, policy.ServerArrays[0].Metric.Timeout, "Metric timeout should default")
+
+	value, err := policy.ServerArrays[0].Metric.Read()
+	assert.Nil(err, "Couldn't read metric")
+	assert.Equal(42.0, value, "Unexpected metric value")
+
+	for _, invalid := range []string{
+		"server_arrays: []",
+		"server_arrays: [{min: 1, max: 2}]",
+		"server_arrays: [{id: a, min: 3, max: 2}]",
+		"server_arrays: [{id: a, max: 2, schedules: [{cron: '* * *', duration: 1h}]}]",
+		"server_arrays: [{id: a, max: 2, schedules: [{cron: '* * * * *', duration: 1h, min: 3}]}]",
+		"server_arrays: [{id: a, max: 2, metric: {command: x}}]",
+		"server_arrays: [{id: a, max: 2, metric: {command: x, scale_up_above: 1, scale_down_below: 2}}]",
+		"server_arrays: [{id: a, max: 2}, {id: a, max: 3}]",
+		"server_arrays: [{id: a, max: 2, unknown: 1}]",
+	} {
+		_, err = ParsePolicy([]byte(invalid))
+		assert.NotNil(err, "%s should be invalid", invalid)
+	}
+}
+
+func TestDecide(t *testing.T) {
+	assert := assert.New(t)
+
+	policy, err := ParsePolicy([]byte(testPolicy))
+	assert.Nil(err, "Couldn't parse policy")
+	array := policy.ServerArrays[0]
+	friday, saturday := time.Date(2018, 6, 1, 12, 0, 0, 0, time.UTC), time.Date(2018, 6, 2, 12, 0, 0, 0, time.UTC)
+	metric := func(value float64) *float64 { return &value }
+
+	d := array.Decide(1, nil, time.Time{}, friday)
+	assert.Equal(ActionEnlarge, d.Action, "Scheduled minimum should enlarge")
+	assert.Equal(3, d.Desired, "Unexpected desired size")
+
+	d = array.Decide(2, nil, time.Time{}, saturday)
+	assert.Equal(ActionNone, d.Action, "Size within bounds should not change")
+
+	d = array.Decide(2, metric(150), time.Time{}, saturday)
+	assert.Equal(ActionEnlarge, d.Action, "Metric above threshold should enlarge")
+	assert.Equal(4, d.Desired, "Unexpected desired size")
+	assert.Equal("150", d.Metric, "Unexpected metric")
+
+	d = array.Decide(8, metric(150), time.Time{}, saturday)
+	assert.Equal(ActionNone, d.Action, "Size should not exceed the maximum")
+
+	d = array.Decide(4, metric(1), time.Time{}, friday)
+	assert.Equal(ActionShrink, d.Action, "Metric below threshold should shrink")
+	assert.Equal(3, d.Desired, "Size should not go below the scheduled minimum")
+
+	d = array.Decide(4, metric(1), saturday.Add(-time.Minute), saturday)
+	assert.Equal(ActionCooldown, d.Action, "Recent changes should cool down")
+	assert.Equal(2, d.Desired, "Desired size should be kept while cooling down")
+}
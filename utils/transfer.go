@@ -0,0 +1,281 @@
+package utils
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+// DefaultDownloadRetries is the number of times an interrupted download is resumed
+const DefaultDownloadRetries = 5
+
+var (
+	// downloadRetryInterval is the time waited before resuming an interrupted download
+	downloadRetryInterval = 2 * time.Second
+	// progressInterval is the minimum time between progress reports
+	progressInterval = 500 * time.Millisecond
+
+	contentRangeRegexp = regexp.MustCompile(`^bytes (\d+)-\d+/(\d+|\*)$`)
+)
+
+// progressOutput returns where transfer progress is reported: stderr when it is a terminal, nothing otherwise
+func progressOutput() io.Writer {
+	info, err := os.Stderr.Stat()
+	if err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		return nil
+	}
+	return os.Stderr
+}
+
+// progressReader reports the bytes read through it, and the percentage when the total is known
+type progressReader struct {
+	reader   io.Reader
+	output   io.Writer
+	label    string
+	total    int64
+	done     int64
+	reported time.Time
+}
+
+// newProgressReader returns the reader itself when there is nowhere to report the progress
+func newProgressReader(reader io.Reader, output io.Writer, label string, total int64, done int64) io.Reader {
+	if output == nil {
+		return reader
+	}
+	return &progressReader{reader: reader, output: output, label: label, total: total, done: done}
+}
+
+func (p *progressReader) Read(buf []byte) (int, error) {
+	n, err := p.reader.Read(buf)
+	p.done += int64(n)
+	if err != nil || time.Since(p.reported) >= progressInterval {
+		p.reported = time.Now()
+		if p.total > 0 {
+			fmt.Fprintf(p.output, "\r%s: %s of %s (%d%%)", p.label, formatBytes(p.done), formatBytes(p.total), p.done*100/p.total)
+		} else {
+			fmt.Fprintf(p.output, "\r%s: %s", p.label, formatBytes(p.done))
+		}
+		if err != nil {
+			fmt.Fprintln(p.output)
+		}
+	}
+	return n, err
+}
+
+// formatBytes returns the size with a binary unit
+func formatBytes(size int64) string {
+	units := []string{"B", "KiB", "MiB", "GiB", "TiB"}
+	value := float64(size)
+	unit := 0
+	for value >= 1024 && unit < len(units)-1 {
+		value /= 1024
+		unit++
+	}
+	if unit == 0 {
+		return fmt.Sprintf("%d %s", size, units[unit])
+	}
+	return fmt.Sprintf("%.1f %s", value, units[unit])
+}
+
+// fileMD5 returns the MD5 digest of the file contents
+func fileMD5(file *os.File) ([]byte, error) {
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	hash := md5.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return nil, err
+	}
+	return hash.Sum(nil), nil
+}
+
+// responseMD5 returns the MD5 digest of the contents announced by the response Content-MD5 header. It is nil when
+// there is none. ETags are opaque, even when they look like an MD5, so they are never taken as one.
+func responseMD5(header http.Header) []byte {
+	if contentMD5 := header.Get("Content-MD5"); contentMD5 != "" {
+		if digest, err := base64.StdEncoding.DecodeString(contentMD5); err == nil && len(digest) == md5.Size {
+			return digest
+		}
+	}
+	return nil
+}
+
+// download receives the body of a GET request into the file named after the first response. The body is written
+// aside and renamed once complete, so that the file is never left truncated. Interrupted transfers are resumed
+// with range requests, and the size and MD5 digest announced by the server, if any, are verified. Only 200
+// responses are received; others are returned with no file.
+func (hcs *HTTPConcertoservice) download(url string, headers map[string]string, fileName func(*http.Response) (string, error)) (string, http.Header, int, error) {
+	var (
+		output        *os.File
+		realFileName  string
+		header        http.Header
+		status        int
+		validator     string
+		total         int64 = -1
+		written       int64
+		lastErr       error
+		progressLabel string
+	)
+	defer func() {
+		if output != nil {
+			output.Close()
+			os.Remove(output.Name())
+		}
+	}()
+
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 {
+			if attempt > DefaultDownloadRetries {
+				return "", header, status, fmt.Errorf("download failed after %d retries: %v", DefaultDownloadRetries, lastErr)
+			}
+			log.Warnf("Download of %s interrupted at %d bytes (%v), retrying", url, written, lastErr)
+			time.Sleep(downloadRetryInterval)
+		}
+
+		log.Debugf("Sending GET request to %s with headers %v", url, headers)
+		request, err := http.NewRequest("GET", url, nil)
+		if err != nil {
+			return "", nil, 0, err
+		}
+		for key, value := range headers {
+			request.Header.Set(key, value)
+		}
+		if written > 0 {
+			request.Header.Set("Range", fmt.Sprintf("bytes=%d-", written))
+			if validator != "" {
+				request.Header.Set("If-Range", validator)
+			}
+		}
+		response, err := hcs.client.Do(request)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		log.Debugf("Status code:%d message:%s", response.StatusCode, response.Status)
+
+		if output == nil {
+			header, status = response.Header, response.StatusCode
+			if status != http.StatusOK {
+				response.Body.Close()
+				return "", header, status, nil
+			}
+			if realFileName, err = fileName(response); err != nil {
+				response.Body.Close()
+				return "", header, status, err
+			}
+			if output, err = ioutil.TempFile(filepath.Dir(realFileName), "."+filepath.Base(realFileName)+".part"); err != nil {
+				response.Body.Close()
+				return "", header, status, err
+			}
+			total = response.ContentLength
+			validator = response.Header.Get("ETag")
+			if validator == "" {
+				validator = response.Header.Get("Last-Modified")
+			}
+			progressLabel = filepath.Base(realFileName)
+		} else {
+			switch response.StatusCode {
+			case http.StatusPartialContent:
+				values := contentRangeRegexp.FindStringSubmatch(response.Header.Get("Content-Range"))
+				if values == nil || values[1] != strconv.FormatInt(written, 10) {
+					response.Body.Close()
+					return "", header, response.StatusCode, fmt.Errorf("cannot resume download: unexpected content range %q", response.Header.Get("Content-Range"))
+				}
+			case http.StatusOK:
+				// the server ignored the range, or the file changed: start over
+				if err = output.Truncate(0); err == nil {
+					_, err = output.Seek(0, io.SeekStart)
+				}
+				if err != nil {
+					response.Body.Close()
+					return "", header, status, err
+				}
+				header, written, total = response.Header, 0, response.ContentLength
+			default:
+				response.Body.Close()
+				return "", header, response.StatusCode, fmt.Errorf("cannot resume download: %s", response.Status)
+			}
+		}
+
+		n, err := io.Copy(output, newProgressReader(response.Body, progressOutput(), progressLabel, total, written))
+		response.Body.Close()
+		written += n
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		break
+	}
+	log.Debugf("%#v bytes downloaded", written)
+
+	if total >= 0 && written != total {
+		return "", header, status, fmt.Errorf("downloaded %d bytes, %d expected", written, total)
+	}
+	if expected := responseMD5(header); expected != nil {
+		digest, err := fileMD5(output)
+		if err != nil {
+			return "", header, status, err
+		}
+		if !bytes.Equal(digest, expected) {
+			return "", header, status, fmt.Errorf("downloaded file MD5 %x does not match the expected %x", digest, expected)
+		}
+	}
+
+	tmpName := output.Name()
+	err := output.Close()
+	output = nil
+	if err == nil {
+		err = os.Chmod(tmpName, 0644)
+	}
+	if err == nil {
+		err = os.Rename(tmpName, realFileName)
+	}
+	if err != nil {
+		os.Remove(tmpName)
+		return "", header, status, err
+	}
+	return realFileName, header, status, nil
+}
+
+// attachmentFileName returns the file name given by the Content-Disposition header of the response
+func attachmentFileName(response *http.Response) (string, error) {
+	r := regexp.MustCompile(`filename="([^"]*)"`)
+	matches := r.FindStringSubmatch(response.Header.Get("Content-Disposition"))
+	if len(matches) < 2 || filepath.Base(matches[1]) != matches[1] || matches[1] == "." || matches[1] == ".." {
+		return "", fmt.Errorf("cannot discover a valid file name from response")
+	}
+	return matches[1], nil
+}
+
+// uploadBody returns the file to upload, along with its size and the base64 encoded MD5 digest of its contents
+func uploadBody(sourceFilePath string) (*os.File, int64, string, error) {
+	file, err := os.Open(sourceFilePath)
+	if err != nil {
+		return nil, 0, "", err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, 0, "", err
+	}
+	digest, err := fileMD5(file)
+	if err == nil {
+		_, err = file.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		file.Close()
+		return nil, 0, "", err
+	}
+	return file, info.Size(), base64.StdEncoding.EncodeToString(digest), nil
+}
@@ -0,0 +1,122 @@
+package utils
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/base64"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetFileResumesInterruptedDownloads(t *testing.T) {
+	assert := assert.New(t)
+	downloadRetryInterval = 0
+
+	content := bytes.Repeat([]byte("0123456789"), 10000)
+	digest := md5.Sum(content)
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("Content-MD5", base64.StdEncoding.EncodeToString(digest[:]))
+		if requests == 1 {
+			// announces everything but sends half of it
+			w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+			w.Write(content[:len(content)/2])
+			return
+		}
+		assert.Equal("bytes=50000-", r.Header.Get("Range"), "Download should resume where it was interrupted")
+		http.ServeContent(w, r, "file", time.Time{}, bytes.NewReader(content))
+	}))
+	defer server.Close()
+
+	dir, err := ioutil.TempDir("", "cio-transfer")
+	assert.Nil(err, "Couldn't create temp dir")
+	defer os.RemoveAll(dir)
+	hcs := &HTTPConcertoservice{config: &Config{}, client: server.Client()}
+
+	filePath := filepath.Join(dir, "file")
+	realFileName, status, err := hcs.GetFile(server.URL, filePath, false)
+	assert.Nil(err, "Couldn't download file")
+	assert.Equal(200, status, "Unexpected status")
+	assert.Equal(filePath, realFileName, "Unexpected file name")
+	assert.Equal(2, requests, "Download should have been resumed once")
+	received, err := ioutil.ReadFile(filePath)
+	assert.Nil(err, "Couldn't read downloaded file")
+	assert.Equal(content, received, "Unexpected file contents")
+
+	files, _ := ioutil.ReadDir(dir)
+	assert.Equal(1, len(files), "Partial files should be removed")
+}
+
+func TestGetFileVerifiesChecksum(t *testing.T) {
+	assert := assert.New(t)
+
+	digest := md5.Sum([]byte("original"))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-MD5", base64.StdEncoding.EncodeToString(digest[:]))
+		w.Write([]byte("corrupted"))
+	}))
+	defer server.Close()
+
+	dir, err := ioutil.TempDir("", "cio-transfer")
+	assert.Nil(err, "Couldn't create temp dir")
+	defer os.RemoveAll(dir)
+	hcs := &HTTPConcertoservice{config: &Config{}, client: server.Client()}
+
+	_, _, err = hcs.GetFile(server.URL, filepath.Join(dir, "file"), false)
+	assert.NotNil(err, "Checksum mismatch should fail")
+	files, _ := ioutil.ReadDir(dir)
+	assert.Equal(0, len(files), "No file should be left")
+}
+
+func TestGetFileIgnoresETags(t *testing.T) {
+	assert := assert.New(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// multipart uploads and most servers have ETags which look like an MD5 but are not the contents one
+		w.Header().Set("ETag", `"00000000000000000000000000000000"`)
+		w.Write([]byte("contents"))
+	}))
+	defer server.Close()
+
+	dir, err := ioutil.TempDir("", "cio-transfer")
+	assert.Nil(err, "Couldn't create temp dir")
+	defer os.RemoveAll(dir)
+	hcs := &HTTPConcertoservice{config: &Config{}, client: server.Client()}
+
+	_, _, err = hcs.GetFile(server.URL, filepath.Join(dir, "file"), false)
+	assert.Nil(err, "ETags should not be verified as checksums")
+}
+
+func TestPutFileSendsLengthAndChecksum(t *testing.T) {
+	assert := assert.New(t)
+
+	content := []byte("cookbook contents")
+	digest := md5.Sum(content)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(int64(len(content)), r.ContentLength, "Unexpected Content-Length")
+		assert.Equal(base64.StdEncoding.EncodeToString(digest[:]), r.Header.Get("Content-MD5"), "Unexpected Content-MD5")
+		body, _ := ioutil.ReadAll(r.Body)
+		assert.Equal(content, body, "Unexpected body")
+	}))
+	defer server.Close()
+
+	f, err := ioutil.TempFile("", "cio-transfer")
+	assert.Nil(err, "Couldn't create temp file")
+	defer os.Remove(f.Name())
+	f.Write(content)
+	f.Close()
+	hcs := &HTTPConcertoservice{config: &Config{}, client: server.Client()}
+
+	_, status, err := hcs.PutFile(f.Name(), server.URL)
+	assert.Nil(err, "Couldn't upload file")
+	assert.Equal(200, status, "Unexpected status")
+}
@@ -0,0 +1,33 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+// WaitForState polls the state returned by get, every interval, until it reaches one of the target states.
+// Reaching one of the error states, or not reaching any target state within the timeout, is an error.
+// It returns the last state seen.
+func WaitForState(get func() (string, error), targets []string, errorStates []string, interval time.Duration, timeout time.Duration) (string, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		state, err := get()
+		if err != nil {
+			return state, err
+		}
+		log.Debugf("Current state: %s", state)
+		if Contains(targets, state) {
+			return state, nil
+		}
+		if Contains(errorStates, state) {
+			return state, fmt.Errorf("reached error state %s while waiting for %s", state, strings.Join(targets, ", "))
+		}
+		if !time.Now().Add(interval).Before(deadline) {
+			return state, fmt.Errorf("timed out after %v while waiting for %s, current state is %s", timeout, strings.Join(targets, ", "), state)
+		}
+		time.Sleep(interval)
+	}
+}
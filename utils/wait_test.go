@@ -0,0 +1,52 @@
+package utils
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeStates returns a get function walking through the given states, staying at the last one
+func fakeStates(states ...string) func() (string, error) {
+	i := 0
+	return func() (string, error) {
+		state := states[i]
+		if i < len(states)-1 {
+			i++
+		}
+		return state, nil
+	}
+}
+
+func TestWaitForStateReached(t *testing.T) {
+	assert := assert.New(t)
+
+	state, err := WaitForState(fakeStates("commissioning", "bootstrapping", "operational"), []string{"operational"}, []string{"stalled"}, time.Millisecond, time.Second)
+	assert.Nil(err, "Target state should be reached")
+	assert.Equal("operational", state, "Unexpected state")
+}
+
+func TestWaitForStateError(t *testing.T) {
+	assert := assert.New(t)
+
+	state, err := WaitForState(fakeStates("commissioning", "stalled", "operational"), []string{"operational"}, []string{"stalled"}, time.Millisecond, time.Second)
+	assert.NotNil(err, "Error state should fail")
+	assert.Equal("stalled", state, "Unexpected state")
+}
+
+func TestWaitForStateTimeout(t *testing.T) {
+	assert := assert.New(t)
+
+	state, err := WaitForState(fakeStates("commissioning"), []string{"operational"}, nil, time.Millisecond, 10*time.Millisecond)
+	assert.NotNil(err, "Timeout should fail")
+	assert.Equal("commissioning", state, "Unexpected state")
+}
+
+func TestWaitForStateGetError(t *testing.T) {
+	assert := assert.New(t)
+
+	_, err := WaitForState(func() (string, error) { return "", fmt.Errorf("not found") }, []string{"operational"}, nil, time.Millisecond, time.Second)
+	assert.NotNil(err, "Get error should fail")
+}
@@ -6,11 +6,9 @@ import (
 	"encoding/json"
 	"fmt"
 	log "github.com/Sirupsen/logrus"
-	"io"
 	"io/ioutil"
 	"net/http"
-	"os"
-	"regexp"
+	"path/filepath"
 	"strings"
 )
 
@@ -21,6 +19,7 @@ type ConcertoService interface {
 	Delete(path string) ([]byte, int, error)
 	Get(path string) ([]byte, int, error)
 	GetFile(url string, filePath string, discoveryFileName bool) (string, int, error)
+	GetFileConditional(url string, dirPath string, headers map[string]string) (string, http.Header, int, error)
 	PutFile(sourceFilePath string, targetURL string) ([]byte, int, error)
 }
 
@@ -212,55 +211,79 @@ func (hcs *HTTPConcertoservice) Get(path string) ([]byte, int, error) {
 	return hcs.receiveResponse(response)
 }
 
-// GetFile sends GET request to Concerto API and receives a file
-func (hcs *HTTPConcertoservice) GetFile(url string, filePath string, discoveryFileName bool) (string, int, error) {
+// GetConditional sends GET request with the given headers to Concerto API, returning the response headers too
+func (hcs *HTTPConcertoservice) GetConditional(path string, headers map[string]string) ([]byte, http.Header, int, error) {
 
-	log.Debugf("Sending GET request to %s", url)
-	response, err := hcs.client.Get(url)
+	url, _, err := hcs.prepareCall(path, nil)
 	if err != nil {
-		return "", 0, err
+		return nil, nil, 0, err
 	}
 
-	defer response.Body.Close()
-	log.Debugf("Status code:%d message:%s", response.StatusCode, response.Status)
-
-	realFileName := filePath
-	if discoveryFileName {
-		r, err := regexp.Compile("filename=\\\"([^\\\"]*){1}\\\"")
-		if err != nil {
-			return "", 0, err
-		}
-		realFileName = fmt.Sprintf("%s/%s", filePath, r.FindStringSubmatch(response.Header.Get("Content-Disposition"))[1])
-	}
-
-	output, err := os.Create(realFileName)
+	log.Debugf("Sending GET request to %s with headers %v", url, headers)
+	request, err := http.NewRequest("GET", url, nil)
 	if err != nil {
-		return "", response.StatusCode, err
+		return nil, nil, 0, err
 	}
-	defer output.Close()
-
-	n, err := io.Copy(output, response.Body)
+	for key, value := range headers {
+		request.Header.Set(key, value)
+	}
+	response, err := hcs.client.Do(request)
 	if err != nil {
-		return "", response.StatusCode, err
+		return nil, nil, 0, err
 	}
 
-	log.Debugf("%#v bytes downloaded", n)
-	return realFileName, response.StatusCode, nil
+	body, status, err := hcs.receiveResponse(response)
+	return body, response.Header, status, err
+}
+
+// GetFile sends GET request to Concerto API and receives a file. When discoveryFileName is set, filePath is the
+// directory of the file, which is named as given by the response.
+func (hcs *HTTPConcertoservice) GetFile(url string, filePath string, discoveryFileName bool) (string, int, error) {
+
+	realFileName, _, status, err := hcs.download(url, nil, func(response *http.Response) (string, error) {
+		if !discoveryFileName {
+			return filePath, nil
+		}
+		fileName, err := attachmentFileName(response)
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(filePath, fileName), nil
+	})
+	return realFileName, status, err
+}
+
+// GetFileConditional sends GET request with the given headers to Concerto API and receives a file into dirPath.
+// The file name is taken from the response, and the file is only written if the request succeeds, so that
+// conditional requests (If-None-Match, If-Modified-Since) answered with 304 leave the directory untouched.
+func (hcs *HTTPConcertoservice) GetFileConditional(url string, dirPath string, headers map[string]string) (string, http.Header, int, error) {
+
+	return hcs.download(url, headers, func(response *http.Response) (string, error) {
+		fileName, err := attachmentFileName(response)
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(dirPath, fileName), nil
+	})
 }
 
-// PutFile sends PUT request to send a file
+// PutFile sends PUT request to send a file, with its length and MD5 digest
 func (hcs *HTTPConcertoservice) PutFile(sourceFilePath string, targetURL string) ([]byte, int, error) {
 
-	data, err := os.Open(sourceFilePath)
+	data, size, digest, err := uploadBody(sourceFilePath)
 	if err != nil {
 		return nil, 0, err
 	}
+	defer data.Close()
 
-	req, err := http.NewRequest("PUT", targetURL, data)
+	req, err := http.NewRequest("PUT", targetURL, newProgressReader(data, progressOutput(), filepath.Base(sourceFilePath), size, 0))
 	if err != nil {
 		return nil, 0, err
 	}
+	req.ContentLength = size
+	req.Header.Set("Content-MD5", digest)
 
+	log.Debugf("Sending PUT request to %s with %d bytes", targetURL, size)
 	res, err := hcs.client.Do(req)
 	if err != nil {
 		return nil, 0, err
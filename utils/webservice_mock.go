@@ -1,6 +1,8 @@
 package utils
 
 import (
+	"net/http"
+
 	"github.com/stretchr/testify/mock"
 )
 
@@ -33,12 +35,24 @@ func (m *MockConcertoService) Get(path string) ([]byte, int, error) {
 	return args.Get(0).([]byte), args.Int(1), args.Error(2)
 }
 
+// GetConditional mocks GET request with headers to Concerto API
+func (m *MockConcertoService) GetConditional(path string, headers map[string]string) ([]byte, http.Header, int, error) {
+	args := m.Called(path, headers)
+	return args.Get(0).([]byte), args.Get(1).(http.Header), args.Int(2), args.Error(3)
+}
+
 // GetFile sends GET request to Concerto API and receives a file
 func (m *MockConcertoService) GetFile(url string, filePath string, discoveryFileName bool) (string, int, error) {
 	args := m.Called(url, filePath)
 	return args.String(0), args.Int(1), args.Error(2)
 }
 
+// GetFileConditional mocks GET request with headers to Concerto API and receives a file
+func (m *MockConcertoService) GetFileConditional(url string, dirPath string, headers map[string]string) (string, http.Header, int, error) {
+	args := m.Called(url, dirPath, headers)
+	return args.String(0), args.Get(1).(http.Header), args.Int(2), args.Error(3)
+}
+
 // PutFile sends PUT request to send a file
 func (m *MockConcertoService) PutFile(sourceFilePath string, targetURL string) ([]byte, int, error) {
 	args := m.Called(sourceFilePath, targetURL)
@@ -0,0 +1,33 @@
+package wait
+
+import (
+	"github.com/codegangsta/cli"
+	"github.com/ingrammicro/concerto/cmd"
+)
+
+// SubCommands returns wait commands
+func SubCommands() []cli.Command {
+	return []cli.Command{
+		{
+			Name:      "wait",
+			Usage:     "Waits until a resource reaches a state",
+			ArgsUsage: "server|server_array|volume|floating_ip|vpc|cookbook_version",
+			Action:    cmd.Wait,
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "id",
+					Usage: "Resource Id",
+				},
+				cli.StringFlag{
+					Name:  "state",
+					Usage: "A list of comma separated target states to wait for. By default, the usual state reached by the resource once it is ready",
+				},
+				cli.IntFlag{
+					Name:  "timeout",
+					Usage: "Maximum time to wait, in seconds",
+					Value: cmd.DefaultWaitTimeout,
+				},
+			},
+		},
+	}
+}
@@ -2,6 +2,7 @@ package wizard
 
 import (
 	"github.com/codegangsta/cli"
+	"github.com/ingrammicro/concerto/cmd"
 	"github.com/ingrammicro/concerto/wizard/apps"
 	"github.com/ingrammicro/concerto/wizard/cloud_providers"
 	"github.com/ingrammicro/concerto/wizard/locations"
@@ -21,6 +22,21 @@ func SubCommands() []cli.Command {
 			Usage:       "Provides information about cloud providers",
 			Subcommands: append(cloud_providers.SubCommands()),
 		},
+		{
+			Name:   "deploy",
+			Usage:  "Deploys an app, choosing its location, cloud provider, cloud account and server plan step by step",
+			Action: cmd.WizardDeploy,
+			Flags: append([]cli.Flag{
+				cli.StringFlag{
+					Name:  "answers-file, f",
+					Usage: "Answers file (YAML or JSON) giving the app, location, cloud_provider, cloud_account, server_plan and hostname by ID or name, or \"-\" to read it from STDIN. The deployment is not interactive when given",
+				},
+				cli.StringFlag{
+					Name:  "hostname",
+					Usage: "A hostname for the cloud server to deploy",
+				},
+			}, cmd.WaitFlags("operational")...),
+		},
 		{
 			Name:        "locations",
 			Usage:       "Provides information about locations",